@@ -0,0 +1,143 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultAdoptNamespace is the namespace of the codes adopted by Adopt when none is configured
+	DefaultAdoptNamespace = "adopted"
+	// DefaultMaxAdoptedCodes is the number of codes Adopt registers when AdoptConfig.MaxCodes is not set
+	DefaultMaxAdoptedCodes = 256
+)
+
+// Coder is implemented by the errors of libraries that carry their own codes
+type Coder interface {
+	Code() string
+}
+
+// AdoptConfig configures how Adopt turns foreign Coder errors into errorex errors
+type AdoptConfig struct {
+	// Namespace prefixes the foreign codes, e.g. "stripe" adopts the code card_declined as stripe.card_declined.
+	// DefaultAdoptNamespace is used when empty.
+	Namespace string
+	// RegisteredOnly adopts only the codes already registered, instead of registering the unknown ones with an
+	// AdoptedDetail
+	RegisteredOnly bool
+	// MaxCodes caps the codes registered by Adopt, as foreign codes may come from the wire, DefaultMaxAdoptedCodes
+	// when zero. Once reached, only the codes already registered are adopted.
+	MaxCodes int
+}
+
+// AdoptedDetail is the detail of the codes registered by Adopt
+type AdoptedDetail struct {
+	// Code is the foreign code
+	Code string `json:"code"`
+	// Type is the Go type of the foreign error
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+var (
+	adoptConfig       atomic.Pointer[AdoptConfig]
+	adoptMutex        sync.Mutex
+	adoptedDetailType = reflect.TypeOf(AdoptedDetail{})
+	// adoptedCodes is the number of codes registered by Adopt, guarded by adoptMutex
+	adoptedCodes int
+)
+
+// SetAdoptConfig sets how Adopt turns foreign errors into errorex errors
+func SetAdoptConfig(config AdoptConfig) {
+	adoptConfig.Store(&config)
+}
+
+// GetAdoptConfig returns the current adoption configuration
+func GetAdoptConfig() AdoptConfig {
+	if config := adoptConfig.Load(); config != nil {
+		return *config
+	}
+	return AdoptConfig{}
+}
+
+// Adopt wraps the first foreign Coder error in the chain of err as a genuine errorex, with the foreign code under
+// the configured namespace and err as its cause, so errors.As still finds the foreign error. Unknown codes are
+// registered with an AdoptedDetail unless AdoptConfig.RegisteredOnly is set or AdoptConfig.MaxCodes were already
+// registered.
+// It returns the errorex itself when the first Coder of the chain already is an errorex, and false when there is
+// no Coder in the chain, the code violates a strict naming policy or it is registered with another detail type.
+func Adopt(err error, options ...Option) (EX, bool) {
+	var coder Coder
+	if !errors.As(err, &coder) {
+		return nil, false
+	}
+	if ex, ok := coder.(EX); ok {
+		return ex, true
+	}
+	config := GetAdoptConfig()
+	namespace := config.Namespace
+	if namespace == "" {
+		namespace = DefaultAdoptNamespace
+	}
+	maxCodes := config.MaxCodes
+	if maxCodes <= 0 {
+		maxCodes = DefaultMaxAdoptedCodes
+	}
+	code, ok := adoptable(namespace+"."+coder.Code(), config.RegisteredOnly, maxCodes)
+	if !ok {
+		return nil, false
+	}
+	detail := AdoptedDetail{Code: coder.Code(), Type: fmt.Sprintf("%T", coder), Message: coder.(error).Error()}
+	e := newEX(code, detail, 1)
+	e.cause = err
+	applyOptions(e, options)
+	return e, true
+}
+
+// adoptable tells if errors with the code can be created with an AdoptedDetail, registering the code if needed.
+// It returns the canonical code, which differs from the code when it is an alias. The freeze mutex is held, so
+// Freeze cannot happen between the check and the registration.
+func adoptable(code string, registeredOnly bool, maxCodes int) (string, bool) {
+	adoptMutex.Lock()
+	defer adoptMutex.Unlock()
+	if codeRegistry, ok := lookupCode(code); ok {
+		return codeRegistry.code, codeRegistry.detailType == adoptedDetailType
+	}
+	if registeredOnly || adoptedCodes >= maxCodes || namingViolation(code) != nil {
+		return "", false
+	}
+	freezeMutex.Lock()
+	defer freezeMutex.Unlock()
+	if IsFrozen() {
+		return "", false
+	}
+	RegisterErrorCode(code, "Adopted error "+code, AdoptedDetail{})
+	adoptedCodes++
+	return code, true
+}
@@ -0,0 +1,145 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type libraryError struct {
+	code string
+}
+
+func (e *libraryError) Error() string {
+	return "library failed: " + e.code
+}
+
+func (e *libraryError) Code() string {
+	return e.code
+}
+
+func TestAdopt(t *testing.T) {
+	defer SetAdoptConfig(AdoptConfig{})
+
+	t.Run("should wrap foreign coders under the namespace", func(t *testing.T) {
+		foreign := &libraryError{code: "card_declined"}
+
+		adopted, ok := Adopt(fmt.Errorf("charging: %w", foreign), WithTenant("acme"))
+
+		assert.True(t, ok)
+		assert.Equal(t, "adopted.card_declined", adopted.Code())
+		assert.Equal(t, AdoptedDetail{Code: "card_declined", Type: "*errorex.libraryError", Message: "library failed: card_declined"}, adopted.Detail())
+		assert.Equal(t, "acme", Tenant(adopted))
+		var target *libraryError
+		assert.True(t, errors.As(adopted, &target))
+		assert.True(t, Is(adopted, "adopted.card_declined"))
+
+		again, ok := Adopt(foreign)
+		assert.True(t, ok)
+		assert.Equal(t, adopted.Code(), again.Code())
+	})
+
+	t.Run("should use the configured namespace", func(t *testing.T) {
+		SetAdoptConfig(AdoptConfig{Namespace: "stripe"})
+
+		adopted, ok := Adopt(&libraryError{code: "expired_card"})
+
+		assert.True(t, ok)
+		assert.Equal(t, "stripe.expired_card", adopted.Code())
+	})
+
+	t.Run("should only adopt registered codes when configured", func(t *testing.T) {
+		SetAdoptConfig(AdoptConfig{Namespace: "strict", RegisteredOnly: true})
+
+		_, ok := Adopt(&libraryError{code: "unknown"})
+		assert.False(t, ok)
+
+		RegisterErrorCode("strict.known", "Known", AdoptedDetail{})
+		_, ok = Adopt(&libraryError{code: "known"})
+		assert.True(t, ok)
+
+		RegisterErrorCode("strict.typed", "Typed", ErrorEXDetail{})
+		_, ok = Adopt(&libraryError{code: "typed"})
+		assert.False(t, ok)
+	})
+
+	t.Run("should cap the registered codes", func(t *testing.T) {
+		adoptMutex.Lock()
+		registered := adoptedCodes
+		adoptMutex.Unlock()
+		SetAdoptConfig(AdoptConfig{Namespace: "capped", MaxCodes: registered + 1})
+
+		_, ok := Adopt(&libraryError{code: "first"})
+		assert.True(t, ok)
+		_, ok = Adopt(&libraryError{code: "second"})
+		assert.False(t, ok)
+		_, ok = Adopt(&libraryError{code: "first"})
+		assert.True(t, ok)
+	})
+
+	t.Run("should not panic when the registry is frozen concurrently", func(t *testing.T) {
+		SetAdoptConfig(AdoptConfig{Namespace: "racing", MaxCodes: 1 << 20})
+		defer unfreezeRegistry()
+		var group sync.WaitGroup
+		for i := range 8 {
+			group.Add(1)
+			go func() {
+				defer group.Done()
+				for j := range 50 {
+					Adopt(&libraryError{code: fmt.Sprintf("code_%d_%d", i, j)})
+				}
+			}()
+		}
+
+		assert.NotPanics(t, Freeze)
+		group.Wait()
+	})
+
+	t.Run("should validate the codes with the naming policy", func(t *testing.T) {
+		SetAdoptConfig(AdoptConfig{})
+		SetNamingPolicy(&NamingPolicy{Pattern: regexp.MustCompile(`^[a-z._]+$`)})
+		defer SetNamingPolicy(nil)
+
+		_, ok := Adopt(&libraryError{code: "Invalid-Code"})
+
+		assert.False(t, ok)
+	})
+
+	t.Run("should return errorex errors as is", func(t *testing.T) {
+		ex := New(ErrCodeUnknownError, UnknownErrorDetail{})
+
+		adopted, ok := Adopt(fmt.Errorf("wrapped: %w", ex))
+		assert.True(t, ok)
+		assert.Same(t, ex, adopted)
+
+		_, ok = Adopt(errors.New("plain"))
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,432 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package errorexcheck defines an Analyzer that checks the usage of errorex codes at compile time.
+//
+// It reports:
+//   - New, NewPooled, NewCtx and Is calls with constant codes that are not registered by the package or its
+//     dependencies, with RegisterErrorCode, Define, RegisterInterfaceCode, DefineInterface, RegisterAlias,
+//     DeferAlias or the Registration literals of RegisterAll and RegisterSentinels;
+//   - codes (or aliases) registered more than once, in the same package or across packages;
+//   - New, NewPooled and NewCtx calls whose detail type differs from the registered one, or does not implement it
+//     for the codes of RegisterInterfaceCode and DefineInterface.
+//
+// Only codes given as constants (literals or const declarations) are checked. The unregistered code check is
+// disabled when the package or one of its dependencies registers codes computed at runtime. The errorex package
+// itself is exempt, since it forwards the codes of Define, RegisterAlias and friends to the registry.
+package errorexcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const (
+	errorexPath = "github.com/fkmatsuda/errorex"
+)
+
+// Analyzer checks errorex code registrations and usages
+var Analyzer = &analysis.Analyzer{
+	Name:      "errorexcheck",
+	Doc:       "check errorex codes for unregistered usages, duplicate registrations and detail type mismatches",
+	Run:       run,
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	FactTypes: []analysis.Fact{new(Registrations)},
+}
+
+// Registrations is the package fact listing the codes registered by a package
+type Registrations struct {
+	// Codes maps each registered code to its detail type
+	Codes map[string]string
+	// Interfaces holds the codes whose detail type is an interface, registered with RegisterInterfaceCode or
+	// DefineInterface: their details only have to implement it
+	Interfaces map[string]bool
+	// Dynamic is set when the package registers codes that are not constants, never for errorex itself
+	Dynamic bool
+}
+
+// AFact marks Registrations as an analysis fact
+func (*Registrations) AFact() {}
+
+func (r *Registrations) String() string {
+	codes := make([]string, 0, len(r.Codes))
+	for code := range r.Codes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	if r.Dynamic {
+		return fmt.Sprintf("errorex codes(%s) dynamic", strings.Join(codes, ", "))
+	}
+	return fmt.Sprintf("errorex codes(%s)", strings.Join(codes, ", "))
+}
+
+// usage is a New, NewPooled or Is call waiting for the whole package to be registered
+type usage struct {
+	call       *ast.CallExpr
+	code       string
+	detailType types.Type
+	// detailArg is the index of the detail argument of the call
+	detailArg int
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	// Codes visible from the dependencies, with the package registering them
+	visible := make(map[string]string)
+	visibleTypes := make(map[string]string)
+	visibleInterfaces := make(map[string]bool)
+	dynamic := false
+	for _, fact := range pass.AllPackageFacts() {
+		registrations, ok := fact.Fact.(*Registrations)
+		if !ok || fact.Package == pass.Pkg {
+			continue
+		}
+		dynamic = dynamic || registrations.Dynamic
+
+		for code, detailType := range registrations.Codes {
+			visible[code] = fact.Package.Path()
+			visibleTypes[code] = detailType
+		}
+		for code := range registrations.Interfaces {
+			visibleInterfaces[code] = true
+		}
+	}
+
+	own := &Registrations{Codes: make(map[string]string), Interfaces: make(map[string]bool)}
+	// Interface detail types of the own codes, the ones of the dependencies are looked up by name
+	interfaces := make(map[string]types.Type)
+	// register records a constant code of the package, unless it is already registered
+	register := func(pos token.Pos, code string, detailType string) bool {
+		if _, ok := own.Codes[code]; ok {
+			pass.Reportf(pos, "errorex code %q is registered more than once", code)
+			return false
+		}
+		if from, ok := visible[code]; ok {
+			pass.Reportf(pos, "errorex code %q is already registered by %s", code, from)
+			return false
+		}
+		own.Codes[code] = detailType
+		return true
+	}
+	var usages []usage
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != errorexPath {
+			return
+		}
+		if signature, ok := fn.Type().(*types.Signature); !ok || signature.Recv() != nil {
+			return
+		}
+		switch fn.Name() {
+		case "RegisterErrorCode", "Define":
+			code, ok := constantCode(pass, call, 0)
+			if !ok {
+				own.Dynamic = own.Dynamic || pass.Pkg.Path() != errorexPath
+				return
+			}
+			register(call.Pos(), code, typeString(registeredType(pass, call, fn.Name())))
+		case "RegisterInterfaceCode", "DefineInterface":
+			code, ok := constantCode(pass, call, 0)
+			if !ok {
+				own.Dynamic = own.Dynamic || pass.Pkg.Path() != errorexPath
+				return
+			}
+			detailType := registeredType(pass, call, fn.Name())
+			if register(call.Pos(), code, typeString(detailType)) && detailType != nil {
+				own.Interfaces[code] = true
+				interfaces[code] = detailType
+			}
+		case "RegisterAll", "RegisterSentinels":
+			registrations, ok := batchRegistrations(pass, call)
+			if !ok {
+				own.Dynamic = own.Dynamic || pass.Pkg.Path() != errorexPath
+			}
+			for _, registration := range registrations {
+				register(registration.pos, registration.code, registration.detailType)
+			}
+		case "RegisterAlias", "DeferAlias":
+			alias, ok := constantCode(pass, call, 0)
+			if !ok {
+				own.Dynamic = own.Dynamic || pass.Pkg.Path() != errorexPath
+				return
+			}
+			// The alias gets the detail type of its code, when it is known: DeferAlias codes may be registered
+			// later by packages that are not dependencies
+			detailType, code := "", ""
+			if code, ok = constantCode(pass, call, 1); ok {
+				if detailType, ok = own.Codes[code]; !ok {
+					detailType = visibleTypes[code]
+				}
+			}
+			if register(call.Pos(), alias, detailType) && (own.Interfaces[code] || visibleInterfaces[code]) {
+				own.Interfaces[alias] = true
+				interfaces[alias] = interfaces[code]
+			}
+		case "New", "NewPooled", "CausedByRemote":
+			code, ok := constantCode(pass, call, 0)
+			if !ok {
+				return
+			}
+			u := usage{call: call, code: code, detailArg: 1}
+			if len(call.Args) > 1 {
+				u.detailType = pass.TypesInfo.TypeOf(call.Args[1])
+			}
+			usages = append(usages, u)
+		case "NewCtx":
+			code, ok := constantCode(pass, call, 1)
+			if !ok {
+				return
+			}
+			u := usage{call: call, code: code, detailArg: 2}
+			if len(call.Args) > 2 {
+				u.detailType = pass.TypesInfo.TypeOf(call.Args[2])
+			}
+			usages = append(usages, u)
+		case "Is":
+			if code, ok := constantCode(pass, call, 1); ok {
+				usages = append(usages, usage{call: call, code: code})
+			}
+		}
+	})
+
+	for _, u := range usages {
+		registered, ok := own.Codes[u.code]
+		if !ok {
+			registered, ok = visibleTypes[u.code]
+		}
+		if !ok {
+			if !dynamic && !own.Dynamic {
+				pass.Reportf(u.call.Pos(), "errorex code %q is not registered", u.code)
+			}
+			continue
+		}
+		if u.detailType == nil || registered == "" {
+			continue
+		}
+		if own.Interfaces[u.code] || visibleInterfaces[u.code] {
+			iface := interfaces[u.code]
+			if iface == nil {
+				iface = lookupType(pass.Pkg, registered)
+			}
+			if iface, ok := iface.Underlying().(*types.Interface); ok && !types.Implements(u.detailType, iface) {
+				pass.Reportf(u.call.Args[u.detailArg].Pos(), "errorex code %q expects detail implementing %s, got %s",
+					u.code, registered, typeString(u.detailType))
+			}
+			continue
+		}
+		if typeString(u.detailType) != registered {
+			pass.Reportf(u.call.Args[u.detailArg].Pos(), "errorex code %q expects detail of type %s, got %s",
+				u.code, registered, typeString(u.detailType))
+		}
+	}
+
+	if len(own.Interfaces) == 0 {
+		own.Interfaces = nil
+	}
+	if len(own.Codes) > 0 || own.Dynamic {
+		pass.ExportPackageFact(own)
+	}
+	return nil, nil
+}
+
+// constantCode returns the code argument of a call at the given position when it is a string constant
+func constantCode(pass *analysis.Pass, call *ast.CallExpr, position int) (string, bool) {
+	if len(call.Args) <= position {
+		return "", false
+	}
+	value := pass.TypesInfo.Types[call.Args[position]].Value
+	if value == nil || value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(value), true
+}
+
+// registration is a constant code of a Registration literal
+type registration struct {
+	pos        token.Pos
+	code       string
+	detailType string
+}
+
+// batchRegistrations returns the registrations of a RegisterAll or RegisterSentinels call, the elements of the
+// slice or the values of the map, ok is false when some of them are not Registration literals with constant codes
+func batchRegistrations(pass *analysis.Pass, call *ast.CallExpr) ([]registration, bool) {
+	if len(call.Args) == 0 {
+		return nil, false
+	}
+	batch, ok := ast.Unparen(call.Args[0]).(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+	registrations := make([]registration, 0, len(batch.Elts))
+	for _, element := range batch.Elts {
+		if keyed, ok := element.(*ast.KeyValueExpr); ok {
+			element = keyed.Value
+		}
+		r, ok := registrationLiteral(pass, element)
+		if !ok {
+			return registrations, false
+		}
+		registrations = append(registrations, r)
+	}
+	return registrations, true
+}
+
+// registrationLiteral returns the code and detail type of a Registration literal, ok is false when the code is
+// not a constant
+func registrationLiteral(pass *analysis.Pass, expr ast.Expr) (registration, bool) {
+	literal, ok := ast.Unparen(expr).(*ast.CompositeLit)
+	if !ok {
+		return registration{}, false
+	}
+	var code, detail ast.Expr
+	for i, element := range literal.Elts {
+		switch element := element.(type) {
+		case *ast.KeyValueExpr:
+			key, _ := element.Key.(*ast.Ident)
+			if key == nil {
+				continue
+			}
+			switch key.Name {
+			case "Code":
+				code = element.Value
+			case "Detail":
+				detail = element.Value
+			}
+		default:
+			// Positional fields: Code, Description, Detail, Options
+			switch i {
+			case 0:
+				code = element
+			case 2:
+				detail = element
+			}
+		}
+	}
+	if code == nil {
+		return registration{}, false
+	}
+	value := pass.TypesInfo.Types[code].Value
+	if value == nil || value.Kind() != constant.String {
+		return registration{}, false
+	}
+	r := registration{pos: literal.Pos(), code: constant.StringVal(value)}
+	// A nil detail has no type to check
+	if detail != nil && !pass.TypesInfo.Types[detail].IsNil() {
+		r.detailType = typeString(pass.TypesInfo.TypeOf(detail))
+	}
+	return r, true
+}
+
+// registeredType returns the detail type of a registration
+func registeredType(pass *analysis.Pass, call *ast.CallExpr, name string) types.Type {
+	if name == "RegisterErrorCode" {
+		if len(call.Args) < 3 {
+			return nil
+		}
+		return pass.TypesInfo.TypeOf(call.Args[2])
+	}
+	// Define[T] carries the detail type as its type argument
+	var ident *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.IndexExpr:
+		ident = calleeIdent(fun.X)
+	case *ast.IndexListExpr:
+		ident = calleeIdent(fun.X)
+	default:
+		ident = calleeIdent(fun)
+	}
+	if ident == nil {
+		return nil
+	}
+	instance, ok := pass.TypesInfo.Instances[ident]
+	if !ok || instance.TypeArgs.Len() == 0 {
+		return nil
+	}
+	return instance.TypeArgs.At(0)
+}
+
+// lookupType returns the type of a fully qualified name, looked up in the universe or in the packages imported,
+// directly or not, by pkg. It returns an invalid type when the name is not found.
+func lookupType(pkg *types.Package, name string) types.Type {
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 {
+		if object, ok := types.Universe.Lookup(name).(*types.TypeName); ok {
+			return object.Type()
+		}
+		return types.Typ[types.Invalid]
+	}
+	path, local := name[:dot], name[dot+1:]
+	seen := make(map[*types.Package]bool)
+	pending := []*types.Package{pkg}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		if seen[current] {
+			continue
+		}
+		seen[current] = true
+		if current.Path() == path {
+			if object, ok := current.Scope().Lookup(local).(*types.TypeName); ok {
+				return object.Type()
+			}
+			return types.Typ[types.Invalid]
+		}
+		pending = append(pending, current.Imports()...)
+	}
+	return types.Typ[types.Invalid]
+}
+
+// calleeIdent returns the identifier of a function or qualified function expression
+func calleeIdent(expr ast.Expr) *ast.Ident {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr
+	case *ast.SelectorExpr:
+		return expr.Sel
+	}
+	return nil
+}
+
+// typeString returns the fully qualified name of a type, empty for unknown types
+func typeString(t types.Type) string {
+	if t == nil {
+		return ""
+	}
+	if basic, ok := t.(*types.Basic); ok && basic.Info()&types.IsUntyped != 0 {
+		t = types.Default(t)
+	}
+	return types.TypeString(t, nil)
+}
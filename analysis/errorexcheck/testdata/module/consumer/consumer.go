@@ -0,0 +1,14 @@
+// want package:`errorex codes\(consumer.failed\)`
+
+package consumer
+
+import "github.com/fkmatsuda/errorex"
+
+func init() {
+	errorex.RegisterErrorCode("consumer.failed", "Consumer failed", errorex.ErrorEXDetail{})
+}
+
+func check(err error) bool {
+	return errorex.Is(err, "consumer.failed") || errorex.Is(err, errorex.ErrCodeNotRegistered) ||
+		errorex.Is(err, "consumer.missing") // want `errorex code "consumer.missing" is not registered`
+}
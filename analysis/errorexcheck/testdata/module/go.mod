@@ -0,0 +1,7 @@
+module consumer
+
+go 1.22.3
+
+require github.com/fkmatsuda/errorex v0.0.0
+
+replace github.com/fkmatsuda/errorex => ../../../../
@@ -0,0 +1,34 @@
+// want package:`errorex codes\(batch.conflict, batch.missing, batch.timeout\)`
+
+package batch
+
+import "github.com/fkmatsuda/errorex"
+
+type MissingDetail struct {
+	Resource string
+}
+
+const CodeTimeout = "batch.timeout"
+
+var _ = errorex.RegisterAll([]errorex.Registration{
+	{Code: "batch.missing", Description: "Resource missing", Detail: MissingDetail{}},
+	{CodeTimeout, "Timed out", nil, nil},
+	errorex.Registration{Code: "batch.conflict", Detail: 0},
+	{Code: "batch.missing", Description: "Resource missing"}, // want `errorex code "batch.missing" is registered more than once`
+})
+
+func Missing() error {
+	return errorex.New("batch.missing", MissingDetail{Resource: "invoice"})
+}
+
+func Timeout(err error) bool {
+	return errorex.Is(err, CodeTimeout) || errorex.Is(err, "batch.conflict")
+}
+
+func Mismatch() error {
+	return errorex.New("batch.conflict", MissingDetail{}) // want `errorex code "batch.conflict" expects detail of type int, got batch.MissingDetail`
+}
+
+func Unknown(err error) bool {
+	return errorex.Is(err, "batch.unknown") // want `errorex code "batch.unknown" is not registered`
+}
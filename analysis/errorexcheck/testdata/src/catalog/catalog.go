@@ -0,0 +1,27 @@
+// want package:`errorex codes\(billing.declined, billing.expired, billing.limit\)`
+
+package catalog
+
+import "github.com/fkmatsuda/errorex"
+
+const CodeDeclined = "billing.declined"
+
+type DeclinedDetail struct {
+	Reason string
+}
+
+type ExpiredDetail struct {
+	Month int
+}
+
+var Expired = errorex.Define[ExpiredDetail]("billing.expired", "Card expired")
+
+func init() {
+	errorex.RegisterErrorCode(CodeDeclined, "Payment declined", DeclinedDetail{})
+	errorex.RegisterErrorCode("billing.limit", "Limit exceeded", 0)
+	errorex.RegisterErrorCode("billing.limit", "Limit exceeded", 0) // want `errorex code "billing.limit" is registered more than once`
+}
+
+func Declined() error {
+	return errorex.New(CodeDeclined, DeclinedDetail{Reason: "insufficient funds"})
+}
@@ -0,0 +1,13 @@
+// want package:`errorex codes\(\) dynamic`
+
+package dynamic
+
+import "github.com/fkmatsuda/errorex"
+
+func Register(code string) {
+	errorex.RegisterErrorCode(code, "Generated code", struct{}{})
+}
+
+func Check(err error) bool {
+	return errorex.Is(err, "generated.code")
+}
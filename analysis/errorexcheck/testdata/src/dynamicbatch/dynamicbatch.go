@@ -0,0 +1,13 @@
+// want package:`errorex codes\(\) dynamic`
+
+package dynamicbatch
+
+import "github.com/fkmatsuda/errorex"
+
+func Register(registrations []errorex.Registration) error {
+	return errorex.RegisterAll(registrations)
+}
+
+func Check(err error) bool {
+	return errorex.Is(err, "generated.batch")
+}
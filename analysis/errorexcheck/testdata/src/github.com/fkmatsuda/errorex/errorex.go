@@ -0,0 +1,62 @@
+// Package errorex is a stub of the errorex API used by the analyzer tests
+package errorex
+
+import "context"
+
+type EX interface {
+	error
+	Code() string
+}
+
+type ErrorEXDetail struct {
+	Code string
+}
+
+const ErrCodeNotRegistered = "errorex.001"
+
+func init() {
+	RegisterErrorCode(ErrCodeNotRegistered, "Errorex code not registered", ErrorEXDetail{})
+}
+
+func RegisterErrorCode[T any](code string, description string, detail T) {}
+
+func New[T any](code string, detail T) EX { return nil }
+
+func NewPooled[T any](code string, detail T) EX { return nil }
+
+func NewCtx[T any](ctx context.Context, code string, detail T) EX { return nil }
+
+func CausedByRemote[T any](code string, detail T, remote EX) EX { return nil }
+
+func Is(err error, code string) bool { return false }
+
+type Definition[T any] struct{}
+
+func Define[T any](code string, description string) Definition[T] { return Definition[T]{} }
+
+func (d Definition[T]) New(detail T) EX { return nil }
+
+func RegisterAlias(alias string, code string) {}
+
+type RegistrationOption func()
+
+type Registration struct {
+	Code        string
+	Description string
+	Detail      any
+	Options     []RegistrationOption
+}
+
+func RegisterAll(registrations []Registration) error { return nil }
+
+func RegisterInterfaceCode[I any](code string, description string) {}
+
+func DefineInterface[I any](code string, description string) Definition[I] { return Definition[I]{} }
+
+func DeferAlias(alias string, code string) {}
+
+type ErrorConverter interface {
+	ConvertError(err error) EX
+}
+
+func RegisterSentinels(sentinels map[error]Registration) (ErrorConverter, error) { return nil, nil }
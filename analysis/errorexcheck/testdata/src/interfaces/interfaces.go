@@ -0,0 +1,30 @@
+// want package:`errorex codes\(storage.failed, storage.io, storage.missing\)`
+
+package interfaces
+
+import "github.com/fkmatsuda/errorex"
+
+type StorageDetail interface {
+	Bucket() string
+}
+
+type ObjectDetail struct {
+	Name string
+}
+
+func (d ObjectDetail) Bucket() string { return "objects" }
+
+var Failed = errorex.DefineInterface[StorageDetail]("storage.failed", "Storage failed")
+
+func init() {
+	errorex.RegisterInterfaceCode[StorageDetail]("storage.missing", "Object missing")
+	errorex.RegisterAlias("storage.io", "storage.missing")
+}
+
+func Missing() error {
+	return errorex.New("storage.missing", ObjectDetail{Name: "invoice.pdf"})
+}
+
+func Mismatch() error {
+	return errorex.New("storage.failed", 0) // want `errorex code "storage.failed" expects detail implementing interfaces.StorageDetail, got int`
+}
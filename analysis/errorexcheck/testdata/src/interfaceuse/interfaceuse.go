@@ -0,0 +1,18 @@
+package interfaceuse
+
+import (
+	"interfaces"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+func Failed() error {
+	return errorex.New("storage.failed", interfaces.ObjectDetail{})
+}
+
+func Alias(err error) error {
+	if errorex.Is(err, "storage.io") {
+		return errorex.New("storage.io", interfaces.ObjectDetail{})
+	}
+	return errorex.New("storage.io", "disk") // want `errorex code "storage.io" expects detail implementing interfaces.StorageDetail, got string`
+}
@@ -0,0 +1,35 @@
+// want package:`errorex codes\(store.closed, store.not_found\)`
+
+package sentinels
+
+import (
+	"errors"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrClosed   = errors.New("closed")
+)
+
+type NotFoundDetail struct {
+	Key string
+}
+
+var Converter, _ = errorex.RegisterSentinels(map[error]errorex.Registration{
+	ErrNotFound: {Code: "store.not_found", Description: "Key not found", Detail: NotFoundDetail{}},
+	ErrClosed:   {Code: "store.closed", Description: "Store closed"},
+})
+
+func NotFound(key string) error {
+	return errorex.New("store.not_found", NotFoundDetail{Key: key})
+}
+
+func Closed(err error) bool {
+	return errorex.Is(err, "store.closed") || errorex.Is(err, "store.open") // want `errorex code "store.open" is not registered`
+}
+
+func Mismatch() error {
+	return errorex.New("store.not_found", "key") // want `errorex code "store.not_found" expects detail of type sentinels.NotFoundDetail, got string`
+}
@@ -0,0 +1,66 @@
+// want package:`errorex codes\(billing.card_expired, billing.card_lost, billing.settled\)`
+
+package service
+
+import (
+	"catalog"
+	"context"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+func init() {
+	errorex.RegisterAlias("billing.card_expired", catalog.CodeDeclined)
+	errorex.DeferAlias("billing.card_lost", catalog.CodeDeclined)
+	errorex.DeferAlias("billing.settled", "ledger.settled")
+	errorex.DeferAlias("billing.card_lost", "billing.stolen")                                   // want `errorex code "billing.card_lost" is registered more than once`
+	errorex.RegisterAlias(catalog.CodeDeclined, "billing.limit")                                // want `errorex code "billing.declined" is already registered by catalog`
+	errorex.RegisterErrorCode("billing.declined", "Payment declined", catalog.DeclinedDetail{}) // want `errorex code "billing.declined" is already registered by catalog`
+}
+
+func Check(err error) bool {
+	return errorex.Is(err, catalog.CodeDeclined) || errorex.Is(err, "billing.expired") || errorex.Is(err, errorex.ErrCodeNotRegistered)
+}
+
+func Unknown(err error) bool {
+	return errorex.Is(err, "billing.unknown") // want `errorex code "billing.unknown" is not registered`
+}
+
+func Mismatch() error {
+	return errorex.New("billing.expired", catalog.DeclinedDetail{}) // want `errorex code "billing.expired" expects detail of type catalog.ExpiredDetail, got catalog.DeclinedDetail`
+}
+
+func Alias() error {
+	return errorex.New("billing.card_expired", catalog.ExpiredDetail{}) // want `errorex code "billing.card_expired" expects detail of type catalog.DeclinedDetail, got catalog.ExpiredDetail`
+}
+
+func Context(ctx context.Context) error {
+	return errorex.NewCtx(ctx, "billing.expired", catalog.DeclinedDetail{}) // want `errorex code "billing.expired" expects detail of type catalog.ExpiredDetail, got catalog.DeclinedDetail`
+}
+
+func ContextUnknown(ctx context.Context) error {
+	return errorex.NewCtx(ctx, "billing.missing", catalog.DeclinedDetail{}) // want `errorex code "billing.missing" is not registered`
+}
+
+func Remote(remote errorex.EX) error {
+	return errorex.CausedByRemote("billing.expired", catalog.DeclinedDetail{}, remote) // want `errorex code "billing.expired" expects detail of type catalog.ExpiredDetail, got catalog.DeclinedDetail`
+}
+
+func Limit() error {
+	return errorex.NewPooled("billing.limit", 10)
+}
+
+func Typed() error {
+	return catalog.Expired.New(catalog.ExpiredDetail{Month: 1})
+}
+
+func Dynamic(code string) bool {
+	return errorex.Is(nil, code)
+}
+
+func Deferred(err error) error {
+	if errorex.Is(err, "billing.settled") {
+		return errorex.New("billing.settled", 0)
+	}
+	return errorex.New("billing.card_lost", catalog.ExpiredDetail{}) // want `errorex code "billing.card_lost" expects detail of type catalog.DeclinedDetail, got catalog.ExpiredDetail`
+}
@@ -0,0 +1,84 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"reflect"
+)
+
+// As matches targets pointing to the type of the detail, setting them to the detail, so errors.As extracts the
+// detail of an errorex directly. A detail held by pointer also matches targets pointing to the struct, which get a
+// copy of it.
+//
+// errors.As only accepts targets of interface types or types implementing error, use DetailAs for other details.
+func (e *ex) As(target any) bool {
+	if setter, ok := target.(*detailSetter); ok && *setter != nil {
+		return (*setter).set(e.detail)
+	}
+	return setDetail(reflect.ValueOf(target), e.detail)
+}
+
+// DetailAs returns the detail of the first errorex in the chain of err whose detail is a T, or a pointer to a T
+func DetailAs[T any](err error) (T, bool) {
+	target := &detailTarget[T]{}
+	setter := detailSetter(target)
+	if !errors.As(err, &setter) {
+		var zero T
+		return zero, false
+	}
+	return target.detail, true
+}
+
+// detailSetter is implemented by the targets of DetailAs
+type detailSetter interface {
+	set(detail any) bool
+}
+
+// detailTarget receives the detail found by DetailAs
+type detailTarget[T any] struct {
+	detail T
+}
+
+func (t *detailTarget[T]) set(detail any) bool {
+	return setDetail(reflect.ValueOf(&t.detail), detail)
+}
+
+// setDetail sets the value pointed by target to the detail when their types match
+func setDetail(target reflect.Value, detail any) bool {
+	if detail == nil || target.Kind() != reflect.Pointer || target.IsNil() {
+		return false
+	}
+	value := reflect.ValueOf(detail)
+	targetType := target.Type().Elem()
+	if value.Type() == targetType {
+		target.Elem().Set(value)
+		return true
+	}
+	if value.Kind() == reflect.Pointer && !value.IsNil() && value.Type().Elem() == targetType {
+		target.Elem().Set(value.Elem())
+		return true
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type asDetail struct {
+	Reason string `json:"reason"`
+}
+
+type asErrorDetail struct {
+	Reason string `json:"reason"`
+}
+
+func (d asErrorDetail) Error() string {
+	return d.Reason
+}
+
+func TestAs(t *testing.T) {
+	RegisterErrorCode("as.value", "Value", asDetail{})
+	RegisterErrorCode("as.pointer", "Pointer", &asDetail{})
+	RegisterErrorCode("as.error", "Error", asErrorDetail{})
+
+	t.Run("should match the detail type with errors.As", func(t *testing.T) {
+		var detail asErrorDetail
+		assert.True(t, errors.As(fmt.Errorf("wrapped: %w", New("as.error", asErrorDetail{Reason: "expired"})), &detail))
+		assert.Equal(t, "expired", detail.Reason)
+	})
+
+	t.Run("should still match errorex targets", func(t *testing.T) {
+		var target EX
+		assert.True(t, errors.As(New("as.value", asDetail{}), &target))
+		assert.Equal(t, "as.value", target.Code())
+	})
+
+	t.Run("should match the detail type directly", func(t *testing.T) {
+		var detail asDetail
+		assert.True(t, New("as.value", asDetail{Reason: "value"}).(*ex).As(&detail))
+		assert.Equal(t, "value", detail.Reason)
+
+		var pointer *asDetail
+		assert.True(t, New("as.pointer", &asDetail{Reason: "pointer"}).(*ex).As(&pointer))
+		assert.Equal(t, "pointer", pointer.Reason)
+
+		var other ErrorEXDetail
+		assert.False(t, New("as.value", asDetail{}).(*ex).As(&other))
+	})
+}
+
+func TestDetailAs(t *testing.T) {
+	t.Run("should return the first detail of the type in the chain", func(t *testing.T) {
+		err := errors.Join(errors.New("other"), New("as.value", asDetail{Reason: "first"}), New("as.value", asDetail{Reason: "second"}))
+
+		detail, ok := DetailAs[asDetail](fmt.Errorf("wrapped: %w", err))
+
+		assert.True(t, ok)
+		assert.Equal(t, "first", detail.Reason)
+	})
+
+	t.Run("should dereference pointer details", func(t *testing.T) {
+		detail, ok := DetailAs[asDetail](New("as.pointer", &asDetail{Reason: "pointer"}))
+
+		assert.True(t, ok)
+		assert.Equal(t, "pointer", detail.Reason)
+	})
+
+	t.Run("should skip errors with other details", func(t *testing.T) {
+		err := errors.Join(New("as.error", asErrorDetail{}), New("as.value", asDetail{Reason: "value"}))
+
+		detail, ok := DetailAs[asDetail](err)
+		assert.True(t, ok)
+		assert.Equal(t, "value", detail.Reason)
+
+		_, ok = DetailAs[ErrorEXDetail](err)
+		assert.False(t, ok)
+		_, ok = DetailAs[asDetail](errors.New("other"))
+		assert.False(t, ok)
+	})
+}
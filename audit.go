@@ -0,0 +1,98 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"context"
+	"time"
+)
+
+// OutcomeSuccess is the outcome of the audit entries of actions that did not fail
+const OutcomeSuccess = "success"
+
+// AuditEntry is a normalized audit record of an action, for systems that must audit failures consistently
+type AuditEntry struct {
+	// Actor is who performed the action, e.g. a user or service ID
+	Actor string `json:"actor"`
+	// Action is what was performed, e.g. "invoice.approve"
+	Action string `json:"action"`
+	// Outcome is the code of the error, aliases resolved, or OutcomeSuccess
+	Outcome  string   `json:"outcome"`
+	Severity Severity `json:"severity,omitempty"`
+	// Detail is the detail projected for SinkAudit, or as external sinks see it (see Exposed) when the code has
+	// no audit projection
+	Detail any `json:"detail,omitempty"`
+	// ErrorID is the instance ID of the errorex, empty when instance IDs are disabled
+	ErrorID string `json:"error_id,omitempty"`
+	// Tenant is the tenant the error is scoped to, see WithTenant
+	Tenant string `json:"tenant,omitempty"`
+	// Time is the creation time of the errorex, or the time the entry was built when it carries none
+	Time time.Time `json:"time"`
+}
+
+// AuditEmitter writes audit entries to an audit trail, such as an append-only store or a SIEM
+type AuditEmitter interface {
+	Emit(ctx context.Context, entry AuditEntry) error
+}
+
+// AuditEmitterFunc is an AuditEmitter function
+type AuditEmitterFunc func(ctx context.Context, entry AuditEntry) error
+
+// Emit calls the function
+func (f AuditEmitterFunc) Emit(ctx context.Context, entry AuditEntry) error {
+	return f(ctx, entry)
+}
+
+// ToAuditEntry returns the audit entry of an action of the actor that ended with err: the outcome is the code of the
+// first errorex in its chain, ErrCodeUnknownError without detail for other errors, or OutcomeSuccess for nil errors.
+// The detail is redacted, only the projection for SinkAudit or the exposed detail is recorded, after the filter
+// set by SetSinkFilter.
+func ToAuditEntry(err error, actor string, action string) AuditEntry {
+	entry := AuditEntry{Actor: actor, Action: action, Outcome: OutcomeSuccess}
+	if err == nil {
+		entry.Time = now()
+		return entry
+	}
+	entry.Outcome = ErrCodeUnknownError
+	entry.Severity = SeverityOf(err)
+	target, ok := firstEX(err)
+	if !ok {
+		entry.Time = now()
+		return entry
+	}
+	entry.Outcome = canonicalCode(target.Code())
+	audited := target
+	if _, projected := projectionOf(target.Code(), SinkAudit); !projected {
+		audited = Exposed(target)
+	}
+	entry.Detail = Projected(audited, SinkAudit).Detail()
+	entry.ErrorID, _ = InstanceID(target)
+	entry.Tenant = Tenant(target)
+	if timestamp, ok := Timestamp(target); ok {
+		entry.Time = timestamp
+	} else {
+		entry.Time = now()
+	}
+	return entry
+}
@@ -0,0 +1,123 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type auditDetail struct {
+	Invoice string `json:"invoice"`
+	IBAN    string `json:"iban"`
+}
+
+func TestToAuditEntry(t *testing.T) {
+	RegisterErrorCode("test.audit.denied", "test description", auditDetail{}, WithSeverity(SeverityWarning))
+	RegisterErrorCode("test.audit.hidden", "test description", auditDetail{})
+	RegisterAlias("test.audit.forbidden", "test.audit.denied")
+	RegisterProjection("test.audit.denied", SinkAudit, func(detail auditDetail) any {
+		return auditDetail{Invoice: detail.Invoice}
+	})
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return at })
+	defer SetClock(nil)
+
+	t.Run("should record the outcome with the audit projection", func(t *testing.T) {
+		err := fmt.Errorf("approving: %w", New("test.audit.forbidden", auditDetail{Invoice: "inv-1", IBAN: "DE89"}, WithTenant("acme")))
+		assert.Equal(t, AuditEntry{
+			Actor:    "user-1",
+			Action:   "invoice.approve",
+			Outcome:  "test.audit.denied",
+			Severity: SeverityWarning,
+			Detail:   auditDetail{Invoice: "inv-1"},
+			Tenant:   "acme",
+			Time:     at,
+		}, ToAuditEntry(err, "user-1", "invoice.approve"))
+	})
+
+	t.Run("should record the exposed detail without audit projection", func(t *testing.T) {
+		SetSeverityPolicy(SeverityPolicy{SeverityError: {}})
+		defer SetSeverityPolicy(nil)
+		entry := ToAuditEntry(New("test.audit.hidden", auditDetail{Invoice: "inv-1"}), "user-1", "invoice.approve")
+		assert.Equal(t, auditDetail{}, entry.Detail)
+	})
+
+	t.Run("should record the detail filtered for the audit sink", func(t *testing.T) {
+		SetSinkFilter(func(err EX, sink Sink) EX {
+			if sink != SinkAudit {
+				return err
+			}
+			return WithDetail(err, auditDetail{Invoice: "[filtered]", IBAN: "[filtered]"})
+		})
+		defer SetSinkFilter(nil)
+		SetSeverityPolicy(SeverityPolicy{SeverityError: {ExposeDetail: true}})
+		defer SetSeverityPolicy(nil)
+
+		projected := ToAuditEntry(New("test.audit.denied", auditDetail{Invoice: "inv-1", IBAN: "DE89"}), "user-1", "invoice.approve")
+		exposed := ToAuditEntry(New("test.audit.hidden", auditDetail{Invoice: "inv-1", IBAN: "DE89"}), "user-1", "invoice.approve")
+
+		assert.Equal(t, auditDetail{Invoice: "[filtered]"}, projected.Detail)
+		assert.Equal(t, auditDetail{Invoice: "[filtered]", IBAN: "[filtered]"}, exposed.Detail)
+	})
+
+	t.Run("should record the instance of the errorex", func(t *testing.T) {
+		SetInstanceConfig(InstanceConfig{IDs: true, Timestamps: true})
+		defer SetInstanceConfig(InstanceConfig{})
+		err := New("test.audit.hidden", auditDetail{})
+		entry := ToAuditEntry(err, "user-1", "invoice.approve")
+		id, _ := InstanceID(err)
+		assert.NotEmpty(t, entry.ErrorID)
+		assert.Equal(t, id, entry.ErrorID)
+		assert.Equal(t, at, entry.Time)
+	})
+
+	t.Run("should record other errors as unknown without their message", func(t *testing.T) {
+		entry := ToAuditEntry(errors.New("password=secret"), "user-1", "login")
+		assert.Equal(t, ErrCodeUnknownError, entry.Outcome)
+		assert.Nil(t, entry.Detail)
+	})
+
+	t.Run("should record successes", func(t *testing.T) {
+		entry := ToAuditEntry(nil, "user-1", "login")
+		assert.Equal(t, AuditEntry{Actor: "user-1", Action: "login", Outcome: OutcomeSuccess, Time: at}, entry)
+	})
+}
+
+func TestAuditEmitterFunc(t *testing.T) {
+	t.Run("should emit through the function", func(t *testing.T) {
+		var emitted []AuditEntry
+		var emitter AuditEmitter = AuditEmitterFunc(func(ctx context.Context, entry AuditEntry) error {
+			emitted = append(emitted, entry)
+			return nil
+		})
+		assert.NoError(t, emitter.Emit(context.Background(), ToAuditEntry(nil, "user-1", "login")))
+		assert.Len(t, emitted, 1)
+	})
+}
@@ -0,0 +1,141 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package auth provides the standard errorex codes of authentication and authorization failures, converters for
+// the errors of golang-jwt and x/oauth2, and the WWW-Authenticate header (RFC 6750, RFC 9470) written by
+// httpex.WriteError for them:
+//
+//	converter := errorex.BuildErrorConverterChain(auth.NewJWTErrorConverter(), auth.NewOAuth2ErrorConverter())
+//	if _, err := jwt.Parse(raw, keyFunc); err != nil {
+//		httpex.WriteError(w, converter.ConvertError(err))
+//	}
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/httpex"
+)
+
+const (
+	// ErrCodeTokenExpired is the errorex code for expired tokens
+	ErrCodeTokenExpired = "auth.token_expired"
+	// ErrCodeTokenInvalid is the errorex code for malformed, unverifiable or revoked tokens
+	ErrCodeTokenInvalid = "auth.token_invalid"
+	// ErrCodeInsufficientScope is the errorex code for tokens lacking the scopes required by the request
+	ErrCodeInsufficientScope = "auth.insufficient_scope"
+	// ErrCodeMFARequired is the errorex code for requests requiring a stronger, multi-factor, authentication
+	ErrCodeMFARequired = "auth.mfa_required"
+)
+
+var realm atomic.Pointer[string]
+
+// TokenDetail is the detail of ErrCodeTokenExpired and ErrCodeTokenInvalid
+type TokenDetail struct {
+	// Reason describes why the token was rejected
+	Reason string `json:"reason"`
+}
+
+// ScopeDetail is the detail of ErrCodeInsufficientScope
+type ScopeDetail struct {
+	// Required are the scopes required by the request
+	Required []string `json:"required"`
+}
+
+// MFADetail is the detail of ErrCodeMFARequired
+type MFADetail struct {
+	// ACRValues are the authentication context class references accepted by the request, e.g. "phr"
+	ACRValues []string `json:"acr_values,omitempty"`
+}
+
+func init() {
+	// Register the errorex codes
+	errorex.RegisterErrorCode(ErrCodeTokenExpired, "Token expired", TokenDetail{}, errorex.WithHTTPStatus(http.StatusUnauthorized))
+	errorex.RegisterErrorCode(ErrCodeTokenInvalid, "Token invalid", TokenDetail{}, errorex.WithHTTPStatus(http.StatusUnauthorized))
+	errorex.RegisterErrorCode(ErrCodeInsufficientScope, "Insufficient scope", ScopeDetail{}, errorex.WithHTTPStatus(http.StatusForbidden))
+	errorex.RegisterErrorCode(ErrCodeMFARequired, "Multi-factor authentication required", MFADetail{}, errorex.WithHTTPStatus(http.StatusUnauthorized))
+
+	httpex.RegisterHeaders(ErrCodeTokenExpired, challengeHeaders)
+	httpex.RegisterHeaders(ErrCodeTokenInvalid, challengeHeaders)
+	httpex.RegisterHeaders(ErrCodeInsufficientScope, challengeHeaders)
+	httpex.RegisterHeaders(ErrCodeMFARequired, challengeHeaders)
+}
+
+// SetRealm sets the realm of the WWW-Authenticate challenges, empty to leave it out
+func SetRealm(name string) {
+	realm.Store(&name)
+}
+
+// Challenge returns the WWW-Authenticate Bearer challenge for an errorex of this package, empty for other errors
+func Challenge(ex errorex.EX) string {
+	switch detail := ex.Detail().(type) {
+	case TokenDetail:
+		return challenge("invalid_token", detail.Reason, "")
+	case ScopeDetail:
+		return challenge("insufficient_scope", "", `scope="`+quote(strings.Join(detail.Required, " "))+`"`)
+	case MFADetail:
+		var extra string
+		if len(detail.ACRValues) > 0 {
+			extra = `acr_values="` + quote(strings.Join(detail.ACRValues, " ")) + `"`
+		}
+		return challenge("insufficient_user_authentication", "", extra)
+	}
+	return ""
+}
+
+// challengeHeaders sets the WWW-Authenticate header
+func challengeHeaders(ex errorex.EX, header http.Header) {
+	header.Set("WWW-Authenticate", Challenge(ex))
+}
+
+// challenge formats a Bearer challenge
+func challenge(code string, description string, extra string) string {
+	var b strings.Builder
+	b.WriteString("Bearer ")
+	if name := realm.Load(); name != nil && *name != "" {
+		b.WriteString(`realm="`)
+		b.WriteString(quote(*name))
+		b.WriteString(`", `)
+	}
+	b.WriteString(`error="`)
+	b.WriteString(code)
+	b.WriteByte('"')
+	if description != "" {
+		b.WriteString(`, error_description="`)
+		b.WriteString(quote(description))
+		b.WriteByte('"')
+	}
+	if extra != "" {
+		b.WriteString(", ")
+		b.WriteString(extra)
+	}
+	return b.String()
+}
+
+// quote escapes a value of a quoted-string
+func quote(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
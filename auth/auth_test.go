@@ -0,0 +1,120 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/httpex"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+var key = []byte("secret")
+
+func sign(t *testing.T, claims jwt.Claims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	assert.NoError(t, err)
+	return token
+}
+
+func parse(raw string) error {
+	_, err := jwt.Parse(raw, func(token *jwt.Token) (any, error) {
+		return key, nil
+	})
+	return err
+}
+
+func TestJWTErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewJWTErrorConverter())
+
+	t.Run("should convert expired tokens", func(t *testing.T) {
+		err := parse(sign(t, jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour))}))
+
+		assert.True(t, errorex.Is(converter.ConvertError(err), ErrCodeTokenExpired))
+	})
+
+	t.Run("should convert invalid tokens", func(t *testing.T) {
+		assert.True(t, errorex.Is(converter.ConvertError(parse("not a token")), ErrCodeTokenInvalid))
+
+		_, err := jwt.Parse(sign(t, jwt.RegisteredClaims{}), func(token *jwt.Token) (any, error) {
+			return []byte("other"), nil
+		})
+		ex := converter.ConvertError(err)
+		assert.True(t, errorex.Is(ex, ErrCodeTokenInvalid))
+		assert.Contains(t, ex.Detail().(TokenDetail).Reason, "signature is invalid")
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		assert.True(t, errorex.Is(converter.ConvertError(errors.New("other")), errorex.ErrCodeUnknownError))
+	})
+}
+
+func TestOAuth2ErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewOAuth2ErrorConverter())
+
+	t.Run("should convert token endpoint errors", func(t *testing.T) {
+		ex := converter.ConvertError(fmt.Errorf("refreshing: %w", &oauth2.RetrieveError{ErrorCode: "invalid_grant", ErrorDescription: "refresh token revoked"}))
+
+		assert.True(t, errorex.Is(ex, ErrCodeTokenInvalid))
+		assert.Equal(t, TokenDetail{Reason: "invalid_grant: refresh token revoked"}, ex.Detail())
+	})
+
+	t.Run("should convert invalid scopes", func(t *testing.T) {
+		assert.True(t, errorex.Is(converter.ConvertError(&oauth2.RetrieveError{ErrorCode: "invalid_scope"}), ErrCodeInsufficientScope))
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		assert.True(t, errorex.Is(converter.ConvertError(errors.New("other")), errorex.ErrCodeUnknownError))
+	})
+}
+
+func TestChallenge(t *testing.T) {
+	t.Run("should write the WWW-Authenticate header", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		httpex.WriteError(recorder, errorex.New(ErrCodeTokenExpired, TokenDetail{Reason: `token "a" is expired`}))
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		assert.Equal(t, `Bearer error="invalid_token", error_description="token \"a\" is expired"`, recorder.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("should describe the missing scopes and authentication", func(t *testing.T) {
+		SetRealm("api")
+		defer SetRealm("")
+
+		assert.Equal(t, `Bearer realm="api", error="insufficient_scope", scope="read write"`,
+			Challenge(errorex.New(ErrCodeInsufficientScope, ScopeDetail{Required: []string{"read", "write"}})))
+		assert.Equal(t, `Bearer realm="api", error="insufficient_user_authentication", acr_values="phr"`,
+			Challenge(errorex.New(ErrCodeMFARequired, MFADetail{ACRValues: []string{"phr"}})))
+		assert.Empty(t, Challenge(errorex.New(errorex.ErrCodeUnknownError, errorex.UnknownErrorDetail{})))
+	})
+}
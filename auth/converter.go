@@ -0,0 +1,95 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package auth
+
+import (
+	"errors"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// jwtErrorConverter converts the validation errors of golang-jwt
+type jwtErrorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewJWTErrorConverter creates a converter of the errors returned by golang-jwt when parsing tokens:
+// jwt.ErrTokenExpired becomes ErrCodeTokenExpired and the other validation errors ErrCodeTokenInvalid
+func NewJWTErrorConverter() errorex.ErrorConverter {
+	return &jwtErrorConverter{}
+}
+
+// ConvertError converts the golang-jwt errors, delegating the others to the next handler in the chain
+func (c *jwtErrorConverter) ConvertError(err error) errorex.EX {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return errorex.New(ErrCodeTokenExpired, TokenDetail{Reason: "token is expired"})
+	case errors.Is(err, jwt.ErrTokenMalformed),
+		errors.Is(err, jwt.ErrTokenUnverifiable),
+		errors.Is(err, jwt.ErrTokenSignatureInvalid),
+		errors.Is(err, jwt.ErrTokenNotValidYet),
+		errors.Is(err, jwt.ErrTokenUsedBeforeIssued),
+		errors.Is(err, jwt.ErrTokenInvalidAudience),
+		errors.Is(err, jwt.ErrTokenInvalidIssuer),
+		errors.Is(err, jwt.ErrTokenInvalidSubject),
+		errors.Is(err, jwt.ErrTokenInvalidId),
+		errors.Is(err, jwt.ErrTokenInvalidClaims),
+		errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return errorex.New(ErrCodeTokenInvalid, TokenDetail{Reason: err.Error()})
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
+
+// oauth2ErrorConverter converts the token endpoint errors of x/oauth2
+type oauth2ErrorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewOAuth2ErrorConverter creates a converter of the *oauth2.RetrieveError returned when a token cannot be
+// obtained or refreshed: invalid_scope becomes ErrCodeInsufficientScope and the other errors ErrCodeTokenInvalid,
+// with the OAuth2 error code and description as reason
+func NewOAuth2ErrorConverter() errorex.ErrorConverter {
+	return &oauth2ErrorConverter{}
+}
+
+// ConvertError converts the x/oauth2 errors, delegating the others to the next handler in the chain
+func (c *oauth2ErrorConverter) ConvertError(err error) errorex.EX {
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) {
+		return c.BaseErrorConverter.ConvertError(err)
+	}
+	if retrieveErr.ErrorCode == "invalid_scope" {
+		return errorex.New(ErrCodeInsufficientScope, ScopeDetail{})
+	}
+	reason := retrieveErr.ErrorCode
+	if retrieveErr.ErrorDescription != "" {
+		reason += ": " + retrieveErr.ErrorDescription
+	}
+	if reason == "" {
+		reason = retrieveErr.Error()
+	}
+	return errorex.New(ErrCodeTokenInvalid, TokenDetail{Reason: reason})
+}
@@ -0,0 +1,18 @@
+module github.com/fkmatsuda/errorex/auth
+
+go 1.22.3
+
+require (
+	github.com/fkmatsuda/errorex v0.0.0
+	github.com/golang-jwt/jwt/v5 v5.2.1
+	github.com/stretchr/testify v1.10.0
+	golang.org/x/oauth2 v0.25.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/fkmatsuda/errorex => ../
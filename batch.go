@@ -0,0 +1,82 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+// Registration is a code to register with RegisterAll, with the arguments of RegisterErrorCode
+type Registration struct {
+	Code        string
+	Description string
+	// Detail is a value of the detail type, e.g. InvalidInputDetail{}
+	Detail  any
+	Options []RegistrationOption
+}
+
+// ErrorEXInvalidDetail is the detail of ErrCodeInvalidDetail
+type ErrorEXInvalidDetail struct {
+	Code   string `json:"code"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// RegisterAll registers a batch of codes atomically: the whole batch is validated first (repeated codes, in the
+// batch or in the registry, the naming policy and serializable detail types) and either every code is registered
+// or none is, so a failed startup can't leave a partially initialized registry.
+// The returned error joins one errorex per problem (ErrCodeAlreadyRegistered, ErrCodeInvalidName,
+// ErrCodeInvalidDetail or ErrCodeRegistryFrozen), it is nil when the batch was registered.
+func RegisterAll(registrations []Registration) error {
+	var problems []error
+	registries := make([]errorCodeRegistry, 0, len(registrations))
+	batch := make(map[string]bool, len(registrations))
+	for _, registration := range registrations {
+		if batch[registration.Code] {
+			problems = append(problems, New(ErrCodeAlreadyRegistered, ErrorEXDetail{Code: registration.Code}))
+			continue
+		}
+		batch[registration.Code] = true
+		if violation := namingViolation(registration.Code); violation != nil {
+			problems = append(problems, violation)
+		}
+		detailType := reflect.TypeOf(registration.Detail)
+		if detailType != nil {
+			if _, err := json.Marshal(reflect.Zero(detailType).Interface()); err != nil {
+				problems = append(problems, New(ErrCodeInvalidDetail, ErrorEXInvalidDetail{
+					Code:   registration.Code,
+					Type:   detailType.String(),
+					Reason: err.Error(),
+				}))
+			}
+		}
+		registries = append(registries, newRegistry(registration.Code, registration.Description, detailType, registration.Options))
+	}
+	if len(problems) > 0 {
+		return errors.Join(problems...)
+	}
+	return registerBatch(registries)
+}
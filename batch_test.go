@@ -0,0 +1,86 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterAll(t *testing.T) {
+	t.Run("should register every code", func(t *testing.T) {
+		err := RegisterAll([]Registration{
+			{Code: "batch.first", Description: "First", Detail: ErrorEXDetail{}, Options: []RegistrationOption{WithHTTPStatus(404)}},
+			{Code: "batch.second", Description: "Second", Detail: UnknownErrorDetail{}},
+		})
+
+		assert.NoError(t, err)
+		info, ok := Lookup("batch.first")
+		assert.True(t, ok)
+		assert.Equal(t, 404, info.HTTPStatus)
+		assert.NotPanics(t, func() { New("batch.second", UnknownErrorDetail{}) })
+	})
+
+	t.Run("should register nothing when a code fails", func(t *testing.T) {
+		SetNamingPolicy(&NamingPolicy{Pattern: regexp.MustCompile(`^[a-z.]+$`)})
+		defer SetNamingPolicy(nil)
+
+		err := RegisterAll([]Registration{
+			{Code: "batch.third", Description: "Third", Detail: ErrorEXDetail{}},
+			{Code: "batch.third", Description: "Again", Detail: ErrorEXDetail{}},
+			{Code: "batch.Fourth", Description: "Fourth", Detail: ErrorEXDetail{}},
+			{Code: "batch.fifth", Description: "Fifth", Detail: func() {}},
+		})
+
+		var codes []string
+		for _, problem := range err.(interface{ Unwrap() []error }).Unwrap() {
+			codes = append(codes, problem.(EX).Code())
+		}
+		assert.Equal(t, []string{ErrCodeAlreadyRegistered, ErrCodeInvalidName, ErrCodeInvalidDetail}, codes)
+		_, ok := Lookup("batch.third")
+		assert.False(t, ok)
+	})
+
+	t.Run("should register nothing when a code is already registered", func(t *testing.T) {
+		err := RegisterAll([]Registration{
+			{Code: "batch.sixth", Description: "Sixth", Detail: ErrorEXDetail{}},
+			{Code: "batch.first", Description: "First", Detail: ErrorEXDetail{}},
+		})
+
+		assert.EqualError(t, err, New(ErrCodeAlreadyRegistered, ErrorEXDetail{Code: "batch.first"}).Error())
+		_, ok := Lookup("batch.sixth")
+		assert.False(t, ok)
+	})
+
+	t.Run("should fail when the registry is frozen", func(t *testing.T) {
+		Freeze()
+		defer unfreezeRegistry()
+
+		err := RegisterAll([]Registration{{Code: "batch.seventh", Description: "Seventh", Detail: ErrorEXDetail{}}})
+
+		assert.True(t, Is(err, ErrCodeRegistryFrozen))
+	})
+}
@@ -0,0 +1,103 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// Behavior is how errors of a severity are handled
+type Behavior struct {
+	// CaptureStack captures stack traces for the errors, whatever StackConfig.Enabled says
+	CaptureStack bool
+	// Report tells reporters to report the errors
+	Report bool
+	// ExposeDetail tells external sinks, such as the responses written by httpex and grpcex, to include the
+	// detail. The detail is replaced by the zero value of its type when false.
+	ExposeDetail bool
+}
+
+// SeverityPolicy maps severities to behaviors, one configuration point instead of per-call decisions.
+// Severities missing from the policy get DefaultBehavior.
+type SeverityPolicy map[Severity]Behavior
+
+var severityPolicy atomic.Pointer[SeverityPolicy]
+
+// DefaultBehavior is the behavior of severities without a policy: errors are reported and their detail is exposed,
+// stack traces are captured when enabled by the StackConfig
+func DefaultBehavior() Behavior {
+	return Behavior{CaptureStack: GetStackConfig().Enabled, Report: true, ExposeDetail: true}
+}
+
+// SetSeverityPolicy sets the behaviors by severity, nil restores DefaultBehavior for every severity
+func SetSeverityPolicy(policy SeverityPolicy) {
+	if policy == nil {
+		severityPolicy.Store(nil)
+		return
+	}
+	copied := make(SeverityPolicy, len(policy))
+	for severity, behavior := range policy {
+		copied[severity] = behavior
+	}
+	severityPolicy.Store(&copied)
+}
+
+// BehaviorOf returns the behavior for the severity of err, see SeverityOf
+func BehaviorOf(err error) Behavior {
+	if policy := severityPolicy.Load(); policy != nil {
+		if behavior, ok := (*policy)[SeverityOf(err)]; ok {
+			return behavior
+		}
+	}
+	return DefaultBehavior()
+}
+
+// Exposed returns the errorex as external sinks should see it: with the zero value of its detail type when its
+// behavior does not expose the detail, as is otherwise. Encrypted details are kept.
+func Exposed(err EX) EX {
+	if BehaviorOf(err).ExposeDetail || err.Detail() == nil {
+		return err
+	}
+	if _, ok := err.Detail().(EncryptedDetail); ok {
+		return err
+	}
+	return WithDetail(err, reflect.Zero(reflect.TypeOf(err.Detail())).Interface())
+}
+
+// capturesStack tells if a stack trace is captured for an errorex with the code, enabled is the StackConfig
+func capturesStack(code string, enabled bool) bool {
+	policy := severityPolicy.Load()
+	if policy == nil {
+		return enabled
+	}
+	severity := SeverityError
+	if codeRegistry, ok := lookupCode(code); ok {
+		severity = codeRegistry.severityOrDefault()
+	}
+	if behavior, ok := (*policy)[severity]; ok {
+		return behavior.CaptureStack
+	}
+	return enabled
+}
@@ -0,0 +1,72 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeverityPolicy(t *testing.T) {
+	RegisterErrorCode("behavior.debug", "Debug", ErrorEXDetail{}, WithSeverity(SeverityDebug))
+	RegisterErrorCode("behavior.critical", "Critical", ErrorEXDetail{}, WithSeverity(SeverityCritical))
+	defer SetSeverityPolicy(nil)
+
+	t.Run("should default to reporting and exposing", func(t *testing.T) {
+		SetSeverityPolicy(nil)
+
+		assert.Equal(t, Behavior{CaptureStack: false, Report: true, ExposeDetail: true}, BehaviorOf(New("behavior.debug", ErrorEXDetail{})))
+		assert.Nil(t, New("behavior.critical", ErrorEXDetail{}).(*ex).stack)
+	})
+
+	t.Run("should follow the behavior of the severity", func(t *testing.T) {
+		SetSeverityPolicy(SeverityPolicy{
+			SeverityDebug:    {},
+			SeverityCritical: {CaptureStack: true, Report: true},
+		})
+
+		debug := New("behavior.debug", ErrorEXDetail{Code: "internal"})
+		critical := New("behavior.critical", ErrorEXDetail{Code: "internal"})
+
+		assert.False(t, BehaviorOf(debug).Report)
+		assert.True(t, BehaviorOf(critical).Report)
+		assert.Nil(t, debug.(*ex).stack)
+		assert.NotNil(t, critical.(*ex).stack)
+		assert.Equal(t, DefaultBehavior(), BehaviorOf(New(ErrCodeNotRegistered, ErrorEXDetail{})))
+	})
+
+	t.Run("should hide the details not exposed", func(t *testing.T) {
+		SetSeverityPolicy(SeverityPolicy{SeverityCritical: {}})
+		critical := New("behavior.critical", ErrorEXDetail{Code: "internal"})
+		debug := New("behavior.debug", ErrorEXDetail{Code: "internal"})
+
+		exposed := Exposed(critical)
+
+		assert.Equal(t, ErrorEXDetail{}, exposed.Detail())
+		assert.Equal(t, "behavior.critical", exposed.Code())
+		assert.Equal(t, ErrorEXDetail{Code: "internal"}, critical.Detail())
+		assert.Same(t, debug, Exposed(debug))
+	})
+}
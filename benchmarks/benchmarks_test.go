@@ -0,0 +1,166 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package benchmarks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	codeSmall = "bench.small"
+	codeLarge = "bench.large"
+)
+
+type smallDetail struct {
+	Field string `json:"field"`
+}
+
+type largeDetail struct {
+	Resource string            `json:"resource"`
+	Fields   []string          `json:"fields"`
+	Labels   map[string]string `json:"labels"`
+	Payload  string            `json:"payload"`
+}
+
+var (
+	errMatched = errors.New("matched error")
+	errOther   = errors.New("other error")
+	small      = smallDetail{Field: "name"}
+	large      = largeDetail{
+		Resource: "customer",
+		Fields:   []string{"name", "email", "phone", "address", "document"},
+		Labels:   map[string]string{"tenant": "acme", "region": "us-east-1", "service": "billing"},
+		Payload:  strings.Repeat("x", 4096),
+	}
+)
+
+func init() {
+	errorex.RegisterErrorCode(codeSmall, "Small detail", smallDetail{})
+	errorex.RegisterErrorCode(codeLarge, "Large detail", largeDetail{})
+}
+
+// matchConverter converts only errMatched, delegating anything else
+type matchConverter struct {
+	errorex.BaseErrorConverter
+}
+
+func (m *matchConverter) ConvertError(err error) errorex.EX {
+	if err != errMatched {
+		return m.BaseErrorConverter.ConvertError(err)
+	}
+	return errorex.New(codeSmall, small)
+}
+
+func BenchmarkNew(b *testing.B) {
+	b.Run("small", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = errorex.New(codeSmall, small)
+		}
+	})
+	b.Run("large", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = errorex.New(codeLarge, large)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = errorex.New(codeSmall, small)
+			}
+		})
+	})
+}
+
+func BenchmarkIs(b *testing.B) {
+	ex := errorex.New(codeSmall, small)
+	b.Run("match", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = errorex.Is(ex, codeSmall)
+		}
+	})
+	b.Run("mismatch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = errorex.Is(ex, codeLarge)
+		}
+	})
+	b.Run("foreign", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = errorex.Is(errOther, codeSmall)
+		}
+	})
+}
+
+func BenchmarkConverterChain(b *testing.B) {
+	for _, length := range []int{1, 4, 16} {
+		converters := make([]errorex.ErrorConverter, length)
+		for i := range converters {
+			converters[i] = &matchConverter{}
+		}
+		chain := errorex.BuildErrorConverterChain(converters...)
+		b.Run(fmt.Sprintf("len-%d/matched", length), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = chain.ConvertError(errMatched)
+			}
+		})
+		b.Run(fmt.Sprintf("len-%d/unknown", length), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = chain.ConvertError(errOther)
+			}
+		})
+	}
+}
+
+func BenchmarkSerialization(b *testing.B) {
+	for name, ex := range map[string]errorex.EX{
+		"small": errorex.New(codeSmall, small),
+		"large": errorex.New(codeLarge, large),
+	} {
+		b.Run(name+"/Error", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = ex.Error()
+			}
+		})
+		b.Run(name+"/AppendError", func(b *testing.B) {
+			b.ReportAllocs()
+			dst := make([]byte, 0, 8192)
+			for i := 0; i < b.N; i++ {
+				dst = errorex.AppendError(dst[:0], ex)
+			}
+		})
+	}
+}
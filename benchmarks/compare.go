@@ -0,0 +1,158 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package benchmarks holds the official errorex benchmark suite and the helpers used to compare runs.
+//
+// Run the suite with:
+//
+//	go test -run xxx -bench . -benchmem -count 5 ./benchmarks > new.txt
+//
+// and compare it against a baseline with errorex-benchcmp (see cmd/errorex-benchcmp).
+package benchmarks
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Result is the averaged measurement of one benchmark
+type Result struct {
+	Name        string
+	Runs        int
+	NsPerOp     float64
+	BytesPerOp  float64
+	AllocsPerOp float64
+}
+
+// Delta is the comparison of one benchmark between two runs
+type Delta struct {
+	Name string
+	Old  Result
+	New  Result
+	// Change is the relative change of ns/op, 0.1 means 10% slower
+	Change float64
+	// Regression is set when Change is above the threshold given to Compare
+	Regression bool
+}
+
+var (
+	benchLine = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+(.*)$`)
+)
+
+// ParseResults reads the output of go test -bench and returns the results by benchmark name.
+// Repeated runs of the same benchmark (-count) are averaged, the GOMAXPROCS suffix is removed from the names.
+func ParseResults(r io.Reader) (map[string]Result, error) {
+	results := make(map[string]Result)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		match := benchLine.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		measure, err := parseMeasures(match[2])
+		if err != nil {
+			return nil, fmt.Errorf("benchmark %s: %w", match[1], err)
+		}
+		result := results[match[1]]
+		result.Name = match[1]
+		result.NsPerOp = (result.NsPerOp*float64(result.Runs) + measure.NsPerOp) / float64(result.Runs+1)
+		result.BytesPerOp = (result.BytesPerOp*float64(result.Runs) + measure.BytesPerOp) / float64(result.Runs+1)
+		result.AllocsPerOp = (result.AllocsPerOp*float64(result.Runs) + measure.AllocsPerOp) / float64(result.Runs+1)
+		result.Runs++
+		results[match[1]] = result
+	}
+	return results, scanner.Err()
+}
+
+// parseMeasures parses the "value unit" pairs of a benchmark line
+func parseMeasures(text string) (Result, error) {
+	var result Result
+	fields := strings.Fields(text)
+	for i := 0; i+1 < len(fields); i += 2 {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return result, err
+		}
+		switch fields[i+1] {
+		case "ns/op":
+			result.NsPerOp = value
+		case "B/op":
+			result.BytesPerOp = value
+		case "allocs/op":
+			result.AllocsPerOp = value
+		}
+	}
+	return result, nil
+}
+
+// Compare compares the benchmarks present in both runs, sorted by name.
+// A benchmark is flagged as a regression when its ns/op grows more than threshold (0.1 for 10%),
+// or when it allocates more than in the baseline.
+func Compare(baseline, current map[string]Result, threshold float64) []Delta {
+	deltas := make([]Delta, 0, len(current))
+	for name, result := range current {
+		old, ok := baseline[name]
+		if !ok || old.NsPerOp == 0 {
+			continue
+		}
+		change := (result.NsPerOp - old.NsPerOp) / old.NsPerOp
+		deltas = append(deltas, Delta{
+			Name:       name,
+			Old:        old,
+			New:        result,
+			Change:     change,
+			Regression: change > threshold || result.AllocsPerOp > old.AllocsPerOp,
+		})
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		return deltas[i].Name < deltas[j].Name
+	})
+	return deltas
+}
+
+// WriteReport writes a human readable table of the deltas and returns whether any of them is a regression
+func WriteReport(w io.Writer, deltas []Delta) (bool, error) {
+	regression := false
+	if _, err := fmt.Fprintf(w, "%-50s %14s %14s %9s %12s\n", "benchmark", "old ns/op", "new ns/op", "delta", "allocs"); err != nil {
+		return false, err
+	}
+	for _, delta := range deltas {
+		mark := ""
+		if delta.Regression {
+			mark = " REGRESSION"
+			regression = true
+		}
+		_, err := fmt.Fprintf(w, "%-50s %14.1f %14.1f %+8.1f%% %5.0f -> %-4.0f%s\n",
+			delta.Name, delta.Old.NsPerOp, delta.New.NsPerOp, delta.Change*100,
+			delta.Old.AllocsPerOp, delta.New.AllocsPerOp, mark)
+		if err != nil {
+			return regression, err
+		}
+	}
+	return regression, nil
+}
@@ -0,0 +1,80 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package benchmarks
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	baselineOutput = `goos: linux
+BenchmarkNew/small-8         	 2000000	       100.0 ns/op	      48 B/op	       1 allocs/op
+BenchmarkNew/small-8         	 2000000	       120.0 ns/op	      48 B/op	       1 allocs/op
+BenchmarkIs/match-8          	 1000000	       200.0 ns/op
+PASS
+`
+	currentOutput = `BenchmarkNew/small-8         	 2000000	       180.0 ns/op	      48 B/op	       1 allocs/op
+BenchmarkIs/match-8          	 1000000	       190.0 ns/op
+BenchmarkIs/foreign-8        	 1000000	       10.0 ns/op
+`
+)
+
+func TestParseResults(t *testing.T) {
+	t.Run("should average repeated runs", func(t *testing.T) {
+		results, err := ParseResults(strings.NewReader(baselineOutput))
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.Equal(t, 110.0, results["BenchmarkNew/small"].NsPerOp)
+		assert.Equal(t, 2, results["BenchmarkNew/small"].Runs)
+		assert.Equal(t, 1.0, results["BenchmarkNew/small"].AllocsPerOp)
+	})
+
+	t.Run("should fail on malformed measures", func(t *testing.T) {
+		_, err := ParseResults(strings.NewReader("BenchmarkNew-8 100 abc ns/op"))
+		assert.Error(t, err)
+	})
+}
+
+func TestCompare(t *testing.T) {
+	baseline, _ := ParseResults(strings.NewReader(baselineOutput))
+	current, _ := ParseResults(strings.NewReader(currentOutput))
+
+	deltas := Compare(baseline, current, 0.1)
+
+	assert.Len(t, deltas, 2)
+	assert.Equal(t, "BenchmarkIs/match", deltas[0].Name)
+	assert.False(t, deltas[0].Regression)
+	assert.Equal(t, "BenchmarkNew/small", deltas[1].Name)
+	assert.True(t, deltas[1].Regression)
+
+	var report bytes.Buffer
+	regression, err := WriteReport(&report, deltas)
+	assert.NoError(t, err)
+	assert.True(t, regression)
+	assert.Contains(t, report.String(), "REGRESSION")
+}
@@ -0,0 +1,52 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+// Boundary returns the first errorex in the chain of err as it may cross a trust boundary, such as a response to
+// an external client: the code, the public detail (see Exposed and the projection for SinkExternal), the instance
+// ID and timestamp and the retry hint are kept, the causes, the stack trace and the metadata are stripped. The
+// same error can then be logged internally in full and returned externally:
+//
+//	log.Error(err)
+//	httpex.WriteError(w, errorex.Boundary(err))
+//
+// Errors without an errorex become an ErrCodeUnknownError errorex with an empty detail, so their messages don't
+// leak. It returns nil for nil errors.
+func Boundary(err error) EX {
+	if err == nil {
+		return nil
+	}
+	target, ok := firstEX(err)
+	if !ok {
+		return &ex{code: ErrCodeUnknownError, detail: UnknownErrorDetail{}}
+	}
+	public := Projected(Exposed(target), SinkExternal)
+	bounded := &ex{code: public.Code(), detail: public.Detail()}
+	if e, ok := target.(*ex); ok {
+		bounded.id = e.id
+		bounded.timestamp = e.timestamp
+		bounded.retryAfter = e.retryAfter
+	}
+	return bounded
+}
@@ -0,0 +1,80 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type boundaryDetail struct {
+	Constraint string `json:"constraint"`
+	Statement  string `json:"statement"`
+}
+
+func TestBoundary(t *testing.T) {
+	RegisterErrorCode("boundary.conflict", "Conflict", boundaryDetail{})
+	RegisterProjection("boundary.conflict", SinkExternal, func(detail boundaryDetail) any {
+		return boundaryDetail{Constraint: detail.Constraint}
+	})
+	defer SetInstanceConfig(GetInstanceConfig())
+	SetInstanceConfig(InstanceConfig{IDs: true})
+	defer SetStackConfig(GetStackConfig())
+	SetStackConfig(StackConfig{Enabled: true})
+
+	t.Run("should strip the internals of the errorex", func(t *testing.T) {
+		internal := Wrap(errors.New("pq: duplicate key"), "boundary.conflict",
+			boundaryDetail{Constraint: "users_email_key", Statement: "INSERT INTO users"},
+			WithTenant("acme"), WithRetryAfter(time.Second))
+
+		bounded := Boundary(fmt.Errorf("creating user: %w", internal))
+
+		assert.Equal(t, "boundary.conflict", bounded.Code())
+		assert.Equal(t, boundaryDetail{Constraint: "users_email_key"}, bounded.Detail())
+		id, _ := InstanceID(internal)
+		boundedID, _ := InstanceID(bounded)
+		assert.Equal(t, id, boundedID)
+		delay, _ := RetryAfter(bounded)
+		assert.Equal(t, time.Second, delay)
+		assert.Nil(t, errors.Unwrap(bounded))
+		assert.Nil(t, bounded.(StackTracer).StackTrace())
+		_, ok := Metadata(bounded)
+		assert.False(t, ok)
+		assert.NotContains(t, bounded.Error(), "INSERT")
+		assert.Contains(t, internal.Error(), "INSERT")
+	})
+
+	t.Run("should hide the message of other errors", func(t *testing.T) {
+		bounded := Boundary(errors.New("dial tcp 10.0.0.1:5432: connection refused"))
+
+		assert.True(t, Is(bounded, ErrCodeUnknownError))
+		assert.Equal(t, UnknownErrorDetail{}, bounded.Detail())
+		assert.Nil(t, bounded.(StackTracer).StackTrace())
+		assert.Nil(t, Boundary(nil))
+	})
+}
@@ -0,0 +1,117 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WithVolatileFields marks detail fields, by path (dot separated JSON names such as "attempt" or "upstream.latency"),
+// as volatile: they change between occurrences of the same failure, such as timestamps or durations, and are left
+// out of CacheKey
+func WithVolatileFields(paths ...string) RegistrationOption {
+	return func(registry *errorCodeRegistry) {
+		registry.volatileFields = append(registry.volatileFields, paths...)
+		sort.Strings(registry.volatileFields)
+	}
+}
+
+// CacheKey returns a key identifying the failure of err, so negative-result caches and singleflight groups key on
+// the failure rather than on the full message: the code of the first errorex in its chain (aliases resolved)
+// followed by a SHA-256 of its detail, normalized as JSON with sorted keys and without the fields marked with
+// WithVolatileFields, or of the name of its type when it cannot be encoded. Errors without an errorex are keyed by
+// a SHA-256 of their message, nil errors by an empty key.
+func CacheKey(err error) string {
+	if err == nil {
+		return ""
+	}
+	target, ok := firstEX(err)
+	if !ok {
+		sum := sha256.Sum256([]byte(err.Error()))
+		return hex.EncodeToString(sum[:])
+	}
+	code := canonicalCode(target.Code())
+	sum := sha256.Sum256(detailDigest(target.Detail(), volatileFieldsOf(code)))
+	return code + ":" + hex.EncodeToString(sum[:])
+}
+
+// volatileFieldsOf returns the paths of the volatile fields of the code
+func volatileFieldsOf(code string) []string {
+	if codeRegistry, ok := lookupCode(code); ok {
+		return codeRegistry.volatileFields
+	}
+	return nil
+}
+
+// normalizedDetail returns the JSON encoding of the detail with sorted keys and without the volatile fields, ok is
+// false when the detail cannot be encoded, e.g. with channel fields, cycles or NaN floats
+func normalizedDetail(detail any, volatile []string) (normalized []byte, ok bool) {
+	data, err := GetJSONCodec().Marshal(detail)
+	if err != nil {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic any
+	if decoder.Decode(&generic) != nil {
+		return data, true
+	}
+	for _, path := range volatile {
+		removePath(generic, strings.Split(path, "."))
+	}
+	// encoding/json sorts the keys of maps
+	normalized, err = json.Marshal(generic)
+	if err != nil {
+		return data, true
+	}
+	return normalized, true
+}
+
+// detailDigest returns the bytes identifying the detail for CacheKey and Hash: its normalized encoding, or the name
+// of its type when it cannot be encoded
+func detailDigest(detail any, volatile []string) []byte {
+	if normalized, ok := normalizedDetail(detail, volatile); ok {
+		return normalized
+	}
+	return []byte(fmt.Sprintf("%T", detail))
+}
+
+// removePath deletes the field of a decoded JSON object at the path
+func removePath(value any, path []string) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		delete(object, path[0])
+		return
+	}
+	removePath(object[path[0]], path[1:])
+}
@@ -0,0 +1,80 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type upstreamDetail struct {
+	Host    string        `json:"host"`
+	Latency time.Duration `json:"latency"`
+}
+
+type lookupDetail struct {
+	Key      string         `json:"key"`
+	Attempt  int            `json:"attempt"`
+	Upstream upstreamDetail `json:"upstream"`
+}
+
+func TestCacheKey(t *testing.T) {
+	RegisterErrorCode("test.cache.miss", "test description", lookupDetail{}, WithVolatileFields("attempt", "upstream.latency"))
+	RegisterErrorCode("test.cache.other", "test description", lookupDetail{})
+	RegisterAlias("test.cache.not_found", "test.cache.miss")
+
+	t.Run("should ignore the volatile fields and the instance metadata", func(t *testing.T) {
+		SetInstanceConfig(InstanceConfig{IDs: true, Timestamps: true})
+		defer SetInstanceConfig(InstanceConfig{})
+		first := New("test.cache.miss", lookupDetail{Key: "user:1", Attempt: 1, Upstream: upstreamDetail{Host: "db", Latency: time.Second}})
+		second := New("test.cache.not_found", lookupDetail{Key: "user:1", Attempt: 3, Upstream: upstreamDetail{Host: "db", Latency: time.Minute}})
+		assert.Equal(t, CacheKey(first), CacheKey(fmt.Errorf("loading: %w", second)))
+		assert.True(t, strings.HasPrefix(CacheKey(first), "test.cache.miss:"))
+	})
+
+	t.Run("should tell failures apart", func(t *testing.T) {
+		key := CacheKey(New("test.cache.miss", lookupDetail{Key: "user:1"}))
+		assert.NotEqual(t, key, CacheKey(New("test.cache.miss", lookupDetail{Key: "user:2"})))
+		assert.NotEqual(t, key, CacheKey(New("test.cache.miss", lookupDetail{Key: "user:1", Upstream: upstreamDetail{Host: "replica"}})))
+		assert.NotEqual(t,
+			CacheKey(New("test.cache.other", lookupDetail{Attempt: 1})),
+			CacheKey(New("test.cache.other", lookupDetail{Attempt: 2})))
+	})
+
+	t.Run("should key other errors by message", func(t *testing.T) {
+		assert.Equal(t, CacheKey(errors.New("boom")), CacheKey(errors.New("boom")))
+		assert.NotEqual(t, CacheKey(errors.New("boom")), CacheKey(errors.New("bang")))
+		assert.Empty(t, CacheKey(nil))
+	})
+
+	t.Run("should list the volatile fields", func(t *testing.T) {
+		info, _ := Lookup("test.cache.miss")
+		assert.Equal(t, []string{"attempt", "upstream.latency"}, info.VolatileFields)
+	})
+}
@@ -0,0 +1,222 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// RegistrationOption attaches metadata to a code when it is registered
+type RegistrationOption func(registry *errorCodeRegistry)
+
+// CodeInfo describes a registered code, as returned by Catalog
+type CodeInfo struct {
+	Code        string
+	Description string
+	// DetailType is the registered detail type, nil when the detail was registered as a nil interface, an interface
+	// type for codes registered with RegisterInterfaceCode
+	DetailType reflect.Type
+	// HTTPStatus is the HTTP status mapped to the code, zero when not set
+	HTTPStatus int
+	// GRPCCode is the gRPC status code mapped to the code, zero (OK) when not set
+	GRPCCode uint32
+	// ExitCode is the process exit status mapped to the code, zero when not set
+	ExitCode int
+	// ProblemType is the RFC 7807 problem type URI set with WithProblemType, empty when not set
+	ProblemType string
+	// Severity is the severity registered with WithSeverity, SeverityError when not set
+	Severity Severity
+	// FaultClass is the SLO impact class of the code, see FaultClass
+	FaultClass Fault
+	// Retryable tells if the code was registered with WithRetryable
+	Retryable bool
+	// TripsCircuit tells if errors with the code count as failures for circuit breakers, see IsCircuitTripworthy
+	TripsCircuit bool
+	// Parent is the code set with WithParent, empty when not set
+	Parent string
+	// Aliases are the other names registered for the code with RegisterAlias, sorted
+	Aliases []string
+	// DeprecatedFields are the JSON names of the detail fields marked with WithDeprecatedFields, sorted
+	DeprecatedFields []string
+	// VolatileFields are the paths of the detail fields marked with WithVolatileFields, sorted
+	VolatileFields []string
+	// Owner is the owner set with WithOwner, zero when not set. Inherited owners are not reported, see CodeOwner.
+	Owner Owner
+	// Examples are the example details registered with WithExamples, in order
+	Examples []Example
+}
+
+// DetailField describes a field of a detail struct as seen by encoding/json
+type DetailField struct {
+	// Name is the JSON name of the field
+	Name      string
+	OmitEmpty bool
+	Field     reflect.StructField
+}
+
+// WithHTTPStatus maps the code to an HTTP status
+func WithHTTPStatus(status int) RegistrationOption {
+	return func(registry *errorCodeRegistry) {
+		registry.httpStatus = status
+	}
+}
+
+// WithGRPCCode maps the code to a gRPC status code, e.g. WithGRPCCode(uint32(codes.NotFound))
+func WithGRPCCode(code uint32) RegistrationOption {
+	return func(registry *errorCodeRegistry) {
+		registry.grpcCode = code
+	}
+}
+
+// WithProblemType sets the RFC 7807 problem type URI of the code, written by httpex.ProblemWriter instead of the
+// URI built from its template
+func WithProblemType(uri string) RegistrationOption {
+	return func(registry *errorCodeRegistry) {
+		registry.problemType = uri
+	}
+}
+
+// WithExitCode maps the code to a process exit status, used by the cli package
+func WithExitCode(status int) RegistrationOption {
+	return func(registry *errorCodeRegistry) {
+		registry.exitCode = status
+	}
+}
+
+// Catalog returns every registered code sorted by code, aliases are listed in the CodeInfo of their code
+func Catalog() []CodeInfo {
+	var catalog []CodeInfo
+	rangeCodes(func(codeRegistry errorCodeRegistry) {
+		if codeRegistry.alias != "" {
+			return
+		}
+		info := codeRegistry.info()
+		info.Aliases = aliasesOf(info.Code)
+		catalog = append(catalog, info)
+	})
+	sort.Slice(catalog, func(i, j int) bool {
+		return catalog[i].Code < catalog[j].Code
+	})
+	return catalog
+}
+
+// Lookup returns the description of a registered code, aliases resolve to the CodeInfo of their code
+func Lookup(code string) (CodeInfo, bool) {
+	codeRegistry, ok := lookupCode(code)
+	if !ok {
+		return CodeInfo{}, false
+	}
+	info := codeRegistry.info()
+	info.Aliases = aliasesOf(info.Code)
+	return info, true
+}
+
+// info exposes the registry as a CodeInfo
+func (r errorCodeRegistry) info() CodeInfo {
+	info := CodeInfo{
+		Code:         r.code,
+		Description:  r.description,
+		DetailType:   r.detailType,
+		HTTPStatus:   r.httpStatus,
+		GRPCCode:     r.grpcCode,
+		ExitCode:     r.exitCode,
+		ProblemType:  r.problemType,
+		Severity:     r.severityOrDefault(),
+		FaultClass:   r.fault(),
+		Retryable:    r.retryable,
+		TripsCircuit: r.trips(),
+		Parent:       r.parent,
+		Owner:        r.owner,
+	}
+	if len(r.deprecatedFields) > 0 {
+		info.DeprecatedFields = append([]string(nil), r.deprecatedFields...)
+	}
+	if len(r.volatileFields) > 0 {
+		info.VolatileFields = append([]string(nil), r.volatileFields...)
+	}
+	info.Examples = r.renderExamples()
+	return info
+}
+
+// DetailFields returns the fields of a detail struct type that encoding/json serializes, in declaration order.
+// Pointers are dereferenced, fields of embedded structs are promoted and fields tagged with "-" are left out.
+// The Index of a promoted field is its full index path from t, as with reflect.VisibleFields. Structs embedding
+// themselves, directly or not, are walked once. It returns nil for types that are not structs.
+func DetailFields(t reflect.Type) []DetailField {
+	return detailFields(t, nil, make(map[reflect.Type]bool))
+}
+
+// detailFields returns the fields of the detail struct type, their index paths prefixed with index. visiting holds
+// the structs being walked, whose embedding again is skipped.
+func detailFields(t reflect.Type, index []int, visiting map[reflect.Type]bool) []DetailField {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || visiting[t] {
+		return nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	var fields []DetailField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		field.Index = append(append([]int(nil), index...), i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, detailFields(embedded, field.Index, visiting)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, DetailField{
+			Name:      name,
+			OmitEmpty: strings.Contains(","+options+",", ",omitempty,"),
+			Field:     field,
+		})
+	}
+	return fields
+}
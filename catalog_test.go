@@ -0,0 +1,119 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type catalogBase struct {
+	Resource string `json:"resource"`
+}
+
+type catalogDetail struct {
+	catalogBase
+	ID       string `json:"id"`
+	Note     string `json:"note,omitempty"`
+	Internal string `json:"-"`
+	Untagged int
+	hidden   bool
+}
+
+func TestCatalog(t *testing.T) {
+	RegisterErrorCode("test.catalog", "test description", catalogDetail{}, WithHTTPStatus(http.StatusNotFound), WithGRPCCode(5))
+
+	t.Run("should list the registered codes sorted", func(t *testing.T) {
+		catalog := Catalog()
+		assert.NotEmpty(t, catalog)
+		for i := 1; i < len(catalog); i++ {
+			assert.Less(t, catalog[i-1].Code, catalog[i].Code)
+		}
+		assert.Contains(t, catalog, CodeInfo{
+			Code:        "test.catalog",
+			Description: "test description",
+			DetailType:  reflect.TypeOf(catalogDetail{}),
+			HTTPStatus:  http.StatusNotFound,
+			GRPCCode:    5,
+			Severity:    SeverityError,
+			FaultClass:  ClientFault,
+		})
+	})
+
+	t.Run("should list the codes of a frozen registry", func(t *testing.T) {
+		size := len(Catalog())
+		Freeze()
+		defer unfreezeRegistry()
+		assert.Len(t, Catalog(), size)
+	})
+
+	t.Run("should look up a code", func(t *testing.T) {
+		info, ok := Lookup("test.catalog")
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusNotFound, info.HTTPStatus)
+
+		_, ok = Lookup("unregistered.code")
+		assert.False(t, ok)
+	})
+}
+
+func TestDetailFields(t *testing.T) {
+	t.Run("should list the fields serialized by encoding/json", func(t *testing.T) {
+		fields := DetailFields(reflect.TypeOf(&catalogDetail{}))
+
+		names := make([]string, len(fields))
+		for i, field := range fields {
+			names[i] = field.Name
+		}
+		assert.Equal(t, []string{"resource", "id", "note", "Untagged"}, names)
+		assert.True(t, fields[2].OmitEmpty)
+		assert.False(t, fields[1].OmitEmpty)
+	})
+
+	t.Run("should give the full index path of promoted fields", func(t *testing.T) {
+		fields := DetailFields(reflect.TypeOf(catalogDetail{}))
+		assert.Equal(t, []int{0, 0}, fields[0].Field.Index)
+		assert.Equal(t, []int{1}, fields[1].Field.Index)
+	})
+
+	t.Run("should walk self-embedding structs once", func(t *testing.T) {
+		type node struct {
+			*node
+			Name string `json:"name"`
+		}
+
+		fields := DetailFields(reflect.TypeOf(node{}))
+
+		assert.Len(t, fields, 1)
+		assert.Equal(t, "name", fields[0].Name)
+	})
+
+	t.Run("should return nil for non struct types", func(t *testing.T) {
+		assert.Nil(t, DetailFields(reflect.TypeOf("")))
+		assert.Nil(t, DetailFields(nil))
+	})
+}
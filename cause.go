@@ -0,0 +1,145 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"reflect"
+	"sync/atomic"
+)
+
+// DefaultMaxCauseDepth is the number of causes followed by serialization and CauseChain when no limit is set
+const DefaultMaxCauseDepth = 32
+
+var maxCauseDepth atomic.Int64
+
+// SetMaxCauseDepth sets the number of causes followed by serialization and CauseChain, the causes past it are
+// summarized as {"truncated": n, "codes": [...]}. Zero or less restores DefaultMaxCauseDepth.
+func SetMaxCauseDepth(depth int) {
+	maxCauseDepth.Store(int64(max(depth, 0)))
+}
+
+// GetMaxCauseDepth returns the number of causes followed by serialization and CauseChain
+func GetMaxCauseDepth() int {
+	if depth := maxCauseDepth.Load(); depth > 0 {
+		return int(depth)
+	}
+	return DefaultMaxCauseDepth
+}
+
+// WithCause sets the error that caused the errorex, returned by Unwrap and serialized as "cause"
+func WithCause(cause error) Option {
+	return func(e *ex) {
+		e.cause = cause
+	}
+}
+
+// Wrap returns a new errorex caused by another error, like New with WithCause
+func Wrap[T any](cause error, code string, detail T, options ...Option) EX {
+	code, internal := checkDetail(code, detail)
+	if internal != nil {
+		return internal
+	}
+	e := newEX(code, detail, 1)
+	e.cause = cause
+	applyOptions(e, options)
+	return e
+}
+
+// Unwrap returns the cause set by Wrap or WithCause, so errors.Is and errors.As follow it
+func (e *ex) Unwrap() error {
+	return e.cause
+}
+
+// CauseChain returns the causes of err, following Unwrap() error up to GetMaxCauseDepth causes.
+// The walk stops at the first cause already seen, so cycles are not followed. Truncated tells if causes were
+// left out because of the depth limit.
+func CauseChain(err error) (causes []error, truncated bool) {
+	depth := GetMaxCauseDepth()
+	seen := map[error]bool{}
+	if err != nil {
+		visited(seen, err)
+	}
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+		if visited(seen, cause) {
+			return causes, false
+		}
+		if len(causes) == depth {
+			return causes, true
+		}
+		causes = append(causes, cause)
+	}
+	return causes, false
+}
+
+// CauseTail summarizes the causes left out of a serialized errorex by the depth limit
+type CauseTail struct {
+	// Truncated is the number of causes left out
+	Truncated int `json:"truncated"`
+	// Codes are the distinct errorex codes of the causes left out, in chain order
+	Codes []string `json:"codes,omitempty"`
+}
+
+// tailOf summarizes the chain starting at cause, stopping at cycles
+func tailOf(cause error, seen map[error]bool) CauseTail {
+	var tail CauseTail
+	codes := map[string]bool{}
+	for ; cause != nil && !visited(seen, cause); cause = errors.Unwrap(cause) {
+		tail.Truncated++
+		if e, ok := cause.(EX); ok && !codes[e.Code()] {
+			codes[e.Code()] = true
+			tail.Codes = append(tail.Codes, e.Code())
+		}
+	}
+	return tail
+}
+
+// reaches tells if the chain starting at err reaches one of the errors in seen
+func reaches(err error, seen map[error]bool) bool {
+	walked := map[error]bool{}
+	for ; err != nil; err = errors.Unwrap(err) {
+		if !reflect.TypeOf(err).Comparable() {
+			continue
+		}
+		if seen[err] {
+			return true
+		}
+		if visited(walked, err) {
+			return false
+		}
+	}
+	return false
+}
+
+// visited marks err as seen and tells if it already was. Errors of non comparable types are never seen twice.
+func visited(seen map[error]bool, err error) bool {
+	if !reflect.TypeOf(err).Comparable() {
+		return false
+	}
+	if seen[err] {
+		return true
+	}
+	seen[err] = true
+	return false
+}
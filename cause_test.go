@@ -0,0 +1,143 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type loopError struct {
+	next error
+}
+
+func (e *loopError) Error() string {
+	return "loop"
+}
+
+func (e *loopError) Unwrap() error {
+	return e.next
+}
+
+func TestWrap(t *testing.T) {
+	RegisterErrorCode("cause.outer", "Outer", ErrorEXDetail{})
+	RegisterErrorCode("cause.inner", "Inner", ErrorEXDetail{})
+
+	t.Run("should follow the cause", func(t *testing.T) {
+		inner := New("cause.inner", ErrorEXDetail{Code: "inner"})
+		outer := Wrap(fmt.Errorf("loading: %w", inner), "cause.outer", ErrorEXDetail{})
+
+		assert.True(t, errors.Is(outer, inner))
+		assert.Equal(t, `{"code": "cause.outer", "detail": {"code":""}, "cause": {"type":"*fmt.wrapError",`+
+			`"message":"loading: {\"code\": \"cause.inner\", \"detail\": {\"code\":\"inner\"}}"}}`, outer.Error())
+	})
+
+	t.Run("should serialize errorex causes", func(t *testing.T) {
+		outer := New("cause.outer", ErrorEXDetail{}, WithCause(New("cause.inner", ErrorEXDetail{Code: "inner"})))
+
+		assert.Equal(t, `{"code": "cause.outer", "detail": {"code":""}, "cause": {"code": "cause.inner", "detail": {"code":"inner"}}}`, outer.Error())
+	})
+
+	t.Run("should parse the causes", func(t *testing.T) {
+		outer := Wrap(Wrap(errors.New("disk full"), "cause.inner", ErrorEXDetail{Code: "inner"}), "cause.outer", ErrorEXDetail{})
+
+		parsed, err := ParseJSON([]byte(outer.Error()))
+
+		assert.NoError(t, err)
+		assert.Equal(t, outer.Error(), parsed.Error())
+		var inner EX
+		assert.True(t, errors.As(errors.Unwrap(parsed), &inner))
+		assert.Equal(t, "cause.inner", inner.Code())
+		assert.EqualError(t, errors.Unwrap(inner), "disk full")
+	})
+}
+
+func TestCauseLimits(t *testing.T) {
+	RegisterErrorCode("cause.link", "Link", ErrorEXDetail{})
+	RegisterErrorCode("cause.root", "Root", ErrorEXDetail{})
+	defer SetMaxCauseDepth(0)
+
+	chain := func(links int) EX {
+		err := New("cause.root", ErrorEXDetail{})
+		for i := 0; i < links; i++ {
+			err = Wrap(err, "cause.link", ErrorEXDetail{})
+		}
+		return err
+	}
+
+	t.Run("should summarize the causes past the depth", func(t *testing.T) {
+		SetMaxCauseDepth(1)
+		assert.Equal(t, 1, GetMaxCauseDepth())
+
+		assert.Equal(t, `{"code": "cause.link", "detail": {"code":""}, "cause": {"code": "cause.link", "detail": {"code":""}, `+
+			`"cause": {"truncated":3,"codes":["cause.link","cause.root"]}}}`, chain(4).Error())
+
+		causes, truncated := CauseChain(chain(4))
+		assert.Len(t, causes, 1)
+		assert.True(t, truncated)
+	})
+
+	t.Run("should parse the causes up to the depth", func(t *testing.T) {
+		SetMaxCauseDepth(0)
+		payload := []byte(chain(4).Error())
+		SetMaxCauseDepth(2)
+
+		parsed, err := ParseJSON(payload)
+		assert.NoError(t, err)
+		causes, truncated := CauseChain(parsed)
+		assert.Len(t, causes, 2)
+		assert.False(t, truncated)
+	})
+
+	t.Run("should restore the default depth", func(t *testing.T) {
+		SetMaxCauseDepth(0)
+		assert.Equal(t, DefaultMaxCauseDepth, GetMaxCauseDepth())
+
+		causes, truncated := CauseChain(chain(3))
+		assert.Len(t, causes, 3)
+		assert.False(t, truncated)
+
+		parsed, err := ParseJSON([]byte(chain(3).Error()))
+		assert.NoError(t, err)
+		assert.Equal(t, chain(3).Error(), parsed.Error())
+	})
+
+	t.Run("should stop at cycles", func(t *testing.T) {
+		loop := &loopError{}
+		err := Wrap(loop, "cause.link", ErrorEXDetail{})
+		loop.next = err
+
+		assert.Equal(t, `{"code": "cause.link", "detail": {"code":""}, "cause": {"cycle": true}}`, err.Error())
+		causes, truncated := CauseChain(err)
+		assert.Equal(t, []error{loop}, causes)
+		assert.False(t, truncated)
+
+		parsed, parseErr := ParseJSON([]byte(err.Error()))
+		assert.NoError(t, parseErr)
+		assert.Nil(t, errors.Unwrap(parsed))
+	})
+}
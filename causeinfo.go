@@ -0,0 +1,114 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+const (
+	// MaxCauseFields is the number of exported fields of a cause recorded in its CauseInfo
+	MaxCauseFields = 16
+	// MaxCauseFieldLength is the number of bytes of a string field after which it is truncated in a CauseInfo
+	MaxCauseFieldLength = 256
+)
+
+// CauseInfo describes a cause that is not an errorex, as serialized under "cause": its message, its concrete Go
+// type and the scalar exported fields of its struct, such as the SQLSTATE of a database driver error.
+// ParseJSON restores such causes as *CauseInfo, which errors.As finds.
+type CauseInfo struct {
+	Type    string         `json:"type"`
+	Message string         `json:"message"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+// Error returns the message of the cause
+func (c *CauseInfo) Error() string {
+	return c.Message
+}
+
+// DescribeCause returns the CauseInfo of an error. Only exported fields of boolean, numeric and string kinds are
+// recorded, up to MaxCauseFields, strings are truncated to MaxCauseFieldLength bytes and NaN or infinite floats
+// are left out. Nil pointers are described as "<nil>" without calling their Error method.
+func DescribeCause(err error) CauseInfo {
+	value := reflect.ValueOf(err)
+	if value.Kind() == reflect.Pointer && value.IsNil() {
+		return CauseInfo{Type: fmt.Sprintf("%T", err), Message: "<nil>"}
+	}
+	if info, ok := err.(*CauseInfo); ok {
+		return *info
+	}
+	info := CauseInfo{Type: fmt.Sprintf("%T", err), Message: err.Error()}
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return info
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return info
+	}
+	for i := 0; i < value.NumField() && len(info.Fields) < MaxCauseFields; i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldValue, ok := scalar(value.Field(i))
+		if !ok {
+			continue
+		}
+		if info.Fields == nil {
+			info.Fields = make(map[string]any)
+		}
+		info.Fields[field.Name] = fieldValue
+	}
+	return info
+}
+
+// scalar returns the value of boolean, numeric and string kinds, strings truncated to MaxCauseFieldLength and
+// non finite floats left out
+func scalar(v reflect.Value) (any, bool) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		if math.IsNaN(v.Float()) || math.IsInf(v.Float(), 0) {
+			return nil, false
+		}
+		return v.Float(), true
+	case reflect.String:
+		text := v.String()
+		if len(text) > MaxCauseFieldLength {
+			text = text[:MaxCauseFieldLength] + "..."
+		}
+		return text, true
+	}
+	return nil, false
+}
@@ -0,0 +1,133 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type driverError struct {
+	Severity string
+	SQLState string
+	Position int
+	Retry    bool
+	Query    string
+	Params   []any
+	internal string
+}
+
+func (e *driverError) Error() string {
+	return "pq: " + e.SQLState
+}
+
+func TestDescribeCause(t *testing.T) {
+	RegisterErrorCode("causeinfo.query", "Query failed", ErrorEXDetail{})
+	driverErr := &driverError{
+		Severity: "ERROR",
+		SQLState: "23505",
+		Position: 12,
+		Query:    strings.Repeat("x", MaxCauseFieldLength+10),
+		Params:   []any{1},
+		internal: "hidden",
+	}
+
+	t.Run("should record the type and the scalar exported fields", func(t *testing.T) {
+		info := DescribeCause(driverErr)
+
+		assert.Equal(t, "*errorex.driverError", info.Type)
+		assert.Equal(t, "pq: 23505", info.Message)
+		assert.Equal(t, map[string]any{
+			"Severity": "ERROR",
+			"SQLState": "23505",
+			"Position": int64(12),
+			"Retry":    false,
+			"Query":    strings.Repeat("x", MaxCauseFieldLength) + "...",
+		}, info.Fields)
+	})
+
+	t.Run("should serialize and parse the cause info", func(t *testing.T) {
+		err := Wrap(driverErr, "causeinfo.query", ErrorEXDetail{})
+		assert.Contains(t, err.Error(), `"cause": {"type":"*errorex.driverError","message":"pq: 23505","fields":{`)
+
+		parsed, parseErr := ParseJSON([]byte(err.Error()))
+
+		assert.NoError(t, parseErr)
+		var info *CauseInfo
+		assert.True(t, errors.As(parsed, &info))
+		assert.Equal(t, "*errorex.driverError", info.Type)
+		assert.Equal(t, "23505", info.Fields["SQLState"])
+		assert.Equal(t, err.Error(), parsed.Error())
+	})
+
+	t.Run("should keep the message of plain errors", func(t *testing.T) {
+		assert.Equal(t, CauseInfo{Type: "*errors.errorString", Message: "boom"}, DescribeCause(errors.New("boom")))
+	})
+	t.Run("should leave out non finite floats", func(t *testing.T) {
+		err := Wrap(&measureError{Value: math.NaN(), Limit: math.Inf(1), Unit: "ms"}, "causeinfo.query", ErrorEXDetail{})
+
+		assert.True(t, json.Valid([]byte(err.Error())))
+		assert.Equal(t, map[string]any{"Unit": "ms"}, DescribeCause(errors.Unwrap(err)).Fields)
+	})
+
+	t.Run("should describe typed nil errors", func(t *testing.T) {
+		var nilErr *driverError
+
+		assert.Equal(t, CauseInfo{Type: "*errorex.driverError", Message: "<nil>"}, DescribeCause(nilErr))
+		assert.True(t, json.Valid([]byte(Wrap(nilErr, "causeinfo.query", ErrorEXDetail{}).Error())))
+	})
+
+	t.Run("should write the message when the cause info cannot be encoded", func(t *testing.T) {
+		SetJSONCodec(JSONCodecFunc{
+			MarshalFunc: func(v any) ([]byte, error) {
+				if _, ok := v.(CauseInfo); ok {
+					return nil, errors.New("unsupported")
+				}
+				return json.Marshal(v)
+			},
+			UnmarshalFunc: json.Unmarshal,
+		})
+		defer SetJSONCodec(nil)
+
+		err := Wrap(driverErr, "causeinfo.query", ErrorEXDetail{})
+
+		assert.Contains(t, err.Error(), `"cause": "pq: 23505"`)
+		assert.True(t, json.Valid([]byte(err.Error())))
+	})
+}
+
+type measureError struct {
+	Value float64
+	Limit float64
+	Unit  string
+}
+
+func (e *measureError) Error() string {
+	return "measure out of range"
+}
@@ -0,0 +1,58 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import "errors"
+
+// WithCircuitTripping classifies whether errors with the code count as failures for circuit breakers.
+// Codes registered without it trip circuits when they are retryable (see WithRetryable): an unavailable or timed
+// out dependency should open the circuit, an invalid argument should not.
+func WithCircuitTripping(trips bool) RegistrationOption {
+	return func(registry *errorCodeRegistry) {
+		registry.tripsCircuit = &trips
+	}
+}
+
+// IsCircuitTripworthy checks if err should count as a failure for a circuit breaker, following the classification
+// of the code of the first errorex in its chain. Errors without an errorex in their chain, or with an unregistered
+// code, are failures.
+func IsCircuitTripworthy(err error) bool {
+	if err == nil {
+		return false
+	}
+	var target EX
+	if !errors.As(err, &target) {
+		return true
+	}
+	codeRegistry, ok := lookupCode(target.Code())
+	return !ok || codeRegistry.trips()
+}
+
+// trips tells if errors with the code trip circuit breakers
+func (r errorCodeRegistry) trips() bool {
+	if r.tripsCircuit != nil {
+		return *r.tripsCircuit
+	}
+	return r.retryable
+}
@@ -0,0 +1,52 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package circuit adapts errorex.IsCircuitTripworthy to circuit breaker libraries, so every breaker of a service
+// trips on the same error semantics. The adapters match the signatures expected by the libraries, without
+// depending on them.
+//
+// sony/gobreaker:
+//
+//	breaker := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+//		Name:         "payments",
+//		IsSuccessful: circuit.IsSuccessful,
+//	})
+//
+// failsafe-go:
+//
+//	breaker := circuitbreaker.Builder[*http.Response]().
+//		HandleIf(circuit.HandleIf[*http.Response]).
+//		Build()
+package circuit
+
+import "github.com/fkmatsuda/errorex"
+
+// IsSuccessful reports the errors that do not trip the circuit as successes, for gobreaker Settings.IsSuccessful
+func IsSuccessful(err error) bool {
+	return !errorex.IsCircuitTripworthy(err)
+}
+
+// HandleIf reports the errors that trip the circuit as failures, for failsafe-go circuitbreaker Builder.HandleIf
+func HandleIf[R any](_ R, err error) bool {
+	return errorex.IsCircuitTripworthy(err)
+}
@@ -0,0 +1,59 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package circuit
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	errorex.RegisterErrorCode("circuit.timeout", "Timeout", errorex.ErrorEXDetail{}, errorex.WithRetryable())
+	errorex.RegisterErrorCode("circuit.invalid_argument", "Invalid argument", errorex.ErrorEXDetail{})
+}
+
+func TestIsSuccessful(t *testing.T) {
+	t.Run("should report the errors that do not trip as successes", func(t *testing.T) {
+		var isSuccessful func(err error) bool = IsSuccessful
+
+		assert.True(t, isSuccessful(nil))
+		assert.True(t, isSuccessful(errorex.New("circuit.invalid_argument", errorex.ErrorEXDetail{})))
+		assert.False(t, isSuccessful(errorex.New("circuit.timeout", errorex.ErrorEXDetail{})))
+		assert.False(t, isSuccessful(errors.New("other")))
+	})
+}
+
+func TestHandleIf(t *testing.T) {
+	t.Run("should report the errors that trip as failures", func(t *testing.T) {
+		var handleIf func(response *http.Response, err error) bool = HandleIf[*http.Response]
+
+		assert.False(t, handleIf(&http.Response{}, nil))
+		assert.False(t, handleIf(nil, errorex.New("circuit.invalid_argument", errorex.ErrorEXDetail{})))
+		assert.True(t, handleIf(nil, errorex.New("circuit.timeout", errorex.ErrorEXDetail{})))
+	})
+}
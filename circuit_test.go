@@ -0,0 +1,56 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCircuitTripworthy(t *testing.T) {
+	RegisterErrorCode("circuit.unavailable", "Unavailable", ErrorEXDetail{}, WithRetryable())
+	RegisterErrorCode("circuit.invalid", "Invalid", ErrorEXDetail{})
+	RegisterErrorCode("circuit.conflict", "Conflict", ErrorEXDetail{}, WithRetryable(), WithCircuitTripping(false))
+	RegisterErrorCode("circuit.corrupted", "Corrupted", ErrorEXDetail{}, WithCircuitTripping(true))
+
+	t.Run("should follow the retryability by default", func(t *testing.T) {
+		assert.True(t, IsCircuitTripworthy(New("circuit.unavailable", ErrorEXDetail{})))
+		assert.False(t, IsCircuitTripworthy(fmt.Errorf("wrapped: %w", New("circuit.invalid", ErrorEXDetail{}))))
+	})
+
+	t.Run("should follow the classification of the code", func(t *testing.T) {
+		assert.False(t, IsCircuitTripworthy(New("circuit.conflict", ErrorEXDetail{})))
+		assert.True(t, IsCircuitTripworthy(New("circuit.corrupted", ErrorEXDetail{})))
+		info, _ := Lookup("circuit.corrupted")
+		assert.True(t, info.TripsCircuit)
+	})
+
+	t.Run("should count other errors as failures", func(t *testing.T) {
+		assert.True(t, IsCircuitTripworthy(errors.New("other")))
+		assert.False(t, IsCircuitTripworthy(nil))
+	})
+}
@@ -0,0 +1,140 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package cli gives command line tools built on errorex a consistent exit behavior: the exit status is mapped
+// from the code of the returned error and the error is printed in a human readable form.
+//
+//	func main() {
+//		cli.Main(run)
+//	}
+//
+// The exit status of a code is the one registered with errorex.WithExitCode, or a sysexits(3) default derived from
+// its HTTP status and retryability.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// Exit statuses of sysexits(3)
+const (
+	ExitOK          = 0
+	ExitFailure     = 1
+	ExitUsage       = 64
+	ExitDataErr     = 65
+	ExitNoInput     = 66
+	ExitUnavailable = 69
+	ExitSoftware    = 70
+	ExitTempFail    = 75
+	ExitNoPerm      = 77
+	ExitConfig      = 78
+)
+
+var (
+	exit             = os.Exit
+	stderr io.Writer = os.Stderr
+)
+
+// ExitCode returns the exit status for err: ExitOK for nil, the status mapped to the code of the first errorex
+// in its chain, and ExitFailure for other errors
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var ex errorex.EX
+	if !errors.As(err, &ex) {
+		return ExitFailure
+	}
+	info, ok := errorex.Lookup(ex.Code())
+	if !ok {
+		return ExitFailure
+	}
+	if info.ExitCode != 0 {
+		return info.ExitCode
+	}
+	return defaultExitCode(info)
+}
+
+// Message returns the human readable form of err: the description and the code of its errorex followed by the
+// detail, unless it is the zero value, or the message of other errors
+func Message(err error) string {
+	var ex errorex.EX
+	if !errors.As(err, &ex) {
+		return err.Error()
+	}
+	var message strings.Builder
+	if info, ok := errorex.Lookup(ex.Code()); ok && info.Description != "" {
+		message.WriteString(info.Description)
+		message.WriteString(" (")
+		message.WriteString(ex.Code())
+		message.WriteString(")")
+	} else {
+		message.WriteString(ex.Code())
+	}
+	if detail := ex.Detail(); detail != nil && !reflect.ValueOf(detail).IsZero() {
+		if data, marshalErr := errorex.GetJSONCodec().Marshal(detail); marshalErr == nil {
+			message.WriteString(": ")
+			message.Write(data)
+		}
+	}
+	return message.String()
+}
+
+// Main runs the command and, when it fails, prints Message to stderr prefixed by the program name and exits with
+// ExitCode
+func Main(run func() error) {
+	err := run()
+	if err == nil {
+		return
+	}
+	fmt.Fprintf(stderr, "%s: %s\n", filepath.Base(os.Args[0]), Message(err))
+	exit(ExitCode(err))
+}
+
+// defaultExitCode derives the sysexits status of a code from its HTTP status and retryability
+func defaultExitCode(info errorex.CodeInfo) int {
+	switch {
+	case info.HTTPStatus == http.StatusBadRequest, info.HTTPStatus == http.StatusUnprocessableEntity:
+		return ExitDataErr
+	case info.HTTPStatus == http.StatusNotFound:
+		return ExitNoInput
+	case info.HTTPStatus == http.StatusUnauthorized, info.HTTPStatus == http.StatusForbidden:
+		return ExitNoPerm
+	case info.Retryable, info.HTTPStatus == http.StatusTooManyRequests, info.HTTPStatus == http.StatusGatewayTimeout:
+		return ExitTempFail
+	case info.HTTPStatus == http.StatusServiceUnavailable, info.HTTPStatus == http.StatusBadGateway:
+		return ExitUnavailable
+	case info.HTTPStatus >= http.StatusInternalServerError:
+		return ExitSoftware
+	}
+	return ExitFailure
+}
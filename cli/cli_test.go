@@ -0,0 +1,104 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type inputDetail struct {
+	Field string `json:"field"`
+}
+
+func init() {
+	errorex.RegisterErrorCode("cli.invalid_input", "Invalid input", inputDetail{}, errorex.WithHTTPStatus(http.StatusBadRequest))
+	errorex.RegisterErrorCode("cli.missing_file", "Missing file", inputDetail{}, errorex.WithHTTPStatus(http.StatusNotFound))
+	errorex.RegisterErrorCode("cli.timeout", "Timeout", errorex.ErrorEXDetail{}, errorex.WithRetryable())
+	errorex.RegisterErrorCode("cli.bad_config", "Bad configuration", errorex.ErrorEXDetail{}, errorex.WithExitCode(ExitConfig))
+	errorex.RegisterErrorCode("cli.crashed", "Crashed", errorex.ErrorEXDetail{}, errorex.WithHTTPStatus(http.StatusInternalServerError))
+}
+
+func TestExitCode(t *testing.T) {
+	t.Run("should map the codes", func(t *testing.T) {
+		assert.Equal(t, ExitOK, ExitCode(nil))
+		assert.Equal(t, ExitConfig, ExitCode(errorex.New("cli.bad_config", errorex.ErrorEXDetail{})))
+		assert.Equal(t, ExitDataErr, ExitCode(fmt.Errorf("parsing: %w", errorex.New("cli.invalid_input", inputDetail{}))))
+		assert.Equal(t, ExitNoInput, ExitCode(errorex.New("cli.missing_file", inputDetail{})))
+		assert.Equal(t, ExitTempFail, ExitCode(errorex.New("cli.timeout", errorex.ErrorEXDetail{})))
+		assert.Equal(t, ExitSoftware, ExitCode(errorex.New("cli.crashed", errorex.ErrorEXDetail{})))
+	})
+
+	t.Run("should default to failure", func(t *testing.T) {
+		assert.Equal(t, ExitFailure, ExitCode(errors.New("other")))
+		assert.Equal(t, ExitFailure, ExitCode(errorex.New(errorex.ErrCodeUnknownError, errorex.UnknownErrorDetail{})))
+	})
+}
+
+func TestMessage(t *testing.T) {
+	t.Run("should describe the errorex", func(t *testing.T) {
+		assert.Equal(t, `Invalid input (cli.invalid_input): {"field":"name"}`, Message(errorex.New("cli.invalid_input", inputDetail{Field: "name"})))
+		assert.Equal(t, `Timeout (cli.timeout)`, Message(errorex.New("cli.timeout", errorex.ErrorEXDetail{})))
+	})
+
+	t.Run("should use the message of other errors", func(t *testing.T) {
+		assert.Equal(t, "other", Message(errors.New("other")))
+	})
+}
+
+func TestMainExit(t *testing.T) {
+	var (
+		output bytes.Buffer
+		status = -1
+	)
+	exit = func(code int) { status = code }
+	stderr = &output
+	defer func() {
+		exit = os.Exit
+		stderr = os.Stderr
+	}()
+
+	t.Run("should print the error and exit", func(t *testing.T) {
+		Main(func() error {
+			return errorex.New("cli.missing_file", inputDetail{Field: "config.yaml"})
+		})
+
+		assert.Equal(t, ExitNoInput, status)
+		assert.Equal(t, filepath.Base(os.Args[0])+`: Missing file (cli.missing_file): {"field":"config.yaml"}`+"\n", output.String())
+	})
+
+	t.Run("should return on success", func(t *testing.T) {
+		status = -1
+		Main(func() error { return nil })
+		assert.Equal(t, -1, status)
+	})
+}
@@ -0,0 +1,83 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import "reflect"
+
+// WithDetail returns a copy of the errorex with another detail of the type registered for its code, keeping its
+// instance metadata (ID, timestamp, metadata, stack trace, retry hint, cause). It is meant for transformations of the
+// detail, such as scrubbing secrets before the error reaches an external sink.
+// It panics if the detail type does not match the registered type, see SetMode, unless it is the type of the
+// current detail, as errors parsed from other services keep their detail decoded as generic JSON, see ParseJSON.
+func WithDetail[T any](err EX, detail T) EX {
+	if e, ok := err.(*ex); ok && e.detail != nil && reflect.TypeOf(detail) == reflect.TypeOf(e.detail) {
+		copied := e.clone()
+		copied.detail = detail
+		return copied
+	}
+	code, internal := checkDetail(err.Code(), detail)
+	if internal != nil {
+		return internal
+	}
+	e, ok := err.(*ex)
+	if !ok {
+		return newEX(code, detail, 1)
+	}
+	copied := e.clone()
+	copied.detail = detail
+	return copied
+}
+
+// Annotate returns a copy of the errorex with the options applied, e.g. to attach metadata to an error received
+// from elsewhere without modifying it. Errorex errors of other implementations are returned as is.
+func Annotate(err EX, options ...Option) EX {
+	e, ok := err.(*ex)
+	if !ok || len(options) == 0 {
+		return err
+	}
+	annotated := e.clone()
+	applyOptions(annotated, options)
+	return annotated
+}
+
+// clone returns an unpooled copy of the errorex, the metadata map is copied too
+func (e *ex) clone() *ex {
+	copied := &ex{
+		code:       e.code,
+		detail:     e.detail,
+		stack:      e.stack,
+		id:         e.id,
+		timestamp:  e.timestamp,
+		retryAfter: e.retryAfter,
+		cause:      e.cause,
+		escalated:  e.escalated,
+	}
+	if e.metadata != nil {
+		copied.metadata = make(map[string]string, len(e.metadata))
+		for key, value := range e.metadata {
+			copied.metadata[key] = value
+		}
+	}
+	return copied
+}
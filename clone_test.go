@@ -0,0 +1,108 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// codedEX is an EX implemented outside of the package
+type codedEX struct {
+	code string
+}
+
+func (e codedEX) Error() string { return e.code }
+func (e codedEX) Code() string  { return e.code }
+func (e codedEX) Detail() any   { return nil }
+
+func TestWithDetail(t *testing.T) {
+	defer SetInstanceConfig(GetInstanceConfig())
+	SetInstanceConfig(InstanceConfig{IDs: true})
+
+	t.Run("should copy the errorex with the new detail", func(t *testing.T) {
+		original := New(ErrCodeUnknownError, UnknownErrorDetail{Detail: "secret"}, WithTenant("acme"))
+
+		copied := WithDetail(original, UnknownErrorDetail{Detail: "[redacted]"})
+
+		assert.Equal(t, UnknownErrorDetail{Detail: "[redacted]"}, copied.Detail())
+		assert.Equal(t, UnknownErrorDetail{Detail: "secret"}, original.Detail())
+		assert.Equal(t, "acme", Tenant(copied))
+		originalID, _ := InstanceID(original)
+		copiedID, _ := InstanceID(copied)
+		assert.Equal(t, originalID, copiedID)
+	})
+
+	t.Run("should not share pooled instances", func(t *testing.T) {
+		pooled := NewPooled(ErrCodeUnknownError, UnknownErrorDetail{Detail: "secret"})
+		copied := WithDetail(pooled, UnknownErrorDetail{})
+		Release(pooled)
+
+		assert.Equal(t, UnknownErrorDetail{}, copied.Detail())
+		assert.NotPanics(t, func() { Release(copied) })
+	})
+
+	t.Run("should create foreign errorex errors anew", func(t *testing.T) {
+		copied := WithDetail[UnknownErrorDetail](codedEX{code: ErrCodeUnknownError}, UnknownErrorDetail{Detail: "new"})
+		assert.True(t, Is(copied, ErrCodeUnknownError))
+	})
+
+	t.Run("should keep the detail type of errors from other services", func(t *testing.T) {
+		SetCatalogProvider(CatalogProviderFunc(func(code string) (CodeInfo, bool) {
+			return CodeInfo{Code: code}, code == "clone.remote"
+		}))
+		defer SetCatalogProvider(nil)
+		parsed, err := ParseJSON([]byte(`{"code": "clone.remote", "detail": {"token": "secret"}}`))
+		assert.NoError(t, err)
+
+		copied := WithDetail(parsed, any(map[string]any{"token": "[redacted]"}))
+
+		assert.Equal(t, "clone.remote", copied.Code())
+		assert.Equal(t, map[string]any{"token": "[redacted]"}, copied.Detail())
+	})
+
+	t.Run("should panic on detail type mismatch", func(t *testing.T) {
+		assert.Panics(t, func() {
+			WithDetail(New(ErrCodeUnknownError, UnknownErrorDetail{}), ErrorEXDetail{})
+		})
+	})
+}
+
+func TestAnnotate(t *testing.T) {
+	t.Run("should copy the errorex with the options applied", func(t *testing.T) {
+		original := New(ErrCodeNotRegistered, ErrorEXDetail{Code: "x"}, WithTenant("acme"))
+
+		annotated := Annotate(original, WithMetadata("task", "invoice-1"))
+
+		metadata, _ := Metadata(annotated)
+		assert.Equal(t, map[string]string{MetadataTenant: "acme", "task": "invoice-1"}, metadata)
+		metadata, _ = Metadata(original)
+		assert.Equal(t, map[string]string{MetadataTenant: "acme"}, metadata)
+	})
+
+	t.Run("should return other errors as is", func(t *testing.T) {
+		assert.Equal(t, codedEX{code: "x"}, Annotate(codedEX{code: "x"}, WithTenant("acme")))
+	})
+}
@@ -0,0 +1,105 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Command errorex-migrate renames errorex codes across Go sources.
+//
+// Given a JSON mapping of old to new codes:
+//
+//	{"billing.card_declined": "billing.payment_declined"}
+//
+// it rewrites the string literals used as codes in New, NewPooled, Is, Define, RegisterErrorCode and
+// RegisterAlias calls, and in const declarations, of the files importing errorex, keeping their formatting. With
+// -aliases it also writes a Go file registering the old codes as aliases of the new ones with DeferAlias, so
+// errors still carrying the old codes on the wire keep matching, whichever package registers the new codes and
+// whenever it does.
+//
+// Usage:
+//
+//	errorex-migrate -mapping mapping.json [-aliases billing/zz_errorex_aliases.go] [-report report.txt] [-dry-run] [dir ...]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	mappingFile := flag.String("mapping", "", "JSON file mapping old codes to new codes")
+	aliasesFile := flag.String("aliases", "", "Go file to write the alias registrations to")
+	reportFile := flag.String("report", "", "file to write the migration report to, stdout when empty")
+	dryRun := flag.Bool("dry-run", false, "report the changes without writing them")
+	flag.Parse()
+	if *mappingFile == "" {
+		fmt.Fprintln(os.Stderr, "usage: errorex-migrate -mapping mapping.json [-aliases file.go] [-report report.txt] [-dry-run] [dir ...]")
+		os.Exit(2)
+	}
+	mapping, err := readMapping(*mappingFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "errorex-migrate:", err)
+		os.Exit(1)
+	}
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	report, err := migrate(dirs, mapping, *aliasesFile, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "errorex-migrate:", err)
+		os.Exit(1)
+	}
+	var out io.Writer = os.Stdout
+	if *reportFile != "" {
+		file, err := os.Create(*reportFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "errorex-migrate:", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+	report.write(out)
+}
+
+// readMapping reads the old to new code mapping
+func readMapping(name string) (map[string]string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var mapping map[string]string
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for old, renamed := range mapping {
+		if old == "" || renamed == "" || old == renamed {
+			return nil, fmt.Errorf("%s: invalid mapping %q -> %q", name, old, renamed)
+		}
+		if _, chained := mapping[renamed]; chained {
+			return nil, fmt.Errorf("%s: %q is renamed and also the target of %q", name, renamed, old)
+		}
+	}
+	return mapping, nil
+}
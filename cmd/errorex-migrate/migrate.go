@@ -0,0 +1,329 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	errorexPath = "github.com/fkmatsuda/errorex"
+)
+
+// codeArgument is the position of the code argument of the errorex functions taking codes
+var codeArgument = map[string]int{
+	"New":               0,
+	"NewPooled":         0,
+	"Define":            0,
+	"RegisterErrorCode": 0,
+	"RegisterAlias":     1,
+	"Is":                1,
+}
+
+// change is one rewritten code literal
+type change struct {
+	File string
+	Line int
+	// Site is the function called, or "const" for const declarations
+	Site string
+	Old  string
+	New  string
+}
+
+// report summarizes a migration
+type report struct {
+	Mapping map[string]string
+	Changes []change
+	Files   []string
+	Aliases string
+	DryRun  bool
+}
+
+// replacement is a byte range of the source to be replaced
+type replacement struct {
+	start, end int
+	text       string
+}
+
+// migrate rewrites the Go files under dirs and writes the aliases file
+func migrate(dirs []string, mapping map[string]string, aliasesFile string, dryRun bool) (*report, error) {
+	r := &report{Mapping: mapping, Aliases: aliasesFile, DryRun: dryRun}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if path != dir && (entry.Name() == "vendor" || entry.Name() == "testdata" || strings.HasPrefix(entry.Name(), ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(path, ".go") || (aliasesFile != "" && filepath.Clean(path) == filepath.Clean(aliasesFile)) {
+				return nil
+			}
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			out, changes, err := rewriteFile(path, src, mapping)
+			if err != nil {
+				return err
+			}
+			if len(changes) == 0 {
+				return nil
+			}
+			r.Changes = append(r.Changes, changes...)
+			r.Files = append(r.Files, path)
+			if dryRun {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(path, out, info.Mode().Perm())
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if aliasesFile != "" && !dryRun {
+		if err := writeAliases(aliasesFile, mapping); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// rewriteFile replaces the old codes of a file, left as is when it does not import errorex
+func rewriteFile(path string, src []byte, mapping map[string]string) ([]byte, []change, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	importName := errorexImportName(file)
+	if importName == "" && file.Name.Name != "errorex" {
+		return src, nil, nil
+	}
+	var (
+		changes      []change
+		replacements []replacement
+	)
+	visit := func(expr ast.Expr, site string) {
+		literal, ok := ast.Unparen(expr).(*ast.BasicLit)
+		if !ok || literal.Kind != token.STRING {
+			return
+		}
+		value, err := strconv.Unquote(literal.Value)
+		if err != nil {
+			return
+		}
+		renamed, ok := mapping[value]
+		if !ok {
+			return
+		}
+		text := strconv.Quote(renamed)
+		if strings.HasPrefix(literal.Value, "`") && !strings.Contains(renamed, "`") {
+			text = "`" + renamed + "`"
+		}
+		replacements = append(replacements, replacement{
+			start: fset.Position(literal.Pos()).Offset,
+			end:   fset.Position(literal.End()).Offset,
+			text:  text,
+		})
+		changes = append(changes, change{
+			File: path,
+			Line: fset.Position(literal.Pos()).Line,
+			Site: site,
+			Old:  value,
+			New:  renamed,
+		})
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.GenDecl:
+			if node.Tok != token.CONST {
+				return true
+			}
+			for _, spec := range node.Specs {
+				for _, value := range spec.(*ast.ValueSpec).Values {
+					visit(value, "const")
+				}
+			}
+		case *ast.CallExpr:
+			name := calledFunction(node, importName, file.Name.Name)
+			position, ok := codeArgument[name]
+			if ok && position < len(node.Args) {
+				visit(node.Args[position], name)
+			}
+		}
+		return true
+	})
+	if len(replacements) == 0 {
+		return src, nil, nil
+	}
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start > replacements[j].start
+	})
+	out := append([]byte(nil), src...)
+	for _, r := range replacements {
+		out = append(out[:r.start], append([]byte(r.text), out[r.end:]...)...)
+	}
+	return out, changes, nil
+}
+
+// errorexImportName returns the name errorex is imported as, empty when it is not imported
+func errorexImportName(file *ast.File) string {
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if path != errorexPath {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+		return "errorex"
+	}
+	return ""
+}
+
+// calledFunction returns the name of the errorex function called, empty for other calls.
+// Calls inside the errorex package itself are recognized by their unqualified names.
+func calledFunction(call *ast.CallExpr, importName string, packageName string) string {
+	fun := ast.Unparen(call.Fun)
+	switch index := fun.(type) {
+	case *ast.IndexExpr:
+		fun = index.X
+	case *ast.IndexListExpr:
+		fun = index.X
+	}
+	switch fun := fun.(type) {
+	case *ast.SelectorExpr:
+		if ident, ok := fun.X.(*ast.Ident); ok && importName != "" && ident.Name == importName {
+			return fun.Sel.Name
+		}
+	case *ast.Ident:
+		if packageName == "errorex" || importName == "." {
+			return fun.Name
+		}
+	}
+	return ""
+}
+
+// writeAliases writes the Go file registering the old codes as deferred aliases, in the package of the target
+// directory
+func writeAliases(name string, mapping map[string]string) error {
+	packageName, err := packageOf(filepath.Dir(name))
+	if err != nil {
+		return err
+	}
+	olds := make([]string, 0, len(mapping))
+	for old := range mapping {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	var buffer bytes.Buffer
+	fmt.Fprintln(&buffer, "// Code generated by errorex-migrate. DO NOT EDIT.")
+	fmt.Fprintln(&buffer)
+	fmt.Fprintf(&buffer, "package %s\n\n", packageName)
+	fmt.Fprintf(&buffer, "import %q\n\n", errorexPath)
+	fmt.Fprintln(&buffer, "func init() {")
+	fmt.Fprintln(&buffer, "\t// Keep the renamed codes working on the wire")
+	for _, old := range olds {
+		fmt.Fprintf(&buffer, "\terrorex.DeferAlias(%q, %q)\n", old, mapping[old])
+	}
+	fmt.Fprintln(&buffer, "}")
+	source, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, source, 0o644)
+}
+
+// packageOf returns the package name of the non test Go files of a directory
+func packageOf(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, entry.Name()), nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		return file.Name.Name, nil
+	}
+	return "", fmt.Errorf("no Go package found in %s", dir)
+}
+
+// write writes the human readable report
+func (r *report) write(w io.Writer) {
+	fmt.Fprintln(w, "errorex-migrate report")
+	if r.DryRun {
+		fmt.Fprintln(w, "dry run, no file was written")
+	}
+	olds := make([]string, 0, len(r.Mapping))
+	for old := range r.Mapping {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	for _, old := range olds {
+		var changes []change
+		for _, c := range r.Changes {
+			if c.Old == old {
+				changes = append(changes, c)
+			}
+		}
+		if len(changes) == 0 {
+			fmt.Fprintf(w, "\n%s -> %s: no occurrence\n", old, r.Mapping[old])
+			continue
+		}
+		fmt.Fprintf(w, "\n%s -> %s: %d occurrence(s)\n", old, r.Mapping[old], len(changes))
+		for _, c := range changes {
+			fmt.Fprintf(w, "  %s:%d: %s\n", c.File, c.Line, c.Site)
+		}
+	}
+	fmt.Fprintf(w, "\nfiles changed: %d\n", len(r.Files))
+	if r.Aliases != "" {
+		fmt.Fprintf(w, "aliases: %s\n", r.Aliases)
+	}
+}
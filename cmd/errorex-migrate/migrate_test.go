@@ -0,0 +1,147 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const codesSource = `package billing
+
+import ex "github.com/fkmatsuda/errorex"
+
+const (
+	// CodeDeclined is kept aligned with the comment
+	CodeDeclined = "billing.card_declined"
+	other        = "billing.other"
+)
+
+var Expired = ex.Define[struct{}](` + "`billing.card_expired`" + `, "Card expired")
+
+func init() {
+	ex.RegisterErrorCode(CodeDeclined, "Card declined", struct{}{})
+}
+
+func Check(err error) bool {
+	return ex.Is(err, "billing.card_declined") || ex.Is(err, "billing.card_expired")
+}
+
+func Unrelated() string {
+	return "billing.card_declined"
+}
+`
+
+func TestMigrate(t *testing.T) {
+	mapping := map[string]string{
+		"billing.card_declined": "billing.payment_declined",
+		"billing.card_expired":  "billing.payment_expired",
+		"billing.missing":       "billing.found",
+	}
+
+	t.Run("should rewrite code literals and write the aliases", func(t *testing.T) {
+		dir := t.TempDir()
+		codes := filepath.Join(dir, "codes.go")
+		assert.NoError(t, os.WriteFile(codes, []byte(codesSource), 0o644))
+		aliases := filepath.Join(dir, "zz_errorex_aliases.go")
+
+		r, err := migrate([]string{dir}, mapping, aliases, false)
+		assert.NoError(t, err)
+
+		migrated, _ := os.ReadFile(codes)
+		assert.Contains(t, string(migrated), "\tCodeDeclined = \"billing.payment_declined\"\n")
+		assert.Contains(t, string(migrated), "ex.Define[struct{}](`billing.payment_expired`")
+		assert.Contains(t, string(migrated), `ex.Is(err, "billing.payment_declined") || ex.Is(err, "billing.payment_expired")`)
+		assert.Contains(t, string(migrated), "\treturn \"billing.card_declined\"\n", "unrelated strings are kept")
+		assert.Len(t, r.Changes, 4)
+
+		generated, _ := os.ReadFile(aliases)
+		assert.Contains(t, string(generated), "package billing")
+		assert.Contains(t, string(generated), `errorex.DeferAlias("billing.card_declined", "billing.payment_declined")`)
+
+		var report bytes.Buffer
+		r.write(&report)
+		assert.Contains(t, report.String(), "billing.card_declined -> billing.payment_declined: 2 occurrence(s)")
+		assert.Contains(t, report.String(), "codes.go:7: const")
+		assert.Contains(t, report.String(), "billing.missing -> billing.found: no occurrence")
+		assert.Contains(t, report.String(), "files changed: 1")
+	})
+
+	t.Run("should keep the permissions and skip the files not importing errorex", func(t *testing.T) {
+		dir := t.TempDir()
+		codes := filepath.Join(dir, "codes.go")
+		assert.NoError(t, os.WriteFile(codes, []byte(codesSource), 0o640))
+		assert.NoError(t, os.Chmod(codes, 0o640))
+		unrelated := "package billing\n\nconst legacy = \"billing.card_declined\"\n"
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "legacy.go"), []byte(unrelated), 0o644))
+
+		r, err := migrate([]string{dir}, mapping, "", false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{codes}, r.Files)
+
+		info, err := os.Stat(codes)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+		content, _ := os.ReadFile(filepath.Join(dir, "legacy.go"))
+		assert.Equal(t, unrelated, string(content))
+	})
+
+	t.Run("should not write files on dry run", func(t *testing.T) {
+		dir := t.TempDir()
+		codes := filepath.Join(dir, "codes.go")
+		assert.NoError(t, os.WriteFile(codes, []byte(codesSource), 0o644))
+
+		r, err := migrate([]string{dir}, mapping, filepath.Join(dir, "zz_errorex_aliases.go"), true)
+		assert.NoError(t, err)
+		assert.Len(t, r.Changes, 4)
+
+		content, _ := os.ReadFile(codes)
+		assert.Equal(t, codesSource, string(content))
+		assert.NoFileExists(t, filepath.Join(dir, "zz_errorex_aliases.go"))
+	})
+}
+
+func TestReadMapping(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"valid.json":   `{"a.old": "a.new"}`,
+		"chained.json": `{"a.old": "a.new", "a.new": "a.newer"}`,
+		"same.json":    `{"a.old": "a.old"}`,
+		"invalid.json": `[]`,
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	mapping, err := readMapping(filepath.Join(dir, "valid.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a.old": "a.new"}, mapping)
+	for _, name := range []string{"chained.json", "same.json", "invalid.json", "missing.json"} {
+		_, err := readMapping(filepath.Join(dir, name))
+		assert.Error(t, err, name)
+	}
+}
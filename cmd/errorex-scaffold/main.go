@@ -0,0 +1,60 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Command errorex-scaffold creates the skeleton of a new error domain package following the errorex conventions:
+// a codes file registering the codes, the detail types, a converter stub and its tests.
+//
+// Usage:
+//
+//	errorex-scaffold -domain billing [-dir ./billing] [-force]
+//
+// The generated package registers a single example code (<domain>.not_found), meant to be renamed and extended.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	domain := flag.String("domain", "", "name of the error domain, used as package name and code prefix")
+	dir := flag.String("dir", "", "directory of the package, ./<domain> when empty")
+	force := flag.Bool("force", false, "overwrite existing files")
+	flag.Parse()
+	if *domain == "" {
+		fmt.Fprintln(os.Stderr, "usage: errorex-scaffold -domain billing [-dir ./billing] [-force]")
+		os.Exit(2)
+	}
+	if *dir == "" {
+		*dir = *domain
+	}
+	files, err := scaffold(*domain, *dir, *force)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "errorex-scaffold:", err)
+		os.Exit(1)
+	}
+	for _, file := range files {
+		fmt.Println("created", file)
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// domain is the data given to the templates
+type domain struct {
+	// Package is the package name and code prefix
+	Package string
+	// Name is the exported form of the domain name
+	Name string
+}
+
+var templates = []struct {
+	name   string
+	source string
+}{
+	{"codes.go", `// Package {{.Package}} holds the errorex codes of the {{.Package}} domain.
+package {{.Package}}
+
+import "github.com/fkmatsuda/errorex"
+
+const (
+	// ErrCodeNotFound is the errorex code for when a {{.Package}} resource is not found
+	ErrCodeNotFound = "{{.Package}}.not_found"
+)
+
+func init() {
+	// Register the errorex codes
+	errorex.RegisterErrorCode(ErrCodeNotFound, "{{.Name}} resource not found", NotFoundDetail{})
+}
+
+// NewNotFound returns a new ErrCodeNotFound errorex
+func NewNotFound(resource string, id string) errorex.EX {
+	return errorex.New(ErrCodeNotFound, NotFoundDetail{Resource: resource, ID: id})
+}
+`},
+	{"details.go", `package {{.Package}}
+
+// NotFoundDetail is the detail of ErrCodeNotFound
+type NotFoundDetail struct {
+	Resource string ` + "`json:\"resource\"`" + `
+	ID       string ` + "`json:\"id\"`" + `
+}
+`},
+	{"converter.go", `package {{.Package}}
+
+import (
+	"errors"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// ErrNotFound is an example of an error produced by a dependency of the domain, to be replaced by the real ones
+var ErrNotFound = errors.New("{{.Package}}: not found")
+
+// errorConverter converts the errors of the dependencies of the {{.Package}} domain into its errorex codes
+type errorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewErrorConverter creates the converter of the {{.Package}} domain, to be used in errorex.BuildErrorConverterChain
+func NewErrorConverter() errorex.ErrorConverter {
+	return &errorConverter{}
+}
+
+// ConvertError converts the known errors, delegating the others to the next handler in the chain
+func (c *errorConverter) ConvertError(err error) errorex.EX {
+	if errors.Is(err, ErrNotFound) {
+		return NewNotFound("unknown", "")
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
+`},
+	{"{{.Package}}_test.go", `package {{.Package}}
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewNotFound(t *testing.T) {
+	t.Run("should create a not found error", func(t *testing.T) {
+		ex := NewNotFound("invoice", "42")
+
+		assert.True(t, errorex.Is(ex, ErrCodeNotFound))
+		assert.Equal(t, NotFoundDetail{Resource: "invoice", ID: "42"}, ex.Detail())
+	})
+}
+
+func TestErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewErrorConverter())
+
+	t.Run("should convert the known errors", func(t *testing.T) {
+		assert.True(t, errorex.Is(converter.ConvertError(fmt.Errorf("wrapped: %w", ErrNotFound)), ErrCodeNotFound))
+	})
+
+	t.Run("should delegate the other errors", func(t *testing.T) {
+		assert.True(t, errorex.Is(converter.ConvertError(fmt.Errorf("other")), errorex.ErrCodeUnknownError))
+	})
+}
+`},
+}
+
+// scaffold writes the files of the domain package and returns their paths
+func scaffold(name string, dir string, force bool) ([]string, error) {
+	if !token.IsIdentifier(name) || strings.ToLower(name) != name || token.Lookup(name).IsKeyword() {
+		return nil, fmt.Errorf("invalid domain %q, it must be a lower case Go identifier", name)
+	}
+	d := domain{Package: name, Name: string(unicode.ToUpper(rune(name[0]))) + name[1:]}
+	type output struct {
+		path   string
+		source []byte
+	}
+	outputs := make([]output, 0, len(templates))
+	for _, t := range templates {
+		var fileName, source bytes.Buffer
+		if err := template.Must(template.New("name").Parse(t.name)).Execute(&fileName, d); err != nil {
+			return nil, err
+		}
+		if err := template.Must(template.New(t.name).Parse(t.source)).Execute(&source, d); err != nil {
+			return nil, err
+		}
+		formatted, err := format.Source(source.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fileName.String(), err)
+		}
+		path := filepath.Join(dir, fileName.String())
+		if _, err := os.Stat(path); err == nil && !force {
+			return nil, fmt.Errorf("%s already exists, use -force to overwrite", path)
+		}
+		outputs = append(outputs, output{path: path, source: formatted})
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(outputs))
+	for _, o := range outputs {
+		if err := os.WriteFile(o.path, o.source, 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, o.path)
+	}
+	return paths, nil
+}
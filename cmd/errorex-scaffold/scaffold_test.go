@@ -0,0 +1,67 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaffold(t *testing.T) {
+	t.Run("should create the domain package", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "billing")
+
+		files, err := scaffold("billing", dir, false)
+		assert.NoError(t, err)
+		assert.Len(t, files, 4)
+
+		codes, _ := os.ReadFile(filepath.Join(dir, "codes.go"))
+		assert.Contains(t, string(codes), "package billing")
+		assert.Contains(t, string(codes), `ErrCodeNotFound = "billing.not_found"`)
+		assert.Contains(t, string(codes), `"Billing resource not found"`)
+		assert.FileExists(t, filepath.Join(dir, "billing_test.go"))
+	})
+
+	t.Run("should not overwrite existing files", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := scaffold("billing", dir, false)
+		assert.NoError(t, err)
+
+		_, err = scaffold("billing", dir, false)
+		assert.Error(t, err)
+
+		_, err = scaffold("billing", dir, true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should reject invalid domains", func(t *testing.T) {
+		for _, name := range []string{"Billing", "my-domain", "func", ""} {
+			_, err := scaffold(name, t.TempDir(), false)
+			assert.Error(t, err, name)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// catalog is the declarative description of an error domain
+type catalog struct {
+	Package string   `json:"package" yaml:"package"`
+	Imports []string `json:"imports" yaml:"imports"`
+	Codes   []code   `json:"codes" yaml:"codes"`
+}
+
+// code is one errorex code of the catalog
+type code struct {
+	// Name is the Go identifier used for the constant, the detail type and the constructor
+	Name        string  `json:"name" yaml:"name"`
+	Code        string  `json:"code" yaml:"code"`
+	Description string  `json:"description" yaml:"description"`
+	Fields      []field `json:"fields" yaml:"fields"`
+}
+
+// field is a field of the detail struct
+type field struct {
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"`
+	// JSON is the json tag, the lower camel case name is used when empty
+	JSON string `json:"json" yaml:"json"`
+	// Doc is an optional comment for the field
+	Doc string `json:"doc" yaml:"doc"`
+}
+
+// parseCatalog decodes and validates a catalog, the format is chosen from the file extension
+func parseCatalog(name string, data []byte) (catalog, error) {
+	var c catalog
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		err = json.Unmarshal(data, &c)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &c)
+	default:
+		return c, fmt.Errorf("%s: unsupported catalog format, use .yaml, .yml or .json", name)
+	}
+	if err != nil {
+		return c, fmt.Errorf("%s: %w", name, err)
+	}
+	return c, c.validate()
+}
+
+// validate checks identifiers and duplicates
+func (c catalog) validate() error {
+	if !token.IsIdentifier(c.Package) {
+		return fmt.Errorf("invalid package name %q", c.Package)
+	}
+	names := make(map[string]bool)
+	codes := make(map[string]bool)
+	for _, code := range c.Codes {
+		if !token.IsIdentifier(code.Name) || !token.IsExported(code.Name) {
+			return fmt.Errorf("code %q: name %q must be an exported Go identifier", code.Code, code.Name)
+		}
+		if code.Code == "" {
+			return fmt.Errorf("%s: empty code", code.Name)
+		}
+		if names[code.Name] {
+			return fmt.Errorf("duplicate name %s", code.Name)
+		}
+		if codes[code.Code] {
+			return fmt.Errorf("duplicate code %s", code.Code)
+		}
+		names[code.Name] = true
+		codes[code.Code] = true
+		for _, field := range code.Fields {
+			if !token.IsIdentifier(field.Name) || !token.IsExported(field.Name) {
+				return fmt.Errorf("%s: field %q must be an exported Go identifier", code.Name, field.Name)
+			}
+			if field.Type == "" {
+				return fmt.Errorf("%s: field %s has no type", code.Name, field.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// jsonName returns the json tag of the field
+func (f field) jsonName() string {
+	if f.JSON != "" {
+		return f.JSON
+	}
+	return lowerCamel(f.Name)
+}
+
+// paramName returns the constructor parameter name of the field
+func (f field) paramName() string {
+	name := lowerCamel(f.Name)
+	if token.Lookup(name).IsKeyword() {
+		name += "Value"
+	}
+	return name
+}
+
+// lowerCamel lowers the leading upper case letters of an identifier (InvoiceID -> invoiceID, URLPath -> urlPath)
+func lowerCamel(name string) string {
+	runes := []rune(name)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		// Keep the last upper case letter of an initialism that is followed by a word
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
@@ -0,0 +1,121 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"text/template"
+)
+
+var sourceTemplate = template.Must(template.New("source").Parse(`// Code generated by errorexgen from {{.Source}}. DO NOT EDIT.
+
+package {{.Catalog.Package}}
+
+import (
+{{- range .Catalog.Imports}}
+	"{{.}}"
+{{- end}}
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+{{- range .Catalog.Codes}}
+	// Code{{.Name}} is the errorex code of {{.Name}}: {{.Description}}
+	Code{{.Name}} = "{{.Code}}"
+{{- end}}
+)
+{{range .Catalog.Codes}}
+// {{.Name}}Detail is the detail of {{.Code}}
+type {{.Name}}Detail struct {
+{{- range .Fields}}
+{{- if .Doc}}
+	// {{.Doc}}
+{{- end}}
+	{{.Name}} {{.Type}} ` + "`json:\"{{.JSONName}}\"`" + `
+{{- end}}
+}
+{{end}}
+var (
+{{- range .Catalog.Codes}}
+	// {{.Name}} is the definition of {{.Code}}
+	{{.Name}} = errorex.Define[{{.Name}}Detail](Code{{.Name}}, {{printf "%q" .Description}})
+{{- end}}
+)
+{{range .Catalog.Codes}}
+// New{{.Name}} returns a new {{.Code}} errorex
+func New{{.Name}}({{range $i, $f := .Fields}}{{if $i}}, {{end}}{{$f.ParamName}} {{$f.Type}}{{end}}) errorex.EX {
+	return {{.Name}}.New({{.Name}}Detail{
+{{- range .Fields}}
+		{{.Name}}: {{.ParamName}},
+{{- end}}
+	})
+}
+{{end}}`))
+
+// templateField exposes the computed names of a field to the template
+type templateField struct {
+	field
+	JSONName  string
+	ParamName string
+}
+
+// templateCode exposes the computed fields of a code to the template
+type templateCode struct {
+	code
+	Fields []templateField
+}
+
+// generate renders the Go source of the catalog
+func generate(c catalog, source string) ([]byte, error) {
+	data := struct {
+		Source  string
+		Catalog struct {
+			Package string
+			Imports []string
+			Codes   []templateCode
+		}
+	}{Source: filepath.Base(source)}
+	data.Catalog.Package = c.Package
+	data.Catalog.Imports = c.Imports
+	for _, code := range c.Codes {
+		tc := templateCode{code: code}
+		for _, f := range code.Fields {
+			tc.Fields = append(tc.Fields, templateField{field: f, JSONName: f.jsonName(), ParamName: f.paramName()})
+		}
+		data.Catalog.Codes = append(data.Catalog.Codes, tc)
+	}
+	var buffer bytes.Buffer
+	if err := sourceTemplate.Execute(&buffer, data); err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code is invalid: %w", err)
+	}
+	return formatted, nil
+}
@@ -0,0 +1,78 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Run("should generate the golden file from the YAML catalog", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "billing_gen.go")
+		assert.NoError(t, run("testdata/billing.yaml", output))
+
+		generated, _ := os.ReadFile(output)
+		golden, _ := os.ReadFile("testdata/billing_gen.go.golden")
+		assert.Equal(t, string(golden), string(generated))
+	})
+
+	t.Run("should read JSON catalogs", func(t *testing.T) {
+		c, err := parseCatalog("errors.json", []byte(`{"package": "auth", "codes": [{"name": "Expired", "code": "auth.expired", "description": "Token expired"}]}`))
+		assert.NoError(t, err)
+
+		source, err := generate(c, "errors.json")
+		assert.NoError(t, err)
+		assert.Contains(t, string(source), `Expired = errorex.Define[ExpiredDetail](CodeExpired, "Token expired")`)
+		assert.Contains(t, string(source), "func NewExpired() errorex.EX")
+	})
+
+	t.Run("should reject invalid catalogs", func(t *testing.T) {
+		for name, content := range map[string]string{
+			"package":        `package: "my-package"`,
+			"name":           "package: auth\ncodes: [{name: expired, code: auth.expired}]",
+			"empty code":     "package: auth\ncodes: [{name: Expired}]",
+			"duplicate code": "package: auth\ncodes: [{name: A, code: auth.a}, {name: B, code: auth.a}]",
+			"duplicate name": "package: auth\ncodes: [{name: A, code: auth.a}, {name: A, code: auth.b}]",
+			"field":          "package: auth\ncodes: [{name: A, code: auth.a, fields: [{name: reason, type: string}]}]",
+			"field type":     "package: auth\ncodes: [{name: A, code: auth.a, fields: [{name: Reason}]}]",
+		} {
+			_, err := parseCatalog("errors.yaml", []byte(content))
+			assert.Error(t, err, name)
+		}
+		_, err := parseCatalog("errors.toml", nil)
+		assert.True(t, strings.Contains(err.Error(), "unsupported catalog format"))
+	})
+
+	t.Run("should derive json and parameter names", func(t *testing.T) {
+		assert.Equal(t, "invoiceID", field{Name: "InvoiceID"}.jsonName())
+		assert.Equal(t, "urlPath", field{Name: "URLPath"}.jsonName())
+		assert.Equal(t, "declined_at", field{Name: "At", JSON: "declined_at"}.jsonName())
+		assert.Equal(t, "typeValue", field{Name: "Type"}.paramName())
+	})
+}
@@ -0,0 +1,85 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Command errorexgen generates Go code from a declarative errorex catalog, keeping the catalog as the
+// single source of truth of an error domain. It is meant to be used with go:generate:
+//
+//	//go:generate go run github.com/fkmatsuda/errorex/cmd/errorexgen -catalog errors.yaml -out errors_gen.go
+//
+// The catalog is read as YAML or JSON, depending on the file extension:
+//
+//	package: billing
+//	imports: [time]
+//	codes:
+//	  - name: PaymentDeclined
+//	    code: billing.payment_declined
+//	    description: Payment declined by the issuer
+//	    fields:
+//	      - name: Reason
+//	        type: string
+//	      - name: At
+//	        type: time.Time
+//	        json: declined_at
+//
+// For each code it emits a typed constant, the detail struct, an errorex.Define registration and a constructor.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	catalogFile := flag.String("catalog", "", "catalog file (.yaml, .yml or .json)")
+	output := flag.String("out", "", "output file, stdout when empty")
+	flag.Parse()
+	if *catalogFile == "" {
+		fmt.Fprintln(os.Stderr, "usage: errorexgen -catalog errors.yaml [-out errors_gen.go]")
+		os.Exit(2)
+	}
+	if err := run(*catalogFile, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "errorexgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(catalogFile, output string) error {
+	data, err := os.ReadFile(catalogFile)
+	if err != nil {
+		return err
+	}
+	catalog, err := parseCatalog(catalogFile, data)
+	if err != nil {
+		return err
+	}
+	source, err := generate(catalog, catalogFile)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+	return os.WriteFile(output, source, 0o644)
+}
@@ -0,0 +1,97 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"sync/atomic"
+)
+
+var (
+	jsonCodec atomic.Pointer[JSONCodec]
+)
+
+// JSONCodec is the marshaling backend used by errorex to serialize errors and their details.
+// encoding/json is used by default, services where error serialization shows up in profiles can plug
+// a faster implementation (go-json, sonic, easyjson...) with SetJSONCodec.
+type JSONCodec interface {
+	// Marshal returns the JSON encoding of v
+	Marshal(v any) ([]byte, error)
+	// Unmarshal parses the JSON-encoded data and stores the result in the value pointed to by v
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodecFunc adapts a pair of marshal/unmarshal functions into a JSONCodec, e.g.:
+//
+//	errorex.SetJSONCodec(errorex.JSONCodecFunc{MarshalFunc: gojson.Marshal, UnmarshalFunc: gojson.Unmarshal})
+type JSONCodecFunc struct {
+	MarshalFunc   func(v any) ([]byte, error)
+	UnmarshalFunc func(data []byte, v any) error
+}
+
+// Marshal calls MarshalFunc
+func (c JSONCodecFunc) Marshal(v any) ([]byte, error) {
+	return c.MarshalFunc(v)
+}
+
+// Unmarshal calls UnmarshalFunc
+func (c JSONCodecFunc) Unmarshal(data []byte, v any) error {
+	return c.UnmarshalFunc(data, v)
+}
+
+// stdJSONCodec is the default JSONCodec backed by encoding/json
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// SetJSONCodec sets the marshaling backend, a nil codec restores encoding/json
+func SetJSONCodec(codec JSONCodec) {
+	if codec == nil {
+		jsonCodec.Store(nil)
+		return
+	}
+	jsonCodec.Store(&codec)
+}
+
+// GetJSONCodec returns the current marshaling backend
+func GetJSONCodec() JSONCodec {
+	if codec := jsonCodec.Load(); codec != nil {
+		return *codec
+	}
+	return stdJSONCodec{}
+}
+
+// customJSONCodec returns the codec set by SetJSONCodec, or nil when encoding/json is in use
+func customJSONCodec() JSONCodec {
+	if codec := jsonCodec.Load(); codec != nil {
+		return *codec
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingCodec is a JSONCodec that counts how many times it was used
+type countingCodec struct {
+	marshals int
+}
+
+func (c *countingCodec) Marshal(v any) ([]byte, error) {
+	c.marshals++
+	return json.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func TestSetJSONCodec(t *testing.T) {
+	RegisterErrorCode("test.codec", "test description", struct{ Message string }{})
+	defer SetJSONCodec(nil)
+
+	t.Run("should use encoding/json by default", func(t *testing.T) {
+		assert.IsType(t, stdJSONCodec{}, GetJSONCodec())
+	})
+
+	t.Run("should serialize through the configured codec", func(t *testing.T) {
+		codec := &countingCodec{}
+		SetJSONCodec(codec)
+
+		ex := New("test.codec", struct{ Message string }{Message: "test detail"})
+		assert.JSONEq(t, `{"code": "test.codec", "detail": {"Message":"test detail"}}`, ex.Error())
+		pooled := NewPooled("test.codec", struct{ Message string }{Message: "test detail"})
+		assert.JSONEq(t, `{"code": "test.codec", "detail": {"Message":"test detail"}}`, pooled.Error())
+		Release(pooled)
+
+		assert.Equal(t, 2, codec.marshals)
+	})
+
+	t.Run("should adapt functions into a codec", func(t *testing.T) {
+		SetJSONCodec(JSONCodecFunc{
+			MarshalFunc: func(v any) ([]byte, error) {
+				return nil, fmt.Errorf("codec failure")
+			},
+			UnmarshalFunc: json.Unmarshal,
+		})
+
+		ex := New("test.codec", struct{ Message string }{Message: "test detail"})
+		assert.JSONEq(t, `{"code": "test.codec", "detail": "failed to marshal detail: codec failure"}`, ex.Error())
+	})
+
+	t.Run("should restore encoding/json", func(t *testing.T) {
+		SetJSONCodec(nil)
+		assert.IsType(t, stdJSONCodec{}, GetJSONCodec())
+	})
+}
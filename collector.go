@@ -0,0 +1,100 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Collector accumulates the errorex errors of a unit of work, such as a request, carried by its context so the
+// code handling the work summarizes them, e.g. the error budget middleware of httpex and grpcex
+type Collector struct {
+	mutex  sync.Mutex
+	errors []EX
+}
+
+// collectorKey is the context key of the Collector
+type collectorKey struct{}
+
+// WithCollector returns a context carrying a new Collector, along with the Collector
+func WithCollector(ctx context.Context) (context.Context, *Collector) {
+	collector := &Collector{}
+	return context.WithValue(ctx, collectorKey{}, collector), collector
+}
+
+// CollectorFrom returns the Collector carried by the context
+func CollectorFrom(ctx context.Context) (*Collector, bool) {
+	collector, ok := ctx.Value(collectorKey{}).(*Collector)
+	return collector, ok
+}
+
+// Collect adds err to the Collector carried by the context, if any, see Collector.Add
+func Collect(ctx context.Context, err error) {
+	if collector, ok := CollectorFrom(ctx); ok {
+		collector.Add(err)
+	}
+}
+
+// Add records the first errorex in the chain of err, other errors are ignored
+func (c *Collector) Add(err error) {
+	target, ok := firstEX(err)
+	if !ok {
+		return
+	}
+	c.mutex.Lock()
+	c.errors = append(c.errors, target)
+	c.mutex.Unlock()
+}
+
+// Errors returns the recorded errors, in order
+func (c *Collector) Errors() []EX {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]EX(nil), c.errors...)
+}
+
+// Count returns the number of recorded errors
+func (c *Collector) Count() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.errors)
+}
+
+// Codes returns the distinct codes of the recorded errors, sorted
+func (c *Collector) Codes() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	seen := make(map[string]bool, len(c.errors))
+	var codes []string
+	for _, recorded := range c.errors {
+		if !seen[recorded.Code()] {
+			seen[recorded.Code()] = true
+			codes = append(codes, recorded.Code())
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
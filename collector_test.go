@@ -0,0 +1,57 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	t.Run("should accumulate the errors of the context", func(t *testing.T) {
+		ctx, collector := WithCollector(context.Background())
+		notRegistered := New(ErrCodeNotRegistered, ErrorEXDetail{})
+
+		Collect(ctx, fmt.Errorf("loading: %w", notRegistered))
+		Collect(ctx, New(ErrCodeUnknownError, UnknownErrorDetail{}))
+		Collect(ctx, New(ErrCodeNotRegistered, ErrorEXDetail{}))
+		Collect(ctx, errors.New("other"))
+
+		assert.Equal(t, 3, collector.Count())
+		assert.Equal(t, []string{ErrCodeUnknownError, ErrCodeNotRegistered}, collector.Codes())
+		assert.Same(t, notRegistered, collector.Errors()[0])
+	})
+
+	t.Run("should ignore contexts without a collector", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			Collect(context.Background(), New(ErrCodeNotRegistered, ErrorEXDetail{}))
+		})
+		_, ok := CollectorFrom(context.Background())
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,198 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const (
+	// catalogSnapshotVersion is the version of the format written by CatalogSnapshot
+	catalogSnapshotVersion = 1
+)
+
+// ErrorEXCatalogIncompatibility is the detail of ErrCodeIncompatibleCatalog
+type ErrorEXCatalogIncompatibility struct {
+	Changes []string `json:"changes"`
+}
+
+// TypeSchema is the JSON shape of a detail type, as recorded in catalog snapshots
+type TypeSchema struct {
+	// Kind is one of object, array, map, string, number, boolean or any
+	Kind string `json:"kind"`
+	// Fields are the fields of objects
+	Fields map[string]TypeSchema `json:"fields,omitempty"`
+	// Elem is the element of arrays and maps
+	Elem *TypeSchema `json:"elem,omitempty"`
+	// Ref names a type already described above, used for recursive types
+	Ref string `json:"ref,omitempty"`
+}
+
+// catalogSnapshot is the committed form of the catalog
+type catalogSnapshot struct {
+	Version int                 `json:"version"`
+	Codes   []catalogSnapshotEx `json:"codes"`
+}
+
+type catalogSnapshotEx struct {
+	Code        string     `json:"code"`
+	Description string     `json:"description"`
+	Aliases     []string   `json:"aliases,omitempty"`
+	Detail      TypeSchema `json:"detail"`
+}
+
+// CatalogSnapshot returns the current catalog (codes, aliases and detail schemas) as JSON, to be committed and
+// later given to CheckCompatibility.
+func CatalogSnapshot() ([]byte, error) {
+	snapshot := catalogSnapshot{Version: catalogSnapshotVersion}
+	for _, info := range Catalog() {
+		snapshot.Codes = append(snapshot.Codes, catalogSnapshotEx{
+			Code:        info.Code,
+			Description: info.Description,
+			Aliases:     info.Aliases,
+			Detail:      SchemaOf(info.DetailType),
+		})
+	}
+	return json.MarshalIndent(snapshot, "", "  ")
+}
+
+// CheckCompatibility compares the current catalog against a baseline written by CatalogSnapshot, enforcing the
+// stability of the error contract like an API contract. It returns an ErrCodeIncompatibleCatalog errorex listing
+// the codes that disappeared (unless they became aliases) and the detail fields that were removed or changed type.
+// New codes and new fields are compatible.
+//
+// It is meant to be called from a test against a committed snapshot:
+//
+//	baseline, _ := os.ReadFile("testdata/errors.json")
+//	if err := errorex.CheckCompatibility(baseline); err != nil {
+//		t.Fatal(err)
+//	}
+func CheckCompatibility(baseline []byte) error {
+	var snapshot catalogSnapshot
+	if err := json.Unmarshal(baseline, &snapshot); err != nil {
+		return fmt.Errorf("invalid catalog snapshot: %w", err)
+	}
+	if snapshot.Version != catalogSnapshotVersion {
+		return fmt.Errorf("unsupported catalog snapshot version %d", snapshot.Version)
+	}
+	var changes []string
+	for _, baselineCode := range snapshot.Codes {
+		info, ok := Lookup(baselineCode.Code)
+		if !ok {
+			changes = append(changes, fmt.Sprintf("%s: code removed", baselineCode.Code))
+			continue
+		}
+		changes = append(changes, compareSchema(baselineCode.Code+": detail", baselineCode.Detail, SchemaOf(info.DetailType))...)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return New(ErrCodeIncompatibleCatalog, ErrorEXCatalogIncompatibility{Changes: changes})
+}
+
+// SchemaOf returns the JSON shape of a type as produced by encoding/json
+func SchemaOf(t reflect.Type) TypeSchema {
+	return schemaOf(t, make(map[reflect.Type]bool))
+}
+
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) TypeSchema {
+	if t == nil {
+		return TypeSchema{Kind: "any"}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+			return TypeSchema{Kind: "string"}
+		}
+		return TypeSchema{Kind: "any"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return TypeSchema{Kind: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return TypeSchema{Kind: "number"}
+	case reflect.String:
+		return TypeSchema{Kind: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return TypeSchema{Kind: "string"}
+		}
+		elem := schemaOf(t.Elem(), visiting)
+		return TypeSchema{Kind: "array", Elem: &elem}
+	case reflect.Map:
+		elem := schemaOf(t.Elem(), visiting)
+		return TypeSchema{Kind: "map", Elem: &elem}
+	case reflect.Struct:
+		if visiting[t] {
+			return TypeSchema{Kind: "object", Ref: t.String()}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		schema := TypeSchema{Kind: "object", Fields: make(map[string]TypeSchema)}
+		for _, field := range DetailFields(t) {
+			schema.Fields[field.Name] = schemaOf(field.Field.Type, visiting)
+		}
+		return schema
+	}
+	return TypeSchema{Kind: "any"}
+}
+
+// compareSchema lists the incompatible changes from the baseline schema to the current one
+func compareSchema(path string, baseline, current TypeSchema) []string {
+	if baseline.Kind != current.Kind {
+		return []string{fmt.Sprintf("%s: type changed from %s to %s", path, baseline.Kind, current.Kind)}
+	}
+	var changes []string
+	switch baseline.Kind {
+	case "array", "map":
+		if baseline.Elem != nil && current.Elem != nil {
+			changes = append(changes, compareSchema(path+"[]", *baseline.Elem, *current.Elem)...)
+		}
+	case "object":
+		if baseline.Ref != "" || current.Ref != "" {
+			return nil
+		}
+		names := make([]string, 0, len(baseline.Fields))
+		for name := range baseline.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			currentField, ok := current.Fields[name]
+			if !ok {
+				changes = append(changes, fmt.Sprintf("%s.%s: field removed", path, name))
+				continue
+			}
+			changes = append(changes, compareSchema(path+"."+name, baseline.Fields[name], currentField)...)
+		}
+	}
+	return changes
+}
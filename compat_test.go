@@ -0,0 +1,99 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type compatNode struct {
+	Name     string        `json:"name"`
+	Children []*compatNode `json:"children"`
+}
+
+type compatDetail struct {
+	Reason string            `json:"reason"`
+	Amount float64           `json:"amount"`
+	Tags   []string          `json:"tags"`
+	Labels map[string]int    `json:"labels"`
+	At     time.Time         `json:"at"`
+	Tree   compatNode        `json:"tree"`
+	Extra  map[string]string `json:"extra,omitempty"`
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	RegisterErrorCode("test.compat", "test description", compatDetail{})
+
+	t.Run("should accept its own snapshot", func(t *testing.T) {
+		snapshot, err := CatalogSnapshot()
+		assert.NoError(t, err)
+		assert.NoError(t, CheckCompatibility(snapshot))
+	})
+
+	t.Run("should accept new codes and new fields", func(t *testing.T) {
+		baseline := `{"version": 1, "codes": [{"code": "test.compat", "detail": {"kind": "object", "fields": {"reason": {"kind": "string"}}}}]}`
+		assert.NoError(t, CheckCompatibility([]byte(baseline)))
+	})
+
+	t.Run("should report removed codes, removed fields and type changes", func(t *testing.T) {
+		baseline := `{"version": 1, "codes": [
+			{"code": "test.compat.removed", "detail": {"kind": "any"}},
+			{"code": "test.compat", "detail": {"kind": "object", "fields": {
+				"reason": {"kind": "number"},
+				"gone": {"kind": "string"},
+				"tags": {"kind": "array", "elem": {"kind": "number"}},
+				"tree": {"kind": "object", "fields": {"name": {"kind": "string"}, "parent": {"kind": "string"}}}
+			}}}
+		]}`
+		err := CheckCompatibility([]byte(baseline))
+
+		assert.True(t, Is(err, ErrCodeIncompatibleCatalog))
+		assert.Equal(t, []string{
+			"test.compat.removed: code removed",
+			"test.compat: detail.gone: field removed",
+			"test.compat: detail.reason: type changed from number to string",
+			"test.compat: detail.tags[]: type changed from number to string",
+			"test.compat: detail.tree.parent: field removed",
+		}, err.(EX).Detail().(ErrorEXCatalogIncompatibility).Changes)
+	})
+
+	t.Run("should reject invalid snapshots", func(t *testing.T) {
+		assert.Error(t, CheckCompatibility([]byte("{")))
+		assert.Error(t, CheckCompatibility([]byte(`{"version": 2}`)))
+	})
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema := SchemaOf(reflect.TypeOf(compatDetail{}))
+
+	assert.Equal(t, "object", schema.Kind)
+	assert.Equal(t, TypeSchema{Kind: "string"}, schema.Fields["at"])
+	assert.Equal(t, "map", schema.Fields["labels"].Kind)
+	assert.Equal(t, "number", schema.Fields["labels"].Elem.Kind)
+	assert.Equal(t, "errorex.compatNode", schema.Fields["tree"].Fields["children"].Elem.Ref)
+}
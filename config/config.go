@@ -0,0 +1,99 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package config provides the errorex code of invalid configurations and converters for the errors of
+// gopkg.in/yaml.v3, go-toml and viper, locating the problem by file, line and key so command line tools and
+// operators can tell their users what to fix:
+//
+//	converter := errorex.BuildErrorConverterChain(config.NewViperErrorConverter(), config.NewYAMLErrorConverter())
+//	if err := viper.ReadInConfig(); err != nil {
+//		return converter.ConvertError(config.WithFile(err, viper.ConfigFileUsed()))
+//	}
+package config
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/cli"
+)
+
+// ErrCodeInvalid is the errorex code for configurations that cannot be read or decoded
+const ErrCodeInvalid = "config.invalid"
+
+// InvalidDetail is the detail of ErrCodeInvalid
+type InvalidDetail struct {
+	// File is the path of the configuration file, empty when unknown
+	File string `json:"file,omitempty"`
+	// Line is the 1-based line of the problem, zero when unknown
+	Line int `json:"line,omitempty"`
+	// Column is the 1-based column of the problem, zero when unknown
+	Column int `json:"column,omitempty"`
+	// Key is the dotted path of the offending key, empty when unknown
+	Key string `json:"key,omitempty"`
+	// Reason describes the problem
+	Reason string `json:"reason"`
+}
+
+func init() {
+	// Register the errorex codes
+	errorex.RegisterErrorCode(ErrCodeInvalid, "Invalid configuration", InvalidDetail{},
+		errorex.WithHTTPStatus(http.StatusInternalServerError), errorex.WithExitCode(cli.ExitConfig))
+}
+
+// FileError is an error raised while reading a configuration file
+type FileError struct {
+	// File is the path of the configuration file
+	File string
+	// Err is the underlying error
+	Err error
+}
+
+// Error returns the file followed by the message of the underlying error
+func (e *FileError) Error() string {
+	return e.File + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// WithFile wraps err with the path of the configuration file it was raised for, reported as the file of the
+// converted errorex; nil errors and empty paths are returned as they are
+func WithFile(err error, file string) error {
+	if err == nil || file == "" {
+		return err
+	}
+	return &FileError{File: file, Err: err}
+}
+
+// invalid creates an ErrCodeInvalid errorex, filling the file from a FileError in the chain of err
+func invalid(err error, detail InvalidDetail) errorex.EX {
+	var fileErr *FileError
+	if detail.File == "" && errors.As(err, &fileErr) {
+		detail.File = fileErr.File
+	}
+	return errorex.New(ErrCodeInvalid, detail)
+}
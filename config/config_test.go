@@ -0,0 +1,164 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/cli"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type settings struct {
+	Server struct {
+		Port int `yaml:"port" toml:"port"`
+	} `yaml:"server" toml:"server"`
+}
+
+func TestRegistration(t *testing.T) {
+	t.Run("should register the code as a configuration failure", func(t *testing.T) {
+		info, ok := errorex.Lookup(ErrCodeInvalid)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusInternalServerError, info.HTTPStatus)
+		assert.Equal(t, cli.ExitConfig, info.ExitCode)
+	})
+}
+
+func TestWithFile(t *testing.T) {
+	t.Run("should keep nil errors and empty paths", func(t *testing.T) {
+		assert.NoError(t, WithFile(nil, "app.yaml"))
+		err := errors.New("boom")
+		assert.Same(t, err, WithFile(err, ""))
+	})
+
+	t.Run("should wrap the error with the file", func(t *testing.T) {
+		err := errors.New("boom")
+		wrapped := WithFile(err, "app.yaml")
+		assert.EqualError(t, wrapped, "app.yaml: boom")
+		assert.ErrorIs(t, wrapped, err)
+	})
+}
+
+func TestYAMLErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewYAMLErrorConverter())
+
+	t.Run("should convert type errors", func(t *testing.T) {
+		var target settings
+		err := yaml.Unmarshal([]byte("server:\n  port: abc\n"), &target)
+		ex := converter.ConvertError(WithFile(err, "app.yaml"))
+		assert.Equal(t, ErrCodeInvalid, ex.Code())
+		detail := ex.Detail().(InvalidDetail)
+		assert.Equal(t, "app.yaml", detail.File)
+		assert.Equal(t, 2, detail.Line)
+		assert.Contains(t, detail.Reason, "cannot unmarshal")
+	})
+
+	t.Run("should report the key of unknown fields", func(t *testing.T) {
+		var target settings
+		decoder := yaml.NewDecoder(bytes.NewBufferString("server:\n  port: 80\n  host: localhost\n"))
+		decoder.KnownFields(true)
+		ex := converter.ConvertError(decoder.Decode(&target))
+		detail := ex.Detail().(InvalidDetail)
+		assert.Equal(t, 3, detail.Line)
+		assert.Equal(t, "host", detail.Key)
+	})
+
+	t.Run("should convert syntax errors", func(t *testing.T) {
+		var target settings
+		err := yaml.Unmarshal([]byte("server:\n  port: 80\n bad\n"), &target)
+		ex := converter.ConvertError(err)
+		assert.Equal(t, ErrCodeInvalid, ex.Code())
+		detail := ex.Detail().(InvalidDetail)
+		assert.Equal(t, 2, detail.Line)
+		assert.NotContains(t, detail.Reason, "line")
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(errors.New("line 1: boom")).Code())
+	})
+}
+
+func TestTOMLErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewTOMLErrorConverter())
+
+	t.Run("should convert decode errors", func(t *testing.T) {
+		var target settings
+		err := toml.Unmarshal([]byte("[server]\nport = \"abc\"\n"), &target)
+		ex := converter.ConvertError(WithFile(err, "app.toml"))
+		assert.Equal(t, ErrCodeInvalid, ex.Code())
+		detail := ex.Detail().(InvalidDetail)
+		assert.Equal(t, "app.toml", detail.File)
+		assert.Equal(t, 2, detail.Line)
+		assert.NotEmpty(t, detail.Reason)
+	})
+
+	t.Run("should convert strict mode errors", func(t *testing.T) {
+		var target settings
+		decoder := toml.NewDecoder(bytes.NewBufferString("[server]\nport = 80\nhost = \"localhost\"\n"))
+		decoder.DisallowUnknownFields()
+		ex := converter.ConvertError(decoder.Decode(&target))
+		detail := ex.Detail().(InvalidDetail)
+		assert.Equal(t, 3, detail.Line)
+		assert.Equal(t, "server.host", detail.Key)
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(errors.New("boom")).Code())
+	})
+}
+
+func TestViperErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewViperErrorConverter(), NewYAMLErrorConverter(), NewTOMLErrorConverter())
+
+	t.Run("should convert missing files", func(t *testing.T) {
+		v := viper.New()
+		v.SetConfigName("missing")
+		v.AddConfigPath(t.TempDir())
+		ex := converter.ConvertError(v.ReadInConfig())
+		assert.Equal(t, ErrCodeInvalid, ex.Code())
+		assert.Contains(t, ex.Detail().(InvalidDetail).Reason, "missing")
+	})
+
+	t.Run("should convert unsupported formats", func(t *testing.T) {
+		v := viper.New()
+		v.SetConfigType("xml")
+		ex := converter.ConvertError(v.ReadConfig(bytes.NewBufferString("<server/>")))
+		assert.Equal(t, ErrCodeInvalid, ex.Code())
+	})
+
+	t.Run("should unwrap parsing errors", func(t *testing.T) {
+		v := viper.New()
+		v.SetConfigType("toml")
+		ex := converter.ConvertError(v.ReadConfig(bytes.NewBufferString("[server\n")))
+		assert.Equal(t, ErrCodeInvalid, ex.Code())
+		assert.Equal(t, 1, ex.Detail().(InvalidDetail).Line)
+	})
+}
@@ -0,0 +1,148 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package config
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	yamlLine  = regexp.MustCompile(`(?:^|\s)(?:yaml: )?line (\d+): (?:column (\d+): )?`)
+	yamlField = regexp.MustCompile(`field (\S+) (?:not found|already set) in type`)
+)
+
+// yamlErrorConverter converts the decoding errors of gopkg.in/yaml.v3
+type yamlErrorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewYAMLErrorConverter creates a converter of the errors returned by gopkg.in/yaml.v3 when decoding: a
+// *yaml.TypeError or a syntax error becomes ErrCodeInvalid with the line of its first problem and, for unknown or
+// duplicated fields, the key
+func NewYAMLErrorConverter() errorex.ErrorConverter {
+	return &yamlErrorConverter{}
+}
+
+// ConvertError converts the yaml errors, delegating the others to the next handler in the chain
+func (c *yamlErrorConverter) ConvertError(err error) errorex.EX {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		detail := yamlDetail(typeErr.Errors[0])
+		if len(typeErr.Errors) > 1 {
+			detail.Reason = strings.Join(typeErr.Errors, "; ")
+		}
+		return invalid(err, detail)
+	}
+	if message := err.Error(); strings.Contains(message, "yaml: ") && yamlLine.MatchString(message) {
+		return invalid(err, yamlDetail(message[strings.Index(message, "yaml: "):]))
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
+
+// yamlDetail locates a yaml error message of the form "line N: reason"
+func yamlDetail(message string) InvalidDetail {
+	detail := InvalidDetail{Reason: message}
+	if match := yamlLine.FindStringSubmatchIndex(message); match != nil {
+		detail.Line, _ = strconv.Atoi(message[match[2]:match[3]])
+		if match[4] >= 0 {
+			detail.Column, _ = strconv.Atoi(message[match[4]:match[5]])
+		}
+		detail.Reason = message[match[1]:]
+	}
+	if match := yamlField.FindStringSubmatch(message); match != nil {
+		detail.Key = match[1]
+	}
+	return detail
+}
+
+// tomlErrorConverter converts the decoding errors of go-toml
+type tomlErrorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewTOMLErrorConverter creates a converter of the errors returned by go-toml when decoding: a *toml.DecodeError
+// becomes ErrCodeInvalid with its position and key, and a *toml.StrictMissingError with the position and key of
+// the first field missing in the target
+func NewTOMLErrorConverter() errorex.ErrorConverter {
+	return &tomlErrorConverter{}
+}
+
+// ConvertError converts the toml errors, delegating the others to the next handler in the chain
+func (c *tomlErrorConverter) ConvertError(err error) errorex.EX {
+	var strictErr *toml.StrictMissingError
+	if errors.As(err, &strictErr) && len(strictErr.Errors) > 0 {
+		detail := tomlDetail(&strictErr.Errors[0])
+		detail.Reason = "field not found in the target: " + detail.Key
+		return invalid(err, detail)
+	}
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		return invalid(err, tomlDetail(decodeErr))
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
+
+// tomlDetail locates a toml decoding error
+func tomlDetail(decodeErr *toml.DecodeError) InvalidDetail {
+	line, column := decodeErr.Position()
+	return InvalidDetail{
+		Line:   line,
+		Column: column,
+		Key:    strings.Join(decodeErr.Key(), "."),
+		Reason: strings.TrimPrefix(decodeErr.Error(), "toml: "),
+	}
+}
+
+// viperErrorConverter converts the errors of viper
+type viperErrorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewViperErrorConverter creates a converter of the errors returned by viper when reading its configuration:
+// viper.ConfigFileNotFoundError and viper.UnsupportedConfigError become ErrCodeInvalid. Parsing errors are
+// unwrapped to the yaml and toml converters, so chain them after this one
+func NewViperErrorConverter() errorex.ErrorConverter {
+	return &viperErrorConverter{}
+}
+
+// ConvertError converts the viper errors, delegating the others to the next handler in the chain
+func (c *viperErrorConverter) ConvertError(err error) errorex.EX {
+	var notFoundErr viper.ConfigFileNotFoundError
+	if errors.As(err, &notFoundErr) {
+		return invalid(err, InvalidDetail{Reason: notFoundErr.Error()})
+	}
+	var unsupportedErr viper.UnsupportedConfigError
+	if errors.As(err, &unsupportedErr) {
+		return invalid(err, InvalidDetail{Reason: unsupportedErr.Error()})
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
@@ -0,0 +1,96 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"reflect"
+	"sync"
+)
+
+// constructors holds the constructors registered with RegisterConstructor, by canonical code
+var constructors sync.Map
+
+// RegisterConstructor binds a constructor to a registered code, so ParseJSON rebuilds the errors of the code
+// through it instead of populating the errorex directly, applying its defaults and validation:
+//
+//	errorex.RegisterConstructor("billing.declined", func(code string, detail DeclinedDetail) errorex.EX {
+//		if detail.Currency == "" {
+//			detail.Currency = "USD"
+//		}
+//		return errorex.New(code, detail)
+//	})
+//
+// The instance metadata of the parsed payload (ID, timestamp, stack trace, cause, metadata and retry hint)
+// is kept on the errorex returned by the constructor. A later call replaces the constructor of the code.
+// It panics if the code is not registered or if T is not its detail type.
+func RegisterConstructor[T any](code string, constructor ErrorConstructor[T]) {
+	codeRegistry, ok := lookupCode(code)
+	if !ok {
+		// Fatal errorex
+		fatal(New(ErrCodeNotRegistered, ErrorEXDetail{Code: code}))
+	}
+	detailType := reflect.TypeOf((*T)(nil)).Elem()
+	if codeRegistry.detailType != nil && detailType != codeRegistry.detailType {
+		// Fatal errorex
+		fatal(New(ErrDetailTypeMismatch, ErrorEXDetailTypeMismatch{
+			ExpectedType: codeRegistry.detailType.String(),
+			ActualType:   detailType.String(),
+		}))
+	}
+	constructors.Store(codeRegistry.code, func(code string, detail any) EX {
+		typed, _ := detail.(T)
+		return constructor(code, typed)
+	})
+}
+
+// construct rebuilds a parsed errorex through the constructor registered for its code, keeping its instance
+// metadata. It returns the errorex as is when no constructor is registered, or when the constructor returns nil.
+func construct(parsed *ex) EX {
+	constructor, ok := constructors.Load(parsed.code)
+	if !ok {
+		return parsed
+	}
+	built := constructor.(func(code string, detail any) EX)(parsed.code, parsed.detail)
+	if built == nil {
+		return parsed
+	}
+	e, ok := built.(*ex)
+	if !ok || e.pooled != nil {
+		return built
+	}
+	e.id = parsed.id
+	e.timestamp = parsed.timestamp
+	e.stack = parsed.stack
+	e.cause = parsed.cause
+	if parsed.retryAfter > 0 {
+		e.retryAfter = parsed.retryAfter
+	}
+	if len(parsed.metadata) > 0 && e.metadata == nil {
+		e.metadata = make(map[string]string, len(parsed.metadata))
+	}
+	for key, value := range parsed.metadata {
+		e.metadata[key] = value
+	}
+	return e
+}
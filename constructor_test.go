@@ -0,0 +1,82 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type constructorDetail struct {
+	Currency string `json:"currency"`
+	Amount   int    `json:"amount"`
+}
+
+func TestRegisterConstructor(t *testing.T) {
+	RegisterErrorCode("constructor.declined", "Declined", constructorDetail{})
+	RegisterErrorCode("constructor.invalid", "Invalid", ErrorEXDetail{})
+	RegisterConstructor("constructor.declined", func(code string, detail constructorDetail) EX {
+		if detail.Amount < 0 {
+			return New("constructor.invalid", ErrorEXDetail{Code: code})
+		}
+		if detail.Currency == "" {
+			detail.Currency = "USD"
+		}
+		return New(code, detail, WithMetadata("source", "constructor"), WithRetryAfter(time.Second))
+	})
+
+	t.Run("should rebuild parsed errors through the constructor", func(t *testing.T) {
+		parsed, err := ParseJSON([]byte(`{"code": "constructor.declined", "detail": {"amount": 10}, "id": "abc", "metadata": {"tenant": "acme"}}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, constructorDetail{Currency: "USD", Amount: 10}, parsed.Detail())
+		id, _ := InstanceID(parsed)
+		assert.Equal(t, "abc", id)
+		metadata, _ := Metadata(parsed)
+		assert.Equal(t, map[string]string{"source": "constructor", "tenant": "acme"}, metadata)
+		delay, _ := RetryAfter(parsed)
+		assert.Equal(t, time.Second, delay)
+	})
+
+	t.Run("should return the errorex built by the constructor", func(t *testing.T) {
+		parsed, err := ParseJSON([]byte(`{"code": "constructor.declined", "detail": {"amount": -1}}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "constructor.invalid", parsed.Code())
+	})
+
+	t.Run("should panic on mismatched detail types", func(t *testing.T) {
+		assert.PanicsWithError(t, New(ErrDetailTypeMismatch, ErrorEXDetailTypeMismatch{
+			ExpectedType: "errorex.constructorDetail",
+			ActualType:   "errorex.ErrorEXDetail",
+		}).Error(), func() {
+			RegisterConstructor("constructor.declined", func(code string, detail ErrorEXDetail) EX { return nil })
+		})
+		assert.Panics(t, func() {
+			RegisterConstructor("constructor.unregistered", func(code string, detail ErrorEXDetail) EX { return nil })
+		})
+	})
+}
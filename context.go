@@ -0,0 +1,114 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"context"
+	"sync"
+)
+
+// Metadata keys conventionally set by the context extractors
+const (
+	// MetadataTraceID is the metadata key of the trace ID of the request
+	MetadataTraceID = "trace_id"
+	// MetadataSpanID is the metadata key of the span ID of the request
+	MetadataSpanID = "span_id"
+	// MetadataRequestID is the metadata key of the request ID
+	MetadataRequestID = "request_id"
+)
+
+// ContextExtractor extracts a metadata value from a context, false when the context has none
+type ContextExtractor func(ctx context.Context) (string, bool)
+
+// contextExtractor is a registered extractor and the metadata key of its values
+type contextExtractor struct {
+	key     string
+	extract ContextExtractor
+}
+
+var (
+	extractorMutex    sync.RWMutex
+	contextExtractors []contextExtractor
+)
+
+// RegisterContextExtractor registers how the value of a metadata key is extracted from the contexts given to NewCtx
+// and WithContext, e.g. the trace ID of OpenTelemetry:
+//
+//	errorex.RegisterContextExtractor(errorex.MetadataTraceID, func(ctx context.Context) (string, bool) {
+//		span := trace.SpanContextFromContext(ctx)
+//		return span.TraceID().String(), span.HasTraceID()
+//	})
+//
+// Extractors run in registration order, a later call for the same key replaces its extractor and a nil extractor
+// removes it.
+func RegisterContextExtractor(key string, extract ContextExtractor) {
+	extractorMutex.Lock()
+	defer extractorMutex.Unlock()
+	for i, registered := range contextExtractors {
+		if registered.key != key {
+			continue
+		}
+		if extract == nil {
+			contextExtractors = append(contextExtractors[:i:i], contextExtractors[i+1:]...)
+		} else {
+			contextExtractors[i].extract = extract
+		}
+		return
+	}
+	if extract != nil {
+		contextExtractors = append(contextExtractors, contextExtractor{key: key, extract: extract})
+	}
+}
+
+// WithContext sets the metadata extracted from ctx by the registered extractors, values already set are kept
+func WithContext(ctx context.Context) Option {
+	return func(e *ex) {
+		if ctx == nil {
+			return
+		}
+		extractorMutex.RLock()
+		defer extractorMutex.RUnlock()
+		for _, registered := range contextExtractors {
+			if _, ok := e.metadata[registered.key]; ok {
+				continue
+			}
+			if value, ok := registered.extract(ctx); ok && value != "" {
+				WithMetadata(registered.key, value)(e)
+			}
+		}
+	}
+}
+
+// NewCtx returns a new errorex.EX like New, with the metadata extracted from ctx by the registered extractors (see
+// RegisterContextExtractor). The options are applied first, so they take precedence over the extracted values.
+func NewCtx[T any](ctx context.Context, code string, detail T, options ...Option) EX {
+	code, internal := checkDetail(code, detail)
+	if internal != nil {
+		return internal
+	}
+	e := newEX(code, detail, 1)
+	applyOptions(e, options)
+	WithContext(ctx)(e)
+	return e
+}
@@ -0,0 +1,86 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type requestIDKey struct{}
+
+func TestNewCtx(t *testing.T) {
+	RegisterErrorCode("test.context", "test description", ErrorEXDetail{})
+	RegisterContextExtractor(MetadataRequestID, func(ctx context.Context) (string, bool) {
+		id, ok := ctx.Value(requestIDKey{}).(string)
+		return id, ok
+	})
+	RegisterContextExtractor(MetadataTenant, func(ctx context.Context) (string, bool) {
+		return "acme", true
+	})
+	defer RegisterContextExtractor(MetadataRequestID, nil)
+	defer RegisterContextExtractor(MetadataTenant, nil)
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+
+	t.Run("should extract the metadata of the context", func(t *testing.T) {
+		metadata, ok := Metadata(NewCtx(ctx, "test.context", ErrorEXDetail{}))
+		assert.True(t, ok)
+		assert.Equal(t, map[string]string{MetadataRequestID: "req-1", MetadataTenant: "acme"}, metadata)
+	})
+
+	t.Run("should skip the values the context does not have", func(t *testing.T) {
+		metadata, _ := Metadata(NewCtx(context.Background(), "test.context", ErrorEXDetail{}))
+		assert.Equal(t, map[string]string{MetadataTenant: "acme"}, metadata)
+	})
+
+	t.Run("should keep the values set by the options", func(t *testing.T) {
+		err := NewCtx(ctx, "test.context", ErrorEXDetail{}, WithTenant("globex"))
+		assert.Equal(t, "globex", Tenant(err))
+	})
+
+	t.Run("should replace and remove extractors", func(t *testing.T) {
+		RegisterContextExtractor(MetadataTenant, func(ctx context.Context) (string, bool) {
+			return "initech", true
+		})
+		assert.Equal(t, "initech", Tenant(NewCtx(ctx, "test.context", ErrorEXDetail{})))
+		RegisterContextExtractor(MetadataTenant, nil)
+		assert.Empty(t, Tenant(NewCtx(ctx, "test.context", ErrorEXDetail{})))
+	})
+
+	t.Run("should capture the stack of the caller", func(t *testing.T) {
+		SetStackConfig(StackConfig{Enabled: true})
+		defer SetStackConfig(StackConfig{})
+		frames := NewCtx(ctx, "test.context", ErrorEXDetail{}).(*ex).StackTrace()
+		assert.True(t, strings.HasPrefix(frames[0].Function, "github.com/fkmatsuda/errorex.TestNewCtx"))
+	})
+
+	t.Run("should enrich errors created with other constructors", func(t *testing.T) {
+		err := Wrap(assert.AnError, "test.context", ErrorEXDetail{}, WithContext(ctx))
+		metadata, _ := Metadata(err)
+		assert.Equal(t, "req-1", metadata[MetadataRequestID])
+	})
+}
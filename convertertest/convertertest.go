@@ -0,0 +1,102 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package convertertest provides fake errorex.ErrorConverter implementations for tests of code wiring converter
+// chains, such as middleware, without depending on real converters.
+//
+//	spy := convertertest.Spy()
+//	chain := errorex.BuildErrorConverterChain(spy, convertertest.Scripted(map[string]errorex.EX{
+//		"connection refused": errorex.New(ErrCodeUnavailable, UnavailableDetail{}),
+//	}))
+package convertertest
+
+import (
+	"sync"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// SpyConverter records every error offered to it and delegates the conversion to the next handler in the chain
+type SpyConverter struct {
+	errorex.BaseErrorConverter
+	mutex   sync.Mutex
+	offered []error
+}
+
+// Spy creates a SpyConverter
+func Spy() *SpyConverter {
+	return &SpyConverter{}
+}
+
+// ConvertError records the error and delegates to the next handler
+func (s *SpyConverter) ConvertError(err error) errorex.EX {
+	s.mutex.Lock()
+	s.offered = append(s.offered, err)
+	s.mutex.Unlock()
+	return s.BaseErrorConverter.ConvertError(err)
+}
+
+// Offered returns the errors offered to the converter, in order
+func (s *SpyConverter) Offered() []error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	offered := make([]error, len(s.offered))
+	copy(offered, s.offered)
+	return offered
+}
+
+// Calls returns the number of errors offered to the converter
+func (s *SpyConverter) Calls() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.offered)
+}
+
+// Reset forgets the errors offered so far
+func (s *SpyConverter) Reset() {
+	s.mutex.Lock()
+	s.offered = nil
+	s.mutex.Unlock()
+}
+
+// ScriptedConverter returns canned conversions keyed by error message
+type ScriptedConverter struct {
+	errorex.BaseErrorConverter
+	script map[string]errorex.EX
+}
+
+// Scripted creates a ScriptedConverter converting the errors whose Error() is a key of the script into the mapped
+// errorex, the other errors are delegated to the next handler in the chain
+func Scripted(script map[string]errorex.EX) *ScriptedConverter {
+	return &ScriptedConverter{script: script}
+}
+
+// ConvertError returns the scripted conversion of the error, or delegates to the next handler
+func (s *ScriptedConverter) ConvertError(err error) errorex.EX {
+	if err != nil {
+		if ex, ok := s.script[err.Error()]; ok {
+			return ex
+		}
+	}
+	return s.BaseErrorConverter.ConvertError(err)
+}
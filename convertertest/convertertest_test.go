@@ -0,0 +1,83 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package convertertest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	errorex.RegisterErrorCode("convertertest.unavailable", "Unavailable", errorex.ErrorEXDetail{})
+}
+
+func TestSpy(t *testing.T) {
+	t.Run("should record the offered errors and delegate", func(t *testing.T) {
+		spy := Spy()
+		chain := errorex.BuildErrorConverterChain(spy)
+		first, second := errors.New("first"), errors.New("second")
+
+		assert.True(t, errorex.Is(chain.ConvertError(first), errorex.ErrCodeUnknownError))
+		chain.ConvertError(second)
+
+		assert.Equal(t, []error{first, second}, spy.Offered())
+		assert.Equal(t, 2, spy.Calls())
+		spy.Reset()
+		assert.Zero(t, spy.Calls())
+	})
+
+	t.Run("should not be offered errorex errors", func(t *testing.T) {
+		spy := Spy()
+		chain := errorex.BuildErrorConverterChain(spy)
+
+		chain.ConvertError(errorex.New("convertertest.unavailable", errorex.ErrorEXDetail{}))
+
+		assert.Zero(t, spy.Calls())
+	})
+
+	t.Run("should return nil without a next handler", func(t *testing.T) {
+		assert.Nil(t, Spy().ConvertError(errors.New("test")))
+	})
+}
+
+func TestScripted(t *testing.T) {
+	unavailable := errorex.New("convertertest.unavailable", errorex.ErrorEXDetail{Code: "db"})
+	spy := Spy()
+	chain := errorex.BuildErrorConverterChain(Scripted(map[string]errorex.EX{
+		"connection refused": unavailable,
+	}), spy)
+
+	t.Run("should return the scripted conversions", func(t *testing.T) {
+		assert.Same(t, unavailable, chain.ConvertError(errors.New("connection refused")))
+		assert.Zero(t, spy.Calls())
+	})
+
+	t.Run("should delegate the other errors", func(t *testing.T) {
+		assert.True(t, errorex.Is(chain.ConvertError(errors.New("other")), errorex.ErrCodeUnknownError))
+		assert.Equal(t, 1, spy.Calls())
+	})
+}
@@ -0,0 +1,213 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package cronex wraps the functions of scheduled jobs so their panics and errors become errorex errors, are
+// reported through hooks and decide, by code, whether the job is retried or disabled. A Job is a robfig/cron Job
+// and its Run method a cron FuncJob:
+//
+//	job := cronex.NewJob("invoices.sync", billing.Sync, converter)
+//	job.DisableCodes = []string{auth.ErrCodeTokenInvalid}
+//	job.Hooks = append(job.Hooks, func(event cronex.Event) { logger.Error("job failed", "error", event.Err) })
+//	c.AddJob("@every 5m", job)
+package cronex
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// Metadata keys set on the errors of failed runs
+const (
+	// MetadataJob is the name of the job
+	MetadataJob = "job"
+	// MetadataAttempts is the number of times the function was called in the run
+	MetadataAttempts = "attempts"
+)
+
+// Decision is what a failed run does to its job
+type Decision int
+
+const (
+	// Continue keeps the job scheduled, it runs again at its next activation
+	Continue Decision = iota
+	// Retry calls the function again in the same run, after the errorex.WithRetryAfter hint of the error
+	Retry
+	// Disable skips the next activations of the job until Enable is called
+	Disable
+)
+
+var decisionNames = [...]string{Continue: "continue", Retry: "retry", Disable: "disable"}
+
+// String returns the name of the decision
+func (d Decision) String() string {
+	if d >= 0 && int(d) < len(decisionNames) {
+		return decisionNames[d]
+	}
+	return "Decision(" + strconv.Itoa(int(d)) + ")"
+}
+
+// Event is a failed attempt of a job, passed to its hooks
+type Event struct {
+	// Job is the name of the job
+	Job string
+	// Err is the errorex of the attempt, annotated with MetadataJob and MetadataAttempts
+	Err errorex.EX
+	// Attempt is the number of the attempt in the run, starting at 1
+	Attempt int
+	// Decision is what the job does after the attempt
+	Decision Decision
+}
+
+// Hook is called after every failed attempt of a job
+type Hook func(event Event)
+
+// Job is a scheduled job whose failures are converted to errorex errors
+type Job struct {
+	// Name identifies the job in the errors and the events
+	Name string
+	// Func does the work
+	Func func(ctx context.Context) error
+	// Converter converts the errors of Func, errorex.BuildErrorConverterChain() when nil
+	Converter errorex.ErrorConverter
+	// MaxAttempts is the number of times Func is called in a run while it fails with retryable errors (see
+	// errorex.IsRetryable). It is 1 when not set, so retryable errors wait for the next activation.
+	MaxAttempts int
+	// DisableCodes are the codes disabling the job, such as invalid credentials that failing again will not fix.
+	// Aliases match the code they are an alias of.
+	DisableCodes []string
+	// Decide overrides the decision taken from MaxAttempts and DisableCodes when not nil
+	Decide func(ex errorex.EX, attempt int) Decision
+	// Hooks are called after every failed attempt, in order
+	Hooks []Hook
+	// Timeout bounds each run when positive
+	Timeout time.Duration
+
+	disabled atomic.Bool
+	running  sync.Mutex
+}
+
+// NewJob creates a job calling fn, converting its errors through the converter
+func NewJob(name string, fn func(ctx context.Context) error, converter errorex.ErrorConverter) *Job {
+	return &Job{Name: name, Func: fn, Converter: converter}
+}
+
+// Run runs the job with the background context, implementing the cron Job interface
+func (j *Job) Run() {
+	_ = j.RunContext(context.Background())
+}
+
+// RunContext runs the job unless it is disabled or already running, returning the errorex of its last failed
+// attempt, nil when it succeeded or was skipped. Panics of Func are recovered and converted like errors.
+func (j *Job) RunContext(ctx context.Context) errorex.EX {
+	if j.disabled.Load() || !j.running.TryLock() {
+		return nil
+	}
+	defer j.running.Unlock()
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+	for attempt := 1; ; attempt++ {
+		ex := j.attempt(ctx)
+		if ex == nil {
+			return nil
+		}
+		ex = errorex.Annotate(ex,
+			errorex.WithMetadata(MetadataJob, j.Name),
+			errorex.WithMetadata(MetadataAttempts, strconv.Itoa(attempt)),
+		)
+		decision := j.decide(ex, attempt)
+		if decision == Disable {
+			j.disabled.Store(true)
+		}
+		for _, hook := range j.Hooks {
+			hook(Event{Job: j.Name, Err: ex, Attempt: attempt, Decision: decision})
+		}
+		if decision != Retry || !wait(ctx, ex) {
+			return ex
+		}
+	}
+}
+
+// Disabled tells if a failure disabled the job
+func (j *Job) Disabled() bool {
+	return j.disabled.Load()
+}
+
+// Enable schedules a disabled job again
+func (j *Job) Enable() {
+	j.disabled.Store(false)
+}
+
+// attempt calls the function once, converting its error, panics are reported as errors
+func (j *Job) attempt(ctx context.Context) (ex errorex.EX) {
+	converter := j.Converter
+	if converter == nil {
+		converter = errorex.BuildErrorConverterChain()
+	}
+	defer func() {
+		if panicked := recover(); panicked != nil {
+			ex = converter.ConvertError(fmt.Errorf("job panicked: %v", panicked))
+		}
+	}()
+	if err := j.Func(ctx); err != nil {
+		return converter.ConvertError(err)
+	}
+	return nil
+}
+
+// decide returns the decision for a failed attempt
+func (j *Job) decide(ex errorex.EX, attempt int) Decision {
+	if j.Decide != nil {
+		return j.Decide(ex, attempt)
+	}
+	for _, code := range j.DisableCodes {
+		if errorex.Is(ex, code) {
+			return Disable
+		}
+	}
+	if attempt < max(j.MaxAttempts, 1) && errorex.IsRetryable(ex) {
+		return Retry
+	}
+	return Continue
+}
+
+// wait waits for the retry hint of the errorex, false when the context is done first
+func wait(ctx context.Context, ex errorex.EX) bool {
+	delay, _ := errorex.RetryAfter(ex)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,130 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package cronex
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	errorex.RegisterErrorCode("cronex.unavailable", "Unavailable", errorex.ErrorEXDetail{}, errorex.WithRetryable())
+	errorex.RegisterErrorCode("cronex.forbidden", "Forbidden", errorex.ErrorEXDetail{})
+}
+
+// cronJob is the Job interface of robfig/cron
+type cronJob interface {
+	Run()
+}
+
+var _ cronJob = (*Job)(nil)
+
+func TestJob(t *testing.T) {
+	unavailable := errorex.New("cronex.unavailable", errorex.ErrorEXDetail{})
+	forbidden := errorex.New("cronex.forbidden", errorex.ErrorEXDetail{})
+
+	t.Run("should retry retryable errors and report every attempt", func(t *testing.T) {
+		calls := 0
+		job := NewJob("sync", func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return unavailable
+			}
+			return nil
+		}, nil)
+		job.MaxAttempts = 3
+		var events []Event
+		job.Hooks = []Hook{func(event Event) { events = append(events, event) }}
+		assert.Nil(t, job.RunContext(context.Background()))
+		assert.Equal(t, 3, calls)
+		assert.Len(t, events, 2)
+		assert.Equal(t, Retry, events[1].Decision)
+		assert.Equal(t, 2, events[1].Attempt)
+		metadata, _ := errorex.Metadata(events[1].Err)
+		assert.Equal(t, map[string]string{MetadataJob: "sync", MetadataAttempts: "2"}, metadata)
+	})
+
+	t.Run("should continue after the last attempt", func(t *testing.T) {
+		job := NewJob("sync", func(ctx context.Context) error { return unavailable }, nil)
+		ex := job.RunContext(context.Background())
+		assert.True(t, errorex.Is(ex, "cronex.unavailable"))
+		assert.False(t, job.Disabled())
+	})
+
+	t.Run("should disable the job on the disable codes", func(t *testing.T) {
+		calls := 0
+		job := NewJob("sync", func(ctx context.Context) error {
+			calls++
+			return forbidden
+		}, nil)
+		job.DisableCodes = []string{"cronex.forbidden"}
+		var decision Decision
+		job.Hooks = []Hook{func(event Event) { decision = event.Decision }}
+		job.Run()
+		job.Run()
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, Disable, decision)
+		assert.True(t, job.Disabled())
+
+		job.Enable()
+		job.Run()
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("should convert panics", func(t *testing.T) {
+		job := NewJob("sync", func(ctx context.Context) error { panic("boom") }, nil)
+		ex := job.RunContext(context.Background())
+		assert.Equal(t, errorex.ErrCodeUnknownError, ex.Code())
+		assert.Equal(t, errorex.UnknownErrorDetail{Detail: "job panicked: boom"}, ex.Detail())
+	})
+
+	t.Run("should use the decide function", func(t *testing.T) {
+		job := NewJob("sync", func(ctx context.Context) error { return errors.New("boom") }, nil)
+		job.Decide = func(ex errorex.EX, attempt int) Decision { return Disable }
+		job.Run()
+		assert.True(t, job.Disabled())
+	})
+
+	t.Run("should stop retrying when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		job := NewJob("sync", func(ctx context.Context) error {
+			cancel()
+			return errorex.Annotate(unavailable, errorex.WithRetryAfter(time.Hour))
+		}, nil)
+		job.MaxAttempts = 2
+		assert.NotNil(t, job.RunContext(ctx))
+	})
+}
+
+func TestDecision(t *testing.T) {
+	t.Run("should name the decisions", func(t *testing.T) {
+		assert.Equal(t, "retry", Retry.String())
+		assert.Equal(t, "Decision(7)", Decision(7).String())
+	})
+}
@@ -0,0 +1,185 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// KeyProvider provides the AES keys used to encrypt and decrypt details, so keys can be rotated: details are
+// encrypted with the current key and carry its ID, and are decrypted with the key of that ID
+type KeyProvider interface {
+	// EncryptionKey returns the ID and the key used to encrypt new details
+	EncryptionKey() (id string, key []byte, err error)
+	// DecryptionKey returns the key of an ID
+	DecryptionKey(id string) ([]byte, error)
+}
+
+// staticKeys is the KeyProvider returned by StaticKeys
+type staticKeys struct {
+	current string
+	keys    map[string][]byte
+}
+
+// StaticKeys returns a KeyProvider with a fixed set of keys by ID, encrypting with the key of current.
+// Keys are 16, 24 or 32 bytes long, selecting AES-128, AES-192 or AES-256.
+func StaticKeys(current string, keys map[string][]byte) KeyProvider {
+	copied := make(map[string][]byte, len(keys))
+	for id, key := range keys {
+		copied[id] = append([]byte(nil), key...)
+	}
+	return &staticKeys{current: current, keys: copied}
+}
+
+func (k *staticKeys) EncryptionKey() (string, []byte, error) {
+	key, err := k.DecryptionKey(k.current)
+	return k.current, key, err
+}
+
+func (k *staticKeys) DecryptionKey(id string) ([]byte, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown detail key %q", id)
+	}
+	return key, nil
+}
+
+// EncryptedDetail replaces the detail of errors encrypted by Encrypted
+type EncryptedDetail struct {
+	// Algorithm is A128GCM, A192GCM or A256GCM
+	Algorithm string `json:"alg"`
+	// KeyID is the ID of the key, as given by the KeyProvider
+	KeyID string `json:"kid"`
+	// Ciphertext is the nonce followed by the sealed JSON encoding of the detail
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Encrypted returns a copy of the errorex with the detail encrypted with AES-GCM, for errors sent to untrusted
+// transports such as browser-visible responses or third-party webhooks. The code, ID and metadata stay readable,
+// only the detail is sealed, with the code as additional data so it cannot be moved to another error.
+// The size limits set with SetSizeLimits are applied before encrypting. Project the detail with Projected or
+// Exposed first, they leave encrypted details as they are. Consumers holding the keys restore the detail with
+// SetDecryptionKeys.
+// Errors of other implementations cannot be copied and return an error.
+func Encrypted(err EX, keys KeyProvider) (EX, error) {
+	e, ok := err.(*ex)
+	if !ok {
+		return nil, fmt.Errorf("cannot encrypt the detail of %s: not an errorex error", err.Code())
+	}
+	if _, ok := e.detail.(EncryptedDetail); ok {
+		return err, nil
+	}
+	id, key, keyErr := keys.EncryptionKey()
+	if keyErr != nil {
+		return nil, fmt.Errorf("cannot encrypt the detail of %s: %w", e.code, keyErr)
+	}
+	aead, aeadErr := newAEAD(key)
+	if aeadErr != nil {
+		return nil, fmt.Errorf("cannot encrypt the detail of %s: %w", e.code, aeadErr)
+	}
+	buffer := getEncodeBuffer()
+	defer putEncodeBuffer(buffer)
+	if writeErr := buffer.writeDetail(e.detail); writeErr != nil {
+		return nil, fmt.Errorf("cannot encrypt the detail of %s: %w", e.code, writeErr)
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+buffer.buffer.Len()+aead.Overhead())
+	if _, randErr := rand.Read(nonce); randErr != nil {
+		return nil, fmt.Errorf("cannot encrypt the detail of %s: %w", e.code, randErr)
+	}
+	encrypted := e.clone()
+	encrypted.detail = EncryptedDetail{
+		Algorithm:  algorithmOf(key),
+		KeyID:      id,
+		Ciphertext: aead.Seal(nonce, nonce, buffer.buffer.Bytes(), []byte(e.code)),
+	}
+	return encrypted, nil
+}
+
+var decryptionKeys atomic.Pointer[KeyProvider]
+
+// SetDecryptionKeys sets the keys ParseJSON uses to decrypt details encrypted by Encrypted, before decoding them into
+// the registered detail type. Without keys, the default, ParseJSON keeps encrypted details as an EncryptedDetail, so
+// they can be relayed as they are. nil removes the keys.
+func SetDecryptionKeys(keys KeyProvider) {
+	if keys == nil {
+		decryptionKeys.Store(nil)
+		return
+	}
+	decryptionKeys.Store(&keys)
+}
+
+// decryptDetail tells if a serialized detail is encrypted and, when decryption keys are set, returns its plaintext
+func decryptDetail(codec JSONCodec, code string, data json.RawMessage) (json.RawMessage, *EncryptedDetail, error) {
+	var probe EncryptedDetail
+	if len(data) == 0 || data[0] != '{' || codec.Unmarshal(data, &probe) != nil || len(probe.Ciphertext) == 0 ||
+		!isGCMAlgorithm(probe.Algorithm) {
+		return data, nil, nil
+	}
+	keys := decryptionKeys.Load()
+	if keys == nil {
+		return nil, &probe, nil
+	}
+	key, err := (*keys).DecryptionKey(probe.KeyID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot decrypt the detail of %s: %w", code, err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot decrypt the detail of %s: %w", code, err)
+	}
+	if len(probe.Ciphertext) < aead.NonceSize() {
+		return nil, nil, fmt.Errorf("cannot decrypt the detail of %s: ciphertext too short", code)
+	}
+	nonce, sealed := probe.Ciphertext[:aead.NonceSize()], probe.Ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(code))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot decrypt the detail of %s: %w", code, err)
+	}
+	return plaintext, nil, nil
+}
+
+// newAEAD returns the AES-GCM cipher of a key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// algorithmOf returns the name of the AES-GCM algorithm selected by the length of a key
+func algorithmOf(key []byte) string {
+	return "A" + strconv.Itoa(len(key)*8) + "GCM"
+}
+
+// isGCMAlgorithm tells if an algorithm name is written by Encrypted
+func isGCMAlgorithm(algorithm string) bool {
+	return algorithm == "A128GCM" || algorithm == "A192GCM" || algorithm == "A256GCM"
+}
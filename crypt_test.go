@@ -0,0 +1,140 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type secretDetail struct {
+	Account string `json:"account"`
+	Balance int    `json:"balance"`
+}
+
+func TestEncrypted(t *testing.T) {
+	RegisterErrorCode("test.encrypted", "test description", secretDetail{})
+	RegisterErrorCode("test.encrypted_other", "test description", secretDetail{})
+	keys := StaticKeys("k2", map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 16),
+		"k2": bytes.Repeat([]byte{2}, 32),
+	})
+	defer SetDecryptionKeys(nil)
+
+	t.Run("should seal the detail and keep the rest readable", func(t *testing.T) {
+		err := New("test.encrypted", secretDetail{Account: "acct-42", Balance: 7}, WithMetadata("tenant", "acme"))
+		encrypted, encryptErr := Encrypted(err, keys)
+		assert.NoError(t, encryptErr)
+		assert.NotContains(t, encrypted.Error(), "acct-42")
+		detail, ok := encrypted.Detail().(EncryptedDetail)
+		assert.True(t, ok)
+		assert.Equal(t, "A256GCM", detail.Algorithm)
+		assert.Equal(t, "k2", detail.KeyID)
+		id, _ := InstanceID(err)
+		encryptedID, _ := InstanceID(encrypted)
+		assert.Equal(t, id, encryptedID)
+		metadata, _ := Metadata(encrypted)
+		assert.Equal(t, "acme", metadata["tenant"])
+		assert.Equal(t, secretDetail{Account: "acct-42", Balance: 7}, err.Detail())
+	})
+
+	t.Run("should restore the detail with the decryption keys", func(t *testing.T) {
+		SetDecryptionKeys(keys)
+		err := New("test.encrypted", secretDetail{Account: "acct-42", Balance: 7})
+		encrypted, _ := Encrypted(err, keys)
+		parsed, parseErr := ParseJSON([]byte(encrypted.Error()))
+		assert.NoError(t, parseErr)
+		assert.Equal(t, secretDetail{Account: "acct-42", Balance: 7}, parsed.Detail())
+		id, _ := InstanceID(err)
+		parsedID, _ := InstanceID(parsed)
+		assert.Equal(t, id, parsedID)
+	})
+
+	t.Run("should decrypt with rotated keys", func(t *testing.T) {
+		old := StaticKeys("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 16)})
+		encrypted, _ := Encrypted(New("test.encrypted", secretDetail{Account: "old"}), old)
+		assert.Equal(t, "A128GCM", encrypted.Detail().(EncryptedDetail).Algorithm)
+		SetDecryptionKeys(keys)
+		parsed, err := ParseJSON([]byte(encrypted.Error()))
+		assert.NoError(t, err)
+		assert.Equal(t, secretDetail{Account: "old"}, parsed.Detail())
+	})
+
+	t.Run("should keep the encrypted detail without decryption keys", func(t *testing.T) {
+		SetDecryptionKeys(nil)
+		encrypted, _ := Encrypted(New("test.encrypted", secretDetail{Account: "acct-42"}), keys)
+		parsed, err := ParseJSON([]byte(encrypted.Error()))
+		assert.NoError(t, err)
+		assert.Equal(t, encrypted.Detail(), parsed.Detail())
+		SetDecryptionKeys(keys)
+		relayed, err := ParseJSON([]byte(parsed.Error()))
+		assert.NoError(t, err)
+		assert.Equal(t, secretDetail{Account: "acct-42"}, relayed.Detail())
+	})
+
+	t.Run("should fail when the detail is moved to another code", func(t *testing.T) {
+		SetDecryptionKeys(keys)
+		encrypted, _ := Encrypted(New("test.encrypted", secretDetail{Account: "acct-42"}), keys)
+		moved := strings.Replace(encrypted.Error(), `"test.encrypted"`, `"test.encrypted_other"`, 1)
+		_, err := ParseJSON([]byte(moved))
+		assert.ErrorContains(t, err, "cannot decrypt the detail of test.encrypted_other")
+	})
+
+	t.Run("should fail with an unknown key", func(t *testing.T) {
+		other := StaticKeys("k3", map[string][]byte{"k3": bytes.Repeat([]byte{3}, 24)})
+		encrypted, _ := Encrypted(New("test.encrypted", secretDetail{}), other)
+		SetDecryptionKeys(keys)
+		_, err := ParseJSON([]byte(encrypted.Error()))
+		assert.ErrorContains(t, err, `unknown detail key "k3"`)
+	})
+
+	t.Run("should fail with an invalid key", func(t *testing.T) {
+		invalid := StaticKeys("bad", map[string][]byte{"bad": []byte("short")})
+		_, err := Encrypted(New("test.encrypted", secretDetail{}), invalid)
+		assert.ErrorContains(t, err, "cannot encrypt the detail of test.encrypted")
+	})
+
+	t.Run("should apply the size limits before encrypting", func(t *testing.T) {
+		SetSizeLimits(SizeLimits{MaxStringBytes: 4})
+		defer SetSizeLimits(SizeLimits{})
+		SetDecryptionKeys(keys)
+		encrypted, _ := Encrypted(New("test.encrypted", secretDetail{Account: "acct-42"}), keys)
+		parsed, err := ParseJSON([]byte(encrypted.Error()))
+		assert.NoError(t, err)
+		assert.Equal(t, "acct...[truncated 3 bytes]", parsed.Detail().(secretDetail).Account)
+	})
+
+	t.Run("should leave encrypted details to projections", func(t *testing.T) {
+		encrypted, _ := Encrypted(New("test.encrypted", secretDetail{Account: "acct-42"}), keys)
+		assert.Equal(t, encrypted, Exposed(encrypted))
+		assert.Equal(t, encrypted, Projected(encrypted, SinkExternal))
+		var decoded map[string]json.RawMessage
+		assert.NoError(t, json.Unmarshal([]byte(encrypted.Error()), &decoded))
+		assert.Contains(t, string(decoded["detail"]), `"kid":"k2"`)
+	})
+}
@@ -0,0 +1,193 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Kinds of references between codes
+const (
+	// ReferenceParent is the reference of a code to the parent set with WithParent
+	ReferenceParent = "parent"
+	// ReferenceAlias is the reference of an alias declared with DeferAlias to its code
+	ReferenceAlias = "alias"
+)
+
+// Reference is a reference from a code to another, reported when it cannot be resolved
+type Reference struct {
+	// Code is the referencing code, or alias
+	Code string `json:"code"`
+	// Kind is ReferenceParent or ReferenceAlias
+	Kind string `json:"kind"`
+	// Target is the referenced code
+	Target string `json:"target"`
+	// Cycle tells if the reference is part of a cycle of parents instead of missing its target
+	Cycle bool `json:"cycle,omitempty"`
+}
+
+// ErrorEXUnresolvedReferences is the detail of ErrCodeUnresolvedReference
+type ErrorEXUnresolvedReferences struct {
+	References []Reference `json:"references"`
+}
+
+// deferredAliases are the aliases declared with DeferAlias whose code is not registered yet
+var (
+	deferredMutex   sync.Mutex
+	deferredAliases []Reference
+)
+
+// WithParent sets the parent of the code, making it a more specific case of the parent (see IsUnder). The parent
+// may be registered later, by another package, but must be registered when Freeze is called.
+func WithParent(code string) RegistrationOption {
+	return func(registry *errorCodeRegistry) {
+		registry.parent = code
+	}
+}
+
+// DeferAlias registers alias as another name of code like RegisterAlias, but code may be registered later, by
+// another package: the alias is registered as soon as ResolveReferences or Freeze finds it. It panics if the code is
+// already registered and the alias is, or if the registry is frozen.
+func DeferAlias(alias string, code string) {
+	if _, ok := lookupCode(code); ok {
+		RegisterAlias(alias, code)
+		return
+	}
+	if IsFrozen() {
+		// Fatal errorex
+		fatal(New(ErrCodeRegistryFrozen, ErrorEXDetail{Code: alias}))
+	}
+	deferredMutex.Lock()
+	deferredAliases = append(deferredAliases, Reference{Code: alias, Kind: ReferenceAlias, Target: code})
+	deferredMutex.Unlock()
+}
+
+// ResolveReferences registers the deferred aliases whose code is registered and checks the parents of the codes.
+// It returns an ErrCodeUnresolvedReference errorex listing the aliases and parents still missing and the cycles of
+// parents, nil when every reference is resolved. Freeze panics with that errorex, calling ResolveReferences first
+// reports the problems without panicking.
+func ResolveReferences() error {
+	if problems := resolveReferences(); len(problems) > 0 {
+		return New(ErrCodeUnresolvedReference, ErrorEXUnresolvedReferences{References: problems})
+	}
+	return nil
+}
+
+// resolveReferences registers the deferred aliases that can be and returns the unresolved references, sorted
+func resolveReferences() []Reference {
+	deferredMutex.Lock()
+	defer deferredMutex.Unlock()
+	// An alias may target another deferred alias, register them until no more can be
+	for progress := true; progress; {
+		progress = false
+		pending := deferredAliases[:0]
+		for _, reference := range deferredAliases {
+			if _, ok := lookupCode(reference.Target); !ok {
+				pending = append(pending, reference)
+				continue
+			}
+			RegisterAlias(reference.Code, reference.Target)
+			progress = true
+		}
+		deferredAliases = pending
+	}
+	problems := append([]Reference(nil), deferredAliases...)
+	problems = append(problems, unresolvedParents()...)
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].Code != problems[j].Code {
+			return problems[i].Code < problems[j].Code
+		}
+		return problems[i].Kind < problems[j].Kind
+	})
+	return problems
+}
+
+// unresolvedParents returns the parents that are not registered and the codes whose ancestors form a cycle
+func unresolvedParents() []Reference {
+	parents := make(map[string]string)
+	rangeCodes(func(codeRegistry errorCodeRegistry) {
+		if codeRegistry.parent != "" && codeRegistry.alias == "" {
+			parents[codeRegistry.code] = codeRegistry.parent
+		}
+	})
+	var problems []Reference
+	for code, parent := range parents {
+		target, ok := lookupCode(parent)
+		if !ok {
+			problems = append(problems, Reference{Code: code, Kind: ReferenceParent, Target: parent})
+			continue
+		}
+		// Walk the ancestors, the code is in a cycle when it is met again
+		seen := map[string]bool{code: true}
+		for ancestor := target.code; ancestor != ""; ancestor = canonicalParent(parents, ancestor) {
+			if ancestor == code {
+				problems = append(problems, Reference{Code: code, Kind: ReferenceParent, Target: parent, Cycle: true})
+				break
+			}
+			if seen[ancestor] {
+				// A cycle above the code, reported by its members
+				break
+			}
+			seen[ancestor] = true
+		}
+	}
+	return problems
+}
+
+// canonicalParent returns the canonical code of the parent of a code, empty when it has none or it is unregistered
+func canonicalParent(parents map[string]string, code string) string {
+	if parent, ok := lookupCode(parents[code]); ok && parents[code] != "" {
+		return parent.code
+	}
+	return ""
+}
+
+// IsUnder checks if the code of the first errorex in the chain of err is the code or one of its descendants, see
+// WithParent. Aliases match the code they are an alias of.
+func IsUnder(err error, code string) bool {
+	var target EX
+	if !errors.As(err, &target) {
+		return false
+	}
+	ancestor, ok := lookupCode(code)
+	if !ok {
+		return false
+	}
+	current, ok := lookupCode(target.Code())
+	for depth := 0; ok && depth <= maxParentDepth; depth++ {
+		if current.code == ancestor.code {
+			return true
+		}
+		if current.parent == "" {
+			return false
+		}
+		current, ok = lookupCode(current.parent)
+	}
+	return false
+}
+
+// maxParentDepth bounds the walk of IsUnder, so cycles registered before Freeze do not hang it
+const maxParentDepth = 64
@@ -0,0 +1,130 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unregisterCodes removes codes from the registry, so tests do not leave unresolved references behind
+func unregisterCodes(codes ...string) {
+	for _, code := range codes {
+		shard := shardOf(code)
+		shard.mutex.Lock()
+		delete(shard.codes, code)
+		shard.mutex.Unlock()
+	}
+}
+
+func TestDeferAlias(t *testing.T) {
+	t.Run("should register the alias once its code is registered", func(t *testing.T) {
+		DeferAlias("test.deferred_alias.old", "test.deferred_alias.new")
+		DeferAlias("test.deferred_alias.older", "test.deferred_alias.old")
+		_, ok := Lookup("test.deferred_alias.old")
+		assert.False(t, ok)
+
+		RegisterErrorCode("test.deferred_alias.new", "test description", ErrorEXDetail{})
+		assert.NoError(t, ResolveReferences())
+		info, ok := Lookup("test.deferred_alias.older")
+		assert.True(t, ok)
+		assert.Equal(t, "test.deferred_alias.new", info.Code)
+		assert.Equal(t, []string{"test.deferred_alias.old", "test.deferred_alias.older"}, info.Aliases)
+	})
+
+	t.Run("should register the alias at once when its code is registered", func(t *testing.T) {
+		RegisterErrorCode("test.deferred_alias.registered", "test description", ErrorEXDetail{})
+		DeferAlias("test.deferred_alias.immediate", "test.deferred_alias.registered")
+		assert.True(t, Is(New("test.deferred_alias.immediate", ErrorEXDetail{}), "test.deferred_alias.registered"))
+	})
+
+	t.Run("should report the aliases whose code is missing", func(t *testing.T) {
+		DeferAlias("test.deferred_alias.dangling", "test.deferred_alias.missing")
+		defer func() {
+			deferredMutex.Lock()
+			deferredAliases = nil
+			deferredMutex.Unlock()
+		}()
+		err := ResolveReferences()
+		assert.True(t, Is(err, ErrCodeUnresolvedReference))
+		assert.Equal(t, ErrorEXUnresolvedReferences{References: []Reference{
+			{Code: "test.deferred_alias.dangling", Kind: ReferenceAlias, Target: "test.deferred_alias.missing"},
+		}}, err.(EX).Detail())
+	})
+}
+
+func TestWithParent(t *testing.T) {
+	t.Run("should resolve parents registered later", func(t *testing.T) {
+		RegisterErrorCode("test.parent.child", "test description", ErrorEXDetail{}, WithParent("test.parent.base"))
+		assert.True(t, Is(ResolveReferences(), ErrCodeUnresolvedReference))
+
+		RegisterErrorCode("test.parent.base", "test description", ErrorEXDetail{})
+		assert.NoError(t, ResolveReferences())
+		info, _ := Lookup("test.parent.child")
+		assert.Equal(t, "test.parent.base", info.Parent)
+	})
+
+	t.Run("should report cycles of parents", func(t *testing.T) {
+		RegisterErrorCode("test.parent.cycle_a", "test description", ErrorEXDetail{}, WithParent("test.parent.cycle_b"))
+		RegisterErrorCode("test.parent.cycle_b", "test description", ErrorEXDetail{}, WithParent("test.parent.cycle_a"))
+		defer unregisterCodes("test.parent.cycle_a", "test.parent.cycle_b")
+		err := ResolveReferences()
+		assert.Equal(t, ErrorEXUnresolvedReferences{References: []Reference{
+			{Code: "test.parent.cycle_a", Kind: ReferenceParent, Target: "test.parent.cycle_b", Cycle: true},
+			{Code: "test.parent.cycle_b", Kind: ReferenceParent, Target: "test.parent.cycle_a", Cycle: true},
+		}}, err.(EX).Detail())
+		assert.False(t, IsUnder(New("test.parent.cycle_a", ErrorEXDetail{}), "test.parent.base"))
+	})
+
+	t.Run("should make Freeze panic on unresolved references", func(t *testing.T) {
+		RegisterErrorCode("test.parent.orphan", "test description", ErrorEXDetail{}, WithParent("test.parent.missing"))
+		defer unregisterCodes("test.parent.orphan")
+		assert.PanicsWithError(t, New(ErrCodeUnresolvedReference, ErrorEXUnresolvedReferences{References: []Reference{
+			{Code: "test.parent.orphan", Kind: ReferenceParent, Target: "test.parent.missing"},
+		}}).Error(), Freeze)
+		assert.False(t, IsFrozen())
+	})
+}
+
+func TestIsUnder(t *testing.T) {
+	RegisterErrorCode("test.under.base", "test description", ErrorEXDetail{})
+	RegisterErrorCode("test.under.middle", "test description", ErrorEXDetail{}, WithParent("test.under.base"))
+	RegisterErrorCode("test.under.leaf", "test description", ErrorEXDetail{}, WithParent("test.under.middle"))
+	RegisterAlias("test.under.leaf_alias", "test.under.leaf")
+
+	t.Run("should match the code and its ancestors", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", New("test.under.leaf_alias", ErrorEXDetail{}))
+		assert.True(t, IsUnder(err, "test.under.leaf"))
+		assert.True(t, IsUnder(err, "test.under.middle"))
+		assert.True(t, IsUnder(err, "test.under.base"))
+	})
+
+	t.Run("should not match descendants or other codes", func(t *testing.T) {
+		assert.False(t, IsUnder(New("test.under.middle", ErrorEXDetail{}), "test.under.leaf"))
+		assert.False(t, IsUnder(New("test.under.base", ErrorEXDetail{}), "test.under.missing"))
+		assert.False(t, IsUnder(fmt.Errorf("boom"), "test.under.base"))
+	})
+}
@@ -0,0 +1,76 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+// Definition is a typed handle to a registered errorex code, created by Define.
+// It ties the code to its detail type, so constructing and checking errors don't repeat the code string
+// and detail type mismatches are caught by the compiler instead of panicking at runtime.
+type Definition[T any] struct {
+	code string
+}
+
+// Define registers the errorex code with the detail type T and returns its typed handle.
+// It is meant to be used on package level variables, so the code is registered during initialization:
+//
+//	var ErrInvalidInput = errorex.Define[InvalidInputDetail]("E001", "Invalid input")
+func Define[T any](code string, description string, options ...RegistrationOption) Definition[T] {
+	var detail T
+	RegisterErrorCode(code, description, detail, options...)
+	return Definition[T]{code: code}
+}
+
+// Code returns the errorex code of the definition
+func (d Definition[T]) Code() string {
+	return d.code
+}
+
+// New returns a new errorex.EX with the code of the definition
+func (d Definition[T]) New(detail T, options ...Option) EX {
+	code, internal := checkDetail(d.code, detail)
+	if internal != nil {
+		return internal
+	}
+	e := newEX(code, detail, 1)
+	applyOptions(e, options)
+	return e
+}
+
+// Is checks if the error is of type EX and has the code of the definition
+func (d Definition[T]) Is(err error) bool {
+	return Is(err, d.code)
+}
+
+// Detail returns the detail of the error if it has the code of the definition
+func (d Definition[T]) Detail(err error) (T, bool) {
+	var zero T
+	if !d.Is(err) {
+		return zero, false
+	}
+	target, ok := firstEX(err)
+	if !ok {
+		return zero, false
+	}
+	detail, ok := target.Detail().(T)
+	return detail, ok
+}
@@ -0,0 +1,80 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type defineDetail struct {
+	Field string `json:"field"`
+}
+
+var testDefinition = Define[defineDetail]("test.define", "test description")
+
+func TestDefine(t *testing.T) {
+	t.Run("should register the code", func(t *testing.T) {
+		assert.Equal(t, "test.define", testDefinition.Code())
+		assert.Panics(t, func() {
+			Define[defineDetail]("test.define", "test description")
+		})
+	})
+
+	t.Run("should create and check errors", func(t *testing.T) {
+		ex := testDefinition.New(defineDetail{Field: "name"})
+
+		assert.True(t, testDefinition.Is(ex))
+		assert.True(t, Is(ex, "test.define"))
+		assert.JSONEq(t, `{"code": "test.define", "detail": {"field":"name"}}`, ex.Error())
+	})
+
+	t.Run("should return the typed detail", func(t *testing.T) {
+		detail, ok := testDefinition.Detail(testDefinition.New(defineDetail{Field: "name"}))
+		assert.True(t, ok)
+		assert.Equal(t, "name", detail.Field)
+
+		_, ok = testDefinition.Detail(fmt.Errorf("test error"))
+		assert.False(t, ok)
+	})
+
+	t.Run("should not return the detail of foreign errors with the code", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			_, ok := testDefinition.Detail(&libraryError{code: "test.define"})
+			assert.False(t, ok)
+		})
+	})
+
+	t.Run("should capture the caller of New in the stack", func(t *testing.T) {
+		SetStackConfig(StackConfig{Enabled: true, MaxDepth: 1})
+		defer SetStackConfig(StackConfig{})
+
+		frames, ok := StackTrace(testDefinition.New(defineDetail{}))
+		assert.True(t, ok)
+		assert.True(t, strings.HasSuffix(frames[0].File, "define_test.go"))
+	})
+}
@@ -0,0 +1,120 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+var copyDetails atomic.Bool
+
+// SetCopyDetails makes the constructors (New, NewPooled, Wrap, ...) store a deep copy of the detail, so later
+// changes to maps, slices or pointers shared with the caller don't change the error. It is disabled by default,
+// as copying costs an allocation per reference held by the detail.
+func SetCopyDetails(enabled bool) {
+	copyDetails.Store(enabled)
+}
+
+// DetailCopy returns a deep copy of the detail of the errorex, isolated from later changes, e.g. to retain it
+// after the error was handled. Exported fields are copied deeply, unexported fields are copied as is.
+func DetailCopy(err EX) any {
+	return deepCopy(err.Detail())
+}
+
+// storedDetail returns the detail to store in a new errorex, a deep copy when SetCopyDetails is enabled
+func storedDetail(detail any) any {
+	if !copyDetails.Load() {
+		return detail
+	}
+	return deepCopy(detail)
+}
+
+// deepCopy returns a deep copy of v
+func deepCopy(v any) any {
+	if v == nil {
+		return nil
+	}
+	return copyValue(reflect.ValueOf(v), make(map[uintptr]reflect.Value)).Interface()
+}
+
+// copyValue returns a deep copy of v, copies holds the pointers already copied so shared and cyclic
+// references are preserved
+func copyValue(v reflect.Value, copies map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		if copied, ok := copies[v.Pointer()]; ok && copied.Type() == v.Type() {
+			return copied
+		}
+		copied := reflect.New(v.Type().Elem())
+		copies[v.Pointer()] = copied
+		copied.Elem().Set(copyValue(v.Elem(), copies))
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(copyValue(v.Elem(), copies))
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				copied.Field(i).Set(copyValue(v.Field(i), copies))
+			}
+		}
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(copyValue(v.Index(i), copies))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(copyValue(v.Index(i), copies))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iterator := v.MapRange()
+		for iterator.Next() {
+			copied.SetMapIndex(iterator.Key(), copyValue(iterator.Value(), copies))
+		}
+		return copied
+	}
+	return v
+}
@@ -0,0 +1,97 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type copyNode struct {
+	Name string    `json:"name"`
+	Next *copyNode `json:"next"`
+}
+
+type copyDetail struct {
+	Tags   []string          `json:"tags"`
+	Labels map[string]string `json:"labels"`
+	Node   *copyNode         `json:"node"`
+	Extra  any               `json:"extra"`
+}
+
+func TestDetailCopy(t *testing.T) {
+	RegisterErrorCode("copy.detail", "Copy", copyDetail{})
+	newDetail := func() copyDetail {
+		node := &copyNode{Name: "a"}
+		node.Next = node
+		return copyDetail{
+			Tags:   []string{"a"},
+			Labels: map[string]string{"k": "v"},
+			Node:   node,
+			Extra:  []any{map[string]any{"n": 1}},
+		}
+	}
+
+	t.Run("should return an isolated copy", func(t *testing.T) {
+		detail := newDetail()
+		err := New("copy.detail", detail)
+
+		copied := DetailCopy(err).(copyDetail)
+		assert.Equal(t, detail, copied)
+
+		copied.Tags[0] = "b"
+		copied.Labels["k"] = "w"
+		copied.Node.Name = "b"
+		copied.Extra.([]any)[0].(map[string]any)["n"] = 2
+		assert.Equal(t, newDetail().Tags, detail.Tags)
+		assert.Equal(t, "v", detail.Labels["k"])
+		assert.Equal(t, "a", detail.Node.Name)
+		assert.Equal(t, 1, detail.Extra.([]any)[0].(map[string]any)["n"])
+		assert.Same(t, copied.Node, copied.Node.Next)
+	})
+
+	t.Run("should copy on construction when enabled", func(t *testing.T) {
+		SetCopyDetails(true)
+		defer SetCopyDetails(false)
+		detail := newDetail()
+
+		err := New("copy.detail", detail)
+		pooled := NewPooled("copy.detail", detail)
+		defer Release(pooled)
+		detail.Tags[0] = "changed"
+
+		assert.Equal(t, "a", err.Detail().(copyDetail).Tags[0])
+		assert.Equal(t, "a", pooled.Detail().(copyDetail).Tags[0])
+	})
+
+	t.Run("should share the detail by default", func(t *testing.T) {
+		detail := newDetail()
+
+		err := New("copy.detail", detail)
+		detail.Tags[0] = "changed"
+
+		assert.Equal(t, "changed", err.Detail().(copyDetail).Tags[0])
+	})
+}
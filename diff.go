@@ -0,0 +1,85 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import "reflect"
+
+// FieldChange is a difference between two errorex errors found by Diff
+type FieldChange struct {
+	// Path is "code" for the code, or the dot separated JSON names of the detail field, e.g. "detail.limits.max".
+	// It is "detail" when the details are not structs or have different types.
+	Path   string
+	Before any
+	After  any
+}
+
+// Diff compares the codes and the detail fields of two errorex errors, e.g. to tell if a recurring error changed
+// since its last occurrence. Fields of nested structs are compared one by one, other values (slices, maps, ...)
+// as a whole. Instance metadata (ID, timestamp, stack trace) is not compared.
+// It returns nil when nothing changed.
+func Diff(a, b EX) []FieldChange {
+	var changes []FieldChange
+	if a.Code() != b.Code() {
+		changes = append(changes, FieldChange{Path: "code", Before: a.Code(), After: b.Code()})
+	}
+	return diffValues(changes, "detail", reflect.ValueOf(a.Detail()), reflect.ValueOf(b.Detail()))
+}
+
+// diffValues appends the changes between two values to changes
+func diffValues(changes []FieldChange, path string, before, after reflect.Value) []FieldChange {
+	before, after = indirect(before), indirect(after)
+	if before.IsValid() && after.IsValid() && before.Type() == after.Type() && before.Kind() == reflect.Struct {
+		for _, field := range DetailFields(before.Type()) {
+			// the fields behind nil embedded pointers compare as nil
+			beforeField, _ := before.FieldByIndexErr(field.Field.Index)
+			afterField, _ := after.FieldByIndexErr(field.Field.Index)
+			changes = diffValues(changes, path+"."+field.Name, beforeField, afterField)
+		}
+		return changes
+	}
+	beforeValue, afterValue := valueOf(before), valueOf(after)
+	if !reflect.DeepEqual(beforeValue, afterValue) {
+		changes = append(changes, FieldChange{Path: path, Before: beforeValue, After: afterValue})
+	}
+	return changes
+}
+
+// indirect dereferences pointers and interfaces, returning the zero Value for nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// valueOf returns the value held by v, nil for the zero Value
+func valueOf(v reflect.Value) any {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
@@ -0,0 +1,93 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type diffLimits struct {
+	Max int `json:"max"`
+}
+
+type diffDetail struct {
+	Resource string     `json:"resource"`
+	Limits   diffLimits `json:"limits"`
+	Tags     []string   `json:"tags"`
+	Owner    *string    `json:"owner"`
+}
+
+// DiffOrigin is exported so encoding/json promotes the fields of the embedded pointer
+type DiffOrigin struct {
+	Host string `json:"host"`
+}
+
+type diffEmbeddedDetail struct {
+	*DiffOrigin
+	Resource string `json:"resource"`
+}
+
+func TestDiff(t *testing.T) {
+	RegisterErrorCode("diff.quota", "Quota", diffDetail{})
+	RegisterErrorCode("diff.other", "Other", ErrorEXDetail{})
+	RegisterErrorCode("diff.embedded", "Embedded", diffEmbeddedDetail{})
+	owner := "team"
+
+	t.Run("should return nil when nothing changed", func(t *testing.T) {
+		a := New("diff.quota", diffDetail{Resource: "disk", Tags: []string{"a"}, Owner: &owner})
+		b := New("diff.quota", diffDetail{Resource: "disk", Tags: []string{"a"}, Owner: &owner})
+
+		assert.Nil(t, Diff(a, b))
+	})
+
+	t.Run("should list the changed fields by path", func(t *testing.T) {
+		a := New("diff.quota", diffDetail{Resource: "disk", Limits: diffLimits{Max: 10}, Tags: []string{"a"}})
+		b := New("diff.quota", diffDetail{Resource: "disk", Limits: diffLimits{Max: 20}, Tags: []string{"a", "b"}, Owner: &owner})
+
+		assert.Equal(t, []FieldChange{
+			{Path: "detail.limits.max", Before: 10, After: 20},
+			{Path: "detail.tags", Before: []string{"a"}, After: []string{"a", "b"}},
+			{Path: "detail.owner", Before: nil, After: "team"},
+		}, Diff(a, b))
+	})
+
+	t.Run("should compare details of other types as a whole", func(t *testing.T) {
+		a := New("diff.quota", diffDetail{Resource: "disk"})
+		b := New("diff.other", ErrorEXDetail{Code: "x"})
+
+		assert.Equal(t, []FieldChange{
+			{Path: "code", Before: "diff.quota", After: "diff.other"},
+			{Path: "detail", Before: diffDetail{Resource: "disk"}, After: ErrorEXDetail{Code: "x"}},
+		}, Diff(a, b))
+	})
+
+	t.Run("should compare the fields of nil embedded pointers as nil", func(t *testing.T) {
+		a := New("diff.embedded", diffEmbeddedDetail{Resource: "disk"})
+		b := New("diff.embedded", diffEmbeddedDetail{DiffOrigin: &DiffOrigin{Host: "db"}, Resource: "disk"})
+
+		assert.Equal(t, []FieldChange{{Path: "detail.host", Before: nil, After: "db"}}, Diff(a, b))
+	})
+}
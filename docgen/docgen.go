@@ -0,0 +1,350 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package docgen renders the errorex registry into an error reference for API consumers, as Markdown or as a
+// single page static HTML site. The reference is generated from code, so it is always current.
+//
+// A service usually adds a tiny command that imports its error domains and calls Main:
+//
+//	package main
+//
+//	import (
+//		"github.com/fkmatsuda/errorex/docgen"
+//
+//		_ "example.com/service/billing"
+//	)
+//
+//	func main() {
+//		docgen.Main()
+//	}
+//
+// and runs it with go run ./tools/errdocs -format html -out docs/errors.html
+package docgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// Options configures the rendered reference
+type Options struct {
+	// Title is the title of the document, "Error reference" when empty
+	Title string
+}
+
+// entry is the view model of a code
+type entry struct {
+	Code        string
+	Description string
+	HTTPStatus  string
+	GRPCCode    string
+	Owner       string
+	Fields      []field
+	Examples    []string
+}
+
+// field is the view model of a detail field
+type field struct {
+	Name     string
+	Type     string
+	GoType   string
+	Optional bool
+}
+
+// grpcCodeNames are the names of the canonical gRPC status codes
+var grpcCodeNames = []string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded", "NotFound", "AlreadyExists",
+	"PermissionDenied", "ResourceExhausted", "FailedPrecondition", "Aborted", "OutOfRange", "Unimplemented",
+	"Internal", "Unavailable", "DataLoss", "Unauthenticated",
+}
+
+var funcs = template.FuncMap{
+	"anchor": anchor,
+}
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(funcs).Parse(`# {{.Title}}
+
+| Code | Description | HTTP | gRPC |
+| ---- | ----------- | ---- | ---- |
+{{- range .Entries}}
+| [{{.Code}}](#{{anchor .Code}}) | {{.Description}} | {{.HTTPStatus}} | {{.GRPCCode}} |
+{{- end}}
+{{range .Entries}}
+## {{.Code}}
+
+{{.Description}}
+{{if or .HTTPStatus .GRPCCode .Owner}}
+{{if .HTTPStatus}}- HTTP status: {{.HTTPStatus}}
+{{end}}{{if .GRPCCode}}- gRPC code: {{.GRPCCode}}
+{{end}}{{if .Owner}}- Owner: {{.Owner}}
+{{end}}{{end}}
+{{- if .Fields}}
+| Field | Type | Go type | Required |
+| ----- | ---- | ------- | -------- |
+{{- range .Fields}}
+| ` + "`{{.Name}}`" + ` | {{.Type}} | ` + "`{{.GoType}}`" + ` | {{if .Optional}}no{{else}}yes{{end}} |
+{{- end}}
+{{end}}
+{{- range .Examples}}
+` + "```json" + `
+{{.}}
+` + "```" + `
+{{end}}{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(htmltemplate.FuncMap(funcs)).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 960px; margin: 2em auto; padding: 0 1em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin: 1em 0; }
+th, td { border: 1px solid #ddd; padding: .4em .6em; text-align: left; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+section { border-top: 1px solid #eee; margin-top: 2em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<tr><th>Code</th><th>Description</th><th>HTTP</th><th>gRPC</th></tr>
+{{- range .Entries}}
+<tr><td><a href="#{{anchor .Code}}">{{.Code}}</a></td><td>{{.Description}}</td><td>{{.HTTPStatus}}</td><td>{{.GRPCCode}}</td></tr>
+{{- end}}
+</table>
+{{- range .Entries}}
+<section id="{{anchor .Code}}">
+<h2>{{.Code}}</h2>
+<p>{{.Description}}</p>
+{{- if .HTTPStatus}}
+<p>HTTP status: {{.HTTPStatus}}</p>
+{{- end}}
+{{- if .GRPCCode}}
+<p>gRPC code: {{.GRPCCode}}</p>
+{{- end}}
+{{- if .Owner}}
+<p>Owner: {{.Owner}}</p>
+{{- end}}
+{{- if .Fields}}
+<table>
+<tr><th>Field</th><th>Type</th><th>Go type</th><th>Required</th></tr>
+{{- range .Fields}}
+<tr><td><code>{{.Name}}</code></td><td>{{.Type}}</td><td><code>{{.GoType}}</code></td><td>{{if .Optional}}no{{else}}yes{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range .Examples}}
+<pre>{{.}}</pre>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// Markdown renders the catalog as a Markdown document
+func Markdown(w io.Writer, catalog []errorex.CodeInfo, options Options) error {
+	return markdownTemplate.Execute(w, document(catalog, options))
+}
+
+// HTML renders the catalog as a single page HTML site
+func HTML(w io.Writer, catalog []errorex.CodeInfo, options Options) error {
+	return htmlTemplate.Execute(w, document(catalog, options))
+}
+
+// Main renders the registry of the running program according to the command line flags:
+//
+//	-format markdown|html  output format (markdown)
+//	-out file              output file (stdout)
+//	-title title           document title
+//	-prefix prefix         only render codes starting with prefix
+func Main() {
+	format := flag.String("format", "markdown", "output format: markdown or html")
+	output := flag.String("out", "", "output file, stdout when empty")
+	title := flag.String("title", "", "document title")
+	prefix := flag.String("prefix", "", "only render codes starting with prefix")
+	flag.Parse()
+	if err := run(*format, *output, *prefix, Options{Title: *title}); err != nil {
+		fmt.Fprintln(os.Stderr, "docgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(format, output, prefix string, options Options) error {
+	render := Markdown
+	switch format {
+	case "markdown", "md":
+	case "html":
+		render = HTML
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+	var catalog []errorex.CodeInfo
+	for _, info := range errorex.Catalog() {
+		if strings.HasPrefix(info.Code, prefix) {
+			catalog = append(catalog, info)
+		}
+	}
+	if output == "" {
+		return render(os.Stdout, catalog, options)
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := render(file, catalog, options); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// document builds the view model of the catalog
+func document(catalog []errorex.CodeInfo, options Options) any {
+	if options.Title == "" {
+		options.Title = "Error reference"
+	}
+	entries := make([]entry, 0, len(catalog))
+	for _, info := range catalog {
+		entries = append(entries, newEntry(info))
+	}
+	return struct {
+		Title   string
+		Entries []entry
+	}{Title: options.Title, Entries: entries}
+}
+
+func newEntry(info errorex.CodeInfo) entry {
+	e := entry{
+		Code:        info.Code,
+		Description: info.Description,
+		Examples:    examples(info),
+	}
+	if info.HTTPStatus != 0 {
+		e.HTTPStatus = strconv.Itoa(info.HTTPStatus) + " " + http.StatusText(info.HTTPStatus)
+	}
+	if info.GRPCCode != 0 {
+		e.GRPCCode = strconv.Itoa(int(info.GRPCCode))
+		if int(info.GRPCCode) < len(grpcCodeNames) {
+			e.GRPCCode += " " + grpcCodeNames[info.GRPCCode]
+		}
+	}
+	if owner, ok := errorex.CodeOwner(info.Code); ok {
+		e.Owner = owner.Team
+		if owner.Contact != "" {
+			e.Owner += " (" + owner.Contact + ")"
+		}
+	}
+	for _, detailField := range errorex.DetailFields(info.DetailType) {
+		e.Fields = append(e.Fields, field{
+			Name:     detailField.Name,
+			Type:     JSONType(detailField.Field.Type),
+			GoType:   detailField.Field.Type.String(),
+			Optional: detailField.OmitEmpty || detailField.Field.Type.Kind() == reflect.Pointer,
+		})
+	}
+	return e
+}
+
+// examples renders the example payloads registered for the code with errorex.WithExamples, indented, or an example
+// with a zero valued detail when there are none
+func examples(info errorex.CodeInfo) []string {
+	if len(info.Examples) == 0 {
+		return []string{example(info)}
+	}
+	rendered := make([]string, 0, len(info.Examples))
+	for _, registered := range info.Examples {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, []byte(registered.Payload), "", "  "); err != nil {
+			rendered = append(rendered, registered.Payload)
+			continue
+		}
+		rendered = append(rendered, indented.String())
+	}
+	return rendered
+}
+
+// example renders an example payload of the code with a zero valued detail
+func example(info errorex.CodeInfo) string {
+	payload := struct {
+		Code   string `json:"code"`
+		Detail any    `json:"detail"`
+	}{Code: info.Code}
+	if info.DetailType != nil {
+		payload.Detail = reflect.Zero(info.DetailType).Interface()
+	}
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("failed to marshal example: %v", err)
+	}
+	return string(data)
+}
+
+// JSONType returns the JSON type produced by encoding/json for a Go type
+func JSONType(t reflect.Type) string {
+	if t.Implements(reflect.TypeOf((*json.Marshaler)(nil)).Elem()) {
+		if t.String() == "time.Time" {
+			return "string (date-time)"
+		}
+		return "any"
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return JSONType(t.Elem())
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string (base64)"
+		}
+		return "array of " + JSONType(t.Elem())
+	case reflect.Map:
+		return "object of " + JSONType(t.Elem())
+	case reflect.Struct:
+		return "object"
+	}
+	return "any"
+}
+
+// anchor returns the HTML anchor of a code
+func anchor(code string) string {
+	return strings.NewReplacer(".", "-", "_", "-", " ", "-").Replace(strings.ToLower(code))
+}
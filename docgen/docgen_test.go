@@ -0,0 +1,100 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package docgen
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type declinedDetail struct {
+	Reason string    `json:"reason"`
+	Amount float64   `json:"amount,omitempty"`
+	Tags   []string  `json:"tags"`
+	At     time.Time `json:"at"`
+}
+
+func init() {
+	errorex.RegisterErrorCode("docgen.declined", "Payment <declined>", declinedDetail{},
+		errorex.WithHTTPStatus(http.StatusPaymentRequired), errorex.WithGRPCCode(9),
+		errorex.WithExamples(declinedDetail{Reason: "insufficient_funds"}), errorex.WithOwner("billing", "#billing-oncall"))
+	errorex.RegisterErrorCode("docgen.plain", "Plain error", "")
+}
+
+func catalog() []errorex.CodeInfo {
+	declined, _ := errorex.Lookup("docgen.declined")
+	plain, _ := errorex.Lookup("docgen.plain")
+	return []errorex.CodeInfo{declined, plain}
+}
+
+func TestMarkdown(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, Markdown(&buffer, catalog(), Options{Title: "Billing errors"}))
+	markdown := buffer.String()
+
+	assert.Contains(t, markdown, "# Billing errors")
+	assert.Contains(t, markdown, "| [docgen.declined](#docgen-declined) | Payment <declined> | 402 Payment Required | 9 FailedPrecondition |")
+	assert.Contains(t, markdown, "- HTTP status: 402 Payment Required")
+	assert.Contains(t, markdown, "- Owner: billing (#billing-oncall)")
+	assert.Contains(t, markdown, "| `reason` | string | `string` | yes |")
+	assert.Contains(t, markdown, "| `amount` | number | `float64` | no |")
+	assert.Contains(t, markdown, "| `tags` | array of string | `[]string` | yes |")
+	assert.Contains(t, markdown, "| `at` | string (date-time) | `time.Time` | yes |")
+	assert.Contains(t, markdown, `"code": "docgen.plain",`)
+	assert.Contains(t, markdown, `"reason": "insufficient_funds",`)
+}
+
+func TestHTML(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, HTML(&buffer, catalog(), Options{}))
+	html := buffer.String()
+
+	assert.Contains(t, html, "<title>Error reference</title>")
+	assert.Contains(t, html, `<section id="docgen-declined">`)
+	assert.Contains(t, html, "Payment &lt;declined&gt;")
+	assert.Contains(t, html, "<td><code>reason</code></td>")
+	assert.Contains(t, html, "<p>Owner: billing (#billing-oncall)</p>")
+}
+
+func TestRun(t *testing.T) {
+	t.Run("should write the filtered registry", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "errors.md")
+		assert.NoError(t, run("markdown", output, "docgen.", Options{}))
+
+		content, _ := os.ReadFile(output)
+		assert.Contains(t, string(content), "docgen.declined")
+		assert.NotContains(t, string(content), errorex.ErrCodeUnknownError)
+	})
+
+	t.Run("should reject unknown formats", func(t *testing.T) {
+		assert.Error(t, run("pdf", "", "", Options{}))
+	})
+}
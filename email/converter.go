@@ -0,0 +1,64 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package email
+
+import (
+	"errors"
+	"net/textproto"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/wneessen/go-mail"
+)
+
+// smtpErrorConverter converts the SMTP errors of net/smtp and go-mail
+type smtpErrorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewSMTPErrorConverter creates a converter of the SMTP failure replies, the *textproto.Error returned by net/smtp
+// and the *mail.SendError returned by go-mail, into the codes of this package (see Classify)
+func NewSMTPErrorConverter() errorex.ErrorConverter {
+	return &smtpErrorConverter{}
+}
+
+// ConvertError converts the SMTP errors, delegating the others to the next handler in the chain
+func (c *smtpErrorConverter) ConvertError(err error) errorex.EX {
+	var detail DeliveryDetail
+	var sendErr *mail.SendError
+	var protoErr *textproto.Error
+	switch {
+	case errors.As(err, &sendErr) && sendErr.ErrorCode() != 0:
+		detail = DeliveryDetail{Code: sendErr.ErrorCode(), EnhancedCode: sendErr.EnhancedStatusCode(), Message: sendErr.Error()}
+	case errors.As(err, &protoErr):
+		detail.Code = protoErr.Code
+		detail.EnhancedCode, detail.Message = splitEnhanced(protoErr.Msg)
+	default:
+		return c.BaseErrorConverter.ConvertError(err)
+	}
+	code := Classify(detail.Code, detail.EnhancedCode)
+	if code == "" {
+		return c.BaseErrorConverter.ConvertError(err)
+	}
+	return errorex.New(code, detail)
+}
@@ -0,0 +1,110 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package email
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+	"github.com/wneessen/go-mail"
+)
+
+// serve starts an SMTP server answering RCPT TO with reply, returning its address
+func serve(t *testing.T, reply string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				conn.Write([]byte("220 localhost ready\r\n"))
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					switch command := strings.ToUpper(line); {
+					case strings.HasPrefix(command, "EHLO"):
+						conn.Write([]byte("250-localhost\r\n250 ENHANCEDSTATUSCODES\r\n"))
+					case strings.HasPrefix(command, "RCPT"):
+						conn.Write([]byte(reply + "\r\n"))
+					case strings.HasPrefix(command, "QUIT"):
+						conn.Write([]byte("221 bye\r\n"))
+						return
+					default:
+						conn.Write([]byte("250 ok\r\n"))
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestSMTPErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewSMTPErrorConverter())
+
+	t.Run("should convert net/smtp errors", func(t *testing.T) {
+		err := smtp.SendMail(serve(t, "550 5.1.1 No such user"), nil, "from@example.com", []string{"to@example.com"}, []byte("hello"))
+		ex := converter.ConvertError(err)
+		assert.Equal(t, ErrCodeInvalidRecipient, ex.Code())
+		assert.Equal(t, DeliveryDetail{Code: 550, EnhancedCode: "5.1.1", Message: "No such user"}, ex.Detail())
+	})
+
+	t.Run("should convert go-mail errors", func(t *testing.T) {
+		host, port, _ := net.SplitHostPort(serve(t, "452 4.2.2 Mailbox full, try later"))
+		portNumber, _ := strconv.Atoi(port)
+		client, err := mail.NewClient(host, mail.WithPort(portNumber), mail.WithTLSPolicy(mail.NoTLS))
+		assert.NoError(t, err)
+		message := mail.NewMsg()
+		assert.NoError(t, message.From("from@example.com"))
+		assert.NoError(t, message.To("to@example.com"))
+		ex := converter.ConvertError(client.DialAndSend(message))
+		assert.Equal(t, ErrCodeTransientFailure, ex.Code())
+		assert.True(t, errorex.IsRetryable(ex))
+		detail := ex.Detail().(DeliveryDetail)
+		assert.Equal(t, 452, detail.Code)
+		assert.Equal(t, "4.2.2", detail.EnhancedCode)
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(errors.New("boom")).Code())
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(&mail.SendError{Reason: mail.ErrConnCheck}).Code())
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(&textproto.Error{Code: 354, Msg: "go ahead"}).Code())
+	})
+}
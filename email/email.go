@@ -0,0 +1,134 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package email provides the errorex codes of failed email deliveries and a converter for the SMTP errors of
+// net/smtp and go-mail, classifying them by their reply code and, when the server sends one, their enhanced
+// status code (RFC 3463):
+//
+//	converter := errorex.BuildErrorConverterChain(email.NewSMTPErrorConverter())
+//	if err := client.DialAndSend(message); err != nil {
+//		return converter.ConvertError(err)
+//	}
+//
+// Temporary failures, the 4xx replies, become ErrCodeTransientFailure, the only retryable code of the package.
+package email
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// ErrCodeInvalidRecipient is the errorex code for recipients that do not exist or cannot receive mail
+	ErrCodeInvalidRecipient = "email.invalid_recipient"
+	// ErrCodeMailboxFull is the errorex code for recipients whose mailbox is permanently over its quota
+	ErrCodeMailboxFull = "email.mailbox_full"
+	// ErrCodeBlocked is the errorex code for messages refused by the security or the policy of the server
+	ErrCodeBlocked = "email.blocked"
+	// ErrCodeRejected is the errorex code for the other permanent failures
+	ErrCodeRejected = "email.rejected"
+	// ErrCodeTransientFailure is the errorex code for temporary failures, worth retrying later
+	ErrCodeTransientFailure = "email.transient_failure"
+)
+
+var enhancedStatus = regexp.MustCompile(`^([245])\.(\d{1,3})\.(\d{1,3})(?:\s|$)`)
+
+// DeliveryDetail is the detail of the codes of this package
+type DeliveryDetail struct {
+	// Code is the SMTP reply code, e.g. 550
+	Code int `json:"code"`
+	// EnhancedCode is the enhanced status code, e.g. "5.1.1", empty when the server did not send one
+	EnhancedCode string `json:"enhanced_code,omitempty"`
+	// Message is the text of the reply
+	Message string `json:"message,omitempty"`
+}
+
+func init() {
+	// Register the errorex codes
+	errorex.RegisterErrorCode(ErrCodeInvalidRecipient, "Invalid email recipient", DeliveryDetail{}, errorex.WithHTTPStatus(http.StatusUnprocessableEntity))
+	errorex.RegisterErrorCode(ErrCodeMailboxFull, "Recipient mailbox full", DeliveryDetail{}, errorex.WithHTTPStatus(http.StatusUnprocessableEntity))
+	errorex.RegisterErrorCode(ErrCodeBlocked, "Email blocked", DeliveryDetail{}, errorex.WithHTTPStatus(http.StatusBadGateway))
+	errorex.RegisterErrorCode(ErrCodeRejected, "Email rejected", DeliveryDetail{}, errorex.WithHTTPStatus(http.StatusBadGateway))
+	errorex.RegisterErrorCode(ErrCodeTransientFailure, "Email delivery temporarily failed", DeliveryDetail{},
+		errorex.WithHTTPStatus(http.StatusServiceUnavailable), errorex.WithRetryable())
+}
+
+// Classify returns the errorex code of an SMTP reply code and enhanced status code, empty when the reply is not a
+// failure
+func Classify(code int, enhanced string) string {
+	class, subject, detail := parseEnhanced(enhanced)
+	if class == 0 {
+		class = code / 100
+	}
+	if class == 4 {
+		return ErrCodeTransientFailure
+	}
+	if class != 5 {
+		return ""
+	}
+	switch {
+	case subject == 1 && detail != 7 && detail != 8:
+		// X.1.7 and X.1.8 are about the sender address
+		return ErrCodeInvalidRecipient
+	case subject == 2 && detail == 1:
+		return ErrCodeInvalidRecipient
+	case subject == 2 && detail == 2:
+		return ErrCodeMailboxFull
+	case subject == 7:
+		return ErrCodeBlocked
+	case subject != 0:
+		return ErrCodeRejected
+	}
+	switch code {
+	case 550, 551, 553:
+		return ErrCodeInvalidRecipient
+	case 552:
+		return ErrCodeMailboxFull
+	}
+	return ErrCodeRejected
+}
+
+// splitEnhanced separates the enhanced status code at the start of the text of a reply
+func splitEnhanced(message string) (string, string) {
+	match := enhancedStatus.FindStringIndex(message)
+	if match == nil {
+		return "", message
+	}
+	return strings.TrimSpace(message[:match[1]]), strings.TrimSpace(message[match[1]:])
+}
+
+// parseEnhanced returns the class, subject and detail of an enhanced status code, zeros when it is not valid
+func parseEnhanced(enhanced string) (int, int, int) {
+	match := enhancedStatus.FindStringSubmatch(enhanced)
+	if match == nil {
+		return 0, 0, 0
+	}
+	class, _ := strconv.Atoi(match[1])
+	subject, _ := strconv.Atoi(match[2])
+	detail, _ := strconv.Atoi(match[3])
+	return class, subject, detail
+}
@@ -0,0 +1,64 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package email
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	t.Run("should classify enhanced status codes", func(t *testing.T) {
+		assert.Equal(t, ErrCodeInvalidRecipient, Classify(550, "5.1.1"))
+		assert.Equal(t, ErrCodeInvalidRecipient, Classify(550, "5.2.1"))
+		assert.Equal(t, ErrCodeMailboxFull, Classify(552, "5.2.2"))
+		assert.Equal(t, ErrCodeBlocked, Classify(550, "5.7.1"))
+		assert.Equal(t, ErrCodeRejected, Classify(553, "5.1.8"))
+		assert.Equal(t, ErrCodeRejected, Classify(552, "5.3.4"))
+		assert.Equal(t, ErrCodeTransientFailure, Classify(452, "4.2.2"))
+	})
+
+	t.Run("should classify reply codes", func(t *testing.T) {
+		assert.Equal(t, ErrCodeInvalidRecipient, Classify(550, ""))
+		assert.Equal(t, ErrCodeMailboxFull, Classify(552, ""))
+		assert.Equal(t, ErrCodeRejected, Classify(554, ""))
+		assert.Equal(t, ErrCodeTransientFailure, Classify(421, ""))
+		assert.Empty(t, Classify(250, ""))
+	})
+}
+
+func TestSplitEnhanced(t *testing.T) {
+	t.Run("should split the enhanced status code", func(t *testing.T) {
+		enhanced, message := splitEnhanced("5.7.1 Relaying denied")
+		assert.Equal(t, "5.7.1", enhanced)
+		assert.Equal(t, "Relaying denied", message)
+	})
+
+	t.Run("should keep messages without enhanced status code", func(t *testing.T) {
+		enhanced, message := splitEnhanced("Relaying denied")
+		assert.Empty(t, enhanced)
+		assert.Equal(t, "Relaying denied", message)
+	})
+}
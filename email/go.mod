@@ -0,0 +1,19 @@
+module github.com/fkmatsuda/errorex/email
+
+go 1.22.3
+
+require (
+	github.com/fkmatsuda/errorex v0.0.0
+	github.com/stretchr/testify v1.10.0
+	github.com/wneessen/go-mail v0.6.2
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.33.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/fkmatsuda/errorex => ../
@@ -0,0 +1,175 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxPooledBufferSize is the capacity above which encode buffers are not returned to the pool
+	maxPooledBufferSize = 64 << 10
+)
+
+var encodeBufferPool = sync.Pool{
+	New: func() any {
+		b := &encodeBuffer{}
+		b.encoder = json.NewEncoder(&b.buffer)
+		return b
+	},
+}
+
+// encodeBuffer is a reusable buffer with a json.Encoder writing into it
+type encodeBuffer struct {
+	buffer  bytes.Buffer
+	encoder *json.Encoder
+}
+
+// getEncodeBuffer takes an empty encode buffer from the pool
+func getEncodeBuffer() *encodeBuffer {
+	b := encodeBufferPool.Get().(*encodeBuffer)
+	b.buffer.Reset()
+	return b
+}
+
+// putEncodeBuffer returns an encode buffer to the pool, unless it grew too large
+func putEncodeBuffer(b *encodeBuffer) {
+	if b.buffer.Cap() > maxPooledBufferSize {
+		return
+	}
+	encodeBufferPool.Put(b)
+}
+
+// AppendError appends the serialized form of err (the same returned by Error) to dst and returns the extended buffer.
+// No allocation is made besides growing dst, so log pipelines can serialize errors into caller-owned buffers.
+func AppendError(dst []byte, err EX) []byte {
+	e, ok := err.(*ex)
+	if !ok {
+		return append(dst, err.Error()...)
+	}
+	buffer := getEncodeBuffer()
+	buffer.writeError(e)
+	dst = append(dst, buffer.buffer.Bytes()...)
+	putEncodeBuffer(buffer)
+	return dst
+}
+
+// writeError writes the serialized errorex into the buffer
+func (b *encodeBuffer) writeError(e *ex) {
+	b.writeChain(e, 0, nil)
+}
+
+// writeChain writes the serialized errorex and its causes into the buffer, depth is the number of causes written
+// before it and seen holds the errors already written, nil when there are none
+func (b *encodeBuffer) writeChain(e *ex, depth int, seen map[error]bool) {
+	mark := b.buffer.Len()
+	b.buffer.WriteString(`{"code": "`)
+	b.buffer.WriteString(e.code)
+	b.buffer.WriteString(`", "detail": `)
+	if err := b.writeDetail(e.detail); err != nil {
+		b.buffer.Truncate(mark)
+		fmt.Fprintf(&b.buffer, `{"code": "%s", "detail": "failed to marshal detail: %v"}`, e.code, err)
+		return
+	}
+	if e.id != "" {
+		b.buffer.WriteString(`, "id": `)
+		_ = b.encode(e.id)
+	}
+	if !e.timestamp.IsZero() {
+		var scratch [64]byte
+		b.buffer.WriteString(`, "time": "`)
+		b.buffer.Write(e.timestamp.AppendFormat(scratch[:0], time.RFC3339Nano))
+		b.buffer.WriteByte('"')
+	}
+	if len(e.metadata) > 0 {
+		b.buffer.WriteString(`, "metadata": `)
+		_ = b.encode(e.metadata)
+	}
+	if e.retryAfter > 0 {
+		var scratch [20]byte
+		b.buffer.WriteString(`, "retry_after_ms": `)
+		b.buffer.Write(strconv.AppendInt(scratch[:0], e.retryAfter.Milliseconds(), 10))
+	}
+	if e.cause != nil {
+		b.writeCause(e, depth, seen)
+	}
+	if e.stack != nil {
+		b.buffer.WriteString(`, "stack": `)
+		_ = b.encode(e.stack.Frames())
+	}
+	b.buffer.WriteByte('}')
+}
+
+// writeCause writes the cause of the errorex, other errors are written as their CauseInfo, or as their message
+// when the CauseInfo cannot be encoded. Causes past
+// GetMaxCauseDepth are summarized as a CauseTail, and causes leading back to an error already written as
+// {"cycle": true}.
+func (b *encodeBuffer) writeCause(e *ex, depth int, seen map[error]bool) {
+	if seen == nil {
+		seen = map[error]bool{}
+	}
+	seen[e] = true
+	b.buffer.WriteString(`, "cause": `)
+	switch cause, ok := e.cause.(*ex); {
+	case depth >= GetMaxCauseDepth():
+		_ = b.encode(tailOf(e.cause, seen))
+	case reaches(e.cause, seen):
+		b.buffer.WriteString(`{"cycle": true}`)
+	case ok:
+		b.writeChain(cause, depth+1, seen)
+	default:
+		info := DescribeCause(e.cause)
+		if err := b.encode(info); err != nil {
+			// Fields the codec cannot encode, the message is still written
+			if err := b.encode(info.Message); err != nil {
+				b.buffer.WriteString("null")
+			}
+		}
+	}
+}
+
+// encode appends the JSON encoding of v to the buffer, using the configured JSONCodec
+func (b *encodeBuffer) encode(v any) error {
+	if codec := customJSONCodec(); codec != nil {
+		data, err := codec.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b.buffer.Write(data)
+		return nil
+	}
+	mark := b.buffer.Len()
+	if err := b.encoder.Encode(v); err != nil {
+		b.buffer.Truncate(mark)
+		return err
+	}
+	// json.Encoder terminates each value with a newline
+	b.buffer.Truncate(b.buffer.Len() - 1)
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// foreignEX is an EX implementation that is not created by this package
+type foreignEX struct{}
+
+func (foreignEX) Error() string { return "foreign error" }
+func (foreignEX) Code() string  { return "foreign.code" }
+func (foreignEX) Detail() any   { return nil }
+
+func TestAppendError(t *testing.T) {
+	RegisterErrorCode("test.append", "test description", struct{ Message string }{})
+
+	t.Run("should append the same content returned by Error", func(t *testing.T) {
+		ex := New("test.append", struct{ Message string }{Message: "test detail"})
+		dst := []byte("error=")
+
+		assert.Equal(t, "error="+ex.Error(), string(AppendError(dst, ex)))
+	})
+
+	t.Run("should append errors not created by this package", func(t *testing.T) {
+		assert.Equal(t, "foreign error", string(AppendError(nil, foreignEX{})))
+	})
+
+	t.Run("should not allocate when the destination has enough capacity", func(t *testing.T) {
+		if raceEnabled {
+			t.Skip("sync.Pool drops items under the race detector")
+		}
+		ex := New("test.append", struct{ Message string }{Message: "test detail"})
+		dst := make([]byte, 0, 256)
+		AppendError(dst, ex)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			dst = AppendError(dst[:0], ex)
+		})
+		assert.LessOrEqual(t, allocs, 1.0)
+	})
+
+	t.Run("should serialize the marshal failure", func(t *testing.T) {
+		RegisterErrorCode("test.append.channel", "test description", make(chan int))
+		ex := New("test.append.channel", make(chan int))
+
+		assert.JSONEq(t, `{"code": "test.append.channel", "detail": "failed to marshal detail: json: unsupported type: chan int"}`, string(AppendError(nil, ex)))
+		assert.False(t, errors.Is(ex, foreignEX{}))
+	})
+}
+
+func BenchmarkAppendError(b *testing.B) {
+	RegisterErrorCode("bench.append", "bench description", struct{ Message string }{})
+	ex := New("bench.append", struct{ Message string }{Message: "bench detail"})
+
+	b.Run("Error", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = ex.Error()
+		}
+	})
+
+	b.Run("AppendError", func(b *testing.B) {
+		b.ReportAllocs()
+		dst := make([]byte, 0, 256)
+		for i := 0; i < b.N; i++ {
+			dst = AppendError(dst[:0], ex)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+)
+
+// Equal tells if two errors are the same failure: the first errorex in their chains have the same code (aliases
+// resolved) and deeply equal details, or details normalized the same way as by CacheKey, so the fields marked with
+// WithVolatileFields are ignored. Details that cannot be encoded, e.g. with channel fields, cycles or NaN floats,
+// are only equal when deeply equal. Instance metadata (ID, timestamp, stack trace, metadata, retry hint) and causes
+// are ignored. Errors without an errorex in their chain are equal when their messages are.
+func Equal(a, b error) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	exA, okA := firstEX(a)
+	exB, okB := firstEX(b)
+	if !okA || !okB {
+		return !okA && !okB && a.Error() == b.Error()
+	}
+	code := canonicalCode(exA.Code())
+	if code != canonicalCode(exB.Code()) {
+		return false
+	}
+	if reflect.DeepEqual(exA.Detail(), exB.Detail()) {
+		return true
+	}
+	volatile := volatileFieldsOf(code)
+	normalizedA, okA := normalizedDetail(exA.Detail(), volatile)
+	normalizedB, okB := normalizedDetail(exB.Detail(), volatile)
+	return okA && okB && bytes.Equal(normalizedA, normalizedB)
+}
+
+// Hash returns a hash of err consistent with Equal: equal errors have the same hash, so it can key sets and
+// deduplicate batches of failures. The detail is hashed through its normalized JSON encoding (see CacheKey), or
+// the name of its type and the description of the code when it cannot be encoded, nil hashes to zero.
+func Hash(err error) uint64 {
+	if err == nil {
+		return 0
+	}
+	hash := fnv.New64a()
+	target, ok := firstEX(err)
+	if !ok {
+		_, _ = hash.Write([]byte(err.Error()))
+		return hash.Sum64()
+	}
+	code := canonicalCode(target.Code())
+	_, _ = hash.Write([]byte(code))
+	_, _ = hash.Write([]byte{0})
+	normalized, ok := normalizedDetail(target.Detail(), volatileFieldsOf(code))
+	if !ok {
+		info, _ := Lookup(code)
+		normalized = []byte(fmt.Sprintf("%T\x00%s", target.Detail(), info.Description))
+	}
+	_, _ = hash.Write(normalized)
+	return hash.Sum64()
+}
+
+// firstEX returns the first errorex in the chain of err
+func firstEX(err error) (EX, bool) {
+	var target EX
+	ok := errors.As(err, &target)
+	return target, ok
+}
+
+// canonicalCode resolves an alias to its code, other codes are returned as is
+func canonicalCode(code string) string {
+	if codeRegistry, ok := lookupCode(code); ok {
+		return codeRegistry.code
+	}
+	return code
+}
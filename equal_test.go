@@ -0,0 +1,109 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type equalDetail struct {
+	Tags   []string          `json:"tags"`
+	Labels map[string]string `json:"labels"`
+}
+
+type equalVolatileDetail struct {
+	Key     string `json:"key"`
+	Attempt int    `json:"attempt"`
+}
+
+type equalUnencodableDetail struct {
+	Key  string        `json:"key"`
+	Done chan struct{} `json:"done"`
+}
+
+func TestEqual(t *testing.T) {
+	RegisterErrorCode("equal.failed", "Failed", equalDetail{})
+	RegisterErrorCode("equal.other", "Other", equalDetail{})
+	RegisterAlias("equal.renamed", "equal.failed")
+	config := GetInstanceConfig()
+	SetInstanceConfig(InstanceConfig{IDs: true, Timestamps: true})
+	defer SetInstanceConfig(config)
+
+	detail := func() equalDetail {
+		return equalDetail{Tags: []string{"a"}, Labels: map[string]string{"x": "1", "y": "2"}}
+	}
+
+	t.Run("should ignore the instance metadata", func(t *testing.T) {
+		a := New("equal.failed", detail(), WithTenant("acme"))
+		b := fmt.Errorf("wrapped: %w", New("equal.renamed", detail()))
+
+		assert.True(t, Equal(a, b))
+		assert.Equal(t, Hash(a), Hash(b))
+	})
+
+	t.Run("should compare codes and details", func(t *testing.T) {
+		a := New("equal.failed", detail())
+		changed := detail()
+		changed.Tags = append(changed.Tags, "b")
+
+		assert.False(t, Equal(a, New("equal.other", detail())))
+		assert.False(t, Equal(a, New("equal.failed", changed)))
+		assert.NotEqual(t, Hash(a), Hash(New("equal.other", detail())))
+		assert.NotEqual(t, Hash(a), Hash(New("equal.failed", changed)))
+	})
+
+	t.Run("should ignore the volatile fields like CacheKey", func(t *testing.T) {
+		RegisterErrorCode("equal.volatile", "Volatile", equalVolatileDetail{}, WithVolatileFields("attempt"))
+		a := New("equal.volatile", equalVolatileDetail{Key: "user:1", Attempt: 1})
+		b := New("equal.volatile", equalVolatileDetail{Key: "user:1", Attempt: 3})
+
+		assert.Equal(t, CacheKey(a), CacheKey(b))
+		assert.True(t, Equal(a, b))
+		assert.Equal(t, Hash(a), Hash(b))
+		assert.False(t, Equal(a, New("equal.volatile", equalVolatileDetail{Key: "user:2", Attempt: 1})))
+	})
+
+	t.Run("should not equal details that cannot be encoded", func(t *testing.T) {
+		RegisterErrorCode("equal.unencodable", "Unencodable", equalUnencodableDetail{})
+		a := New("equal.unencodable", equalUnencodableDetail{Key: "user:1", Done: make(chan struct{})})
+		b := New("equal.unencodable", equalUnencodableDetail{Key: "user:2", Done: make(chan struct{})})
+
+		assert.True(t, Equal(a, a))
+		assert.False(t, Equal(a, b))
+		assert.Equal(t, Hash(a), Hash(fmt.Errorf("wrapped: %w", a)))
+	})
+
+	t.Run("should compare other errors by message", func(t *testing.T) {
+		assert.True(t, Equal(errors.New("boom"), errors.New("boom")))
+		assert.False(t, Equal(errors.New("boom"), New("equal.failed", detail())))
+		assert.True(t, Equal(nil, nil))
+		assert.False(t, Equal(nil, errors.New("boom")))
+		assert.Equal(t, Hash(errors.New("boom")), Hash(errors.New("boom")))
+		assert.Zero(t, Hash(nil))
+	})
+}
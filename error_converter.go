@@ -23,6 +23,12 @@
 
 package errorex
 
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
 // ErrorConverter defines the interface for handlers in the chain of responsibility
 // that attempt to convert an error into an error compatible with errorex.EX.
 // If the handler cannot convert the error, it delegates the task to the next handler in the chain.
@@ -80,6 +86,9 @@ type exErrorConverter struct {
 
 // ConvertError checks if the error passed as a parameter implements EX.
 // If so, it returns the error. If not, it attempts to delegate the conversion to the next handler.
+// The members of errors joined with errors.Join, or by other multierror implementations with an Unwrap() []error
+// method, are converted one by one, see convertJoined. Errors wrapping several errors with fmt.Errorf annotate a
+// single failure, such as the errors of golang-jwt, and are converted as a whole.
 func (c *exErrorConverter) ConvertError(err error) EX {
 	if err == nil {
 		return nil
@@ -87,10 +96,55 @@ func (c *exErrorConverter) ConvertError(err error) EX {
 	if ex, ok := err.(EX); ok {
 		return ex // Returns the parameter value if it is already an EX.
 	}
+	if isJoined(err) {
+		if ex := c.convertJoined(err.(interface{ Unwrap() []error }).Unwrap()); ex != nil {
+			return ex
+		}
+	}
 	// Delegates to the next handler in the chain if this is not an EX error.
 	return c.BaseErrorConverter.ConvertError(err)
 }
 
+// fmtWrapErrorsType is the type of the errors returned by fmt.Errorf with several %w verbs
+var fmtWrapErrorsType = reflect.TypeOf(fmt.Errorf("%w%w", errors.ErrUnsupported, errors.ErrUnsupported))
+
+// isJoined tells if err joins several failures, with errors.Join or another multierror implementation with an
+// Unwrap() []error method, as opposed to the errors of fmt.Errorf wrapping several errors
+func isJoined(err error) bool {
+	_, ok := err.(interface{ Unwrap() []error })
+	return ok && reflect.TypeOf(err) != fmtWrapErrorsType
+}
+
+// convertJoined converts the members of joined errors through the chain, nested joins becoming nested groups.
+// When more than one member converts to a code other than ErrCodeUnknownError, it returns an EXGroup of the
+// conversions of every member, in order. When only one does, it returns that conversion, and nil when none does, so
+// the joined error is converted as a whole.
+func (c *exErrorConverter) convertJoined(members []error) EX {
+	var (
+		group     EXGroup
+		converted EX
+		count     int
+	)
+	for _, member := range members {
+		ex := c.ConvertError(member)
+		if ex == nil {
+			continue
+		}
+		group = append(group, ex)
+		if ex.Code() != ErrCodeUnknownError {
+			converted = ex
+			count++
+		}
+	}
+	switch count {
+	case 0:
+		return nil
+	case 1:
+		return converted
+	}
+	return group
+}
+
 // NewEXErrorConverter creates a new exErrorConverter
 // this converter will check if the error passed implements EX, and if so, returns the error itself, otherwise it attempts to delegate the conversion to the next handler in the chain.
 // This converter should be used as the first handler in the chain.
@@ -102,15 +156,14 @@ func NewEXErrorConverter(next ErrorConverter) ErrorConverter {
 
 // BuildErrorConverterChain creates a chain of error converters.
 // The chain starts ExErrorConverter, then the provided converters, and ends with UnknownErrorConverter.
+// The handlers are traced, see SetTraceConversions.
 func BuildErrorConverterChain(converters ...ErrorConverter) ErrorConverter {
 	if len(converters) == 0 {
-		return NewEXErrorConverter(NewUnknownErrorConverter())
+		return traced(NewEXErrorConverter(traced(NewUnknownErrorConverter())))
 	}
-	converter := converters[0]
-	for i := 1; i < len(converters); i++ {
-		converters[i].SetNext(converter)
-		converter = converters[i]
+	for i := 0; i < len(converters)-1; i++ {
+		converters[i].SetNext(traced(converters[i+1]))
 	}
-	converter.SetNext(NewUnknownErrorConverter())
-	return NewEXErrorConverter(converters[0])
+	converters[len(converters)-1].SetNext(traced(NewUnknownErrorConverter()))
+	return traced(NewEXErrorConverter(traced(converters[0])))
 }
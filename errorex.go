@@ -24,13 +24,9 @@
 package errorex
 
 import (
-	"encoding/json"
-	"fmt"
 	"reflect"
-)
-
-var (
-	errorCodes = make(map[string]errorCodeRegistry)
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -42,6 +38,25 @@ const (
 	ErrCodeAlreadyRegistered = "errorex.002"
 	// ErrDetailTypeMismatch is the errorex code for when the errorex detail type does not match the registered type
 	ErrDetailTypeMismatch = "errorex.003"
+	// ErrCodeAlreadyReleased is the errorex code for when a pooled errorex is released more than once
+	ErrCodeAlreadyReleased = "errorex.004"
+	// ErrCodeRegistryFrozen is the errorex code for when a code is registered after the registry was frozen
+	ErrCodeRegistryFrozen = "errorex.005"
+	// ErrCodeIncompatibleCatalog is the errorex code for when the catalog is not compatible with its baseline
+	ErrCodeIncompatibleCatalog = "errorex.006"
+	// ErrCodeInvalidName is the errorex code for when a code violates the naming policy set with SetNamingPolicy
+	ErrCodeInvalidName = "errorex.007"
+	// ErrCodeInvalidDetail is the errorex code for when a detail type cannot be serialized
+	ErrCodeInvalidDetail = "errorex.008"
+	// ErrCodeUnresolvedReference is the errorex code for when a parent or alias target is still not registered at
+	// Freeze, or the parents form a cycle
+	ErrCodeUnresolvedReference = "errorex.009"
+	// ErrCodeGroup is the errorex code of EXGroup, the errors aggregating several errorex errors
+	ErrCodeGroup = "errorex.010"
+	// ErrCodeValidation is the errorex code for when the input fails validation, see Validation
+	ErrCodeValidation = "errorex.011"
+	// ErrCodeInternal is the errorex code returned instead of panicking on programmer errors in Lenient mode
+	ErrCodeInternal = "errorex.internal"
 )
 
 // UnknownErrorDetail is the type of the detail of an unknown errorex
@@ -66,9 +81,19 @@ func init() {
 	RegisterErrorCode(ErrCodeNotRegistered, "Errorex code not registered", ErrorEXDetail{})
 	RegisterErrorCode(ErrCodeAlreadyRegistered, "Errorex code already registered", ErrorEXDetail{})
 	RegisterErrorCode(ErrDetailTypeMismatch, "Errorex detail type mismatch", ErrorEXDetailTypeMismatch{})
+	RegisterErrorCode(ErrCodeAlreadyReleased, "Pooled errorex already released", ErrorEXDetail{})
+	RegisterErrorCode(ErrCodeRegistryFrozen, "Errorex registry is frozen", ErrorEXDetail{})
+	RegisterErrorCode(ErrCodeIncompatibleCatalog, "Errorex catalog is not compatible with its baseline", ErrorEXCatalogIncompatibility{})
+	RegisterErrorCode(ErrCodeInvalidName, "Errorex code violates the naming policy", ErrorEXNamingViolation{})
+	RegisterErrorCode(ErrCodeInvalidDetail, "Errorex detail type cannot be serialized", ErrorEXInvalidDetail{})
+	RegisterErrorCode(ErrCodeUnresolvedReference, "Errorex code references are unresolved", ErrorEXUnresolvedReferences{})
+	RegisterErrorCode(ErrCodeGroup, "Errorex group", ErrorEXGroupDetail{})
+	// 422 Unprocessable Entity and gRPC InvalidArgument
+	RegisterErrorCode(ErrCodeValidation, "Validation failed", ValidationDetail{}, WithHTTPStatus(422), WithGRPCCode(3))
+	RegisterErrorCode(ErrCodeInternal, "Errorex misused", ErrorEXInternal{})
 }
 
-// ErrorConstructor is a function that creates an errorEX
+// ErrorConstructor is a function that creates an errorEX, bound to a code with RegisterConstructor
 type ErrorConstructor[T any] func(code string, detail T) EX
 
 // EX is a custom errorex type with additional information
@@ -80,31 +105,83 @@ type EX interface {
 	Detail() any
 }
 
+// CodeMatcher is implemented by errors matching codes other than their own Code, such as EXGroup. Is consults it
+// before the Code method, so IsCode must not call Is or IsSafe on its receiver.
+type CodeMatcher interface {
+	// IsCode checks if the error has the code, aliases matching their code
+	IsCode(code string) bool
+}
+
 type ex struct {
 	code   string
 	detail any
+	// stack is set when stack capture is enabled
+	stack *stack
+	// id and timestamp are set when enabled by SetInstanceConfig
+	id        string
+	timestamp time.Time
+	// retryAfter is set by WithRetryAfter
+	retryAfter time.Duration
+	// metadata is set by WithMetadata, WithTenant and WithUser
+	metadata map[string]string
+	// cause is set by Wrap and WithCause
+	cause error
+	// escalated is the severity set by Escalate, zero when not escalated
+	escalated Severity
+	// pooled is set for instances created by NewPooled
+	pooled *pooledState
 }
 
 type errorCodeRegistry struct {
 	code        string
 	description string
 	detailType  reflect.Type
+	httpStatus  int
+	grpcCode    uint32
+	exitCode    int
+	severity    Severity
+	retryable   bool
+	// faultClass is set by WithFaultClass, derived from the HTTP status when it is not
+	faultClass Fault
+	// tripsCircuit is set by WithCircuitTripping, retryable codes trip circuit breakers when it is not
+	tripsCircuit *bool
+	// deprecatedFields is set by WithDeprecatedFields, sorted
+	deprecatedFields []string
+	// volatileFields is set by WithVolatileFields, sorted
+	volatileFields []string
+	// owner is set by WithOwner
+	owner Owner
+	// problemType is set by WithProblemType
+	problemType string
+	// examples is set by WithExamples
+	examples []any
+	// parent is set by WithParent, it may not be registered until Freeze
+	parent string
+	// alias is set when the registry was registered under an alias of the code
+	alias string
 }
 
 // RegisterErrorCode registers errorex codes to prevent repeats
-func RegisterErrorCode[T any](code string, description string, detail T) {
-	// Prevent repeats
-	if _, ok := errorCodes[code]; ok {
-		// Fatal errorex
-		panic(New(ErrCodeAlreadyRegistered, ErrorEXDetail{Code: code}))
-	}
+// Options attach metadata to the code, such as its HTTP status or gRPC code.
+// It panics if the registry was already frozen by Freeze, or if the code violates a strict naming policy (see
+// SetNamingPolicy).
+func RegisterErrorCode[T any](code string, description string, detail T, options ...RegistrationOption) {
+	checkNaming(code)
 	// Register the errorex code
+	registerCode(newRegistry(code, description, reflect.TypeOf(detail), options))
+}
+
+// newRegistry creates the registry of a code with the options applied
+func newRegistry(code string, description string, detailType reflect.Type, options []RegistrationOption) errorCodeRegistry {
 	registry := errorCodeRegistry{
 		code:        code,
 		description: description,
-		detailType:  reflect.TypeOf(detail),
+		detailType:  detailType,
 	}
-	errorCodes[code] = registry
+	for _, option := range options {
+		option(&registry)
+	}
+	return registry
 }
 
 // Code returns the errorex code
@@ -117,68 +194,139 @@ func (e *ex) Detail() any {
 	return e.detail
 }
 
+// StackTrace returns the frames captured when the errorex was created, or nil if stack capture was disabled
+func (e *ex) StackTrace() []Frame {
+	if e.stack == nil {
+		return nil
+	}
+	return e.stack.Frames()
+}
+
 // Error returns the errorex message
 func (e *ex) Error() string {
-	detailJSON, err := json.Marshal(e.detail)
-	if err != nil {
-		return fmt.Sprintf(`{"code": "%s", "detail": "failed to marshal detail: %v"}`, e.code, err)
+	if e.pooled != nil {
+		e.pooled.buffer.Reset()
+		e.pooled.writeError(e)
+		return e.pooled.buffer.String()
 	}
-	return fmt.Sprintf(`{"code": "%s", "detail": %s}`, e.code, string(detailJSON))
+	buffer := getEncodeBuffer()
+	defer putEncodeBuffer(buffer)
+	buffer.writeError(e)
+	return buffer.buffer.String()
 }
 
 // New returns a new errorex.EX
 // Code is the errorex code.
 // Detail is the errorex detail.
-func New[T any](code string, detail T) EX {
+// Options attach metadata to the instance, such as a retry hint.
+func New[T any](code string, detail T, options ...Option) EX {
+	code, internal := checkDetail(code, detail)
+	if internal != nil {
+		return internal
+	}
+	e := newEX(code, detail, 1)
+	applyOptions(e, options)
+	return e
+}
+
+// newEX creates the errorex, skip is the number of frames between the caller and newEX to leave out of the stack trace
+func newEX(code string, detail any, skip int) *ex {
+	e := &ex{
+		code:   code,
+		detail: storedDetail(detail),
+		stack:  captureStack(code, skip),
+	}
+	stampInstance(e)
+	return e
+}
+
+// checkDetail panics if the code is not registered or if the detail type neither matches the registered type nor
+// implements the interface registered with RegisterInterfaceCode, in Lenient mode it returns the ErrCodeInternal
+// errorex describing the problem instead.
+// It returns the canonical code, which differs from the given code when it is an alias.
+func checkDetail[T any](code string, detail T) (string, EX) {
 	// Check if the code exists
 	var (
 		errorRegistry errorCodeRegistry
 		ok            bool
 	)
-	if errorRegistry, ok = errorCodes[code]; !ok {
+	if errorRegistry, ok = lookupCode(code); !ok {
 		// Fatal errorex
-		panic(New(ErrCodeNotRegistered, ErrorEXDetail{Code: code}))
+		return "", misuse(New(ErrCodeNotRegistered, ErrorEXDetail{Code: code}))
 	}
 	// Check if the detail type matches the registered type
-	if reflect.TypeOf(detail) != errorRegistry.detailType {
+	if !detailMatches(errorRegistry.detailType, reflect.TypeOf(detail)) {
 		// Fatal errorex
-		panic(New(ErrDetailTypeMismatch, ErrorEXDetailTypeMismatch{
-			ExpectedType: errorRegistry.detailType.String(),
-			ActualType:   reflect.TypeOf(detail).String(),
+		return "", misuse(New(ErrDetailTypeMismatch, ErrorEXDetailTypeMismatch{
+			ExpectedType: typeName(errorRegistry.detailType),
+			ActualType:   typeName(reflect.TypeOf(detail)),
 		}))
 	}
-	return &ex{
-		code:   code,
-		detail: detail,
-	}
+	return errorRegistry.code, nil
 }
 
-// Is checks if the errorex is of type EX and if the code matches
+// Is checks if the errorex is of type EX and if the code matches.
+// Unregistered codes never match, unless SetStrictIs makes Is panic for them.
 func Is(err error, code string) bool {
+	matched, registered := IsSafe(err, code)
+	if !registered && strictIs.Load() {
+		// Fatal errorex, ignored in Lenient mode
+		misuse(New(ErrCodeNotRegistered, ErrorEXDetail{Code: code}))
+	}
+	return matched
+}
+
+// IsSafe checks if the errorex is of type EX and if the code matches, without ever panicking: registered tells if
+// the code is registered, e.g. when it is owned by an optional module.
+func IsSafe(err error, code string) (matched bool, registered bool) {
 	// Check if the error code is registered
-	if _, ok := errorCodes[code]; !ok {
-		// Fatal errorex
-		panic(New(ErrCodeNotRegistered, ErrorEXDetail{Code: code}))
+	errorRegistry, ok := lookupCode(code)
+	if !ok {
+		return false, false
 	}
 	// Check if the error is nil
 	if err == nil {
-		return false
+		return false, true
+	}
+	// Other errorex implementations, such as EXGroup, may match codes other than their own
+	if _, own := err.(*ex); !own {
+		if matcher, ok := err.(CodeMatcher); ok && matcher.IsCode(code) {
+			return true, true
+		}
 	}
 	// Check if the error has a method Code
 	errorValue := reflect.ValueOf(err)
 	if errorValue.Kind() != reflect.Ptr {
-		return false
+		return false, true
 	}
 	codeMethod := errorValue.MethodByName("Code")
 	if !codeMethod.IsValid() {
-		return false
+		return false, true
 	}
 	codeValue := codeMethod.Call([]reflect.Value{})
 	if len(codeValue) != 1 {
-		return false
+		return false, true
 	}
 	if codeValue[0].Kind() != reflect.String {
-		return false
+		return false, true
+	}
+	return sameCode(codeValue[0].String(), errorRegistry.code), true
+}
+
+// strictIs is set by SetStrictIs
+var strictIs atomic.Bool
+
+// SetStrictIs makes Is panic with ErrCodeNotRegistered when the queried code is not registered, catching typos in
+// tests and development. It is disabled by default and has no effect in Lenient mode, see SetMode.
+func SetStrictIs(strict bool) {
+	strictIs.Store(strict)
+}
+
+// sameCode checks if the error code resolves to the canonical code, following aliases
+func sameCode(errorCode string, canonical string) bool {
+	if errorCode == canonical {
+		return true
 	}
-	return codeValue[0].String() == code
+	errorRegistry, ok := lookupCode(errorCode)
+	return ok && errorRegistry.code == canonical
 }
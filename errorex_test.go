@@ -24,6 +24,7 @@
 package errorex
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -81,7 +82,9 @@ func TestIs(t *testing.T) {
 		assert.False(t, Is(err, code))
 	})
 
-	t.Run("should panic if the error code is not registered", func(t *testing.T) {
+	t.Run("should panic if the error code is not registered in strict mode", func(t *testing.T) {
+		SetStrictIs(true)
+		defer SetStrictIs(false)
 		ex := New("test.is", struct{ Message string }{})
 
 		assert.Panics(t, func() {
@@ -89,6 +92,18 @@ func TestIs(t *testing.T) {
 		})
 	})
 
+	t.Run("should not match unregistered codes", func(t *testing.T) {
+		ex := New("test.is", struct{ Message string }{})
+
+		assert.False(t, Is(ex, "unregistered.code"))
+		matched, registered := IsSafe(ex, "unregistered.code")
+		assert.False(t, matched)
+		assert.False(t, registered)
+		matched, registered = IsSafe(ex, "test.is")
+		assert.True(t, matched)
+		assert.True(t, registered)
+	})
+
 	t.Run("should panic if the type of the provided detail is not the same type as specified in the record", func(t *testing.T) {
 		panicDetail := ErrorEXDetailTypeMismatch{
 			ExpectedType: "struct { Message string }",
@@ -162,6 +177,27 @@ func TestEXConverter(t *testing.T) {
 
 	})
 
+	t.Run("should chain more than one converter", func(t *testing.T) {
+		converter := BuildErrorConverterChain(&mockErrorConverter{}, &mockErrorConverter{}, &mockErrorConverter{})
+
+		assert.True(t, Is(converter.ConvertError(fmt.Errorf("test error")), ErrCodeMockError))
+		assert.True(t, Is(converter.ConvertError(fmt.Errorf("unknown error")), ErrCodeUnknownError))
+	})
+
+	t.Run("should reach every converter of the chain in order", func(t *testing.T) {
+		converter := BuildErrorConverterChain(
+			&messageErrorConverter{message: "first"},
+			&messageErrorConverter{message: "second"},
+			&messageErrorConverter{message: "third"},
+		)
+
+		for _, message := range []string{"first", "second", "third"} {
+			ex := converter.ConvertError(errors.New(message))
+			assert.Equal(t, MockErrorDetail{Detail: message}, ex.Detail(), message)
+		}
+		assert.Equal(t, ErrCodeUnknownError, converter.ConvertError(errors.New("fourth")).Code())
+	})
+
 	t.Run("base converter should return nil if there is no next handler", func(t *testing.T) {
 		converter := BaseErrorConverter{}
 
@@ -206,3 +242,16 @@ func (m *mockErrorConverter) ConvertError(err error) EX {
 	}
 	return New(ErrCodeMockError, MockErrorDetail{Detail: err.Error()})
 }
+
+// messageErrorConverter converts the errors with its message only
+type messageErrorConverter struct {
+	BaseErrorConverter
+	message string
+}
+
+func (m *messageErrorConverter) ConvertError(err error) EX {
+	if err.Error() != m.message {
+		return m.BaseErrorConverter.ConvertError(err)
+	}
+	return New(ErrCodeMockError, MockErrorDetail{Detail: err.Error()})
+}
@@ -0,0 +1,160 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package errorextest provides assertions for tests of code returning errorex errors, comparing codes and details
+// instead of the serialized Error() strings, which change whenever the serialization does.
+//
+//	func TestCharge(t *testing.T) {
+//		err := service.Charge(card)
+//		errorextest.AssertCode(t, err, payments.ErrCodeDeclined)
+//		detail := errorextest.RequireDetail[payments.DeclinedDetail](t, err)
+//		assert.Equal(t, "insufficient_funds", detail.Reason)
+//	}
+//
+// Failures print the difference between the expected and the actual values along with the actual error.
+package errorextest
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tHelper interface {
+	Helper()
+}
+
+// AssertCode asserts that the chain of err has an errorex with the code, or one of its aliases
+func AssertCode(t assert.TestingT, err error, code string, msgAndArgs ...any) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	info, ok := errorex.Lookup(code)
+	if !ok {
+		return assert.Fail(t, fmt.Sprintf("code %q is not registered", code), msgAndArgs...)
+	}
+	ex, ok := find(t, err, msgAndArgs...)
+	if !ok {
+		return false
+	}
+	actual := ex.Code()
+	if actualInfo, ok := errorex.Lookup(actual); ok {
+		actual = actualInfo.Code
+	}
+	return assert.Equal(t, info.Code, actual, inError(err, msgAndArgs)...)
+}
+
+// AssertDetail asserts that the chain of err has an errorex with a detail equal to the expected one
+func AssertDetail[T any](t assert.TestingT, err error, expected T, msgAndArgs ...any) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	ex, ok := find(t, err, msgAndArgs...)
+	if !ok {
+		return false
+	}
+	return assert.Equal(t, expected, ex.Detail(), inError(err, msgAndArgs)...)
+}
+
+// RequireDetail returns the detail of the first errorex in the chain of err, failing the test immediately if it
+// is missing or is not a T
+func RequireDetail[T any](t require.TestingT, err error, msgAndArgs ...any) T {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	ex, ok := find(t, err, msgAndArgs...)
+	if !ok {
+		t.FailNow()
+		var zero T
+		return zero
+	}
+	detail, ok := ex.Detail().(T)
+	if !ok {
+		var zero T
+		assert.Fail(t, fmt.Sprintf("detail type mismatch:\nexpected: %T\nactual  : %T\nin error %s", zero, ex.Detail(), describe(err)), msgAndArgs...)
+		t.FailNow()
+		return zero
+	}
+	return detail
+}
+
+// AssertRetryable asserts that err is retryable, see errorex.IsRetryable
+func AssertRetryable(t assert.TestingT, err error, msgAndArgs ...any) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	if errorex.IsRetryable(err) {
+		return true
+	}
+	return assert.Fail(t, fmt.Sprintf("expected a retryable error, got %s", describe(err)), msgAndArgs...)
+}
+
+// AssertNotRetryable asserts that err is not retryable, see errorex.IsRetryable
+func AssertNotRetryable(t assert.TestingT, err error, msgAndArgs ...any) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	if !errorex.IsRetryable(err) {
+		return true
+	}
+	return assert.Fail(t, fmt.Sprintf("expected a non retryable error, got %s", describe(err)), msgAndArgs...)
+}
+
+// find returns the first errorex in the chain of err, failing when there is none
+func find(t assert.TestingT, err error, msgAndArgs ...any) (errorex.EX, bool) {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	var ex errorex.EX
+	if errors.As(err, &ex) {
+		return ex, true
+	}
+	return nil, assert.Fail(t, fmt.Sprintf("expected an errorex, got %s", describe(err)), msgAndArgs...)
+}
+
+// inError returns the message of an assertion on err: the message of the caller, formatted like testify does,
+// followed by the description of err
+func inError(err error, msgAndArgs []any) []any {
+	message := "in error " + describe(err)
+	if len(msgAndArgs) == 0 {
+		return []any{message}
+	}
+	if format, ok := msgAndArgs[0].(string); ok && len(msgAndArgs) > 1 {
+		return []any{fmt.Sprintf(format, msgAndArgs[1:]...) + "\n" + message}
+	}
+	if len(msgAndArgs) == 1 {
+		return []any{fmt.Sprintf("%+v", msgAndArgs[0]) + "\n" + message}
+	}
+	return []any{message}
+}
+
+// describe formats an error for failure messages
+func describe(err error) string {
+	if err == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%T: %s", err, err.Error())
+}
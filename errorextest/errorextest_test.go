@@ -0,0 +1,135 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorextest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type testDetail struct {
+	Reason string `json:"reason"`
+}
+
+// recorder records the failures instead of failing the test
+type recorder struct {
+	failures []string
+	stopped  bool
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) FailNow() {
+	r.stopped = true
+}
+
+func init() {
+	errorex.RegisterErrorCode("errorextest.declined", "Declined", testDetail{}, errorex.WithRetryable())
+	errorex.RegisterErrorCode("errorextest.invalid", "Invalid", testDetail{})
+	errorex.RegisterAlias("errorextest.refused", "errorextest.declined")
+}
+
+func TestAssertCode(t *testing.T) {
+	err := fmt.Errorf("charging: %w", errorex.New("errorextest.declined", testDetail{Reason: "funds"}))
+
+	t.Run("should pass for the code and its aliases", func(t *testing.T) {
+		assert.True(t, AssertCode(t, err, "errorextest.declined"))
+		assert.True(t, AssertCode(t, err, "errorextest.refused"))
+	})
+
+	t.Run("should print a diff on failure", func(t *testing.T) {
+		r := &recorder{}
+		assert.False(t, AssertCode(r, err, "errorextest.invalid"))
+		assert.Len(t, r.failures, 1)
+		assert.Contains(t, r.failures[0], "Diff:")
+		assert.Contains(t, r.failures[0], "funds")
+	})
+
+	t.Run("should keep the message of the caller", func(t *testing.T) {
+		r := &recorder{}
+		assert.False(t, AssertCode(r, err, "errorextest.invalid", "charging card %d", 7))
+		assert.False(t, AssertCode(r, err, "errorextest.invalid", "charging 100%"))
+		assert.Contains(t, r.failures[0], "charging card 7\n")
+		assert.Contains(t, r.failures[1], "charging 100%\n")
+		assert.Contains(t, r.failures[1], "in error *fmt.wrapError: charging: ")
+		assert.NotContains(t, r.failures[0]+r.failures[1], "%!")
+	})
+
+	t.Run("should fail for other errors and unregistered codes", func(t *testing.T) {
+		r := &recorder{}
+		assert.False(t, AssertCode(r, errors.New("other"), "errorextest.declined"))
+		assert.False(t, AssertCode(r, err, "errorextest.missing"))
+		assert.Len(t, r.failures, 2)
+	})
+}
+
+func TestAssertDetail(t *testing.T) {
+	err := errorex.New("errorextest.declined", testDetail{Reason: "funds"})
+
+	t.Run("should compare the detail", func(t *testing.T) {
+		assert.True(t, AssertDetail(t, err, testDetail{Reason: "funds"}))
+
+		r := &recorder{}
+		assert.False(t, AssertDetail(r, err, testDetail{Reason: "limit"}))
+		assert.Contains(t, r.failures[0], "Diff:")
+	})
+}
+
+func TestRequireDetail(t *testing.T) {
+	t.Run("should return the detail", func(t *testing.T) {
+		detail := RequireDetail[testDetail](t, errorex.New("errorextest.declined", testDetail{Reason: "funds"}))
+		assert.Equal(t, "funds", detail.Reason)
+	})
+
+	t.Run("should stop on type mismatch", func(t *testing.T) {
+		r := &recorder{}
+		RequireDetail[errorex.ErrorEXDetail](r, errorex.New("errorextest.declined", testDetail{}))
+		assert.True(t, r.stopped)
+		assert.Contains(t, r.failures[0], "errorextest.testDetail")
+	})
+
+	t.Run("should stop when there is no errorex", func(t *testing.T) {
+		r := &recorder{}
+		RequireDetail[testDetail](r, nil)
+		assert.True(t, r.stopped)
+	})
+}
+
+func TestAssertRetryable(t *testing.T) {
+	t.Run("should check the retryable flag", func(t *testing.T) {
+		assert.True(t, AssertRetryable(t, errorex.New("errorextest.declined", testDetail{})))
+		assert.True(t, AssertNotRetryable(t, errorex.New("errorextest.invalid", testDetail{})))
+
+		r := &recorder{}
+		assert.False(t, AssertRetryable(r, errorex.New("errorextest.invalid", testDetail{})))
+		assert.False(t, AssertNotRetryable(r, errorex.New("errorextest.declined", testDetail{})))
+		assert.Len(t, r.failures, 2)
+	})
+}
@@ -0,0 +1,81 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorextest
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+// CheckExamples checks that the examples registered with errorex.WithExamples for the codes starting with one of
+// the prefixes (every code when none is given) still match the detail type of their code and round trip through
+// errorex.ParseJSON, so examples are not left behind when detail types change:
+//
+//	func TestExamples(t *testing.T) {
+//		errorextest.CheckExamples(t, "billing.")
+//	}
+func CheckExamples(t assert.TestingT, prefixes ...string) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	passed := true
+	for _, info := range errorex.Catalog() {
+		if !hasPrefix(info.Code, prefixes) {
+			continue
+		}
+		for i, example := range info.Examples {
+			if problem := exampleProblem(info, example); problem != "" {
+				t.Errorf("%s: example %d %s", info.Code, i, problem)
+				passed = false
+			}
+		}
+	}
+	return passed
+}
+
+// exampleProblem describes why the example does not match the code, empty when it does
+func exampleProblem(info errorex.CodeInfo, example errorex.Example) string {
+	detailType := reflect.TypeOf(example.Detail)
+	if info.DetailType != nil && info.DetailType.Kind() == reflect.Interface {
+		// Interface details are parsed as generic JSON values, so they cannot round trip
+		if detailType != nil && !detailType.Implements(info.DetailType) {
+			return fmt.Sprintf("has detail type %v, which does not implement %v", detailType, info.DetailType)
+		}
+		return ""
+	}
+	if detailType != info.DetailType {
+		return fmt.Sprintf("has detail type %v, expected %v", detailType, info.DetailType)
+	}
+	parsed, err := errorex.ParseJSON([]byte(example.Payload))
+	if err != nil {
+		return fmt.Sprintf("does not parse: %v", err)
+	}
+	if !reflect.DeepEqual(parsed.Detail(), example.Detail) {
+		return fmt.Sprintf("does not round trip: %#v became %#v", example.Detail, parsed.Detail())
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorextest
+
+import (
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type lossyDetail struct {
+	Reason string `json:"reason"`
+	secret string
+}
+
+type summarizer interface {
+	Summary() string
+}
+
+func (d testDetail) Summary() string {
+	return d.Reason
+}
+
+func init() {
+	errorex.RegisterErrorCode("errorextest.example.valid", "Valid examples", testDetail{},
+		errorex.WithExamples(testDetail{Reason: "funds"}))
+	errorex.RegisterErrorCode("errorextest.example.mismatched", "Mismatched examples", testDetail{},
+		errorex.WithExamples("funds"))
+	errorex.RegisterErrorCode("errorextest.example.lossy", "Lossy examples", lossyDetail{},
+		errorex.WithExamples(lossyDetail{Reason: "funds", secret: "token"}))
+	errorex.RegisterInterfaceCode[summarizer]("errorextest.example.interface", "Interface examples",
+		errorex.WithExamples(testDetail{Reason: "funds"}))
+	errorex.RegisterInterfaceCode[summarizer]("errorextest.example.unimplemented", "Unimplemented examples",
+		errorex.WithExamples(lossyDetail{Reason: "funds"}))
+}
+
+func TestCheckExamples(t *testing.T) {
+	t.Run("should pass for matching examples", func(t *testing.T) {
+		assert.True(t, CheckExamples(t, "errorextest.example.valid"))
+	})
+
+	t.Run("should fail for examples of other types", func(t *testing.T) {
+		r := &recorder{}
+
+		assert.False(t, CheckExamples(r, "errorextest.example.mismatched"))
+		assert.Equal(t, []string{"errorextest.example.mismatched: example 0 has detail type string, expected errorextest.testDetail"}, r.failures)
+	})
+
+	t.Run("should check the examples of interface codes implement the interface", func(t *testing.T) {
+		r := &recorder{}
+
+		assert.True(t, CheckExamples(t, "errorextest.example.interface"))
+		assert.False(t, CheckExamples(r, "errorextest.example.unimplemented"))
+		assert.Equal(t, []string{"errorextest.example.unimplemented: example 0 has detail type errorextest.lossyDetail, which does not implement errorextest.summarizer"}, r.failures)
+	})
+
+	t.Run("should fail for examples that do not round trip", func(t *testing.T) {
+		r := &recorder{}
+
+		assert.False(t, CheckExamples(r, "errorextest.example.lossy"))
+		assert.Len(t, r.failures, 1)
+		assert.Contains(t, r.failures[0], "does not round trip")
+	})
+}
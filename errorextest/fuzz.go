@@ -0,0 +1,172 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorextest
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// roundTripsPerCode is the number of random details serialized for each code by FuzzRoundTrip
+	roundTripsPerCode = 20
+)
+
+// Backend serializes and parses errorex errors, such as a JSON, proto or msgpack wire format
+type Backend struct {
+	Name      string
+	Marshal   func(ex errorex.EX) ([]byte, error)
+	Unmarshal func(data []byte) (errorex.EX, error)
+}
+
+// JSONBackend is the JSON wire format, serialized by Error and parsed by errorex.ParseJSON
+var JSONBackend = Backend{
+	Name: "json",
+	Marshal: func(ex errorex.EX) ([]byte, error) {
+		return errorex.AppendError(nil, ex), nil
+	},
+	Unmarshal: errorex.ParseJSON,
+}
+
+// RandomDetail generates a random detail of the type registered for the code with testing/quick.
+// It returns false when the type cannot be generated (interfaces, channels, unexported fields...) or when the
+// generated value cannot be serialized by the configured JSONCodec.
+func RandomDetail(code string, r *rand.Rand) (detail any, ok bool) {
+	info, registered := errorex.Lookup(code)
+	if !registered {
+		return nil, false
+	}
+	if info.DetailType == nil {
+		return nil, true
+	}
+	defer func() {
+		if recover() != nil {
+			detail, ok = nil, false
+		}
+	}()
+	value, generated := quick.Value(info.DetailType, r)
+	if !generated {
+		return nil, false
+	}
+	if _, err := errorex.GetJSONCodec().Marshal(value.Interface()); err != nil {
+		return nil, false
+	}
+	return value.Interface(), true
+}
+
+// FuzzRoundTrip serializes random errors of every registered code through each backend (JSONBackend when none
+// is given), parses them back and checks that the code and the detail survived. Codes whose detail type cannot
+// be generated by RandomDetail are skipped.
+func FuzzRoundTrip(t *testing.T, backends ...Backend) {
+	t.Helper()
+	if len(backends) == 0 {
+		backends = []Backend{JSONBackend}
+	}
+	seed := time.Now().UnixNano()
+	r := rand.New(rand.NewSource(seed))
+	for _, info := range errorex.Catalog() {
+		for i := 0; i < roundTripsPerCode; i++ {
+			detail, ok := RandomDetail(info.Code, r)
+			if !ok {
+				t.Logf("%s: skipped, %v cannot be generated", info.Code, info.DetailType)
+				break
+			}
+			original := errorex.New(info.Code, detail)
+			for _, backend := range backends {
+				if err := roundTrip(backend, original); err != nil {
+					t.Errorf("%s round trip of %s failed (seed %d): %v", backend.Name, original.Error(), seed, err)
+				}
+			}
+		}
+	}
+}
+
+// AddCorpus adds to the fuzz corpus the serialized form of a zero and a random error of every registered code
+func AddCorpus(f *testing.F) {
+	r := rand.New(rand.NewSource(1))
+	for _, info := range errorex.Catalog() {
+		var zero any
+		if info.DetailType != nil {
+			zero = reflect.Zero(info.DetailType).Interface()
+		}
+		f.Add(errorex.AppendError(nil, errorex.New(info.Code, zero)))
+		if detail, ok := RandomDetail(info.Code, r); ok && detail != nil {
+			f.Add(errorex.AppendError(nil, errorex.New(info.Code, detail)))
+		}
+	}
+}
+
+// FuzzParse fuzzes errorex.ParseJSON with the corpus of AddCorpus, checking that it never panics and that
+// every payload it accepts survives another round trip:
+//
+//	func FuzzParseJSON(f *testing.F) {
+//		errorextest.FuzzParse(f)
+//	}
+func FuzzParse(f *testing.F) {
+	AddCorpus(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ex, err := errorex.ParseJSON(data)
+		if err != nil {
+			return
+		}
+		if err := roundTrip(JSONBackend, ex); err != nil {
+			t.Errorf("round trip of %s failed: %v", data, err)
+		}
+	})
+}
+
+// roundTrip serializes and parses the errorex with the backend, comparing the code and the detail
+func roundTrip(backend Backend, original errorex.EX) error {
+	data, err := backend.Marshal(original)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	parsed, err := backend.Unmarshal(data)
+	if err != nil {
+		return fmt.Errorf("unmarshal %s: %w", data, err)
+	}
+	if parsed.Code() != original.Code() {
+		return fmt.Errorf("code changed from %s to %s", original.Code(), parsed.Code())
+	}
+	codec := errorex.GetJSONCodec()
+	originalDetail, err := codec.Marshal(original.Detail())
+	if err != nil {
+		return fmt.Errorf("marshal detail: %w", err)
+	}
+	parsedDetail, err := codec.Marshal(parsed.Detail())
+	if err != nil {
+		return fmt.Errorf("marshal parsed detail: %w", err)
+	}
+	if !bytes.Equal(originalDetail, parsedDetail) {
+		return fmt.Errorf("detail changed from %s to %s", originalDetail, parsedDetail)
+	}
+	return nil
+}
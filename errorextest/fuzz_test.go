@@ -0,0 +1,101 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorextest
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type fuzzDetail struct {
+	Name    string            `json:"name"`
+	Count   int               `json:"count,omitempty"`
+	Ratio   float64           `json:"ratio"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels"`
+	Nested  *testDetail       `json:"nested"`
+	Ignored string            `json:"-"`
+}
+
+type unsupportedDetail struct {
+	Callback func() `json:"callback"`
+}
+
+func init() {
+	errorex.RegisterErrorCode("errorextest.fuzz", "Fuzz", fuzzDetail{})
+	errorex.RegisterErrorCode("errorextest.unsupported", "Unsupported", unsupportedDetail{})
+}
+
+func TestRandomDetail(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	t.Run("should generate details of the registered type", func(t *testing.T) {
+		detail, ok := RandomDetail("errorextest.fuzz", r)
+		assert.True(t, ok)
+		assert.IsType(t, fuzzDetail{}, detail)
+	})
+
+	t.Run("should skip types that cannot be generated", func(t *testing.T) {
+		_, ok := RandomDetail("errorextest.unsupported", r)
+		assert.False(t, ok)
+		_, ok = RandomDetail("errorextest.missing", r)
+		assert.False(t, ok)
+	})
+}
+
+func TestFuzzRoundTrip(t *testing.T) {
+	t.Run("should round trip every registered code", func(t *testing.T) {
+		FuzzRoundTrip(t)
+	})
+
+	t.Run("should report broken backends", func(t *testing.T) {
+		broken := Backend{
+			Name:    "broken",
+			Marshal: JSONBackend.Marshal,
+			Unmarshal: func(data []byte) (errorex.EX, error) {
+				return nil, errors.New("broken")
+			},
+		}
+		assert.Error(t, roundTrip(broken, errorex.New("errorextest.fuzz", fuzzDetail{Name: "test"})))
+	})
+
+	t.Run("should report changed details", func(t *testing.T) {
+		lossy := Backend{
+			Name:    "lossy",
+			Marshal: JSONBackend.Marshal,
+			Unmarshal: func(data []byte) (errorex.EX, error) {
+				return errorex.New("errorextest.fuzz", fuzzDetail{}), nil
+			},
+		}
+		assert.Error(t, roundTrip(lossy, errorex.New("errorextest.fuzz", fuzzDetail{Name: "test"})))
+	})
+}
+
+func FuzzParseJSON(f *testing.F) {
+	FuzzParse(f)
+}
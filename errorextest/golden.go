@@ -0,0 +1,126 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorextest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("errorex.update", false, "update the golden files of errorextest.AssertGolden")
+
+// instanceStubs replace the instance metadata, which changes on every run, in snapshots
+var instanceStubs = map[string]json.RawMessage{
+	"id":   json.RawMessage(`"<id>"`),
+	"time": json.RawMessage(`"<time>"`),
+}
+
+// Snapshot serializes the first errorex in the chain of err deterministically: the payload of Error() indented,
+// with the keys sorted, the stack trace left out and the instance ID and timestamp, when present, stubbed.
+func Snapshot(err error) ([]byte, error) {
+	var ex errorex.EX
+	if !errors.As(err, &ex) {
+		return nil, fmt.Errorf("expected an errorex, got %s", describe(err))
+	}
+	payload, unmarshalErr := stable([]byte(ex.Error()))
+	if unmarshalErr != nil {
+		return nil, fmt.Errorf("invalid errorex payload %s: %w", ex.Error(), unmarshalErr)
+	}
+	var snapshot bytes.Buffer
+	encoder := json.NewEncoder(&snapshot)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(payload); encodeErr != nil {
+		return nil, encodeErr
+	}
+	return snapshot.Bytes(), nil
+}
+
+// stable decodes a serialized errorex without its stack trace and with its instance metadata stubbed, recursing
+// into its errorex cause
+func stable(data []byte) (map[string]json.RawMessage, error) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, err
+	}
+	delete(payload, "stack")
+	for key, stub := range instanceStubs {
+		if _, ok := payload[key]; ok {
+			payload[key] = stub
+		}
+	}
+	if cause, ok := payload["cause"]; ok && bytes.HasPrefix(cause, []byte("{")) {
+		stableCause, err := stable(cause)
+		if err != nil {
+			return nil, err
+		}
+		var encoded bytes.Buffer
+		encoder := json.NewEncoder(&encoded)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(stableCause); err != nil {
+			return nil, err
+		}
+		payload["cause"] = bytes.TrimSpace(encoded.Bytes())
+	}
+	return payload, nil
+}
+
+// AssertGolden compares the Snapshot of err against a golden file, locking down the public payload of the error.
+// Running the tests with -errorex.update writes the golden files instead:
+//
+//	go test ./... -errorex.update
+func AssertGolden(t assert.TestingT, err error, path string, msgAndArgs ...any) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	snapshot, snapshotErr := Snapshot(err)
+	if snapshotErr != nil {
+		return assert.Fail(t, snapshotErr.Error(), msgAndArgs...)
+	}
+	if *update {
+		if mkdirErr := os.MkdirAll(filepath.Dir(path), 0o755); mkdirErr != nil {
+			return assert.Fail(t, mkdirErr.Error(), msgAndArgs...)
+		}
+		if writeErr := os.WriteFile(path, snapshot, 0o644); writeErr != nil {
+			return assert.Fail(t, writeErr.Error(), msgAndArgs...)
+		}
+		return true
+	}
+	golden, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return assert.Fail(t, fmt.Sprintf("%v, run the tests with -errorex.update to create it", readErr), msgAndArgs...)
+	}
+	if bytes.Equal(golden, snapshot) {
+		return true
+	}
+	return assert.Equal(t, string(golden), string(snapshot), append([]any{"golden file %s is outdated", path}, msgAndArgs...)...)
+}
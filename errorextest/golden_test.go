@@ -0,0 +1,100 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorextest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertGolden(t *testing.T) {
+	err := errorex.New("errorextest.declined", testDetail{Reason: "funds"})
+
+	t.Run("should match the golden file", func(t *testing.T) {
+		assert.True(t, AssertGolden(t, err, "testdata/declined.json"))
+	})
+
+	t.Run("should leave the stack out", func(t *testing.T) {
+		config := errorex.GetStackConfig()
+		errorex.SetStackConfig(errorex.StackConfig{Enabled: true})
+		defer errorex.SetStackConfig(config)
+
+		assert.True(t, AssertGolden(t, errorex.New("errorextest.declined", testDetail{Reason: "funds"}), "testdata/declined.json"))
+	})
+
+	t.Run("should stub the instance metadata", func(t *testing.T) {
+		config := errorex.GetInstanceConfig()
+		errorex.SetInstanceConfig(errorex.InstanceConfig{IDs: true, Timestamps: true})
+		defer errorex.SetInstanceConfig(config)
+
+		snapshot, err := Snapshot(errorex.New("errorextest.declined", testDetail{Reason: "funds"}))
+		assert.NoError(t, err)
+		assert.Contains(t, string(snapshot), `"id": "<id>"`)
+		assert.Contains(t, string(snapshot), `"time": "<time>"`)
+	})
+
+	t.Run("should stabilize the errorex causes", func(t *testing.T) {
+		config := errorex.GetInstanceConfig()
+		errorex.SetInstanceConfig(errorex.InstanceConfig{IDs: true})
+		defer errorex.SetInstanceConfig(config)
+
+		cause := errorex.New("errorextest.declined", testDetail{Reason: "funds"})
+		snapshot, err := Snapshot(errorex.Wrap(cause, "errorextest.declined", testDetail{Reason: "retry"}))
+		assert.NoError(t, err)
+		assert.Contains(t, string(snapshot), "\"cause\": {\n    \"code\": \"errorextest.declined\",\n    \"detail\": {\n      \"reason\": \"funds\"\n    },\n    \"id\": \"<id>\"\n  }")
+	})
+
+	t.Run("should print a diff when the payload changes", func(t *testing.T) {
+		if *update {
+			t.Skip("golden files are being updated")
+		}
+		r := &recorder{}
+		assert.False(t, AssertGolden(r, errorex.New("errorextest.declined", testDetail{Reason: "limit"}), "testdata/declined.json"))
+		assert.Contains(t, r.failures[0], "Diff:")
+	})
+
+	t.Run("should write the golden file on update", func(t *testing.T) {
+		*update = true
+		defer func() { *update = false }()
+		path := filepath.Join(t.TempDir(), "golden", "declined.json")
+
+		assert.True(t, AssertGolden(t, err, path))
+
+		golden, _ := os.ReadFile(path)
+		expected, _ := os.ReadFile("testdata/declined.json")
+		assert.Equal(t, string(expected), string(golden))
+	})
+
+	t.Run("should fail for missing golden files and other errors", func(t *testing.T) {
+		r := &recorder{}
+		assert.False(t, AssertGolden(r, err, filepath.Join(t.TempDir(), "missing.json")))
+		assert.Contains(t, r.failures[0], "-errorex.update")
+		assert.False(t, AssertGolden(r, errors.New("other"), "testdata/declined.json"))
+	})
+}
@@ -0,0 +1,73 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorextest
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/mock"
+)
+
+// CodeMatcher matches any error whose chain has an errorex with a code, or one of its aliases.
+// It implements gomock.Matcher and, through MatchedBy, testify mock arguments:
+//
+//	reporter.EXPECT().Report(errorextest.ErrorCodeMatcher(payments.ErrCodeDeclined))
+//	reporter.On("Report", errorextest.ErrorCodeMatcher(payments.ErrCodeDeclined).MatchedBy()).Return()
+type CodeMatcher struct {
+	code string
+}
+
+// ErrorCodeMatcher returns a CodeMatcher for the code, it panics if the code is not registered
+func ErrorCodeMatcher(code string) CodeMatcher {
+	info, ok := errorex.Lookup(code)
+	if !ok {
+		// Fatal errorex
+		panic(errorex.New(errorex.ErrCodeNotRegistered, errorex.ErrorEXDetail{Code: code}))
+	}
+	return CodeMatcher{code: info.Code}
+}
+
+// Matches checks if x is an error with the code of the matcher
+func (m CodeMatcher) Matches(x any) bool {
+	err, ok := x.(error)
+	if !ok {
+		return false
+	}
+	var ex errorex.EX
+	return errors.As(err, &ex) && errorex.Is(ex, m.code)
+}
+
+// String describes the matcher in failure messages
+func (m CodeMatcher) String() string {
+	return fmt.Sprintf("is an errorex with code %s", m.code)
+}
+
+// MatchedBy returns the matcher as a testify mock argument
+func (m CodeMatcher) MatchedBy() any {
+	return mock.MatchedBy(func(err error) bool {
+		return m.Matches(err)
+	})
+}
@@ -0,0 +1,76 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorextest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// gomockMatcher is the gomock.Matcher interface
+type gomockMatcher interface {
+	Matches(x any) bool
+	String() string
+}
+
+type reporterMock struct {
+	mock.Mock
+}
+
+func (r *reporterMock) Report(err error) {
+	r.Called(err)
+}
+
+func TestErrorCodeMatcher(t *testing.T) {
+	t.Run("should match the code and its aliases", func(t *testing.T) {
+		var matcher gomockMatcher = ErrorCodeMatcher("errorextest.refused")
+
+		assert.True(t, matcher.Matches(errorex.New("errorextest.declined", testDetail{})))
+		assert.True(t, matcher.Matches(fmt.Errorf("wrapped: %w", errorex.New("errorextest.declined", testDetail{}))))
+		assert.False(t, matcher.Matches(errorex.New("errorextest.invalid", testDetail{})))
+		assert.False(t, matcher.Matches(errors.New("other")))
+		assert.False(t, matcher.Matches(nil))
+		assert.Equal(t, "is an errorex with code errorextest.declined", matcher.String())
+	})
+
+	t.Run("should match testify mock arguments", func(t *testing.T) {
+		reporter := &reporterMock{}
+		reporter.On("Report", ErrorCodeMatcher("errorextest.declined").MatchedBy()).Return().Once()
+
+		reporter.Report(errorex.New("errorextest.declined", testDetail{Reason: "funds"}))
+
+		reporter.AssertExpectations(t)
+	})
+
+	t.Run("should panic for unregistered codes", func(t *testing.T) {
+		assert.Panics(t, func() {
+			ErrorCodeMatcher("errorextest.missing")
+		})
+	})
+}
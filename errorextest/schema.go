@@ -0,0 +1,152 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorextest
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// CheckDetailSchemas checks the detail types of the registered codes starting with one of the prefixes (every
+// code when none is given) with DetailSchemaProblems, and round trips random details of each through JSON.
+// It is meant to be the single test of a service guarding its detail struct definitions:
+//
+//	func TestDetailSchemas(t *testing.T) {
+//		errorextest.CheckDetailSchemas(t, "billing.")
+//	}
+func CheckDetailSchemas(t testing.TB, prefixes ...string) {
+	t.Helper()
+	r := rand.New(rand.NewSource(1))
+	for _, info := range errorex.Catalog() {
+		if !hasPrefix(info.Code, prefixes) {
+			continue
+		}
+		for _, problem := range DetailSchemaProblems(info.DetailType) {
+			t.Errorf("%s: %s", info.Code, problem)
+		}
+		for i := 0; i < roundTripsPerCode; i++ {
+			detail, ok := RandomDetail(info.Code, r)
+			if !ok {
+				break
+			}
+			if err := roundTrip(JSONBackend, errorex.New(info.Code, detail)); err != nil {
+				t.Errorf("%s: %v", info.Code, err)
+				break
+			}
+		}
+	}
+}
+
+// DetailSchemaProblems lists the problems of a detail type that make its JSON form lossy or surprising:
+// exported fields without a json tag, unexported fields that are silently dropped, interface fields decoded
+// into maps, values encoding/json cannot serialize and unsupported map keys. Nested types are checked too.
+func DetailSchemaProblems(t reflect.Type) []string {
+	if t == nil {
+		return nil
+	}
+	return schemaProblems(t.String(), t, make(map[reflect.Type]bool))
+}
+
+func schemaProblems(path string, t reflect.Type, visited map[reflect.Type]bool) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		return []string{fmt.Sprintf("%s: interface type %s is decoded into generic values", path, t)}
+	case reflect.Func, reflect.Chan, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return []string{fmt.Sprintf("%s: %s cannot be serialized", path, t)}
+	case reflect.Slice, reflect.Array:
+		return schemaProblems(path+"[]", t.Elem(), visited)
+	case reflect.Map:
+		var problems []string
+		key := t.Key()
+		switch {
+		case key.Kind() == reflect.String,
+			key.Kind() >= reflect.Int && key.Kind() <= reflect.Uintptr,
+			key.Implements(textMarshalerType):
+		default:
+			problems = append(problems, fmt.Sprintf("%s: map key %s cannot be serialized", path, key))
+		}
+		return append(problems, schemaProblems(path+"[]", t.Elem(), visited)...)
+	case reflect.Struct:
+		if visited[t] {
+			return nil
+		}
+		visited[t] = true
+		var problems []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag, tagged := field.Tag.Lookup("json")
+			if tag == "-" {
+				continue
+			}
+			if !field.IsExported() {
+				if !field.Anonymous {
+					problems = append(problems, fmt.Sprintf("%s.%s: unexported field is not serialized", path, field.Name))
+				}
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" {
+				problems = append(problems, schemaProblems(path, field.Type, visited)...)
+				continue
+			}
+			if !tagged || name == "" {
+				problems = append(problems, fmt.Sprintf("%s.%s: exported field has no json name", path, field.Name))
+			}
+			problems = append(problems, schemaProblems(path+"."+field.Name, field.Type, visited)...)
+		}
+		return problems
+	}
+	return nil
+}
+
+// hasPrefix checks if the code starts with one of the prefixes, or if there are no prefixes
+func hasPrefix(code string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(code, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorextest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaBase struct {
+	Trace string `json:"trace"`
+}
+
+type schemaDetail struct {
+	schemaBase
+	When    time.Time         `json:"when"`
+	Items   []schemaItem      `json:"items"`
+	Labels  map[string]string `json:"labels"`
+	Ignored func()            `json:"-"`
+}
+
+type schemaItem struct {
+	Name string `json:"name"`
+}
+
+type badSchemaDetail struct {
+	Untagged string
+	hidden   string
+	Any      any                   `json:"any"`
+	Keys     map[schemaItem]string `json:"keys"`
+	Nested   []struct{ Count int } `json:"nested"`
+	Callback func()                `json:"callback"`
+}
+
+type recordingTB struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestDetailSchemaProblems(t *testing.T) {
+	t.Run("should accept well defined details", func(t *testing.T) {
+		assert.Empty(t, DetailSchemaProblems(reflect.TypeOf(schemaDetail{})))
+		assert.Empty(t, DetailSchemaProblems(reflect.TypeOf(&schemaDetail{})))
+		assert.Empty(t, DetailSchemaProblems(nil))
+	})
+
+	t.Run("should list the problems", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"errorextest.badSchemaDetail.Untagged: exported field has no json name",
+			"errorextest.badSchemaDetail.hidden: unexported field is not serialized",
+			"errorextest.badSchemaDetail.Any: interface type interface {} is decoded into generic values",
+			"errorextest.badSchemaDetail.Keys: map key errorextest.schemaItem cannot be serialized",
+			"errorextest.badSchemaDetail.Nested[].Count: exported field has no json name",
+			"errorextest.badSchemaDetail.Callback: func() cannot be serialized",
+		}, DetailSchemaProblems(reflect.TypeOf(badSchemaDetail{hidden: ""})))
+	})
+}
+
+func TestCheckDetailSchemas(t *testing.T) {
+	errorex.RegisterErrorCode("schema.good", "Good", schemaDetail{})
+	errorex.RegisterErrorCode("schemabad.bad", "Bad", badSchemaDetail{})
+
+	t.Run("should pass for well defined details", func(t *testing.T) {
+		CheckDetailSchemas(t, "schema.")
+	})
+
+	t.Run("should report the problems of the selected codes", func(t *testing.T) {
+		r := &recordingTB{TB: t}
+		CheckDetailSchemas(r, "schemabad.")
+		assert.Len(t, r.failures, 6)
+		assert.Contains(t, r.failures[0], "schemabad.bad: ")
+	})
+}
@@ -0,0 +1,154 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// MetadataEscalatedSeverity is the metadata key recording the severity an errorex was escalated to by Escalate.
+	// It is informative only: SeverityOf ignores it, so it cannot be spoofed by a remote payload.
+	MetadataEscalatedSeverity = "escalated_severity"
+	// MetadataEscalationRule is the metadata key naming the EscalationRule that escalated an errorex
+	MetadataEscalationRule = "escalation_rule"
+)
+
+// EscalationRule escalates the severity of the errors of a code that repeat, or that occur along with errors of
+// other codes, e.g. turning the fifth db.timeout warning within a minute into a critical error paging someone
+type EscalationRule struct {
+	// Name identifies the rule in the metadata of the escalated errors, under MetadataEscalationRule
+	Name string
+	// Code is the code of the escalated errors, its aliases and descendants included (see IsUnder)
+	Code string
+	// Occurrences escalates from the Nth error of the code within Window, the first one when zero or one
+	Occurrences int
+	// With escalates only when errors of each of these codes also occurred within Window
+	With []string
+	// Window is the period the occurrences are counted in
+	Window time.Duration
+	// Severity is the escalated severity
+	Severity Severity
+}
+
+// escalationRule is an EscalationRule with the times of its latest Occurrences errors
+type escalationRule struct {
+	EscalationRule
+	times []time.Time
+	next  int
+}
+
+var (
+	escalationMutex sync.Mutex
+	escalationRules []*escalationRule
+	// escalationSeen holds the time of the latest error of each code, aliases resolved
+	escalationSeen = make(map[string]time.Time)
+)
+
+// SetEscalationRules replaces the escalation rules applied by Escalate, forgetting the errors counted so far.
+// No rules, the default, disables the escalation.
+func SetEscalationRules(rules ...EscalationRule) {
+	escalationMutex.Lock()
+	defer escalationMutex.Unlock()
+	escalationRules = nil
+	escalationSeen = make(map[string]time.Time)
+	for _, rule := range rules {
+		rule.With = append([]string(nil), rule.With...)
+		escalationRules = append(escalationRules, &escalationRule{EscalationRule: rule})
+	}
+}
+
+// Escalate counts an occurrence of the errorex against the rules set with SetEscalationRules and, when a rule
+// escalates it above its severity, returns a copy carrying the escalated severity, which SeverityOf returns from
+// then on, so reporters, severity policies and LogLevel treat it as escalated. The copy also records it under
+// MetadataEscalatedSeverity. The highest severity wins when several rules apply.
+// Call it once per error, where errors are handled, e.g. before logging and reporting them. Errorex errors of other
+// implementations are counted but returned as is.
+func Escalate(err EX) EX {
+	escalationMutex.Lock()
+	if len(escalationRules) == 0 {
+		escalationMutex.Unlock()
+		return err
+	}
+	at := now()
+	escalationSeen[canonicalCode(err.Code())] = at
+	var escalated *escalationRule
+	for _, rule := range escalationRules {
+		if !IsUnder(err, rule.Code) {
+			continue
+		}
+		if rule.observe(at) && (escalated == nil || rule.Severity > escalated.Severity) {
+			escalated = rule
+		}
+	}
+	escalationMutex.Unlock()
+	if escalated == nil || escalated.Severity <= SeverityOf(err) {
+		return err
+	}
+	return Annotate(err,
+		withEscalation(escalated.Severity),
+		WithMetadata(MetadataEscalatedSeverity, escalated.Severity.String()),
+		WithMetadata(MetadataEscalationRule, escalated.Name),
+	)
+}
+
+// observe records an error at the time, telling if the rule escalates it. The escalation mutex is held.
+func (r *escalationRule) observe(at time.Time) bool {
+	if r.Occurrences > 1 {
+		if len(r.times) < r.Occurrences {
+			r.times = append(r.times, at)
+		} else {
+			r.times[r.next] = at
+			r.next = (r.next + 1) % r.Occurrences
+		}
+		// once the ring is full, its oldest entry is the Nth latest error
+		if len(r.times) < r.Occurrences || at.Sub(r.times[r.next]) >= r.Window {
+			return false
+		}
+	}
+	for _, code := range r.With {
+		seen, ok := escalationSeen[canonicalCode(code)]
+		if !ok || at.Sub(seen) >= r.Window {
+			return false
+		}
+	}
+	return true
+}
+
+// withEscalation sets the severity the errorex was escalated to
+func withEscalation(severity Severity) Option {
+	return func(e *ex) {
+		e.escalated = severity
+	}
+}
+
+// escalatedSeverity returns the severity the errorex was escalated to by Escalate
+func escalatedSeverity(target EX) (Severity, bool) {
+	e, ok := target.(*ex)
+	if !ok || e.escalated == 0 {
+		return 0, false
+	}
+	return e.escalated, true
+}
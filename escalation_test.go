@@ -0,0 +1,116 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscalate(t *testing.T) {
+	RegisterErrorCode("test.escalate.timeout", "test description", ErrorEXDetail{}, WithSeverity(SeverityWarning))
+	RegisterErrorCode("test.escalate.timeout.read", "test description", ErrorEXDetail{}, WithParent("test.escalate.timeout"), WithSeverity(SeverityWarning))
+	RegisterErrorCode("test.escalate.failover", "test description", ErrorEXDetail{}, WithSeverity(SeverityInfo))
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return at })
+	defer SetClock(nil)
+	defer SetEscalationRules()
+
+	t.Run("should escalate from the Nth occurrence within the window", func(t *testing.T) {
+		SetEscalationRules(EscalationRule{
+			Name: "timeouts", Code: "test.escalate.timeout", Occurrences: 3, Window: time.Minute, Severity: SeverityCritical,
+		})
+		var severities []Severity
+		for range 4 {
+			severities = append(severities, SeverityOf(Escalate(New("test.escalate.timeout.read", ErrorEXDetail{}))))
+			at = at.Add(10 * time.Second)
+		}
+		assert.Equal(t, []Severity{SeverityWarning, SeverityWarning, SeverityCritical, SeverityCritical}, severities)
+		escalated := Escalate(New("test.escalate.timeout", ErrorEXDetail{}))
+		metadata, _ := Metadata(escalated)
+		assert.Equal(t, "timeouts", metadata[MetadataEscalationRule])
+		assert.Equal(t, slog.LevelError+4, LogLevel(escalated))
+	})
+
+	t.Run("should not escalate occurrences spread over the window", func(t *testing.T) {
+		SetEscalationRules(EscalationRule{Code: "test.escalate.timeout", Occurrences: 2, Window: time.Minute, Severity: SeverityCritical})
+		for range 3 {
+			assert.Equal(t, SeverityWarning, SeverityOf(Escalate(New("test.escalate.timeout", ErrorEXDetail{}))))
+			at = at.Add(time.Minute)
+		}
+	})
+
+	t.Run("should escalate in combination with other codes", func(t *testing.T) {
+		SetEscalationRules(EscalationRule{
+			Code: "test.escalate.timeout", With: []string{"test.escalate.failover"}, Window: time.Minute, Severity: SeverityError,
+		})
+		assert.Equal(t, SeverityWarning, SeverityOf(Escalate(New("test.escalate.timeout", ErrorEXDetail{}))))
+		Escalate(New("test.escalate.failover", ErrorEXDetail{}))
+		at = at.Add(30 * time.Second)
+		assert.Equal(t, SeverityError, SeverityOf(Escalate(New("test.escalate.timeout", ErrorEXDetail{}))))
+		at = at.Add(time.Minute)
+		assert.Equal(t, SeverityWarning, SeverityOf(Escalate(New("test.escalate.timeout", ErrorEXDetail{}))))
+	})
+
+	t.Run("should keep the highest severity", func(t *testing.T) {
+		SetEscalationRules(
+			EscalationRule{Name: "error", Code: "test.escalate.timeout", Severity: SeverityError},
+			EscalationRule{Name: "critical", Code: "test.escalate.timeout", Severity: SeverityCritical},
+			EscalationRule{Name: "info", Code: "test.escalate.timeout", Severity: SeverityInfo},
+		)
+		escalated := Escalate(New("test.escalate.timeout", ErrorEXDetail{}))
+		assert.Equal(t, SeverityCritical, SeverityOf(escalated))
+		SetEscalationRules(EscalationRule{Code: "test.escalate.timeout", Severity: SeverityInfo})
+		original := New("test.escalate.timeout", ErrorEXDetail{})
+		assert.Equal(t, original, Escalate(original))
+	})
+
+	t.Run("should log escalated errors at their severity level", func(t *testing.T) {
+		SetLogLevels(LogLevels{Codes: map[string]slog.Level{"test.escalate.timeout": slog.LevelInfo}})
+		defer SetLogLevels(LogLevels{})
+		SetEscalationRules(EscalationRule{Code: "test.escalate.timeout", Severity: SeverityError})
+		assert.Equal(t, slog.LevelInfo, LogLevel(New("test.escalate.timeout", ErrorEXDetail{})))
+		assert.Equal(t, slog.LevelError, LogLevel(Escalate(New("test.escalate.timeout", ErrorEXDetail{}))))
+	})
+
+	t.Run("should not trust the escalated severity of a parsed payload", func(t *testing.T) {
+		SetEscalationRules(EscalationRule{Code: "test.escalate.timeout", Severity: SeverityCritical})
+		escalated := Escalate(New("test.escalate.timeout", ErrorEXDetail{}))
+		parsed, err := ParseJSON([]byte(escalated.Error()))
+		assert.NoError(t, err)
+		metadata, _ := Metadata(parsed)
+		assert.Equal(t, "critical", metadata[MetadataEscalatedSeverity])
+		assert.Equal(t, SeverityCritical, SeverityOf(escalated))
+		assert.Equal(t, SeverityWarning, SeverityOf(parsed))
+	})
+
+	t.Run("should return the errorex as is without rules", func(t *testing.T) {
+		SetEscalationRules()
+		original := New("test.escalate.timeout", ErrorEXDetail{})
+		assert.Equal(t, original, Escalate(original))
+	})
+}
@@ -0,0 +1,55 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+// Example is an example detail of a code, registered with WithExamples
+type Example struct {
+	// Detail is the example detail
+	Detail any
+	// Payload is the serialized errorex carrying the detail, as written by Error
+	Payload string
+}
+
+// WithExamples attaches example details to the code, listed with their rendered payloads in its CodeInfo, so
+// references generated by docgen show realistic errors. errorextest.CheckExamples checks that the examples still
+// match the detail type of the code.
+func WithExamples[T any](examples ...T) RegistrationOption {
+	return func(registry *errorCodeRegistry) {
+		for _, example := range examples {
+			registry.examples = append(registry.examples, example)
+		}
+	}
+}
+
+// renderExamples renders the payloads of the examples of the registry
+func (r errorCodeRegistry) renderExamples() []Example {
+	if len(r.examples) == 0 {
+		return nil
+	}
+	examples := make([]Example, len(r.examples))
+	for i, detail := range r.examples {
+		examples[i] = Example{Detail: detail, Payload: string(AppendError(nil, &ex{code: r.code, detail: detail}))}
+	}
+	return examples
+}
@@ -0,0 +1,54 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type exampleDetail struct {
+	Field string `json:"field"`
+}
+
+func TestWithExamples(t *testing.T) {
+	RegisterErrorCode("example.invalid", "Invalid field", exampleDetail{},
+		WithExamples(exampleDetail{Field: "email"}, exampleDetail{Field: "name"}))
+
+	t.Run("should list the rendered examples", func(t *testing.T) {
+		info, _ := Lookup("example.invalid")
+
+		assert.Equal(t, []Example{
+			{Detail: exampleDetail{Field: "email"}, Payload: `{"code": "example.invalid", "detail": {"field":"email"}}`},
+			{Detail: exampleDetail{Field: "name"}, Payload: `{"code": "example.invalid", "detail": {"field":"name"}}`},
+		}, info.Examples)
+	})
+
+	t.Run("should list no examples when none is registered", func(t *testing.T) {
+		info, _ := Lookup(ErrCodeNotRegistered)
+
+		assert.Nil(t, info.Examples)
+	})
+}
@@ -0,0 +1,164 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package execex provides the errorex codes of failed subprocesses and a converter for the errors of os/exec,
+// capturing the command, its exit code and the tail of its standard error:
+//
+//	cmd := exec.Command("git", "fetch")
+//	if _, err := cmd.Output(); err != nil {
+//		return converter.ConvertError(execex.WithCommand(err, cmd))
+//	}
+package execex
+
+import (
+	"errors"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/cli"
+)
+
+const (
+	// ErrCodeFailed is the errorex code for commands that exited with a non-zero status or were killed
+	ErrCodeFailed = "exec.failed"
+	// ErrCodeNotFound is the errorex code for commands whose executable cannot be found
+	ErrCodeNotFound = "exec.not_found"
+)
+
+// DefaultStderrLimit is the default number of bytes of standard error kept in FailedDetail
+const DefaultStderrLimit = 1024
+
+var stderrLimit atomic.Int64
+
+// FailedDetail is the detail of ErrCodeFailed
+type FailedDetail struct {
+	// Command is the command line, empty when unknown
+	Command string `json:"command,omitempty"`
+	// ExitCode is the exit status of the command, -1 when it was killed by a signal
+	ExitCode int `json:"exit_code"`
+	// Stderr is the tail of the standard error captured by exec.Cmd.Output, truncated to the stderr limit
+	Stderr string `json:"stderr,omitempty"`
+}
+
+// NotFoundDetail is the detail of ErrCodeNotFound
+type NotFoundDetail struct {
+	// Command is the name of the executable that was looked up
+	Command string `json:"command"`
+}
+
+func init() {
+	stderrLimit.Store(DefaultStderrLimit)
+
+	// Register the errorex codes
+	errorex.RegisterErrorCode(ErrCodeFailed, "Command failed", FailedDetail{}, errorex.WithHTTPStatus(http.StatusInternalServerError))
+	errorex.RegisterErrorCode(ErrCodeNotFound, "Command not found", NotFoundDetail{},
+		errorex.WithHTTPStatus(http.StatusInternalServerError), errorex.WithExitCode(cli.ExitUnavailable))
+}
+
+// SetStderrLimit sets the number of bytes of standard error kept in FailedDetail, zero to leave it out
+func SetStderrLimit(limit int) {
+	stderrLimit.Store(int64(max(limit, 0)))
+}
+
+// CommandError is an error raised while running a command
+type CommandError struct {
+	// Command is the command line
+	Command string
+	// Err is the underlying error
+	Err error
+}
+
+// Error returns the command followed by the message of the underlying error
+func (e *CommandError) Error() string {
+	return e.Command + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// WithCommand wraps err with the command line of cmd, reported as the command of the converted errorex; nil
+// errors and commands are returned as they are
+func WithCommand(err error, cmd *exec.Cmd) error {
+	if err == nil || cmd == nil {
+		return err
+	}
+	return &CommandError{Command: strings.Join(cmd.Args, " "), Err: err}
+}
+
+// execErrorConverter converts the errors of os/exec
+type execErrorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewExecErrorConverter creates a converter of the errors returned by os/exec: an *exec.ExitError becomes
+// ErrCodeFailed and exec.ErrNotFound ErrCodeNotFound
+func NewExecErrorConverter() errorex.ErrorConverter {
+	return &execErrorConverter{}
+}
+
+// ConvertError converts the os/exec errors, delegating the others to the next handler in the chain
+func (c *execErrorConverter) ConvertError(err error) errorex.EX {
+	var command string
+	var commandErr *CommandError
+	if errors.As(err, &commandErr) {
+		command = commandErr.Command
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return errorex.New(ErrCodeFailed, FailedDetail{
+			Command:  command,
+			ExitCode: exitErr.ExitCode(),
+			Stderr:   tail(exitErr.Stderr, int(stderrLimit.Load())),
+		})
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		var lookupErr *exec.Error
+		if command == "" && errors.As(err, &lookupErr) {
+			command = lookupErr.Name
+		}
+		return errorex.New(ErrCodeNotFound, NotFoundDetail{Command: command})
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
+
+// tail returns the last limit bytes of output, without a leading partial rune, marking the truncation with an
+// ellipsis
+func tail(output []byte, limit int) string {
+	if len(output) <= limit {
+		return string(output)
+	}
+	if limit == 0 {
+		return ""
+	}
+	output = output[len(output)-limit:]
+	for len(output) > 0 && !utf8.RuneStart(output[0]) {
+		output = output[1:]
+	}
+	return "..." + string(output)
+}
@@ -0,0 +1,88 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package execex
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewExecErrorConverter())
+
+	t.Run("should convert exit errors", func(t *testing.T) {
+		cmd := exec.Command("sh", "-c", "echo oops >&2; exit 3")
+		_, err := cmd.Output()
+		ex := converter.ConvertError(WithCommand(err, cmd))
+		assert.Equal(t, ErrCodeFailed, ex.Code())
+		assert.Equal(t, FailedDetail{Command: "sh -c echo oops >&2; exit 3", ExitCode: 3, Stderr: "oops\n"}, ex.Detail())
+	})
+
+	t.Run("should truncate the standard error", func(t *testing.T) {
+		SetStderrLimit(4)
+		t.Cleanup(func() { SetStderrLimit(DefaultStderrLimit) })
+		_, err := exec.Command("sh", "-c", "echo 'first line' >&2; echo last >&2; exit 1").Output()
+		ex := converter.ConvertError(err)
+		assert.Equal(t, "...ast\n", ex.Detail().(FailedDetail).Stderr)
+	})
+
+	t.Run("should convert missing executables", func(t *testing.T) {
+		err := exec.Command("errorex-missing-command").Run()
+		ex := converter.ConvertError(err)
+		assert.Equal(t, ErrCodeNotFound, ex.Code())
+		assert.Equal(t, NotFoundDetail{Command: "errorex-missing-command"}, ex.Detail())
+		info, _ := errorex.Lookup(ErrCodeNotFound)
+		assert.Equal(t, cli.ExitUnavailable, info.ExitCode)
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(errors.New("boom")).Code())
+	})
+}
+
+func TestWithCommand(t *testing.T) {
+	t.Run("should keep nil errors", func(t *testing.T) {
+		assert.NoError(t, WithCommand(nil, exec.Command("true")))
+	})
+
+	t.Run("should wrap the error with the command line", func(t *testing.T) {
+		err := errors.New("boom")
+		wrapped := WithCommand(err, exec.Command("git", "fetch"))
+		assert.EqualError(t, wrapped, "git fetch: boom")
+		assert.ErrorIs(t, wrapped, err)
+	})
+}
+
+func TestTail(t *testing.T) {
+	t.Run("should drop partial runes", func(t *testing.T) {
+		assert.Equal(t, "...é", tail([]byte("aaé"), 2))
+		assert.Equal(t, "...", tail([]byte(strings.Repeat("é", 2)), 1))
+	})
+}
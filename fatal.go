@@ -0,0 +1,95 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// RegistrationError is the value of the panics raised by errorex when it is misused, such as creating an errorex
+// with an unregistered code or a detail of the wrong type, so recovery code and tests can inspect them without
+// parsing messages:
+//
+//	defer func() {
+//		if registrationErr, ok := recover().(*errorex.RegistrationError); ok {
+//			log.Printf("%s at %s:%d", registrationErr.Code(), registrationErr.Caller.File, registrationErr.Caller.Line)
+//		}
+//	}()
+type RegistrationError struct {
+	// EX is the errorex describing the misuse, e.g. ErrCodeNotRegistered
+	EX
+	// Caller is the call site outside errorex of the function that panicked
+	Caller Frame
+}
+
+// Unwrap returns the errorex describing the misuse
+func (e *RegistrationError) Unwrap() error {
+	return e.EX
+}
+
+// packagePath is the import path of errorex, used to find the call site of misuses
+var packagePath = reflect.TypeOf(ex{}).PkgPath()
+
+// fatal panics with a RegistrationError for the errorex, at the first caller outside errorex
+func fatal(ex EX) {
+	panic(&RegistrationError{EX: ex, Caller: callSite()})
+}
+
+// misuse reports a programmer error described by the errorex: it panics like fatal in Strict mode and returns the
+// ErrCodeInternal errorex describing it in Lenient mode, see SetMode
+func misuse(ex EX) EX {
+	if GetMode() == Strict {
+		fatal(ex)
+	}
+	return New(ErrCodeInternal, ErrorEXInternal{
+		Code:        ex.Code(),
+		Description: Message(ex),
+		Detail:      ex.Detail(),
+		Caller:      callSite(),
+	})
+}
+
+// callSite returns the first frame of the stack outside errorex, frames of test files count as outside
+func callSite() Frame {
+	pcs := make([]uintptr, DefaultStackDepth)
+	// Skip runtime.Callers, callSite and fatal
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !inPackage(frame.Function) || strings.HasSuffix(frame.File, "_test.go") {
+			return Frame{Function: frame.Function, File: frame.File, Line: frame.Line}
+		}
+		if !more {
+			return Frame{}
+		}
+	}
+}
+
+// inPackage tells if the function belongs to errorex itself, not to one of its sub packages
+func inPackage(function string) bool {
+	rest, ok := strings.CutPrefix(function, packagePath)
+	return ok && strings.HasPrefix(rest, ".")
+}
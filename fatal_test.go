@@ -0,0 +1,67 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistrationError(t *testing.T) {
+	recovered := func(fn func()) (registrationErr *RegistrationError) {
+		defer func() {
+			registrationErr, _ = recover().(*RegistrationError)
+		}()
+		fn()
+		return nil
+	}
+
+	t.Run("should carry the errorex and the call site", func(t *testing.T) {
+		_, _, line, _ := runtime.Caller(0)
+		registrationErr := recovered(func() { New("fatal.unregistered", ErrorEXDetail{}) })
+
+		assert.NotNil(t, registrationErr)
+		assert.Equal(t, ErrCodeNotRegistered, registrationErr.Code())
+		assert.Equal(t, ErrorEXDetail{Code: "fatal.unregistered"}, registrationErr.Detail())
+		assert.Equal(t, "fatal_test.go", filepath.Base(registrationErr.Caller.File))
+		assert.Equal(t, line+1, registrationErr.Caller.Line)
+		assert.True(t, Is(registrationErr, ErrCodeNotRegistered))
+		var target EX
+		assert.True(t, errors.As(registrationErr, &target))
+	})
+
+	t.Run("should skip the frames of errorex", func(t *testing.T) {
+		RegisterErrorCode("fatal.typed", "Typed", ErrorEXDetail{})
+
+		_, _, line, _ := runtime.Caller(0)
+		registrationErr := recovered(func() { Define[UnknownErrorDetail]("fatal.typed", "Typed") })
+
+		assert.Equal(t, ErrCodeAlreadyRegistered, registrationErr.Code())
+		assert.Equal(t, line+1, registrationErr.Caller.Line)
+	})
+}
@@ -0,0 +1,82 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import "errors"
+
+// Fault is the SLO impact class of a code: who caused the errors, and whether they count against availability
+type Fault string
+
+const (
+	// ClientFault is for errors caused by the caller, such as invalid arguments, not counting against availability
+	ClientFault Fault = "client_fault"
+	// ServerFault is for errors caused by the service itself
+	ServerFault Fault = "server_fault"
+	// DependencyFault is for errors caused by a dependency of the service, such as an unavailable database
+	DependencyFault Fault = "dependency_fault"
+)
+
+// AffectsAvailability tells if errors of the class count against the availability of the service
+func (f Fault) AffectsAvailability() bool {
+	return f != ClientFault
+}
+
+// WithFaultClass sets the SLO impact class of the code.
+// Codes registered without it are classified by their HTTP status: 4xx statuses are client faults, 502, 503 and
+// 504 are dependency faults and everything else is a server fault.
+func WithFaultClass(class Fault) RegistrationOption {
+	return func(registry *errorCodeRegistry) {
+		registry.faultClass = class
+	}
+}
+
+// FaultClass returns the SLO impact class of the code of the first errorex in the chain of err.
+// Errors without an errorex in their chain, or with an unregistered code, are server faults, nil has no class.
+func FaultClass(err error) Fault {
+	if err == nil {
+		return ""
+	}
+	var target EX
+	if !errors.As(err, &target) {
+		return ServerFault
+	}
+	codeRegistry, ok := lookupCode(target.Code())
+	if !ok {
+		return ServerFault
+	}
+	return codeRegistry.fault()
+}
+
+// fault returns the registered fault class, derived from the HTTP status when not set
+func (r errorCodeRegistry) fault() Fault {
+	switch {
+	case r.faultClass != "":
+		return r.faultClass
+	case r.httpStatus >= 400 && r.httpStatus < 500:
+		return ClientFault
+	case r.httpStatus == 502, r.httpStatus == 503, r.httpStatus == 504: // bad gateway, unavailable, gateway timeout
+		return DependencyFault
+	}
+	return ServerFault
+}
@@ -0,0 +1,62 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultClass(t *testing.T) {
+	RegisterErrorCode("fault.invalid", "Invalid", ErrorEXDetail{}, WithHTTPStatus(400))
+	RegisterErrorCode("fault.unavailable", "Unavailable", ErrorEXDetail{}, WithHTTPStatus(503))
+	RegisterErrorCode("fault.internal", "Internal", ErrorEXDetail{})
+	RegisterErrorCode("fault.quota", "Quota", ErrorEXDetail{}, WithHTTPStatus(429), WithFaultClass(DependencyFault))
+
+	t.Run("should derive the class from the HTTP status", func(t *testing.T) {
+		assert.Equal(t, ClientFault, FaultClass(fmt.Errorf("wrapped: %w", New("fault.invalid", ErrorEXDetail{}))))
+		assert.Equal(t, DependencyFault, FaultClass(New("fault.unavailable", ErrorEXDetail{})))
+		assert.Equal(t, ServerFault, FaultClass(New("fault.internal", ErrorEXDetail{})))
+	})
+
+	t.Run("should follow the class of the code", func(t *testing.T) {
+		assert.Equal(t, DependencyFault, FaultClass(New("fault.quota", ErrorEXDetail{})))
+		info, _ := Lookup("fault.quota")
+		assert.Equal(t, DependencyFault, info.FaultClass)
+	})
+
+	t.Run("should classify other errors as server faults", func(t *testing.T) {
+		assert.Equal(t, ServerFault, FaultClass(errors.New("other")))
+		assert.Equal(t, Fault(""), FaultClass(nil))
+	})
+
+	t.Run("should exclude client faults from availability", func(t *testing.T) {
+		assert.False(t, ClientFault.AffectsAvailability())
+		assert.True(t, ServerFault.AffectsAvailability())
+		assert.True(t, DependencyFault.AffectsAvailability())
+	})
+}
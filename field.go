@@ -0,0 +1,78 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Field reads a field of the detail of the first errorex in the chain of err by its path, the dot separated JSON
+// names of the fields, e.g. "customer.id", without knowing the detail type, for generic middleware (audit,
+// masking, routing) inspecting details across codes. Map entries are read by key, and slice and array elements
+// by index, e.g. "items.0.sku". An empty path returns the whole detail.
+// It returns false when there is no errorex or the path does not lead to a field.
+func Field(err error, path string) (any, bool) {
+	target, ok := firstEX(err)
+	if !ok {
+		return nil, false
+	}
+	value := reflect.ValueOf(target.Detail())
+	if path != "" {
+		for _, segment := range strings.Split(path, ".") {
+			if value, ok = fieldOf(indirect(value), segment); !ok {
+				return nil, false
+			}
+		}
+	}
+	return valueOf(indirect(value)), true
+}
+
+// fieldOf returns the field, entry or element of the value named by a segment of a path
+func fieldOf(value reflect.Value, segment string) (reflect.Value, bool) {
+	switch value.Kind() {
+	case reflect.Struct:
+		for _, field := range DetailFields(value.Type()) {
+			if field.Name == segment {
+				// the path may go through a nil embedded pointer
+				fieldValue, err := value.FieldByIndexErr(field.Field.Index)
+				return fieldValue, err == nil
+			}
+		}
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		entry := value.MapIndex(reflect.ValueOf(segment).Convert(value.Type().Key()))
+		return entry, entry.IsValid()
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(segment)
+		if err != nil || index < 0 || index >= value.Len() {
+			return reflect.Value{}, false
+		}
+		return value.Index(index), true
+	}
+	return reflect.Value{}, false
+}
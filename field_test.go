@@ -0,0 +1,109 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fieldCustomer struct {
+	ID   string `json:"id"`
+	Tier *int   `json:"tier,omitempty"`
+}
+
+type fieldDetail struct {
+	Customer fieldCustomer     `json:"customer"`
+	Items    []string          `json:"items"`
+	Labels   map[string]string `json:"labels"`
+	Hidden   string            `json:"-"`
+}
+
+type FieldOrigin struct {
+	Host string `json:"host"`
+}
+
+type fieldEmbeddedDetail struct {
+	*FieldOrigin
+	Name string `json:"name"`
+}
+
+func TestField(t *testing.T) {
+	RegisterErrorCode("field.order", "Order failed", fieldDetail{})
+	detail := fieldDetail{
+		Customer: fieldCustomer{ID: "c-1"},
+		Items:    []string{"sku-1", "sku-2"},
+		Labels:   map[string]string{"region": "eu"},
+		Hidden:   "secret",
+	}
+	err := fmt.Errorf("ordering: %w", New("field.order", detail))
+
+	t.Run("should read the fields by JSON path", func(t *testing.T) {
+		for path, expected := range map[string]any{
+			"customer.id":   "c-1",
+			"items.1":       "sku-2",
+			"labels.region": "eu",
+			"customer":      fieldCustomer{ID: "c-1"},
+			"":              detail,
+		} {
+			value, ok := Field(err, path)
+
+			assert.True(t, ok, path)
+			assert.Equal(t, expected, value, path)
+		}
+	})
+
+	t.Run("should return nil for nil pointers", func(t *testing.T) {
+		value, ok := Field(err, "customer.tier")
+
+		assert.True(t, ok)
+		assert.Nil(t, value)
+	})
+
+	t.Run("should not find missing fields", func(t *testing.T) {
+		for _, path := range []string{"customer.name", "Hidden", "items.2", "items.x", "labels.zone", "customer.id.x"} {
+			_, ok := Field(err, path)
+
+			assert.False(t, ok, path)
+		}
+		_, ok := Field(errors.New("other"), "customer.id")
+		assert.False(t, ok)
+	})
+
+	t.Run("should read the fields of embedded pointers", func(t *testing.T) {
+		RegisterErrorCode("field.embedded", "Embedded", fieldEmbeddedDetail{})
+
+		value, ok := Field(New("field.embedded", fieldEmbeddedDetail{FieldOrigin: &FieldOrigin{Host: "db"}}), "host")
+		assert.True(t, ok)
+		assert.Equal(t, "db", value)
+
+		assert.NotPanics(t, func() {
+			_, ok = Field(New("field.embedded", fieldEmbeddedDetail{Name: "users"}), "host")
+		})
+		assert.False(t, ok)
+	})
+}
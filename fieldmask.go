@@ -0,0 +1,135 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// fieldMask is a parsed field mask, a tree of JSON object keys, a nil mask keeps the whole value
+type fieldMask map[string]fieldMask
+
+// AppendErrorFields appends the serialized form of err, like AppendError, restricted to a field mask: the paths of
+// the fields to keep, dot separated JSON names such as "code" or "detail.reason", e.g. from a ?fields= query
+// parameter of a debug endpoint or of a bandwidth-sensitive client. A path keeps the whole value of its field, and
+// its parents with only the fields masked. Paths only traverse objects, unknown paths are ignored, and an empty mask
+// keeps every field.
+func AppendErrorFields(dst []byte, err EX, fields []string) []byte {
+	mask := parseFieldMask(fields)
+	if mask == nil {
+		return AppendError(dst, err)
+	}
+	return appendMasked(dst, AppendError(nil, err), mask, true)
+}
+
+// ParseFields splits a comma separated field mask, such as the value of a ?fields= query parameter, ignoring
+// blank paths
+func ParseFields(value string) []string {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// parseFieldMask builds the tree of the paths, a path shadowing the longer paths under it
+func parseFieldMask(fields []string) fieldMask {
+	var mask fieldMask
+	for _, field := range fields {
+		if field == "" {
+			continue
+		}
+		if mask == nil {
+			mask = fieldMask{}
+		}
+		node := mask
+		segments := strings.Split(field, ".")
+		for i, segment := range segments {
+			child, ok := node[segment]
+			if ok && child == nil {
+				// a shorter path already keeps the whole value
+				break
+			}
+			if i == len(segments)-1 {
+				node[segment] = nil
+				break
+			}
+			if !ok {
+				child = fieldMask{}
+				node[segment] = child
+			}
+			node = child
+		}
+	}
+	return mask
+}
+
+// appendMasked appends the JSON value with only the masked fields of its objects, in their order, top tells if the
+// value is the serialized errorex, written with the spacing of Error. Values that are not objects are appended as
+// they are.
+func appendMasked(dst []byte, data []byte, mask fieldMask, top bool) []byte {
+	separator, colon := ",", ":"
+	if top {
+		separator, colon = ", ", ": "
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil || token != json.Delim('{') {
+		return append(dst, data...)
+	}
+	dst = append(dst, '{')
+	first := true
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			break
+		}
+		var value json.RawMessage
+		if err = decoder.Decode(&value); err != nil {
+			break
+		}
+		key, _ := token.(string)
+		child, ok := mask[key]
+		if !ok {
+			continue
+		}
+		if !first {
+			dst = append(dst, separator...)
+		}
+		first = false
+		quoted, _ := json.Marshal(key)
+		dst = append(dst, quoted...)
+		dst = append(dst, colon...)
+		if child == nil {
+			dst = append(dst, value...)
+		} else {
+			dst = appendMasked(dst, value, child, false)
+		}
+	}
+	return append(dst, '}')
+}
@@ -0,0 +1,72 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type maskedDetail struct {
+	Reason string            `json:"reason"`
+	Field  string            `json:"field"`
+	Limits map[string]int    `json:"limits"`
+	Tags   map[string]string `json:"tags,omitempty"`
+}
+
+func TestAppendErrorFields(t *testing.T) {
+	RegisterErrorCode("test.masked", "test description", maskedDetail{})
+	err := New("test.masked", maskedDetail{Reason: "too long", Field: "name", Limits: map[string]int{"max": 10, "min": 1}},
+		WithMetadata("tenant", "acme"))
+
+	t.Run("should keep only the masked fields", func(t *testing.T) {
+		masked := AppendErrorFields(nil, err, []string{"code", "detail.reason"})
+		assert.Equal(t, `{"code": "test.masked", "detail": {"reason":"too long"}}`, string(masked))
+	})
+
+	t.Run("should keep the whole value of shorter paths", func(t *testing.T) {
+		masked := AppendErrorFields(nil, err, []string{"detail.limits.max", "detail", "detail.reason"})
+		assert.Equal(t, `{"detail": {"reason":"too long","field":"name","limits":{"max":10,"min":1}}}`, string(masked))
+		masked = AppendErrorFields(nil, err, []string{"detail.limits.max", "metadata"})
+		assert.Equal(t, `{"detail": {"limits":{"max":10}}, "metadata": {"tenant":"acme"}}`, string(masked))
+	})
+
+	t.Run("should ignore unknown paths", func(t *testing.T) {
+		masked := AppendErrorFields(nil, err, []string{"code", "detail.reason.first", "missing"})
+		assert.Equal(t, `{"code": "test.masked", "detail": {"reason":"too long"}}`, string(masked))
+	})
+
+	t.Run("should keep every field without a mask", func(t *testing.T) {
+		assert.Equal(t, err.Error(), string(AppendErrorFields(nil, err, nil)))
+		assert.Equal(t, "prefix "+err.Error(), string(AppendErrorFields([]byte("prefix "), err, ParseFields(" , "))))
+	})
+}
+
+func TestParseFields(t *testing.T) {
+	t.Run("should split the paths", func(t *testing.T) {
+		assert.Equal(t, []string{"code", "detail.reason"}, ParseFields("code, detail.reason,"))
+		assert.Nil(t, ParseFields(""))
+	})
+}
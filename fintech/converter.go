@@ -0,0 +1,117 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package fintech
+
+import (
+	"errors"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// ResponseCodeTable maps the response codes of an issuer network or PSP to the codes of this package
+type ResponseCodeTable map[string]string
+
+// ISO8583 maps the response codes of the ISO 8583 field 39
+var ISO8583 = ResponseCodeTable{
+	"51": ErrCodeInsufficientFunds,
+	"33": ErrCodeCardExpired,
+	"54": ErrCodeCardExpired,
+	"34": ErrCodeFraudSuspected,
+	"41": ErrCodeFraudSuspected,
+	"43": ErrCodeFraudSuspected,
+	"59": ErrCodeFraudSuspected,
+	"61": ErrCodeLimitExceeded,
+	"65": ErrCodeLimitExceeded,
+}
+
+// ResponseCoder is implemented by the errors carrying a response code of an issuer or PSP
+type ResponseCoder interface {
+	// ResponseCode returns the response code, e.g. "51"
+	ResponseCode() string
+}
+
+// ResponseError is an error declined with a response code, for PSP adapters lacking an error type of their own
+type ResponseError struct {
+	// Code is the response code
+	Code string
+	// Message is the message of the issuer or PSP
+	Message string
+}
+
+// Error returns the response code followed by the message
+func (e *ResponseError) Error() string {
+	if e.Message == "" {
+		return "payment declined: " + e.Code
+	}
+	return "payment declined: " + e.Code + " " + e.Message
+}
+
+// ResponseCode returns the response code
+func (e *ResponseError) ResponseCode() string {
+	return e.Code
+}
+
+// responseCodeConverter converts the errors with a response code found in its table
+type responseCodeConverter struct {
+	errorex.BaseErrorConverter
+	table   ResponseCodeTable
+	extract func(err error) (string, string, bool)
+}
+
+// NewResponseCodeConverter creates a converter of the errors whose response code is in the table, e.g. ISO8583 or
+// one written for a PSP. The extract function returns the response code and message of an error and if it has
+// one; when nil, the first ResponseCoder in the chain of the error is used with its message.
+func NewResponseCodeConverter(table ResponseCodeTable, extract func(err error) (code string, message string, ok bool)) errorex.ErrorConverter {
+	if extract == nil {
+		extract = responseCode
+	}
+	return &responseCodeConverter{table: table, extract: extract}
+}
+
+// ConvertError converts the errors with a known response code, delegating the others to the next handler in the
+// chain
+func (c *responseCodeConverter) ConvertError(err error) errorex.EX {
+	responseCode, message, ok := c.extract(err)
+	if !ok {
+		return c.BaseErrorConverter.ConvertError(err)
+	}
+	code, ok := c.table[responseCode]
+	if !ok {
+		return c.BaseErrorConverter.ConvertError(err)
+	}
+	return errorex.New(code, PaymentDetail{ResponseCode: responseCode, Reason: message})
+}
+
+// responseCode extracts the response code of the first ResponseCoder in the chain of err
+func responseCode(err error) (string, string, bool) {
+	var coder ResponseCoder
+	if !errors.As(err, &coder) {
+		return "", "", false
+	}
+	var responseErr *ResponseError
+	if errors.As(err, &responseErr) {
+		return responseErr.Code, responseErr.Message, true
+	}
+	return coder.ResponseCode(), err.Error(), true
+}
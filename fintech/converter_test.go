@@ -0,0 +1,81 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package fintech
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type pspError struct {
+	code string
+}
+
+func (e pspError) Error() string {
+	return "psp: declined with " + e.code
+}
+
+func (e pspError) ResponseCode() string {
+	return e.code
+}
+
+func TestResponseCodeConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewResponseCodeConverter(ISO8583, nil))
+
+	t.Run("should convert the ISO 8583 response codes", func(t *testing.T) {
+		for responseCode, code := range map[string]string{
+			"51": ErrCodeInsufficientFunds,
+			"54": ErrCodeCardExpired,
+			"59": ErrCodeFraudSuspected,
+			"61": ErrCodeLimitExceeded,
+		} {
+			ex := converter.ConvertError(fmt.Errorf("authorize: %w", &ResponseError{Code: responseCode, Message: "declined"}))
+			assert.Equal(t, code, ex.Code())
+			assert.Equal(t, PaymentDetail{ResponseCode: responseCode, Reason: "declined"}, ex.Detail())
+		}
+	})
+
+	t.Run("should use the response coders of PSP errors", func(t *testing.T) {
+		ex := converter.ConvertError(pspError{code: "51"})
+		assert.Equal(t, ErrCodeInsufficientFunds, ex.Code())
+		assert.Equal(t, PaymentDetail{ResponseCode: "51", Reason: "psp: declined with 51"}, ex.Detail())
+	})
+
+	t.Run("should use the extract function", func(t *testing.T) {
+		table := ResponseCodeTable{"card_declined:insufficient_funds": ErrCodeInsufficientFunds}
+		converter := errorex.BuildErrorConverterChain(NewResponseCodeConverter(table, func(err error) (string, string, bool) {
+			return "card_declined:insufficient_funds", "Your card has insufficient funds.", true
+		}))
+		assert.Equal(t, ErrCodeInsufficientFunds, converter.ConvertError(errors.New("stripe")).Code())
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(&ResponseError{Code: "05"}).Code())
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(errors.New("boom")).Code())
+	})
+}
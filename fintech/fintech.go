@@ -0,0 +1,111 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package fintech provides the standard errorex codes of payment failures and a converter of the response codes of
+// ISO 8583 and payment service providers (PSP) into them:
+//
+//	converter := errorex.BuildErrorConverterChain(fintech.NewResponseCodeConverter(fintech.ISO8583, nil))
+//	if err := psp.Authorize(ctx, payment); err != nil {
+//		return converter.ConvertError(err)
+//	}
+//
+// The detail types are PCI DSS aware: card numbers are held as PAN, which never serializes more than the first six
+// and last four digits, and the response codes of suspected frauds are not projected to external sinks, so lost or
+// stolen card declines reach clients as generic declines.
+package fintech
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// ErrCodeInsufficientFunds is the errorex code for payments declined for lack of funds or credit
+	ErrCodeInsufficientFunds = "fintech.insufficient_funds"
+	// ErrCodeCardExpired is the errorex code for payments with an expired card
+	ErrCodeCardExpired = "fintech.card_expired"
+	// ErrCodeFraudSuspected is the errorex code for payments declined as suspected frauds, or with lost or stolen
+	// cards
+	ErrCodeFraudSuspected = "fintech.fraud_suspected"
+	// ErrCodeLimitExceeded is the errorex code for payments exceeding an amount or frequency limit
+	ErrCodeLimitExceeded = "fintech.limit_exceeded"
+)
+
+// PAN is a primary account number, the number of a payment card. It is masked wherever it is printed or
+// serialized, see MaskPAN.
+type PAN string
+
+// PaymentDetail is the detail of the codes of this package
+type PaymentDetail struct {
+	// ResponseCode is the response code of the issuer or PSP, e.g. "51"
+	ResponseCode string `json:"response_code,omitempty"`
+	// Reason is the message of the issuer or PSP
+	Reason string `json:"reason,omitempty"`
+	// Card is the card of the payment, masked when serialized
+	Card PAN `json:"card,omitempty"`
+}
+
+func init() {
+	// Register the errorex codes
+	errorex.RegisterErrorCode(ErrCodeInsufficientFunds, "Insufficient funds", PaymentDetail{}, errorex.WithHTTPStatus(http.StatusPaymentRequired))
+	errorex.RegisterErrorCode(ErrCodeCardExpired, "Card expired", PaymentDetail{}, errorex.WithHTTPStatus(http.StatusPaymentRequired))
+	errorex.RegisterErrorCode(ErrCodeFraudSuspected, "Payment declined", PaymentDetail{}, errorex.WithHTTPStatus(http.StatusPaymentRequired))
+	errorex.RegisterErrorCode(ErrCodeLimitExceeded, "Payment limit exceeded", PaymentDetail{}, errorex.WithHTTPStatus(http.StatusPaymentRequired))
+
+	// Do not tell clients why a payment is suspected, only keep the masked card
+	errorex.RegisterProjection(ErrCodeFraudSuspected, errorex.SinkExternal, func(detail PaymentDetail) any {
+		return PaymentDetail{Card: detail.Card}
+	})
+}
+
+// String returns the masked number
+func (p PAN) String() string {
+	return MaskPAN(string(p))
+}
+
+// MarshalText writes the masked number, used by the JSON codecs
+func (p PAN) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// MaskPAN masks a card number as allowed by PCI DSS: the first six and the last four digits are kept and the
+// others replaced by asterisks, spaces and dashes are removed. Numbers shorter than thirteen digits keep only the
+// last four, or none when shorter than eight.
+func MaskPAN(pan string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, pan)
+	switch n := len(digits); {
+	case n >= 13:
+		return digits[:6] + strings.Repeat("*", n-10) + digits[n-4:]
+	case n >= 8:
+		return strings.Repeat("*", n-4) + digits[n-4:]
+	default:
+		return strings.Repeat("*", n)
+	}
+}
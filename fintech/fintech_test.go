@@ -0,0 +1,64 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package fintech
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskPAN(t *testing.T) {
+	t.Run("should keep the first six and last four digits", func(t *testing.T) {
+		assert.Equal(t, "411111******1111", MaskPAN("4111 1111 1111 1111"))
+		assert.Equal(t, "378282*****0005", MaskPAN("3782-822463-10005"))
+	})
+
+	t.Run("should keep less digits of short numbers", func(t *testing.T) {
+		assert.Equal(t, "*****6789", MaskPAN("123456789"))
+		assert.Equal(t, "****", MaskPAN("1234"))
+	})
+}
+
+func TestPAN(t *testing.T) {
+	t.Run("should never print or serialize the full number", func(t *testing.T) {
+		detail := PaymentDetail{ResponseCode: "51", Card: "4111111111111111"}
+		data, err := json.Marshal(detail)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"response_code":"51","card":"411111******1111"}`, string(data))
+		assert.Equal(t, "411111******1111", fmt.Sprint(detail.Card))
+		assert.Equal(t, "{51  411111******1111}", fmt.Sprintf("%v", detail))
+	})
+}
+
+func TestProjection(t *testing.T) {
+	t.Run("should hide the response code of suspected frauds from external sinks", func(t *testing.T) {
+		ex := errorex.New(ErrCodeFraudSuspected, PaymentDetail{ResponseCode: "43", Reason: "stolen card", Card: "4111111111111111"})
+		assert.Equal(t, PaymentDetail{Card: "4111111111111111"}, errorex.Project(ex, errorex.SinkExternal))
+		assert.Equal(t, ex.Detail(), errorex.Project(ex, errorex.SinkLog))
+	})
+}
@@ -0,0 +1,171 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package graph exports the tree of an error (wrapped errors, errors.Join groups and the errorex errors in them)
+// as a Graphviz DOT or a Mermaid graph, for incident reports and debugging UIs:
+//
+//	fmt.Println(graph.ToMermaid(err))
+//
+// Errorex nodes show their code and detail, other nodes their type and message. Labels longer than
+// MaxLabelLength are truncated so large details do not take over the graph.
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// MaxLabelLength is the number of characters of a label line after which it is truncated
+	MaxLabelLength = 80
+	// MaxDepth is the depth of the tree after which wrapped errors are not followed
+	MaxDepth = 32
+)
+
+// node is an error of the tree
+type node struct {
+	title string
+	text  string
+	ex    bool
+}
+
+// tree is the flattened error tree, edges go from a parent index to a child index
+type tree struct {
+	nodes []node
+	edges [][2]int
+}
+
+// ToDOT returns the error tree as a Graphviz DOT digraph, empty for nil errors
+func ToDOT(err error) string {
+	if err == nil {
+		return ""
+	}
+	t := build(err)
+	var b strings.Builder
+	b.WriteString("digraph errors {\n")
+	b.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+	for i, n := range t.nodes {
+		fmt.Fprintf(&b, "  n%d [label=\"%s\\n%s\"", i, escapeDOT(n.title), escapeDOT(n.text))
+		if n.ex {
+			b.WriteString(", style=bold")
+		}
+		b.WriteString("];\n")
+	}
+	for _, edge := range t.edges {
+		fmt.Fprintf(&b, "  n%d -> n%d;\n", edge[0], edge[1])
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// ToMermaid returns the error tree as a Mermaid flowchart, empty for nil errors
+func ToMermaid(err error) string {
+	if err == nil {
+		return ""
+	}
+	t := build(err)
+	var b strings.Builder
+	b.WriteString("graph TD\n")
+	for i, n := range t.nodes {
+		fmt.Fprintf(&b, "  n%d[\"%s<br/>%s\"]\n", i, escapeMermaid(n.title), escapeMermaid(n.text))
+	}
+	for _, edge := range t.edges {
+		fmt.Fprintf(&b, "  n%d --> n%d\n", edge[0], edge[1])
+	}
+	for i, n := range t.nodes {
+		if n.ex {
+			fmt.Fprintf(&b, "  style n%d stroke-width:3px\n", i)
+		}
+	}
+	return b.String()
+}
+
+// build flattens the error tree
+func build(err error) *tree {
+	t := &tree{}
+	t.add(err, 0, make(map[error]bool))
+	return t
+}
+
+// add appends the error and its wrapped errors, returning the index of its node
+func (t *tree) add(err error, depth int, visiting map[error]bool) int {
+	index := len(t.nodes)
+	t.nodes = append(t.nodes, describe(err))
+	if depth >= MaxDepth {
+		return index
+	}
+	if reflect.TypeOf(err).Comparable() {
+		if visiting[err] {
+			return index
+		}
+		visiting[err] = true
+		defer delete(visiting, err)
+	}
+	var children []error
+	switch unwrapper := err.(type) {
+	case interface{ Unwrap() []error }:
+		children = unwrapper.Unwrap()
+	case interface{ Unwrap() error }:
+		children = []error{unwrapper.Unwrap()}
+	}
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		t.edges = append(t.edges, [2]int{index, len(t.nodes)})
+		t.add(child, depth+1, visiting)
+	}
+	return index
+}
+
+// describe returns the node of an error
+func describe(err error) node {
+	if ex, ok := err.(errorex.EX); ok {
+		detail, marshalErr := errorex.GetJSONCodec().Marshal(ex.Detail())
+		if marshalErr != nil {
+			detail = []byte(marshalErr.Error())
+		}
+		return node{title: ex.Code(), text: truncate(string(detail)), ex: true}
+	}
+	return node{title: fmt.Sprintf("%T", err), text: truncate(err.Error())}
+}
+
+// truncate shortens a label line to MaxLabelLength characters
+func truncate(text string) string {
+	runes := []rune(text)
+	if len(runes) <= MaxLabelLength {
+		return text
+	}
+	return string(runes[:MaxLabelLength-1]) + "…"
+}
+
+func escapeDOT(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text)
+}
+
+func escapeMermaid(text string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", "<br/>").Replace(text)
+}
@@ -0,0 +1,95 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type orderDetail struct {
+	Order string `json:"order"`
+}
+
+// cyclicError wraps itself, as buggy error types may do
+type cyclicError struct{}
+
+func (e *cyclicError) Error() string { return "cyclic" }
+func (e *cyclicError) Unwrap() error { return e }
+
+func init() {
+	errorex.RegisterErrorCode("graph.declined", "Declined", orderDetail{})
+}
+
+func TestToDOT(t *testing.T) {
+	t.Run("should export the error tree", func(t *testing.T) {
+		err := fmt.Errorf("checkout: %w", errors.Join(errorex.New("graph.declined", orderDetail{Order: "42"}), errors.New(`timeout "db"`)))
+
+		assert.Equal(t, `digraph errors {
+  node [shape=box, fontname="monospace"];
+  n0 [label="*fmt.wrapError\ncheckout: {\"code\": \"graph.declined\", \"detail\": {\"order\":\"42\"}}\ntimeout \"db\""];
+  n1 [label="*errors.joinError\n{\"code\": \"graph.declined\", \"detail\": {\"order\":\"42\"}}\ntimeout \"db\""];
+  n2 [label="graph.declined\n{\"order\":\"42\"}", style=bold];
+  n3 [label="*errors.errorString\ntimeout \"db\""];
+  n0 -> n1;
+  n1 -> n2;
+  n1 -> n3;
+}
+`, ToDOT(err))
+	})
+
+	t.Run("should return nothing for nil errors", func(t *testing.T) {
+		assert.Empty(t, ToDOT(nil))
+		assert.Empty(t, ToMermaid(nil))
+	})
+}
+
+func TestToMermaid(t *testing.T) {
+	t.Run("should export the error tree", func(t *testing.T) {
+		err := fmt.Errorf("checkout: %w", errorex.New("graph.declined", orderDetail{Order: "42"}))
+
+		assert.Equal(t, `graph TD
+  n0["*fmt.wrapError<br/>checkout: {#quot;code#quot;: #quot;graph.declined#quot;, #quot;detail#quot;: {#quot;order#quot;:#quot;42#quot;}}"]
+  n1["graph.declined<br/>{#quot;order#quot;:#quot;42#quot;}"]
+  n0 --> n1
+  style n1 stroke-width:3px
+`, ToMermaid(err))
+	})
+
+	t.Run("should truncate large details", func(t *testing.T) {
+		mermaid := ToMermaid(errorex.New("graph.declined", orderDetail{Order: strings.Repeat("x", 200)}))
+
+		assert.Contains(t, mermaid, "…")
+		assert.NotContains(t, mermaid, strings.Repeat("x", MaxLabelLength))
+	})
+
+	t.Run("should stop on cycles", func(t *testing.T) {
+		assert.Equal(t, 2, strings.Count(ToMermaid(&cyclicError{}), "*graph.cyclicError"))
+	})
+}
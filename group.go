@@ -0,0 +1,83 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+// EXGroup is an error aggregating errorex errors, such as the failures of fan-out work or the members of errors
+// joined with errors.Join converted by a chain. It serializes as a JSON array of the errors and unwraps to them, so
+// errors.Is finds each one. It is an errorex itself, of code ErrCodeGroup, so errors.As with an EX target finds the
+// group.
+type EXGroup []EX
+
+// ErrorEXGroupDetail is the detail of ErrCodeGroup, the codes of the errors of the group in order
+type ErrorEXGroupDetail struct {
+	Codes []string `json:"codes"`
+}
+
+// Error returns the errors serialized as a JSON array
+func (g EXGroup) Error() string {
+	data := []byte{'['}
+	for i, ex := range g {
+		if i > 0 {
+			data = append(data, ',')
+		}
+		data = AppendError(data, ex)
+	}
+	return string(append(data, ']'))
+}
+
+// Code returns ErrCodeGroup
+func (g EXGroup) Code() string {
+	return ErrCodeGroup
+}
+
+// Detail returns the codes of the errors of the group
+func (g EXGroup) Detail() any {
+	codes := make([]string, len(g))
+	for i, ex := range g {
+		codes[i] = ex.Code()
+	}
+	return ErrorEXGroupDetail{Codes: codes}
+}
+
+// IsCode checks if the code is ErrCodeGroup, the errors of the group are checked ranging over it
+func (g EXGroup) IsCode(code string) bool {
+	return canonicalCode(code) == ErrCodeGroup
+}
+
+// Unwrap returns the errors of the group
+func (g EXGroup) Unwrap() []error {
+	errs := make([]error, len(g))
+	for i, ex := range g {
+		errs[i] = ex
+	}
+	return errs
+}
+
+// Err returns the group as an error, nil when it is empty
+func (g EXGroup) Err() error {
+	if len(g) == 0 {
+		return nil
+	}
+	return g
+}
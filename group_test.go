@@ -0,0 +1,123 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEXGroup(t *testing.T) {
+	first := New(ErrCodeNotRegistered, ErrorEXDetail{Code: "a"})
+	second := New(ErrCodeUnknownError, UnknownErrorDetail{Detail: "b"})
+	group := EXGroup{first, second}
+
+	t.Run("should serialize the errors as an array", func(t *testing.T) {
+		assert.Equal(t, `[{"code": "errorex.001", "detail": {"code":"a"}},{"code": "errorex.000", "detail": {"detail":"b"}}]`, group.Error())
+	})
+
+	t.Run("should unwrap to the errors", func(t *testing.T) {
+		assert.True(t, errors.Is(group, second))
+		var target EXGroup
+		assert.True(t, errors.As(group.Err(), &target))
+		assert.Len(t, target, 2)
+	})
+
+	t.Run("should return a nil error when empty", func(t *testing.T) {
+		assert.NoError(t, EXGroup{}.Err())
+	})
+
+	t.Run("should be an errorex of the group code", func(t *testing.T) {
+		var target EX
+		assert.True(t, errors.As(fmt.Errorf("fan-out: %w", group), &target))
+		assert.Equal(t, ErrCodeGroup, target.Code())
+		assert.Equal(t, ErrorEXGroupDetail{Codes: []string{ErrCodeNotRegistered, ErrCodeUnknownError}}, target.Detail())
+		matcher, ok := target.(CodeMatcher)
+		assert.True(t, ok)
+		assert.True(t, matcher.IsCode(ErrCodeGroup))
+		assert.False(t, matcher.IsCode(ErrCodeNotRegistered))
+	})
+
+	t.Run("should match Is like IsCode", func(t *testing.T) {
+		assert.True(t, Is(group, ErrCodeGroup))
+		assert.False(t, Is(group, ErrCodeNotRegistered))
+	})
+}
+
+// messageConverter converts the errors with its message into test.joined errors
+type messageConverter struct {
+	BaseErrorConverter
+	message string
+}
+
+func (c *messageConverter) ConvertError(err error) EX {
+	if err.Error() != c.message {
+		return c.BaseErrorConverter.ConvertError(err)
+	}
+	return New("test.joined", ErrorEXDetail{Code: c.message})
+}
+
+func TestJoinedErrorConverter(t *testing.T) {
+	RegisterErrorCode("test.joined", "test description", ErrorEXDetail{})
+	RegisterErrorCode("test.joined.existing", "test description", ErrorEXDetail{})
+	chain := BuildErrorConverterChain(&messageConverter{message: "known"}, &messageConverter{message: "other known"})
+	known := errors.New("known")
+	otherKnown := errors.New("other known")
+
+	t.Run("should group the conversions of the members", func(t *testing.T) {
+		converted := chain.ConvertError(errors.Join(known, errors.New("boom"), otherKnown))
+		group, ok := converted.(EXGroup)
+		assert.True(t, ok)
+		assert.Len(t, group, 3)
+		assert.Equal(t, "test.joined", group[0].Code())
+		assert.Equal(t, ErrCodeUnknownError, group[1].Code())
+		assert.Equal(t, ErrorEXDetail{Code: "other known"}, group[2].Detail())
+	})
+
+	t.Run("should preserve the branches", func(t *testing.T) {
+		existing := New("test.joined.existing", ErrorEXDetail{})
+		converted := chain.ConvertError(errors.Join(existing, errors.Join(known, otherKnown)))
+		group := converted.(EXGroup)
+		assert.Equal(t, existing, group[0])
+		assert.Len(t, group[1].(EXGroup), 2)
+	})
+
+	t.Run("should return the only conversion", func(t *testing.T) {
+		converted := chain.ConvertError(errors.Join(errors.New("boom"), known))
+		assert.Equal(t, "test.joined", converted.Code())
+	})
+
+	t.Run("should convert the joined error as a whole when no member converts", func(t *testing.T) {
+		converted := chain.ConvertError(errors.Join(errors.New("boom"), errors.New("bang")))
+		assert.Equal(t, UnknownErrorDetail{Detail: "boom\nbang"}, converted.Detail())
+	})
+
+	t.Run("should convert errors wrapped with fmt as a whole", func(t *testing.T) {
+		converted := chain.ConvertError(fmt.Errorf("%w: %w", known, otherKnown))
+		assert.Equal(t, ErrCodeUnknownError, converted.Code())
+	})
+}
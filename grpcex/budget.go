@@ -0,0 +1,112 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package grpcex
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/fkmatsuda/errorex"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// TrailerErrorCount is the trailer set by the error budget interceptors to the number of errors of the call
+	TrailerErrorCount = "x-error-count"
+	// TrailerErrorCodes is the trailer set by the error budget interceptors to the distinct codes of the errors of
+	// the call, sorted and separated by commas
+	TrailerErrorCodes = "x-error-codes"
+)
+
+// UnaryErrorBudget returns an interceptor counting the errorex errors of each call, for canary analysis and
+// debugging partial failures. The call context carries an errorex.Collector, fed by errorex.Collect and by the
+// error returned by the handler, and the summary is set in the TrailerErrorCount and TrailerErrorCodes trailers
+// of calls with errors.
+func UnaryErrorBudget() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		collecting, collector := errorex.WithCollector(ctx)
+		response, err := handler(collecting, request)
+		collectReturned(collector, err)
+		if trailer, ok := summary(collector); ok {
+			_ = grpc.SetTrailer(ctx, trailer)
+		}
+		return response, err
+	}
+}
+
+// StreamErrorBudget returns the stream interceptor counterpart of UnaryErrorBudget
+func StreamErrorBudget() grpc.StreamServerInterceptor {
+	return func(server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, collector := errorex.WithCollector(stream.Context())
+		err := handler(server, &collectingStream{ServerStream: stream, ctx: ctx})
+		collectReturned(collector, err)
+		if trailer, ok := summary(collector); ok {
+			stream.SetTrailer(trailer)
+		}
+		return err
+	}
+}
+
+// collectingStream is a server stream whose context carries the Collector
+type collectingStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context carrying the Collector
+func (s *collectingStream) Context() context.Context {
+	return s.ctx
+}
+
+// collectReturned records the error returned by a handler, parsing the errorex of statuses created by Status
+func collectReturned(collector *errorex.Collector, err error) {
+	if err == nil {
+		return
+	}
+	var target errorex.EX
+	if !errors.As(err, &target) {
+		if st, isStatus := status.FromError(err); isStatus {
+			if ex, parseErr := errorex.ParseJSON([]byte(st.Message())); parseErr == nil {
+				err = ex
+			}
+		}
+	}
+	collector.Add(err)
+}
+
+// summary returns the trailer summarizing the errors of the call, false when there are none
+func summary(collector *errorex.Collector) (metadata.MD, bool) {
+	count := collector.Count()
+	if count == 0 {
+		return nil, false
+	}
+	return metadata.Pairs(
+		TrailerErrorCount, strconv.Itoa(count),
+		TrailerErrorCodes, strings.Join(collector.Codes(), ","),
+	), true
+}
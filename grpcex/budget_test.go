@@ -0,0 +1,102 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package grpcex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// transportStream records the trailers set by the interceptors
+type transportStream struct {
+	trailer metadata.MD
+}
+
+func (s *transportStream) Method() string                  { return "/test.Service/Method" }
+func (s *transportStream) SetHeader(md metadata.MD) error  { return nil }
+func (s *transportStream) SendHeader(md metadata.MD) error { return nil }
+func (s *transportStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+// serverStream is a server stream recording its trailers
+type serverStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	trailer metadata.MD
+}
+
+func (s *serverStream) Context() context.Context  { return s.ctx }
+func (s *serverStream) SetTrailer(md metadata.MD) { s.trailer = metadata.Join(s.trailer, md) }
+
+func TestUnaryErrorBudget(t *testing.T) {
+	interceptor := UnaryErrorBudget()
+
+	t.Run("should summarize the errors of the call", func(t *testing.T) {
+		stream := &transportStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, request any) (any, error) {
+			errorex.Collect(ctx, errorex.New("grpcex.unavailable", errorex.ErrorEXDetail{}))
+			return nil, Status(errorex.New("grpcex.not_found", errorex.ErrorEXDetail{})).Err()
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, []string{"2"}, stream.trailer.Get(TrailerErrorCount))
+		assert.Equal(t, []string{"grpcex.not_found,grpcex.unavailable"}, stream.trailer.Get(TrailerErrorCodes))
+	})
+
+	t.Run("should leave out the trailers without errors", func(t *testing.T) {
+		stream := &transportStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, request any) (any, error) {
+			return "ok", nil
+		})
+
+		assert.NoError(t, err)
+		assert.Empty(t, stream.trailer)
+	})
+}
+
+func TestStreamErrorBudget(t *testing.T) {
+	t.Run("should summarize the errors of the stream", func(t *testing.T) {
+		stream := &serverStream{ctx: context.Background()}
+
+		err := StreamErrorBudget()(nil, stream, &grpc.StreamServerInfo{}, func(server any, stream grpc.ServerStream) error {
+			errorex.Collect(stream.Context(), errorex.New("grpcex.unavailable", errorex.ErrorEXDetail{}))
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1"}, stream.trailer.Get(TrailerErrorCount))
+		assert.Equal(t, []string{"grpcex.unavailable"}, stream.trailer.Get(TrailerErrorCodes))
+	})
+}
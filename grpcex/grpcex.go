@@ -0,0 +1,99 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package grpcex converts errorex errors into gRPC statuses, with the code mapped with errorex.WithGRPCCode,
+// the serialized errorex as message and the standard ErrorInfo and RetryInfo details:
+//
+//	func (s *server) Charge(ctx context.Context, request *pb.ChargeRequest) (*pb.ChargeResponse, error) {
+//		response, err := s.service.Charge(ctx, request)
+//		if err != nil {
+//			return nil, grpcex.Status(err).Err()
+//		}
+//		return response, nil
+//	}
+package grpcex
+
+import (
+	"errors"
+
+	"github.com/fkmatsuda/errorex"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// DefaultCode is the gRPC code of errors whose code has no gRPC code mapped
+	DefaultCode = codes.Unknown
+	// Domain is the domain of the ErrorInfo details
+	Domain = "errorex"
+)
+
+var defaultConverter = errorex.BuildErrorConverterChain()
+
+// Code returns the gRPC code mapped to the code of the first errorex in the chain of err, DefaultCode when there
+// is none or the code has no gRPC code mapped. Codes not registered locally are resolved with errorex.Resolve.
+func Code(err error) codes.Code {
+	var ex errorex.EX
+	if !errors.As(err, &ex) {
+		return DefaultCode
+	}
+	if info, ok := errorex.Resolve(ex.Code()); ok && info.GRPCCode != 0 {
+		return codes.Code(info.GRPCCode)
+	}
+	return DefaultCode
+}
+
+// Status converts err into a gRPC status. Errors that are not errorex errors are converted as
+// errorex.ErrCodeUnknownError. The message is the serialized errorex, which FromStatus parses back, and the
+// details hold an ErrorInfo with the code as reason and, when the error carries a hint set with
+// errorex.WithRetryAfter, a RetryInfo. The message crosses the trust boundary like errorex.Boundary: details hidden
+// by the severity policy are left out, projections for errorex.SinkExternal are applied and the causes, stack trace
+// and metadata are stripped.
+func Status(err error) *status.Status {
+	var ex errorex.EX
+	if !errors.As(err, &ex) {
+		ex = defaultConverter.ConvertError(err)
+	}
+	st := status.New(Code(ex), string(errorex.AppendError(nil, errorex.Boundary(ex))))
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: ex.Code(), Domain: Domain}}
+	if delay, ok := errorex.RetryAfter(ex); ok {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	}
+	if withDetails, detailsErr := st.WithDetails(details...); detailsErr == nil {
+		return withDetails
+	}
+	return st
+}
+
+// FromStatus parses the errorex carried in the message of a status created by Status, e.g. returned by a client
+// call, see errorex.ParseJSON. The errorex is stamped with the hop of the service, see errorex.Received.
+func FromStatus(st *status.Status) (errorex.EX, error) {
+	ex, err := errorex.ParseJSON([]byte(st.Message()))
+	if err != nil {
+		return nil, err
+	}
+	return errorex.Received(ex), nil
+}
@@ -0,0 +1,120 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package grpcex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	errorex.RegisterErrorCode("grpcex.not_found", "Not found", errorex.ErrorEXDetail{}, errorex.WithGRPCCode(uint32(codes.NotFound)))
+	errorex.RegisterErrorCode("grpcex.unavailable", "Unavailable", errorex.ErrorEXDetail{}, errorex.WithGRPCCode(uint32(codes.Unavailable)))
+}
+
+func TestCode(t *testing.T) {
+	t.Run("should return the mapped code", func(t *testing.T) {
+		assert.Equal(t, codes.NotFound, Code(fmt.Errorf("loading: %w", errorex.New("grpcex.not_found", errorex.ErrorEXDetail{}))))
+	})
+
+	t.Run("should default to unknown", func(t *testing.T) {
+		assert.Equal(t, DefaultCode, Code(errorex.New(errorex.ErrCodeUnknownError, errorex.UnknownErrorDetail{})))
+		assert.Equal(t, DefaultCode, Code(errors.New("other")))
+	})
+}
+
+func TestStatus(t *testing.T) {
+	t.Run("should convert the errorex", func(t *testing.T) {
+		st := Status(errorex.New("grpcex.not_found", errorex.ErrorEXDetail{Code: "user"}))
+
+		assert.Equal(t, codes.NotFound, st.Code())
+		assert.Equal(t, `{"code": "grpcex.not_found", "detail": {"code":"user"}}`, st.Message())
+		assert.Len(t, st.Details(), 1)
+		info := st.Details()[0].(*errdetails.ErrorInfo)
+		assert.Equal(t, "grpcex.not_found", info.GetReason())
+		assert.Equal(t, Domain, info.GetDomain())
+	})
+
+	t.Run("should strip the causes, stack trace and metadata", func(t *testing.T) {
+		errorex.SetStackConfig(errorex.StackConfig{Enabled: true})
+		defer errorex.SetStackConfig(errorex.StackConfig{})
+		st := Status(errorex.New("grpcex.not_found", errorex.ErrorEXDetail{Code: "user"},
+			errorex.WithCause(errors.New("pq: password authentication failed for user admin")),
+			errorex.WithMetadata("db_host", "10.0.0.4")))
+
+		assert.Equal(t, `{"code": "grpcex.not_found", "detail": {"code":"user"}}`, st.Message())
+	})
+
+	t.Run("should map the retry hint to RetryInfo", func(t *testing.T) {
+		st := Status(errorex.New("grpcex.unavailable", errorex.ErrorEXDetail{}, errorex.WithRetryAfter(2*time.Second)))
+
+		assert.Equal(t, codes.Unavailable, st.Code())
+		assert.Len(t, st.Details(), 2)
+		assert.Equal(t, 2*time.Second, st.Details()[1].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+	})
+
+	t.Run("should convert other errors as unknown", func(t *testing.T) {
+		st := Status(errors.New("boom"))
+
+		assert.Equal(t, codes.Unknown, st.Code())
+		assert.Equal(t, `{"code": "errorex.000", "detail": {"detail":"boom"}}`, st.Message())
+	})
+}
+
+func TestFromStatus(t *testing.T) {
+	t.Run("should parse the errorex of the status", func(t *testing.T) {
+		ex, err := FromStatus(Status(errorex.New("grpcex.unavailable", errorex.ErrorEXDetail{Code: "db"}, errorex.WithRetryAfter(time.Second))))
+
+		assert.NoError(t, err)
+		assert.True(t, errorex.Is(ex, "grpcex.unavailable"))
+		assert.Equal(t, errorex.ErrorEXDetail{Code: "db"}, ex.Detail())
+		delay, _ := errorex.RetryAfter(ex)
+		assert.Equal(t, time.Second, delay)
+	})
+
+	t.Run("should stamp the hop of the service", func(t *testing.T) {
+		errorex.SetServiceName("gateway")
+		defer errorex.SetServiceName("")
+
+		ex, err := FromStatus(Status(errorex.New("grpcex.unavailable", errorex.ErrorEXDetail{})))
+
+		assert.NoError(t, err)
+		hops := errorex.Hops(ex)
+		assert.Len(t, hops, 1)
+		assert.Equal(t, "gateway", hops[0].Service)
+	})
+
+	t.Run("should fail on statuses from other servers", func(t *testing.T) {
+		_, err := FromStatus(status.New(codes.Internal, "internal error"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,56 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+// defaultChain converts the errors of Infer, Guard and Must when no chain is given
+var defaultChain = BuildErrorConverterChain()
+
+// Guard calls fn and converts the error it returns through the chain at an API boundary, so service layers return
+// errorex errors without converting at every call:
+//
+//	user, ex := errorex.Guard(func() (User, error) { return repository.Find(ctx, id) }, converter)
+//
+// The default chain (BuildErrorConverterChain without converters) is used when chain is nil. The whole error goes
+// through the chain, so its converters see wrapped errorex errors too, unlike Infer. The value returned by fn is
+// returned as is, along with a nil EX when fn succeeded.
+func Guard[T any](fn func() (T, error), chain ErrorConverter) (T, EX) {
+	value, err := fn()
+	if err == nil {
+		return value, nil
+	}
+	if chain == nil {
+		chain = defaultChain
+	}
+	return value, chain.ConvertError(err)
+}
+
+// Must calls fn like Guard and panics with the converted errorex when it fails, for initialization code and
+// handlers recovering errorex panics
+func Must[T any](fn func() (T, error), chain ErrorConverter) T {
+	value, ex := Guard(fn, chain)
+	if ex != nil {
+		panic(ex)
+	}
+	return value
+}
@@ -0,0 +1,94 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// repositoryTestConverter converts the errors of the repository, errorex errors they wrap included
+type repositoryTestConverter struct {
+	BaseErrorConverter
+}
+
+func (c *repositoryTestConverter) ConvertError(err error) EX {
+	if strings.HasPrefix(err.Error(), "repository: ") {
+		return New(ErrCodeNotRegistered, ErrorEXDetail{Code: "repository"})
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
+
+func TestGuard(t *testing.T) {
+	t.Run("should return the value of successful calls", func(t *testing.T) {
+		value, ex := Guard(func() (int, error) { return 42, nil }, nil)
+
+		assert.Equal(t, 42, value)
+		assert.Nil(t, ex)
+	})
+
+	t.Run("should convert the errors through the chain", func(t *testing.T) {
+		chain := BuildErrorConverterChain(&tracedTestConverter{})
+
+		_, ex := Guard(func() (int, error) { return 0, errors.New("traced") }, chain)
+
+		assert.True(t, Is(ex, ErrCodeNotRegistered))
+	})
+
+	t.Run("should pass wrapped errorex errors through the chain", func(t *testing.T) {
+		chain := BuildErrorConverterChain(&repositoryTestConverter{})
+		wrapped := fmt.Errorf("repository: %w", New(ErrCodeUnknownError, UnknownErrorDetail{}))
+
+		_, ex := Guard(func() (int, error) { return 0, wrapped }, chain)
+
+		assert.Equal(t, ErrorEXDetail{Code: "repository"}, ex.Detail())
+	})
+
+	t.Run("should convert with the default chain", func(t *testing.T) {
+		_, ex := Guard(func() (string, error) { return "", errors.New("boom") }, nil)
+
+		assert.True(t, Is(ex, ErrCodeUnknownError))
+		assert.Equal(t, UnknownErrorDetail{Detail: "boom"}, ex.Detail())
+	})
+}
+
+func TestMust(t *testing.T) {
+	t.Run("should return the value of successful calls", func(t *testing.T) {
+		assert.Equal(t, "ok", Must(func() (string, error) { return "ok", nil }, nil))
+	})
+
+	t.Run("should panic with the converted errors", func(t *testing.T) {
+		defer func() {
+			ex, ok := recover().(EX)
+			assert.True(t, ok)
+			assert.True(t, Is(ex, ErrCodeUnknownError))
+		}()
+
+		Must(func() (string, error) { return "", errors.New("boom") }, nil)
+	})
+}
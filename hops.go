@@ -0,0 +1,102 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// MetadataHops is the metadata key listing the services an errorex was received by, stamped by Received
+	MetadataHops = "hops"
+	// HopSeparator separates the hops in MetadataHops
+	HopSeparator = ","
+)
+
+// Hop is a service an errorex was received by on its way through the distributed system
+type Hop struct {
+	// Service is the name set with SetServiceName by the receiving service
+	Service string
+	// Time is when the errorex was received
+	Time time.Time
+}
+
+var serviceName atomic.Pointer[string]
+
+// SetServiceName sets the identity of the service, stamped by Received on the errorex errors decoded from remote
+// responses (grpcex.FromStatus, httpex.FromResponse, mq.DecodeEnvelope, ...). Empty, the default, disables the
+// stamping.
+func SetServiceName(name string) {
+	serviceName.Store(&name)
+}
+
+// GetServiceName returns the identity set with SetServiceName
+func GetServiceName() string {
+	if name := serviceName.Load(); name != nil {
+		return *name
+	}
+	return ""
+}
+
+// Received returns a copy of the errorex decoded from a remote response with the service appended to its hops,
+// see Hops. It returns the errorex as is when no service name is set or when it was not created by this package.
+func Received(err EX) EX {
+	name := GetServiceName()
+	e, ok := err.(*ex)
+	if name == "" || !ok {
+		return err
+	}
+	hop := name + "@" + now().UTC().Format(time.RFC3339Nano)
+	if hops := e.metadata[MetadataHops]; hops != "" {
+		hop = hops + HopSeparator + hop
+	}
+	received := e.clone()
+	applyOptions(received, []Option{WithMetadata(MetadataHops, hop)})
+	return received
+}
+
+// Hops returns the services the errorex was received by, in order, as stamped by Received. Malformed hops are
+// skipped.
+func Hops(err error) []Hop {
+	metadata, _ := Metadata(err)
+	stamped := metadata[MetadataHops]
+	if stamped == "" {
+		return nil
+	}
+	var hops []Hop
+	for _, stamp := range strings.Split(stamped, HopSeparator) {
+		at := strings.LastIndexByte(stamp, '@')
+		if at < 0 {
+			continue
+		}
+		received, parseErr := time.Parse(time.RFC3339Nano, stamp[at+1:])
+		if parseErr != nil {
+			continue
+		}
+		hops = append(hops, Hop{Service: stamp[:at], Time: received})
+	}
+	return hops
+}
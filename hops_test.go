@@ -0,0 +1,63 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceived(t *testing.T) {
+	received := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return received })
+	defer SetClock(nil)
+	defer SetServiceName("")
+	original := New(ErrCodeNotRegistered, ErrorEXDetail{})
+
+	t.Run("should not stamp without a service name", func(t *testing.T) {
+		assert.Same(t, original, Received(original))
+	})
+
+	t.Run("should append the hops", func(t *testing.T) {
+		SetServiceName("orders")
+		first := Received(original)
+		SetServiceName("gateway")
+		second := Received(first)
+
+		assert.Equal(t, []Hop{{Service: "orders", Time: received}, {Service: "gateway", Time: received}}, Hops(second))
+		assert.Equal(t, "orders@2024-01-01T12:00:00Z,gateway@2024-01-01T12:00:00Z", second.(*ex).metadata[MetadataHops])
+		assert.Nil(t, Hops(original))
+	})
+
+	t.Run("should survive serialization", func(t *testing.T) {
+		SetServiceName("orders")
+
+		parsed, err := ParseJSON([]byte(Received(original).Error()))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Hop{{Service: "orders", Time: received}}, Hops(parsed))
+	})
+}
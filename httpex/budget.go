@@ -0,0 +1,101 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package httpex
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// HeaderErrorCount is the header set by ErrorBudget to the number of errors of the request
+	HeaderErrorCount = "X-Error-Count"
+	// HeaderErrorCodes is the header set by ErrorBudget to the distinct codes of the errors of the request, sorted
+	// and separated by commas
+	HeaderErrorCodes = "X-Error-Codes"
+)
+
+// ErrorBudget is a middleware counting the errorex errors of each request, for canary analysis and debugging
+// partial failures. The request context carries an errorex.Collector, fed by errorex.Collect and by the errors
+// written with WriteError and HTMLRenderer.WriteError, and the summary is set in the HeaderErrorCount and
+// HeaderErrorCodes headers of responses with errors, before the status is written.
+func ErrorBudget(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, collector := errorex.WithCollector(r.Context())
+		writer := &budgetWriter{ResponseWriter: w, collector: collector}
+		next.ServeHTTP(writer, r.WithContext(ctx))
+		if !writer.wroteHeader {
+			writer.summarize()
+		}
+	})
+}
+
+// budgetWriter sets the error summary of the request before the status is written
+type budgetWriter struct {
+	http.ResponseWriter
+	collector   *errorex.Collector
+	wroteHeader bool
+}
+
+// WriteHeader sets the error summary and writes the status
+func (w *budgetWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.summarize()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the body, writing the status first when it was not
+func (w *budgetWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (w *budgetWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// summarize sets the headers of the error summary when the request had errors
+func (w *budgetWriter) summarize() {
+	count := w.collector.Count()
+	if count == 0 {
+		return
+	}
+	w.Header().Set(HeaderErrorCount, strconv.Itoa(count))
+	w.Header().Set(HeaderErrorCodes, strings.Join(w.collector.Codes(), ","))
+}
+
+// collect records the errorex in the Collector of the ErrorBudget wrapping the writer, if any
+func collect(w http.ResponseWriter, ex errorex.EX) {
+	if writer, ok := w.(*budgetWriter); ok {
+		writer.collector.Add(ex)
+	}
+}
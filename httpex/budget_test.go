@@ -0,0 +1,74 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package httpex
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorBudget(t *testing.T) {
+	t.Run("should summarize the errors of the request", func(t *testing.T) {
+		handler := ErrorBudget(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			errorex.Collect(r.Context(), errorex.New(errorex.ErrCodeUnknownError, errorex.UnknownErrorDetail{}))
+			errorex.Collect(r.Context(), errorex.New("httpex.not_found", errorex.ErrorEXDetail{}))
+			WriteError(w, errorex.New("httpex.not_found", errorex.ErrorEXDetail{}))
+		}))
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, "3", recorder.Header().Get(HeaderErrorCount))
+		assert.Equal(t, "errorex.000,httpex.not_found", recorder.Header().Get(HeaderErrorCodes))
+	})
+
+	t.Run("should summarize partial failures of successful responses", func(t *testing.T) {
+		handler := ErrorBudget(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			errorex.Collect(r.Context(), errorex.New("httpex.not_found", errorex.ErrorEXDetail{}))
+			_, _ = w.Write([]byte("partial"))
+		}))
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "1", recorder.Header().Get(HeaderErrorCount))
+		assert.Equal(t, "partial", recorder.Body.String())
+	})
+
+	t.Run("should leave out the headers without errors", func(t *testing.T) {
+		handler := ErrorBudget(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Empty(t, recorder.Header().Get(HeaderErrorCount))
+		assert.Empty(t, recorder.Header().Get(HeaderErrorCodes))
+	})
+}
@@ -0,0 +1,212 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package httpex
+
+import (
+	"bytes"
+	"html/template"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// HTMLContentType is the content type of the pages written by HTMLRenderer
+const HTMLContentType = "text/html; charset=utf-8"
+
+// PublicTag is the struct tag marking the detail fields exposed to the templates of HTMLRenderer, e.g.
+//
+//	type NotFoundDetail struct {
+//		Resource string `json:"resource" httpex:"public"`
+//		Query    string `json:"query"`
+//	}
+const PublicTag = "httpex"
+
+// DefaultTemplate is the page rendered by HTMLRenderer when no template is given
+var DefaultTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>{{.Status}} {{.StatusText}}</title></head>
+<body>
+<h1>{{.StatusText}}</h1>
+<p>{{.Message}}</p>
+{{- if .Fields}}
+<dl>
+{{- range $name, $value := .Fields}}
+<dt>{{$name}}</dt><dd>{{$value}}</dd>
+{{- end}}
+</dl>
+{{- end}}
+<p><small>{{.Code}}{{if .ID}} ({{.ID}}){{end}}</small></p>
+</body>
+</html>
+`))
+
+// Page is the data given to the templates of HTMLRenderer
+type Page struct {
+	Status     int
+	StatusText string
+	Code       string
+	// Message is the localized message, see HTMLRenderer.Localize
+	Message string
+	// ID is the instance ID of the errorex, empty when instance IDs are disabled
+	ID string
+	// Fields are the detail fields tagged with PublicTag, by JSON name
+	Fields map[string]any
+}
+
+// HTMLRenderer writes errorex errors as HTML pages to clients accepting text/html, and as JSON with WriteError to
+// the others, for server-rendered and HTMX applications
+type HTMLRenderer struct {
+	// Template renders the pages. A template defined with the name of the code, or else with the status (e.g.
+	// {{define "404"}}), is used instead of the root template for the errors of that code or status.
+	Template *template.Template
+	// Localize returns the message of the page, errorex.LocalizedMessage in the AcceptLanguages of the request when
+	// nil
+	Localize func(r *http.Request, ex errorex.EX) string
+}
+
+// NewHTMLRenderer creates an HTMLRenderer with the template, DefaultTemplate when nil
+func NewHTMLRenderer(tmpl *template.Template) *HTMLRenderer {
+	if tmpl == nil {
+		tmpl = DefaultTemplate
+	}
+	return &HTMLRenderer{Template: tmpl}
+}
+
+// WriteError writes err as an HTML page when the client accepts text/html (see AcceptsHTML), and with WriteError
+// otherwise. Both set the same headers.
+// The page is written as errorex.ErrCodeUnknownError with the default template when the template fails.
+func (h *HTMLRenderer) WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	if !AcceptsHTML(r) {
+		WriteError(w, err)
+		return
+	}
+	ex := toEX(err)
+	collect(w, ex)
+	page := h.page(r, ex)
+	var body bytes.Buffer
+	if renderErr := h.templateFor(page).Execute(&body, page); renderErr != nil {
+		body.Reset()
+		page = h.page(r, defaultConverter.ConvertError(renderErr))
+		_ = DefaultTemplate.Execute(&body, page)
+	}
+	setHeaders(w.Header(), ex, HTMLContentType)
+	w.WriteHeader(page.Status)
+	_, _ = w.Write(body.Bytes())
+}
+
+// page returns the data of the page of the errorex
+func (h *HTMLRenderer) page(r *http.Request, ex errorex.EX) Page {
+	status := Status(ex)
+	page := Page{
+		Status:     status,
+		StatusText: http.StatusText(status),
+		Code:       ex.Code(),
+		Fields:     publicFields(external(ex).Detail()),
+	}
+	page.ID, _ = errorex.InstanceID(ex)
+	if h.Localize != nil {
+		page.Message = h.Localize(r, ex)
+	} else {
+		page.Message = errorex.LocalizedMessage(ex, AcceptLanguages(r)...)
+	}
+	return page
+}
+
+// templateFor returns the template defined for the code or the status of the page, or the root template
+func (h *HTMLRenderer) templateFor(page Page) *template.Template {
+	tmpl := h.Template
+	if tmpl == nil {
+		tmpl = DefaultTemplate
+	}
+	for _, name := range []string{page.Code, strconv.Itoa(page.Status)} {
+		if defined := tmpl.Lookup(name); defined != nil {
+			return defined
+		}
+	}
+	return tmpl
+}
+
+// publicFields returns the detail fields tagged with PublicTag, by JSON name
+func publicFields(detail any) map[string]any {
+	value := reflect.ValueOf(detail)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	var fields map[string]any
+	for _, field := range errorex.DetailFields(value.Type()) {
+		if field.Field.Tag.Get(PublicTag) != "public" {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]any)
+		}
+		// encoding/json leaves out the fields of nil embedded pointers too
+		fieldValue, err := value.FieldByIndexErr(field.Field.Index)
+		if err != nil {
+			continue
+		}
+		fields[field.Name] = fieldValue.Interface()
+	}
+	return fields
+}
+
+// AcceptsHTML tells if the client prefers text/html over JSON: its Accept header weighs text/html higher than
+// application/json and the wildcards, or it is an HTMX request (HX-Request header)
+func AcceptsHTML(r *http.Request) bool {
+	if r.Header.Get("HX-Request") == "true" {
+		return true
+	}
+	html, json := 0.0, 0.0
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case "text/html":
+			html = max(html, quality)
+		case "application/json":
+			json = max(json, quality)
+		case "*/*", "application/*":
+			json = max(json, quality)
+		}
+	}
+	return html > json
+}
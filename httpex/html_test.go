@@ -0,0 +1,146 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package httpex
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type pageDetail struct {
+	Resource string `json:"resource" httpex:"public"`
+	Query    string `json:"query"`
+}
+
+// PageOrigin is exported so encoding/json promotes the fields of the embedded pointer
+type PageOrigin struct {
+	Host string `json:"host" httpex:"public"`
+}
+
+type embeddedPageDetail struct {
+	*PageOrigin
+	Resource string `json:"resource" httpex:"public"`
+}
+
+func init() {
+	errorex.RegisterErrorCode("httpex.page_not_found", "Page not found", pageDetail{}, errorex.WithHTTPStatus(http.StatusNotFound))
+	errorex.RegisterErrorCode("httpex.page_gone", "Page gone", embeddedPageDetail{}, errorex.WithHTTPStatus(http.StatusGone))
+}
+
+func TestAcceptsHTML(t *testing.T) {
+	for accept, expected := range map[string]bool{
+		"text/html,application/xhtml+xml,*/*;q=0.8": true,
+		"application/json":                          false,
+		"*/*":                                       false,
+		"application/json;q=0.5, text/html":         true,
+		"text/html;q=0.1, application/json":         false,
+		"":                                          false,
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Accept", accept)
+		assert.Equal(t, expected, AcceptsHTML(request), accept)
+	}
+
+	t.Run("should accept HTMX requests", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("HX-Request", "true")
+		assert.True(t, AcceptsHTML(request))
+	})
+}
+
+func TestHTMLRenderer(t *testing.T) {
+	htmlRequest := httptest.NewRequest(http.MethodGet, "/", nil)
+	htmlRequest.Header.Set("Accept", "text/html")
+	ex := errorex.New("httpex.page_not_found", pageDetail{Resource: "invoice", Query: "secret"})
+
+	t.Run("should render the default page", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		NewHTMLRenderer(nil).WriteError(recorder, htmlRequest, ex)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, HTMLContentType, recorder.Header().Get("Content-Type"))
+		assert.Contains(t, recorder.Body.String(), "<p>Page not found</p>")
+		assert.Contains(t, recorder.Body.String(), "<dt>resource</dt><dd>invoice</dd>")
+		assert.NotContains(t, recorder.Body.String(), "secret")
+	})
+
+	t.Run("should write JSON to other clients", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		NewHTMLRenderer(nil).WriteError(recorder, httptest.NewRequest(http.MethodGet, "/", nil), ex)
+
+		assert.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+		assert.Contains(t, recorder.Body.String(), `"code": "httpex.page_not_found"`)
+	})
+
+	t.Run("should use the templates defined for the code or the status", func(t *testing.T) {
+		tmpl := template.Must(template.New("root").Parse(`root`))
+		template.Must(tmpl.New("404").Parse(`missing {{index .Fields "resource"}}`))
+		recorder := httptest.NewRecorder()
+		NewHTMLRenderer(tmpl).WriteError(recorder, htmlRequest, ex)
+		assert.Equal(t, "missing invoice", recorder.Body.String())
+
+		tmpl = template.Must(template.New("root").Parse(`root`))
+		template.Must(tmpl.New("httpex.page_not_found").Parse(`{{.Message}}`))
+		renderer := NewHTMLRenderer(tmpl)
+		renderer.Localize = func(r *http.Request, ex errorex.EX) string { return "Página não encontrada" }
+		recorder = httptest.NewRecorder()
+		renderer.WriteError(recorder, htmlRequest, ex)
+		assert.Equal(t, "Página não encontrada", recorder.Body.String())
+	})
+
+	t.Run("should escape the fields", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		NewHTMLRenderer(nil).WriteError(recorder, htmlRequest, errorex.New("httpex.page_not_found", pageDetail{Resource: "<script>"}))
+
+		assert.Contains(t, recorder.Body.String(), "&lt;script&gt;")
+	})
+
+	t.Run("should leave out the fields of nil embedded pointers", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		NewHTMLRenderer(nil).WriteError(recorder, htmlRequest, errorex.New("httpex.page_gone", embeddedPageDetail{Resource: "invoice"}))
+
+		assert.Equal(t, http.StatusGone, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "<dt>resource</dt><dd>invoice</dd>")
+		assert.NotContains(t, recorder.Body.String(), "<dt>host</dt>")
+	})
+
+	t.Run("should render the default page when the template fails", func(t *testing.T) {
+		tmpl := template.Must(template.New("root").Parse(`{{.Missing}}`))
+		recorder := httptest.NewRecorder()
+
+		NewHTMLRenderer(tmpl).WriteError(recorder, htmlRequest, ex)
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "<h1>Internal Server Error</h1>")
+	})
+}
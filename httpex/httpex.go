@@ -0,0 +1,198 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package httpex writes errorex errors as HTTP responses, with the status mapped to their code with
+// errorex.WithHTTPStatus and the body serialized by Error:
+//
+//	func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+//		if err := h.service.Do(r.Context()); err != nil {
+//			httpex.WriteError(w, err)
+//			return
+//		}
+//	}
+package httpex
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// DefaultStatus is the status of errors whose code has no HTTP status mapped
+	DefaultStatus = http.StatusInternalServerError
+	// ContentType is the content type of the responses written by WriteError
+	ContentType = "application/json"
+	// FieldsParam is the query parameter holding the field mask read by WriteErrorFields
+	FieldsParam = "fields"
+	// HeaderErrorCode is the response header holding the code of the error, see SetCodeHeaders
+	HeaderErrorCode = "X-Error-Code"
+	// HeaderErrorInstance is the response header holding the instance ID of the error, see SetCodeHeaders
+	HeaderErrorInstance = "X-Error-Instance"
+)
+
+var (
+	defaultConverter = errorex.BuildErrorConverterChain()
+
+	headerMutex sync.RWMutex
+	headerFuncs = make(map[string]HeaderFunc)
+
+	codeHeaders atomic.Bool
+)
+
+// SetCodeHeaders makes the error responses carry the code of the error in HeaderErrorCode and its instance ID, when
+// instance IDs are enabled, in HeaderErrorInstance, so load balancers, CDNs and access logs classify failures
+// without parsing bodies. It is disabled by default.
+func SetCodeHeaders(enabled bool) {
+	codeHeaders.Store(enabled)
+}
+
+// HeaderFunc sets the response headers specific to an errorex, such as WWW-Authenticate
+type HeaderFunc func(ex errorex.EX, header http.Header)
+
+// RegisterHeaders sets the HeaderFunc called by WriteError for errors with the code, replacing the previous one.
+// It panics if the code is not registered.
+func RegisterHeaders(code string, fn HeaderFunc) {
+	info, ok := errorex.Lookup(code)
+	if !ok {
+		// Fatal errorex
+		panic(errorex.New(errorex.ErrCodeNotRegistered, errorex.ErrorEXDetail{Code: code}))
+	}
+	headerMutex.Lock()
+	headerFuncs[info.Code] = fn
+	headerMutex.Unlock()
+}
+
+// Status returns the HTTP status mapped to the code of the first errorex in the chain of err, DefaultStatus when
+// there is none or the code has no status mapped. Codes not registered locally are resolved with errorex.Resolve.
+func Status(err error) int {
+	var ex errorex.EX
+	if !errors.As(err, &ex) {
+		return DefaultStatus
+	}
+	if info, ok := errorex.Resolve(ex.Code()); ok && info.HTTPStatus != 0 {
+		return info.HTTPStatus
+	}
+	return DefaultStatus
+}
+
+// WriteError writes err as a JSON response with the status mapped to its code.
+// Errors that are not errorex errors are written as errorex.ErrCodeUnknownError, details hidden by the severity
+// policy are left out (see errorex.Exposed), projections for errorex.SinkExternal are applied and the causes, stack
+// trace and metadata are stripped (see errorex.Boundary).
+// The Retry-After header is set, in seconds, when the error carries a hint set with errorex.WithRetryAfter, and the
+// HeaderFunc registered for the code with RegisterHeaders is called.
+func WriteError(w http.ResponseWriter, err error) {
+	ex := toEX(err)
+	collect(w, ex)
+	setHeaders(w.Header(), ex, ContentType)
+	w.WriteHeader(Status(ex))
+	_, _ = w.Write(errorex.AppendError(nil, external(ex)))
+}
+
+// WriteErrorFields writes err like WriteError, restricted to the field mask of the FieldsParam query parameter of
+// the request, e.g. ?fields=code,detail.reason, see errorex.AppendErrorFields. The whole error is written when the
+// request has no field mask.
+func WriteErrorFields(w http.ResponseWriter, r *http.Request, err error) {
+	ex := toEX(err)
+	collect(w, ex)
+	setHeaders(w.Header(), ex, ContentType)
+	w.WriteHeader(Status(ex))
+	fields := errorex.ParseFields(r.URL.Query().Get(FieldsParam))
+	_, _ = w.Write(errorex.AppendErrorFields(nil, external(ex), fields))
+}
+
+// FromResponse parses the errorex written by WriteError in the body of a response, e.g. returned by a client
+// call, see errorex.ParseJSON. The errorex is stamped with the hop of the service, see errorex.Received. The body
+// is read but not closed.
+func FromResponse(response *http.Response) (errorex.EX, error) {
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	ex, err := errorex.ParseJSON(body)
+	if err != nil {
+		return nil, err
+	}
+	return errorex.Received(ex), nil
+}
+
+// toEX returns the first errorex in the chain of err, converting err when there is none
+func toEX(err error) errorex.EX {
+	var ex errorex.EX
+	if !errors.As(err, &ex) {
+		ex = defaultConverter.ConvertError(err)
+	}
+	return ex
+}
+
+// external returns the errorex as clients see it, see errorex.Boundary: without the details hidden by the severity
+// policy, with the detail projected for errorex.SinkExternal and without its causes, stack trace and metadata
+func external(ex errorex.EX) errorex.EX {
+	return errorex.Boundary(ex)
+}
+
+// SetHeaders sets the headers of the error responses of the package, for writers of other formats: the content type,
+// the Retry-After header, the code headers enabled with SetCodeHeaders and the headers registered for the code of
+// the errorex with RegisterHeaders
+func SetHeaders(header http.Header, ex errorex.EX, contentType string) {
+	setHeaders(header, ex, contentType)
+}
+
+// setHeaders sets the content type, the Retry-After header, the code headers and the headers registered for the
+// code of the errorex
+func setHeaders(header http.Header, ex errorex.EX, contentType string) {
+	header.Set("Content-Type", contentType)
+	if codeHeaders.Load() {
+		header.Set(HeaderErrorCode, ex.Code())
+		if id, ok := errorex.InstanceID(ex); ok {
+			header.Set(HeaderErrorInstance, id)
+		}
+	}
+	if delay, ok := errorex.RetryAfter(ex); ok {
+		header.Set("Retry-After", strconv.FormatInt(int64(math.Ceil(delay.Seconds())), 10))
+	}
+	if fn := headersOf(ex.Code()); fn != nil {
+		fn(ex, header)
+	}
+}
+
+// headersOf returns the HeaderFunc registered for the code, or for the code it is an alias of
+func headersOf(code string) HeaderFunc {
+	headerMutex.RLock()
+	fn, ok := headerFuncs[code]
+	headerMutex.RUnlock()
+	if ok {
+		return fn
+	}
+	if info, registered := errorex.Lookup(code); registered && info.Code != code {
+		return headersOf(info.Code)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package httpex
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	errorex.RegisterErrorCode("httpex.not_found", "Not found", errorex.ErrorEXDetail{}, errorex.WithHTTPStatus(http.StatusNotFound))
+	errorex.RegisterErrorCode("httpex.unavailable", "Unavailable", errorex.ErrorEXDetail{}, errorex.WithHTTPStatus(http.StatusServiceUnavailable))
+}
+
+func TestStatus(t *testing.T) {
+	t.Run("should return the mapped status", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, Status(fmt.Errorf("loading: %w", errorex.New("httpex.not_found", errorex.ErrorEXDetail{}))))
+	})
+
+	t.Run("should resolve the status of remote codes", func(t *testing.T) {
+		errorex.SetCatalogProvider(errorex.CatalogProviderFunc(func(code string) (errorex.CodeInfo, bool) {
+			return errorex.CodeInfo{Code: code, HTTPStatus: http.StatusGone}, code == "remote.gone"
+		}))
+		defer errorex.SetCatalogProvider(nil)
+		response := &http.Response{Body: io.NopCloser(strings.NewReader(`{"code": "remote.gone", "detail": {"id": "7"}}`))}
+
+		remote, err := FromResponse(response)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusGone, Status(remote))
+	})
+
+	t.Run("should default to internal server error", func(t *testing.T) {
+		assert.Equal(t, DefaultStatus, Status(errorex.New(errorex.ErrCodeUnknownError, errorex.UnknownErrorDetail{})))
+		assert.Equal(t, DefaultStatus, Status(errors.New("other")))
+	})
+}
+
+func TestWriteError(t *testing.T) {
+	t.Run("should write the errorex", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		WriteError(recorder, errorex.New("httpex.not_found", errorex.ErrorEXDetail{Code: "user"}))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+		assert.Equal(t, `{"code": "httpex.not_found", "detail": {"code":"user"}}`, recorder.Body.String())
+		assert.Empty(t, recorder.Header().Get("Retry-After"))
+	})
+
+	t.Run("should strip the causes, stack trace and metadata", func(t *testing.T) {
+		errorex.SetStackConfig(errorex.StackConfig{Enabled: true})
+		defer errorex.SetStackConfig(errorex.StackConfig{})
+		recorder := httptest.NewRecorder()
+
+		WriteError(recorder, errorex.New("httpex.not_found", errorex.ErrorEXDetail{Code: "user"},
+			errorex.WithCause(errors.New("pq: password authentication failed for user admin")),
+			errorex.WithMetadata("db_host", "10.0.0.4")))
+
+		assert.Equal(t, `{"code": "httpex.not_found", "detail": {"code":"user"}}`, recorder.Body.String())
+		assert.NotContains(t, recorder.Body.String(), "password")
+	})
+
+	t.Run("should leave out the details hidden by the severity policy", func(t *testing.T) {
+		errorex.SetSeverityPolicy(errorex.SeverityPolicy{errorex.SeverityError: {}})
+		defer errorex.SetSeverityPolicy(nil)
+		recorder := httptest.NewRecorder()
+
+		WriteError(recorder, errorex.New("httpex.not_found", errorex.ErrorEXDetail{Code: "user"}))
+
+		assert.Equal(t, `{"code": "httpex.not_found", "detail": {"code":""}}`, recorder.Body.String())
+	})
+
+	t.Run("should write the external projection of the detail", func(t *testing.T) {
+		errorex.RegisterErrorCode("httpex.conflict", "Conflict", errorex.ErrorEXDetail{}, errorex.WithHTTPStatus(http.StatusConflict))
+		errorex.RegisterProjection("httpex.conflict", errorex.SinkExternal, func(detail errorex.ErrorEXDetail) any {
+			return map[string]bool{"conflict": true}
+		})
+		recorder := httptest.NewRecorder()
+
+		WriteError(recorder, errorex.New("httpex.conflict", errorex.ErrorEXDetail{Code: "internal"}))
+
+		assert.Equal(t, `{"code": "httpex.conflict", "detail": {"conflict":true}}`, recorder.Body.String())
+	})
+
+	t.Run("should set the Retry-After header", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		WriteError(recorder, errorex.New("httpex.unavailable", errorex.ErrorEXDetail{}, errorex.WithRetryAfter(1500*time.Millisecond)))
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Equal(t, "2", recorder.Header().Get("Retry-After"))
+	})
+
+	t.Run("should set the headers registered for the code", func(t *testing.T) {
+		RegisterHeaders("httpex.not_found", func(ex errorex.EX, header http.Header) {
+			header.Set("X-Missing", ex.Detail().(errorex.ErrorEXDetail).Code)
+		})
+		defer RegisterHeaders("httpex.not_found", nil)
+		recorder := httptest.NewRecorder()
+
+		WriteError(recorder, errorex.New("httpex.not_found", errorex.ErrorEXDetail{Code: "user"}))
+
+		assert.Equal(t, "user", recorder.Header().Get("X-Missing"))
+		assert.Panics(t, func() {
+			RegisterHeaders("httpex.missing", nil)
+		})
+	})
+
+	t.Run("should set the code headers when enabled", func(t *testing.T) {
+		SetCodeHeaders(true)
+		defer SetCodeHeaders(false)
+		errorex.SetInstanceConfig(errorex.InstanceConfig{IDs: true})
+		defer errorex.SetInstanceConfig(errorex.InstanceConfig{})
+		recorder := httptest.NewRecorder()
+		ex := errorex.New("httpex.not_found", errorex.ErrorEXDetail{Code: "user"})
+
+		WriteError(recorder, ex)
+
+		id, _ := errorex.InstanceID(ex)
+		assert.Equal(t, "httpex.not_found", recorder.Header().Get(HeaderErrorCode))
+		assert.Equal(t, id, recorder.Header().Get(HeaderErrorInstance))
+	})
+
+	t.Run("should not set the code headers by default", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		WriteError(recorder, errorex.New("httpex.not_found", errorex.ErrorEXDetail{Code: "user"}))
+
+		assert.Empty(t, recorder.Header().Get(HeaderErrorCode))
+		assert.Empty(t, recorder.Header().Get(HeaderErrorInstance))
+	})
+
+	t.Run("should write other errors as unknown", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		WriteError(recorder, errors.New("boom"))
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Equal(t, `{"code": "errorex.000", "detail": {"detail":"boom"}}`, recorder.Body.String())
+	})
+}
+
+func TestWriteErrorFields(t *testing.T) {
+	t.Run("should write the fields of the mask", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/users/1?fields=code", nil)
+
+		WriteErrorFields(recorder, request, errorex.New("httpex.not_found", errorex.ErrorEXDetail{Code: "user"}))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, `{"code": "httpex.not_found"}`, recorder.Body.String())
+	})
+
+	t.Run("should write the whole errorex without a mask", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+
+		WriteErrorFields(recorder, request, errorex.New("httpex.not_found", errorex.ErrorEXDetail{Code: "user"}))
+
+		assert.Equal(t, `{"code": "httpex.not_found", "detail": {"code":"user"}}`, recorder.Body.String())
+	})
+}
+
+func TestFromResponse(t *testing.T) {
+	t.Run("should parse the errorex of the response", func(t *testing.T) {
+		errorex.SetServiceName("gateway")
+		defer errorex.SetServiceName("")
+		recorder := httptest.NewRecorder()
+		WriteError(recorder, errorex.New("httpex.not_found", errorex.ErrorEXDetail{Code: "user"}))
+
+		ex, err := FromResponse(recorder.Result())
+
+		assert.NoError(t, err)
+		assert.True(t, errorex.Is(ex, "httpex.not_found"))
+		assert.Equal(t, errorex.ErrorEXDetail{Code: "user"}, ex.Detail())
+		assert.Equal(t, "gateway", errorex.Hops(ex)[0].Service)
+	})
+
+	t.Run("should fail on bodies from other servers", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		recorder.WriteString("internal error")
+
+		_, err := FromResponse(recorder.Result())
+
+		assert.Error(t, err)
+	})
+}
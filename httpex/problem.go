@@ -0,0 +1,150 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package httpex
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// ProblemContentType is the content type of the responses written by ProblemWriter
+	ProblemContentType = "application/problem+json"
+	// DefaultProblemType is the problem type of the codes without one when ProblemWriter has no TypeTemplate
+	DefaultProblemType = "about:blank"
+)
+
+// Problem is the RFC 7807 problem details of an errorex, as written by ProblemWriter
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	// Instance is the instance ID of the errorex, omitted when instance IDs are disabled
+	Instance string `json:"instance,omitempty"`
+	// Code is the errorex code, an extension member
+	Code string `json:"code"`
+	// Data is the errorex detail as clients see it (see WriteError), an extension member
+	Data any `json:"data,omitempty"`
+}
+
+// ProblemWriter writes errorex errors as RFC 7807 problem details, titled with the message of the code in the
+// languages of the client (see errorex.LocalizedMessage) and typed with the URI registered for the code with
+// errorex.WithProblemType, or else built from TypeTemplate. The messages of the fields of validation errors are
+// localized in the same languages, see errorex.LocalizeFields.
+type ProblemWriter struct {
+	// TypeTemplate is the URI template of the problem types, "{code}" being replaced by the code, e.g.
+	// "https://errors.example.com/{code}". The problem type is DefaultProblemType when empty.
+	TypeTemplate string
+	// Languages returns the language tags the title is localized in, AcceptLanguages when nil
+	Languages func(r *http.Request) []string
+}
+
+// NewProblemWriter creates a ProblemWriter with the URI template of the problem types
+func NewProblemWriter(typeTemplate string) *ProblemWriter {
+	return &ProblemWriter{TypeTemplate: typeTemplate}
+}
+
+// WriteError writes err as problem details with the status and the headers of WriteError
+func (p *ProblemWriter) WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	ex := toEX(err)
+	collect(w, ex)
+	problem := p.Problem(r, ex)
+	body, marshalErr := errorex.GetJSONCodec().Marshal(problem)
+	if marshalErr != nil {
+		problem.Data = nil
+		body, _ = errorex.GetJSONCodec().Marshal(problem)
+	}
+	setHeaders(w.Header(), ex, ProblemContentType)
+	w.WriteHeader(problem.Status)
+	_, _ = w.Write(body)
+}
+
+// Problem returns the problem details of the errorex for the request
+func (p *ProblemWriter) Problem(r *http.Request, ex errorex.EX) Problem {
+	languages := p.Languages
+	if languages == nil {
+		languages = AcceptLanguages
+	}
+	tags := languages(r)
+	problem := Problem{
+		Type:   p.problemType(ex.Code()),
+		Title:  errorex.LocalizedMessage(ex, tags...),
+		Status: Status(ex),
+		Code:   ex.Code(),
+		Data:   external(errorex.LocalizeFields(ex, tags...)).Detail(),
+	}
+	problem.Instance, _ = errorex.InstanceID(ex)
+	return problem
+}
+
+// problemType returns the problem type URI of the code
+func (p *ProblemWriter) problemType(code string) string {
+	if info, ok := errorex.Resolve(code); ok && info.ProblemType != "" {
+		return info.ProblemType
+	}
+	if p.TypeTemplate == "" {
+		return DefaultProblemType
+	}
+	return strings.ReplaceAll(p.TypeTemplate, "{code}", url.PathEscape(code))
+}
+
+// AcceptLanguages returns the language tags of the Accept-Language header of the request, by decreasing quality.
+// The wildcard and the tags with a zero quality are left out.
+func AcceptLanguages(r *http.Request) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+	var languages []weighted
+	for _, accepted := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(accepted, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			languages = append(languages, weighted{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+	tags := make([]string, len(languages))
+	for i, language := range languages {
+		tags[i] = language.tag
+	}
+	return tags
+}
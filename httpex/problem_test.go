@@ -0,0 +1,103 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package httpex
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	errorex.RegisterErrorCode("httpex.gone", "Resource gone", errorex.ErrorEXDetail{},
+		errorex.WithHTTPStatus(http.StatusGone), errorex.WithProblemType("https://errors.example.com/custom/gone"))
+	errorex.RegisterMessages("pt", map[string]string{"httpex.not_found": "Não encontrado"})
+}
+
+func TestProblemWriter(t *testing.T) {
+	writer := NewProblemWriter("https://errors.example.com/{code}")
+
+	t.Run("should write the localized problem details", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Accept-Language", "de;q=0.5, pt-BR")
+
+		writer.WriteError(recorder, request, errorex.New("httpex.not_found", errorex.ErrorEXDetail{Code: "user"}))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, ProblemContentType, recorder.Header().Get("Content-Type"))
+		var problem map[string]any
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+		assert.Equal(t, map[string]any{
+			"type":   "https://errors.example.com/httpex.not_found",
+			"title":  "Não encontrado",
+			"status": float64(http.StatusNotFound),
+			"code":   "httpex.not_found",
+			"data":   map[string]any{"code": "user"},
+		}, problem)
+	})
+
+	t.Run("should localize the messages of the fields", func(t *testing.T) {
+		errorex.RegisterMessages("pt", map[string]string{"httpex.validation.min": "mínimo de {min}"})
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Accept-Language", "pt-BR")
+		err := errorex.Validation([]errorex.FieldError{{Field: "age", Key: "httpex.validation.min", Params: map[string]any{"min": 18}, Message: "too low"}})
+
+		problem := writer.Problem(request, err)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, problem.Status)
+		assert.Equal(t, "mínimo de 18", problem.Data.(errorex.ValidationDetail).Fields[0].Message)
+	})
+
+	t.Run("should prefer the problem type of the code", func(t *testing.T) {
+		problem := writer.Problem(httptest.NewRequest(http.MethodGet, "/", nil), errorex.New("httpex.gone", errorex.ErrorEXDetail{}))
+
+		assert.Equal(t, "https://errors.example.com/custom/gone", problem.Type)
+		assert.Equal(t, "Resource gone", problem.Title)
+		assert.Equal(t, http.StatusGone, problem.Status)
+	})
+
+	t.Run("should default to about:blank", func(t *testing.T) {
+		problem := NewProblemWriter("").Problem(httptest.NewRequest(http.MethodGet, "/", nil), errorex.New("httpex.not_found", errorex.ErrorEXDetail{}))
+
+		assert.Equal(t, DefaultProblemType, problem.Type)
+	})
+}
+
+func TestAcceptLanguages(t *testing.T) {
+	t.Run("should order the languages by quality", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Accept-Language", "en;q=0.8, pt-BR, *;q=0.1, fr;q=0, de;q=0.9, es;q=x")
+
+		assert.Equal(t, []string{"pt-BR", "de", "en"}, AcceptLanguages(request))
+	})
+
+	t.Run("should return no languages without the header", func(t *testing.T) {
+		assert.Empty(t, AcceptLanguages(httptest.NewRequest(http.MethodGet, "/", nil)))
+	})
+}
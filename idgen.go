@@ -0,0 +1,177 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/hex"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// crockford is the Crockford base32 alphabet of ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULIDGenerator returns a generator of ULIDs for SetIDGenerator, 26 character IDs sorting by creation time, so
+// log stores ordering IDs lexicographically keep errors in order:
+//
+//	errorex.SetIDGenerator(errorex.NewULIDGenerator())
+//
+// The IDs of a generator are monotonic: the random part is incremented for IDs created within the same millisecond.
+// The time is read from the clock set with SetClock.
+func NewULIDGenerator() func() string {
+	var (
+		mutex    sync.Mutex
+		lastMS   int64
+		high     uint16
+		low      uint64
+		previous bool
+	)
+	return func() string {
+		mutex.Lock()
+		ms := now().UnixMilli()
+		if previous && ms <= lastMS {
+			// monotonic within the millisecond, and when the clock goes backwards
+			ms = lastMS
+			low++
+			if low == 0 {
+				high++
+			}
+		} else {
+			high, low = uint16(rand.Uint32()), rand.Uint64()
+		}
+		lastMS, previous = ms, true
+		var id [16]byte
+		for i := 0; i < 6; i++ {
+			id[i] = byte(ms >> (8 * (5 - i)))
+		}
+		id[6], id[7] = byte(high>>8), byte(high)
+		for i := 0; i < 8; i++ {
+			id[8+i] = byte(low >> (8 * (7 - i)))
+		}
+		mutex.Unlock()
+		return encodeULID(id)
+	}
+}
+
+// encodeULID encodes the 128 bits of a ULID in Crockford base32, the first character holding the top 3 bits
+func encodeULID(id [16]byte) string {
+	var encoded [26]byte
+	// the 128 bits are read as 130, with 2 leading zero bits
+	for i := 0; i < 26; i++ {
+		bit := i*5 - 2
+		var value byte
+		for j := 0; j < 5; j++ {
+			position := bit + j
+			if position < 0 {
+				continue
+			}
+			value <<= 1
+			value |= (id[position/8] >> (7 - position%8)) & 1
+		}
+		encoded[i] = crockford[value]
+	}
+	return string(encoded[:])
+}
+
+// NewUUIDv7Generator returns a generator of RFC 9562 version 7 UUIDs for SetIDGenerator, sorting by creation time
+// in their canonical 36 character form. The 12 bits following the millisecond timestamp count the IDs created within
+// the same millisecond, so the IDs of a generator are monotonic. The time is read from the clock set with SetClock.
+func NewUUIDv7Generator() func() string {
+	var (
+		mutex    sync.Mutex
+		lastMS   int64
+		counter  uint16
+		previous bool
+	)
+	return func() string {
+		mutex.Lock()
+		ms := now().UnixMilli()
+		if previous && ms <= lastMS {
+			ms = lastMS
+			counter++
+			if counter > 0xfff {
+				// the counter overflowed, borrow the next millisecond
+				ms++
+				counter = 0
+			}
+		} else {
+			counter = uint16(rand.Uint32() & 0x7ff)
+		}
+		lastMS, previous = ms, true
+		var id [16]byte
+		for i := 0; i < 6; i++ {
+			id[i] = byte(ms >> (8 * (5 - i)))
+		}
+		id[6] = 0x70 | byte(counter>>8)
+		id[7] = byte(counter)
+		random := rand.Uint64()
+		for i := 0; i < 8; i++ {
+			id[8+i] = byte(random >> (8 * (7 - i)))
+		}
+		id[8] = 0x80 | id[8]&0x3f
+		mutex.Unlock()
+		encoded := hex.EncodeToString(id[:])
+		return encoded[:8] + "-" + encoded[8:12] + "-" + encoded[12:16] + "-" + encoded[16:20] + "-" + encoded[20:]
+	}
+}
+
+// SnowflakeEpoch is the epoch of the timestamps of the IDs of NewSnowflakeGenerator
+var SnowflakeEpoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// NewSnowflakeGenerator returns a generator of Snowflake IDs for SetIDGenerator: 63 bit integers made of the
+// milliseconds since SnowflakeEpoch, the node (10 bits, distinguishing the instances of a service) and a 12 bit
+// sequence, formatted as 19 zero-padded decimal digits so they sort by creation time lexicographically too.
+// The time is read from the clock set with SetClock.
+func NewSnowflakeGenerator(node uint16) func() string {
+	var (
+		mutex    sync.Mutex
+		lastMS   int64
+		sequence int64
+	)
+	node &= 0x3ff
+	return func() string {
+		mutex.Lock()
+		ms := now().Sub(SnowflakeEpoch).Milliseconds()
+		if ms <= lastMS {
+			ms = lastMS
+			sequence++
+			if sequence > 0xfff {
+				ms++
+				sequence = 0
+			}
+		} else {
+			sequence = 0
+		}
+		lastMS = ms
+		id := ms<<22 | int64(node)<<12 | sequence
+		mutex.Unlock()
+		encoded := strconv.FormatInt(id, 10)
+		if len(encoded) < 19 {
+			encoded = "0000000000000000000"[len(encoded):] + encoded
+		}
+		return encoded
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewULIDGenerator(t *testing.T) {
+	t.Run("should encode the timestamp first", func(t *testing.T) {
+		SetClock(func() time.Time { return time.UnixMilli(1469918176385) })
+		defer SetClock(nil)
+		id := NewULIDGenerator()()
+		assert.Len(t, id, 26)
+		assert.Equal(t, "01ARYZ6S41", id[:10])
+		assert.Regexp(t, regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`), id)
+	})
+
+	t.Run("should be monotonic", func(t *testing.T) {
+		assertSorted(t, NewULIDGenerator())
+	})
+
+	t.Run("should encode the extremes", func(t *testing.T) {
+		assert.Equal(t, "00000000000000000000000000", encodeULID([16]byte{}))
+		var max [16]byte
+		for i := range max {
+			max[i] = 0xff
+		}
+		assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", encodeULID(max))
+	})
+}
+
+func TestNewUUIDv7Generator(t *testing.T) {
+	t.Run("should generate version 7 UUIDs", func(t *testing.T) {
+		SetClock(func() time.Time { return time.UnixMilli(0x0189_2A3B_4C5D) })
+		defer SetClock(nil)
+		id := NewUUIDv7Generator()()
+		assert.Regexp(t, regexp.MustCompile(`^01892a3b-4c5d-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), id)
+	})
+
+	t.Run("should be monotonic", func(t *testing.T) {
+		assertSorted(t, NewUUIDv7Generator())
+	})
+}
+
+func TestNewSnowflakeGenerator(t *testing.T) {
+	t.Run("should encode the time, the node and the sequence", func(t *testing.T) {
+		SetClock(func() time.Time { return SnowflakeEpoch.Add(time.Millisecond) })
+		defer SetClock(nil)
+		generate := NewSnowflakeGenerator(3)
+		assert.Equal(t, "0000000000004206592", generate())
+		assert.Equal(t, "0000000000004206593", generate())
+	})
+
+	t.Run("should be monotonic", func(t *testing.T) {
+		assertSorted(t, NewSnowflakeGenerator(1))
+	})
+}
+
+// assertSorted checks that the IDs of a generator are unique and sort in creation order, including many IDs within
+// the same millisecond and a clock going backwards
+func assertSorted(t *testing.T, generate func() string) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return at })
+	defer SetClock(nil)
+	var ids []string
+	for i := range 5000 {
+		if i%1000 == 999 {
+			at = at.Add(-time.Millisecond)
+		} else if i%100 == 0 {
+			at = at.Add(time.Millisecond)
+		}
+		ids = append(ids, generate())
+	}
+	assert.True(t, sort.StringsAreSorted(ids))
+	unique := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	assert.Len(t, unique, len(ids))
+}
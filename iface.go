@@ -0,0 +1,83 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import "reflect"
+
+// RegisterInterfaceCode registers an errorex code whose detail type is the interface I: New accepts any
+// implementation of I, or a nil detail, instead of a single detail type, so plugins can extend the detail
+// payload of a shared code:
+//
+//	type FieldErrorsProvider interface {
+//		FieldErrors() []errorex.FieldError
+//	}
+//
+//	errorex.RegisterInterfaceCode[FieldErrorsProvider]("forms.invalid", "Invalid form")
+//	...
+//	return errorex.New("forms.invalid", signupErrors{...})
+//
+// As the implementation is not known to the receivers, ParseJSON decodes the detail of these codes as a generic
+// JSON value (maps, slices, strings, float64 and bool).
+// It panics like RegisterErrorCode, or if I is not an interface type.
+func RegisterInterfaceCode[I any](code string, description string, options ...RegistrationOption) {
+	detailType := reflect.TypeOf((*I)(nil)).Elem()
+	if detailType.Kind() != reflect.Interface {
+		// Fatal errorex
+		fatal(New(ErrDetailTypeMismatch, ErrorEXDetailTypeMismatch{ExpectedType: "interface", ActualType: detailType.String()}))
+	}
+	checkNaming(code)
+	registerCode(newRegistry(code, description, detailType, options))
+}
+
+// DefineInterface registers the errorex code with the interface I as detail type, see RegisterInterfaceCode, and
+// returns its typed handle, see Define
+func DefineInterface[I any](code string, description string, options ...RegistrationOption) Definition[I] {
+	RegisterInterfaceCode[I](code, description, options...)
+	return Definition[I]{code: code}
+}
+
+// detailMatches tells if a detail of the actual type can be used with a code registered with the expected type:
+// the same type, an implementation of an interface registered with RegisterInterfaceCode or a nil detail for it
+func detailMatches(expected, actual reflect.Type) bool {
+	if actual == expected {
+		return true
+	}
+	if expected == nil || expected.Kind() != reflect.Interface {
+		return false
+	}
+	return actual == nil || actual.Implements(expected)
+}
+
+// isInterfaceDetail tells if the detail type was registered with RegisterInterfaceCode
+func isInterfaceDetail(detailType reflect.Type) bool {
+	return detailType != nil && detailType.Kind() == reflect.Interface
+}
+
+// typeName returns the name of the type, "nil" for nil details
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return "nil"
+	}
+	return t.String()
+}
@@ -0,0 +1,83 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fieldErrorsProvider interface {
+	FieldErrors() []FieldError
+}
+
+type signupErrors struct {
+	Email string `json:"email"`
+}
+
+func (s signupErrors) FieldErrors() []FieldError {
+	return []FieldError{{Field: "email", Key: "validation.taken"}}
+}
+
+type pluginErrors []FieldError
+
+func (p *pluginErrors) FieldErrors() []FieldError {
+	return *p
+}
+
+func TestRegisterInterfaceCode(t *testing.T) {
+	RegisterInterfaceCode[fieldErrorsProvider]("test.iface.invalid", "test description")
+	definition := DefineInterface[fieldErrorsProvider]("test.iface.defined", "test description")
+
+	t.Run("should accept any implementation", func(t *testing.T) {
+		err := New("test.iface.invalid", signupErrors{Email: "taken@example.com"})
+		assert.Equal(t, "test.iface.invalid", err.Code())
+		assert.Equal(t, signupErrors{Email: "taken@example.com"}, err.Detail())
+
+		plugin := &pluginErrors{{Field: "age"}}
+		err = definition.New(plugin)
+		detail, ok := definition.Detail(err)
+		assert.True(t, ok)
+		assert.Equal(t, []FieldError{{Field: "age"}}, detail.FieldErrors())
+		assert.Nil(t, New[fieldErrorsProvider]("test.iface.invalid", nil).Detail())
+	})
+
+	t.Run("should reject the details not implementing the interface", func(t *testing.T) {
+		assert.Panics(t, func() { New("test.iface.invalid", ErrorEXDetail{}) })
+		assert.Panics(t, func() { New("test.iface.invalid", pluginErrors{}) })
+		assert.Panics(t, func() { RegisterInterfaceCode[signupErrors]("test.iface.struct", "test description") })
+	})
+
+	t.Run("should parse the detail as a generic value", func(t *testing.T) {
+		parsed, err := ParseJSON([]byte(New("test.iface.invalid", signupErrors{Email: "taken@example.com"}).Error()))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"email": "taken@example.com"}, parsed.Detail())
+	})
+
+	t.Run("should list the interface as detail type", func(t *testing.T) {
+		info, _ := Lookup("test.iface.invalid")
+		assert.Equal(t, "errorex.fieldErrorsProvider", info.DetailType.String())
+	})
+}
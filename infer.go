@@ -0,0 +1,47 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+// Infer returns the errorex of err no matter what, the canonical entry point at boundaries: err itself when it is
+// an errorex, else the first errorex it wraps, else its conversion through the chain, else an ErrCodeUnknownError
+// errorex with its message. Errors joined with errors.Join are converted through the chain member by member (see
+// BuildErrorConverterChain), rather than reduced to their first errorex. The default chain is used when chain is
+// nil. It returns nil for nil errors.
+func Infer(err error, chain ErrorConverter) EX {
+	if err == nil {
+		return nil
+	}
+	if !isJoined(err) {
+		if target, ok := firstEX(err); ok {
+			return target
+		}
+	}
+	if chain == nil {
+		chain = defaultChain
+	}
+	if ex := chain.ConvertError(err); ex != nil {
+		return ex
+	}
+	return New(ErrCodeUnknownError, UnknownErrorDetail{Detail: err.Error()})
+}
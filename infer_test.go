@@ -0,0 +1,72 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type inferConverter struct {
+	BaseErrorConverter
+}
+
+func (c *inferConverter) ConvertError(err error) EX {
+	if err.Error() != "known" {
+		return c.BaseErrorConverter.ConvertError(err)
+	}
+	return New("test.infer.known", ErrorEXDetail{})
+}
+
+func TestInfer(t *testing.T) {
+	RegisterErrorCode("test.infer", "test description", ErrorEXDetail{})
+	RegisterErrorCode("test.infer.known", "test description", ErrorEXDetail{})
+	chain := BuildErrorConverterChain(&inferConverter{})
+	converted := New("test.infer", ErrorEXDetail{Code: "x"})
+
+	t.Run("should return errorex errors as is", func(t *testing.T) {
+		assert.Same(t, converted, Infer(converted, chain))
+	})
+
+	t.Run("should return the wrapped errorex", func(t *testing.T) {
+		assert.Same(t, converted, Infer(fmt.Errorf("loading: %w", converted), chain))
+		assert.Same(t, converted, Infer(fmt.Errorf("%w: %w", converted, errors.New("known")), nil))
+	})
+
+	t.Run("should convert other errors through the chain", func(t *testing.T) {
+		assert.Equal(t, "test.infer.known", Infer(errors.New("known"), chain).Code())
+		assert.Equal(t, ErrCodeGroup, Infer(errors.Join(errors.New("known"), converted), chain).Code())
+	})
+
+	t.Run("should fall back to unknown", func(t *testing.T) {
+		ex := Infer(errors.New("boom"), nil)
+		assert.Equal(t, UnknownErrorDetail{Detail: "boom"}, ex.Detail())
+		ex = Infer(errors.New("boom"), &BaseErrorConverter{})
+		assert.Equal(t, ErrCodeUnknownError, ex.Code())
+		assert.Nil(t, Infer(nil, chain))
+	})
+}
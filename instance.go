@@ -0,0 +1,185 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	instanceConfig atomic.Pointer[InstanceConfig]
+	clock          atomic.Pointer[func() time.Time]
+	idGenerator    atomic.Pointer[func() string]
+)
+
+// InstanceConfig configures the metadata stamped on each errorex when it is created
+type InstanceConfig struct {
+	// IDs assigns a unique ID to each errorex, serialized as "id", useful to correlate logs and responses
+	IDs bool
+	// Timestamps records when each errorex was created, serialized as "time" in RFC 3339 format
+	Timestamps bool
+}
+
+// InstanceIdentifier is implemented by errors that carry an instance ID and a creation time
+type InstanceIdentifier interface {
+	// InstanceID returns the ID assigned when the error was created, empty if IDs were disabled
+	InstanceID() string
+	// Timestamp returns the time the error was created, zero if timestamps were disabled
+	Timestamp() time.Time
+}
+
+// SetInstanceConfig sets which metadata is stamped on new errorex instances, both are disabled by default
+func SetInstanceConfig(config InstanceConfig) {
+	instanceConfig.Store(&config)
+}
+
+// GetInstanceConfig returns the current instance metadata configuration
+func GetInstanceConfig() InstanceConfig {
+	if config := instanceConfig.Load(); config != nil {
+		return *config
+	}
+	return InstanceConfig{}
+}
+
+// SetClock sets the source of the timestamps, nil restores time.Now
+func SetClock(now func() time.Time) {
+	if now == nil {
+		clock.Store(nil)
+		return
+	}
+	clock.Store(&now)
+}
+
+// SetIDGenerator sets the source of the instance IDs, nil restores the default random 128 bit hex IDs.
+// NewULIDGenerator, NewUUIDv7Generator and NewSnowflakeGenerator provide IDs sorting by creation time.
+func SetIDGenerator(generate func() string) {
+	if generate == nil {
+		idGenerator.Store(nil)
+		return
+	}
+	idGenerator.Store(&generate)
+}
+
+// TestMode makes instance IDs, timestamps and stack traces reproducible: IDs are sequential (test-000001, ...),
+// the clock is fixed at 2000-01-01T00:00:00Z and the files of stack frames are reduced to their base names.
+// It returns a function restoring the previous state, meant to be deferred:
+//
+//	defer errorex.TestMode()()
+func TestMode() (restore func()) {
+	previousClock := clock.Load()
+	previousGenerator := idGenerator.Load()
+	previousTrim := trimFrameFiles.Load()
+
+	var sequence atomic.Uint64
+	SetIDGenerator(func() string {
+		return fmt.Sprintf("test-%06d", sequence.Add(1))
+	})
+	SetClock(func() time.Time {
+		return time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	})
+	trimFrameFiles.Store(true)
+
+	return func() {
+		clock.Store(previousClock)
+		idGenerator.Store(previousGenerator)
+		trimFrameFiles.Store(previousTrim)
+	}
+}
+
+// InstanceID returns the instance ID of an error, if it carries one
+func InstanceID(err error) (string, bool) {
+	identifier, ok := err.(InstanceIdentifier)
+	if !ok {
+		return "", false
+	}
+	id := identifier.InstanceID()
+	return id, id != ""
+}
+
+// Timestamp returns the creation time of an error, if it carries one
+func Timestamp(err error) (time.Time, bool) {
+	identifier, ok := err.(InstanceIdentifier)
+	if !ok {
+		return time.Time{}, false
+	}
+	timestamp := identifier.Timestamp()
+	return timestamp, !timestamp.IsZero()
+}
+
+// InstanceID returns the ID assigned when the errorex was created, empty if IDs were disabled
+func (e *ex) InstanceID() string {
+	return e.id
+}
+
+// Timestamp returns the time the errorex was created, zero if timestamps were disabled
+func (e *ex) Timestamp() time.Time {
+	return e.timestamp
+}
+
+// stampInstance sets the instance metadata enabled by SetInstanceConfig
+func stampInstance(e *ex) {
+	config := instanceConfig.Load()
+	if config == nil {
+		return
+	}
+	if config.IDs {
+		if generate := idGenerator.Load(); generate != nil {
+			e.id = (*generate)()
+		} else {
+			e.id = randomID()
+		}
+	}
+	if config.Timestamps {
+		e.timestamp = now()
+	}
+}
+
+// Now returns the time of the clock set with SetClock, for the integrations stamping times, e.g. on dead letters
+func Now() time.Time {
+	return now()
+}
+
+// now returns the time of the clock set with SetClock
+func now() time.Time {
+	if now := clock.Load(); now != nil {
+		return (*now)()
+	}
+	return time.Now()
+}
+
+// randomID returns a random 128 bit ID in hex
+func randomID() string {
+	var id [16]byte
+	for i := 0; i < len(id); i += 8 {
+		value := rand.Uint64()
+		for j := 0; j < 8; j++ {
+			id[i+j] = byte(value >> (8 * j))
+		}
+	}
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,115 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceConfig(t *testing.T) {
+	defer SetInstanceConfig(GetInstanceConfig())
+
+	t.Run("should not stamp instances by default", func(t *testing.T) {
+		SetInstanceConfig(InstanceConfig{})
+		e := New(ErrCodeUnknownError, UnknownErrorDetail{Detail: "test"})
+
+		_, ok := InstanceID(e)
+		assert.False(t, ok)
+		_, ok = Timestamp(e)
+		assert.False(t, ok)
+		assert.Equal(t, `{"code": "errorex.000", "detail": {"detail":"test"}}`, e.Error())
+	})
+
+	t.Run("should stamp unique IDs and timestamps", func(t *testing.T) {
+		SetInstanceConfig(InstanceConfig{IDs: true, Timestamps: true})
+		before := time.Now()
+		first := New(ErrCodeUnknownError, UnknownErrorDetail{Detail: "test"})
+		second := NewPooled(ErrCodeUnknownError, UnknownErrorDetail{Detail: "test"})
+		defer Release(second)
+
+		firstID, ok := InstanceID(first)
+		assert.True(t, ok)
+		assert.Len(t, firstID, 32)
+		secondID, _ := InstanceID(second)
+		assert.NotEqual(t, firstID, secondID)
+		timestamp, ok := Timestamp(first)
+		assert.True(t, ok)
+		assert.False(t, timestamp.Before(before))
+	})
+
+	t.Run("should ignore other errors", func(t *testing.T) {
+		_, ok := InstanceID(errors.New("other"))
+		assert.False(t, ok)
+		_, ok = Timestamp(errors.New("other"))
+		assert.False(t, ok)
+	})
+}
+
+func TestTestMode(t *testing.T) {
+	defer SetInstanceConfig(GetInstanceConfig())
+	defer SetStackConfig(GetStackConfig())
+	SetInstanceConfig(InstanceConfig{IDs: true, Timestamps: true})
+
+	t.Run("should make instances reproducible", func(t *testing.T) {
+		restore := TestMode()
+		SetStackConfig(StackConfig{Enabled: true, MaxDepth: 1})
+		first := New(ErrCodeUnknownError, UnknownErrorDetail{Detail: "test"})
+		second := New(ErrCodeUnknownError, UnknownErrorDetail{Detail: "test"})
+		restore()
+
+		assert.Equal(t, "test-000001", first.(InstanceIdentifier).InstanceID())
+		assert.Equal(t, "test-000002", second.(InstanceIdentifier).InstanceID())
+		frames, _ := StackTrace(first)
+		assert.Equal(t, "instance_test.go", frames[0].File)
+		assert.Equal(t, `{"code": "errorex.000", "detail": {"detail":"test"}, "id": "test-000001", "time": "2000-01-01T00:00:00Z", "stack": [{"function":"github.com/fkmatsuda/errorex.TestTestMode.func1","file":"instance_test.go","line":`+
+			strconv.Itoa(frames[0].Line)+`}]}`, first.Error())
+	})
+
+	t.Run("should restore the previous sources", func(t *testing.T) {
+		SetStackConfig(StackConfig{Enabled: true, MaxDepth: 1})
+		e := New(ErrCodeUnknownError, UnknownErrorDetail{Detail: "test"})
+
+		assert.NotContains(t, e.(InstanceIdentifier).InstanceID(), "test-")
+		frames, _ := StackTrace(e)
+		assert.True(t, filepath.IsAbs(frames[0].File))
+	})
+
+	t.Run("should use the injected sources", func(t *testing.T) {
+		SetClock(func() time.Time { return time.Unix(0, 0) })
+		SetIDGenerator(func() string { return "fixed" })
+		defer SetClock(nil)
+		defer SetIDGenerator(nil)
+
+		e := New(ErrCodeUnknownError, UnknownErrorDetail{Detail: "test"})
+
+		assert.Equal(t, "fixed", e.(InstanceIdentifier).InstanceID())
+		assert.True(t, e.(InstanceIdentifier).Timestamp().Equal(time.Unix(0, 0)))
+	})
+}
@@ -0,0 +1,189 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package jsonapi writes errorex errors as JSON:API error objects, for APIs following the JSON:API specification:
+//
+//	func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+//		if err := h.service.Do(r.Context()); err != nil {
+//			jsonapi.WriteErrors(w, err)
+//			return
+//		}
+//	}
+//
+// The code is the code of the error object, the description of the code its title, the detail as clients see it
+// its meta and the HTTP status mapped to the code its status. Details of validation errors implementing
+// FieldPather point the error objects at the invalid attributes of the request document.
+package jsonapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/httpex"
+)
+
+// ContentType is the content type of the responses written by WriteErrors
+const ContentType = "application/vnd.api+json"
+
+var defaultConverter = errorex.BuildErrorConverterChain()
+
+// ErrorObject is a JSON:API error object
+type ErrorObject struct {
+	// ID is the instance ID of the errorex, omitted when instance IDs are disabled
+	ID     string  `json:"id,omitempty"`
+	Status string  `json:"status"`
+	Code   string  `json:"code"`
+	Title  string  `json:"title"`
+	Source *Source `json:"source,omitempty"`
+	Meta   any     `json:"meta,omitempty"`
+}
+
+// Source points an error object at the part of the request that caused it
+type Source struct {
+	// Pointer is the JSON Pointer of the attribute of the request document, e.g. "/data/attributes/email"
+	Pointer string `json:"pointer,omitempty"`
+}
+
+// Document is the top level document of an error response
+type Document struct {
+	Errors []ErrorObject `json:"errors"`
+}
+
+// FieldPather is implemented by the details of validation errors, returning the paths of the invalid fields: dot
+// separated attribute names such as "address.city", pointing under /data/attributes, or JSON Pointers starting with
+// "/" used as they are
+type FieldPather interface {
+	FieldPaths() []string
+}
+
+// ToJSONAPIErrors returns the error objects of err: one for each errorex of a group or joined errors, and one for
+// each field path of the details implementing FieldPather. Errors without an errorex in their chain are written as
+// errorex.ErrCodeUnknownError. Details hidden by the severity policy are
+// left out and projections for errorex.SinkExternal are applied, like httpex.WriteError.
+func ToJSONAPIErrors(err error) []ErrorObject {
+	if err == nil {
+		return nil
+	}
+	var objects []ErrorObject
+	for _, ex := range collect(err) {
+		objects = append(objects, errorObjects(ex)...)
+	}
+	return objects
+}
+
+// WriteErrors writes the error objects of err as a JSON:API document, with the status mapped to the code of its
+// first errorex and the headers of httpex.WriteError
+func WriteErrors(w http.ResponseWriter, err error) {
+	exs := collect(err)
+	var objects []ErrorObject
+	for _, ex := range exs {
+		objects = append(objects, errorObjects(ex)...)
+	}
+	body, marshalErr := errorex.GetJSONCodec().Marshal(Document{Errors: objects})
+	if marshalErr != nil {
+		for i := range objects {
+			objects[i].Meta = nil
+		}
+		body, _ = errorex.GetJSONCodec().Marshal(Document{Errors: objects})
+	}
+	status := httpex.DefaultStatus
+	if len(exs) > 0 {
+		httpex.SetHeaders(w.Header(), exs[0], ContentType)
+		status = httpex.Status(exs[0])
+	} else {
+		w.Header().Set("Content-Type", ContentType)
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// collect returns the errorex errors of err, walking groups and joined errors, converting the errors without one
+func collect(err error) []errorex.EX {
+	var exs []errorex.EX
+	var walk func(err error)
+	walk = func(err error) {
+		switch typed := err.(type) {
+		case nil:
+		case interface{ Unwrap() []error }:
+			// groups are errorex errors too, their errors are mapped instead
+			for _, wrapped := range typed.Unwrap() {
+				walk(wrapped)
+			}
+		case errorex.EX:
+			exs = append(exs, typed)
+		default:
+			var ex errorex.EX
+			if !errors.As(err, &ex) {
+				ex = defaultConverter.ConvertError(err)
+			}
+			exs = append(exs, ex)
+		}
+	}
+	walk(err)
+	return exs
+}
+
+// errorObjects returns the error objects of an errorex, one for each field path of a FieldPather detail
+func errorObjects(ex errorex.EX) []ErrorObject {
+	object := ErrorObject{
+		Status: strconv.Itoa(httpex.Status(ex)),
+		Code:   ex.Code(),
+		Meta:   errorex.Projected(errorex.Exposed(ex), errorex.SinkExternal).Detail(),
+	}
+	object.ID, _ = errorex.InstanceID(ex)
+	if info, ok := errorex.Resolve(ex.Code()); ok {
+		object.Title = info.Description
+	}
+	pather, ok := ex.Detail().(FieldPather)
+	if !ok {
+		return []ErrorObject{object}
+	}
+	paths := pather.FieldPaths()
+	if len(paths) == 0 {
+		return []ErrorObject{object}
+	}
+	objects := make([]ErrorObject, len(paths))
+	for i, path := range paths {
+		objects[i] = object
+		objects[i].Source = &Source{Pointer: Pointer(path)}
+	}
+	return objects
+}
+
+// Pointer returns the JSON Pointer of a field path, see FieldPather
+func Pointer(path string) string {
+	if strings.HasPrefix(path, "/") {
+		return path
+	}
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	var pointer strings.Builder
+	pointer.WriteString("/data/attributes")
+	for _, segment := range strings.Split(path, ".") {
+		pointer.WriteByte('/')
+		pointer.WriteString(escaper.Replace(segment))
+	}
+	return pointer.String()
+}
@@ -0,0 +1,121 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/httpex"
+	"github.com/stretchr/testify/assert"
+)
+
+type invalidDetail struct {
+	Fields []string `json:"fields"`
+}
+
+func (d invalidDetail) FieldPaths() []string {
+	return d.Fields
+}
+
+func init() {
+	errorex.RegisterErrorCode("jsonapi.invalid", "Invalid attributes", invalidDetail{}, errorex.WithHTTPStatus(http.StatusUnprocessableEntity))
+	errorex.RegisterErrorCode("jsonapi.not_found", "Resource not found", errorex.ErrorEXDetail{}, errorex.WithHTTPStatus(http.StatusNotFound))
+}
+
+func TestToJSONAPIErrors(t *testing.T) {
+
+	t.Run("should map the errorex to an error object", func(t *testing.T) {
+		err := fmt.Errorf("loading: %w", errorex.New("jsonapi.not_found", errorex.ErrorEXDetail{Code: "article"}))
+		assert.Equal(t, []ErrorObject{{
+			Status: "404",
+			Code:   "jsonapi.not_found",
+			Title:  "Resource not found",
+			Meta:   errorex.ErrorEXDetail{Code: "article"},
+		}}, ToJSONAPIErrors(err))
+	})
+
+	t.Run("should point the objects at the invalid fields", func(t *testing.T) {
+		err := errorex.New("jsonapi.invalid", invalidDetail{Fields: []string{"address.city", "a/b", "/data/relationships/author"}})
+		objects := ToJSONAPIErrors(err)
+		assert.Len(t, objects, 3)
+		assert.Equal(t, &Source{Pointer: "/data/attributes/address/city"}, objects[0].Source)
+		assert.Equal(t, &Source{Pointer: "/data/attributes/a~1b"}, objects[1].Source)
+		assert.Equal(t, &Source{Pointer: "/data/relationships/author"}, objects[2].Source)
+		assert.Equal(t, "422", objects[2].Status)
+	})
+
+	t.Run("should map each error of groups and joined errors", func(t *testing.T) {
+		group := errorex.EXGroup{
+			errorex.New("jsonapi.not_found", errorex.ErrorEXDetail{}),
+			errorex.New("jsonapi.invalid", invalidDetail{}),
+		}
+		objects := ToJSONAPIErrors(errors.Join(group, errors.New("boom")))
+		assert.Len(t, objects, 3)
+		assert.Equal(t, "jsonapi.not_found", objects[0].Code)
+		assert.Equal(t, "jsonapi.invalid", objects[1].Code)
+		assert.Nil(t, objects[1].Source)
+		assert.Equal(t, errorex.ErrCodeUnknownError, objects[2].Code)
+		assert.Equal(t, "500", objects[2].Status)
+	})
+
+	t.Run("should map nothing for nil errors", func(t *testing.T) {
+		assert.Nil(t, ToJSONAPIErrors(nil))
+	})
+}
+
+func TestWriteErrors(t *testing.T) {
+
+	t.Run("should write the document with the status of the first error", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		WriteErrors(recorder, errorex.New("jsonapi.invalid", invalidDetail{Fields: []string{"title"}}))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"errors": [{
+			"status": "422",
+			"code": "jsonapi.invalid",
+			"title": "Invalid attributes",
+			"source": {"pointer": "/data/attributes/title"},
+			"meta": {"fields": ["title"]}
+		}]}`, recorder.Body.String())
+	})
+
+	t.Run("should set the headers of httpex", func(t *testing.T) {
+		httpex.SetCodeHeaders(true)
+		defer httpex.SetCodeHeaders(false)
+		recorder := httptest.NewRecorder()
+
+		WriteErrors(recorder, errors.Join(errorex.New("jsonapi.not_found", errorex.ErrorEXDetail{}), errors.New("boom")))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, "jsonapi.not_found", recorder.Header().Get(httpex.HeaderErrorCode))
+		assert.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+	})
+}
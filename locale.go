@@ -0,0 +1,88 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	messageMutex sync.RWMutex
+	// messages holds the localized messages by lowercase language tag, then by code
+	messages = make(map[string]map[string]string)
+)
+
+// RegisterMessages adds the messages of codes in a language, e.g.
+//
+//	errorex.RegisterMessages("pt-BR", map[string]string{
+//		payments.ErrCodeDeclined: "Pagamento recusado",
+//	})
+//
+// Language tags are case insensitive and aliases are stored under their code. A later call replaces the messages
+// of the same codes. The message keys of FieldError are registered the same way, see LocalizeFields.
+func RegisterMessages(tag string, localized map[string]string) {
+	tag = strings.ToLower(tag)
+	messageMutex.Lock()
+	defer messageMutex.Unlock()
+	if messages[tag] == nil {
+		messages[tag] = make(map[string]string, len(localized))
+	}
+	for code, message := range localized {
+		messages[tag][canonicalCode(code)] = message
+	}
+}
+
+// LocalizedMessage returns the message of the first errorex in the chain of err in the first of the languages
+// with a message for its code, trying the base language of each tag after it ("pt" after "pt-BR"). It returns
+// Message when no language has one.
+func LocalizedMessage(err error, tags ...string) string {
+	target, ok := firstEX(err)
+	if !ok {
+		return Message(err)
+	}
+	if message, ok := localized(canonicalCode(target.Code()), tags); ok {
+		return message
+	}
+	return Message(target)
+}
+
+// localized returns the message of a key in the first of the languages with one, trying the base language of each
+// tag after it
+func localized(key string, tags []string) (string, bool) {
+	messageMutex.RLock()
+	defer messageMutex.RUnlock()
+	for _, tag := range tags {
+		tag = strings.ToLower(tag)
+		if message, ok := messages[tag][key]; ok {
+			return message, true
+		}
+		if base, _, ok := strings.Cut(tag, "-"); ok {
+			if message, ok := messages[base][key]; ok {
+				return message, true
+			}
+		}
+	}
+	return "", false
+}
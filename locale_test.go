@@ -0,0 +1,52 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalizedMessage(t *testing.T) {
+	RegisterErrorCode("locale.declined", "Payment declined", ErrorEXDetail{})
+	RegisterAlias("locale.refused", "locale.declined")
+	RegisterMessages("pt", map[string]string{"locale.refused": "Pagamento recusado"})
+	RegisterMessages("PT-br", map[string]string{"locale.declined": "Pagamento negado"})
+	err := fmt.Errorf("charging: %w", New("locale.declined", ErrorEXDetail{}))
+
+	t.Run("should return the message of the first language with one", func(t *testing.T) {
+		assert.Equal(t, "Pagamento negado", LocalizedMessage(err, "de", "pt-BR"))
+	})
+
+	t.Run("should fall back to the base language", func(t *testing.T) {
+		assert.Equal(t, "Pagamento recusado", LocalizedMessage(err, "pt-PT"))
+	})
+
+	t.Run("should fall back to the description", func(t *testing.T) {
+		assert.Equal(t, "Payment declined", LocalizedMessage(err, "de"))
+		assert.Equal(t, "Payment declined", LocalizedMessage(err))
+	})
+}
@@ -0,0 +1,115 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"log/slog"
+	"sync/atomic"
+)
+
+// LogLevels maps codes and severities to the log level of their errors, so every logging integration logs an error
+// at the same level, e.g. auth.invalid_credentials at Info and db.connection_lost at Error, without deciding it at
+// each call site
+type LogLevels struct {
+	// Codes maps codes to levels, a code also applies to its aliases and descendants (see WithParent)
+	Codes map[string]slog.Level
+	// Severities maps severities to levels, for the codes not in Codes
+	Severities map[Severity]slog.Level
+}
+
+// LevelCritical is the default log level of SeverityCritical, above slog.LevelError
+const LevelCritical = slog.LevelError + 4
+
+// defaultSeverityLevels are the log levels of the severities not set in LogLevels.Severities
+var defaultSeverityLevels = map[Severity]slog.Level{
+	SeverityDebug:    slog.LevelDebug,
+	SeverityInfo:     slog.LevelInfo,
+	SeverityWarning:  slog.LevelWarn,
+	SeverityError:    slog.LevelError,
+	SeverityCritical: LevelCritical,
+}
+
+var (
+	logLevels atomic.Pointer[LogLevels]
+	// logLevelCodes holds the codes of the LogLevels, resolved once per registry generation
+	logLevelCodes atomic.Pointer[codeMapping[slog.Level]]
+)
+
+// SetLogLevels sets the mapping of codes and severities to log levels returned by LogLevel. The codes are resolved
+// when looked up, so they may be registered later. The zero LogLevels restores the defaults.
+func SetLogLevels(levels LogLevels) {
+	copied := LogLevels{
+		Codes:      make(map[string]slog.Level, len(levels.Codes)),
+		Severities: make(map[Severity]slog.Level, len(levels.Severities)),
+	}
+	for code, level := range levels.Codes {
+		copied.Codes[code] = level
+	}
+	for severity, level := range levels.Severities {
+		copied.Severities[severity] = level
+	}
+	logLevelCodes.Store(newCodeMapping(copied.Codes))
+	logLevels.Store(&copied)
+}
+
+// GetLogLevels returns the mapping set with SetLogLevels
+func GetLogLevels() LogLevels {
+	if levels := logLevels.Load(); levels != nil {
+		return *levels
+	}
+	return LogLevels{}
+}
+
+// LogLevel returns the level err is logged at, for logging integrations: the level of the code of the first
+// errorex in its chain, or of the nearest of its ancestors, set with SetLogLevels, else the level of its severity
+// (see SeverityOf). Errors escalated by Escalate log at the level of their escalated severity. By default the
+// severities map to the slog level of the same name and SeverityCritical to LevelCritical, so errors that are not
+// errorex errors log at slog.LevelError.
+func LogLevel(err error) slog.Level {
+	levels := GetLogLevels()
+	var target EX
+	if len(levels.Codes) > 0 && errors.As(err, &target) {
+		if _, escalated := escalatedSeverity(target); escalated {
+			return severityLogLevel(levels, SeverityOf(err))
+		}
+		if codes := logLevelCodes.Load(); codes != nil {
+			if level, ok := codes.lookup(target.Code()); ok {
+				return level
+			}
+		}
+	}
+	return severityLogLevel(levels, SeverityOf(err))
+}
+
+// severityLogLevel returns the level of the severity
+func severityLogLevel(levels LogLevels, severity Severity) slog.Level {
+	if level, ok := levels.Severities[severity]; ok {
+		return level
+	}
+	if level, ok := defaultSeverityLevels[severity]; ok {
+		return level
+	}
+	return slog.LevelError
+}
@@ -0,0 +1,76 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogLevel(t *testing.T) {
+	RegisterErrorCode("test.log.credentials", "test description", ErrorEXDetail{}, WithSeverity(SeverityWarning))
+	RegisterErrorCode("test.log.db", "test description", ErrorEXDetail{})
+	RegisterErrorCode("test.log.db.lost", "test description", ErrorEXDetail{}, WithParent("test.log.db"), WithSeverity(SeverityCritical))
+	RegisterErrorCode("test.log.debug", "test description", ErrorEXDetail{}, WithSeverity(SeverityDebug))
+	RegisterAlias("test.log.bad_credentials", "test.log.credentials")
+	defer SetLogLevels(LogLevels{})
+
+	t.Run("should map the severities by default", func(t *testing.T) {
+		SetLogLevels(LogLevels{})
+		assert.Equal(t, slog.LevelWarn, LogLevel(New("test.log.credentials", ErrorEXDetail{})))
+		assert.Equal(t, slog.LevelDebug, LogLevel(New("test.log.debug", ErrorEXDetail{})))
+		assert.Equal(t, LevelCritical, LogLevel(New("test.log.db.lost", ErrorEXDetail{})))
+		assert.Equal(t, slog.LevelError, LogLevel(errors.New("other")))
+	})
+
+	t.Run("should map the codes, their aliases and descendants", func(t *testing.T) {
+		SetLogLevels(LogLevels{Codes: map[string]slog.Level{
+			"test.log.bad_credentials": slog.LevelInfo,
+			"test.log.db":              slog.LevelError,
+		}})
+		assert.Equal(t, slog.LevelInfo, LogLevel(fmt.Errorf("login: %w", New("test.log.credentials", ErrorEXDetail{}))))
+		assert.Equal(t, slog.LevelError, LogLevel(New("test.log.db.lost", ErrorEXDetail{})))
+		assert.Equal(t, slog.LevelDebug, LogLevel(New("test.log.debug", ErrorEXDetail{})))
+	})
+
+	t.Run("should map the severities", func(t *testing.T) {
+		SetLogLevels(LogLevels{
+			Codes:      map[string]slog.Level{"test.log.db.lost": LevelCritical},
+			Severities: map[Severity]slog.Level{SeverityDebug: slog.LevelInfo, SeverityCritical: slog.LevelWarn},
+		})
+		assert.Equal(t, slog.LevelInfo, LogLevel(New("test.log.debug", ErrorEXDetail{})))
+		assert.Equal(t, LevelCritical, LogLevel(New("test.log.db.lost", ErrorEXDetail{})))
+	})
+
+	t.Run("should not share the mapping", func(t *testing.T) {
+		codes := map[string]slog.Level{"test.log.db": slog.LevelInfo}
+		SetLogLevels(LogLevels{Codes: codes})
+		codes["test.log.db"] = slog.LevelWarn
+		assert.Equal(t, slog.LevelInfo, GetLogLevels().Codes["test.log.db"])
+	})
+}
@@ -0,0 +1,153 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package lro encodes errorex errors in the status records of long-running operations, following the JSON mapping
+// of google.longrunning.Operation and google.rpc.Status, and rehydrates the typed errorex when clients poll them:
+//
+//	// worker
+//	store.Save(lro.Failed(operationID, err, metadata))
+//	// client polling the operation
+//	if ex, err := operation.Err(); err == nil && errorex.Is(ex, exports.ErrCodeQuotaExceeded) {
+//		...
+//	}
+//
+// The message of the status is the serialized errorex, like grpcex.Status, and its details hold the standard
+// ErrorInfo and RetryInfo.
+package lro
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// DefaultCode is the gRPC code of errors whose code has no gRPC code mapped, Unknown
+	DefaultCode = 2
+	// Domain is the domain of the ErrorInfo details
+	Domain = "errorex"
+	// ErrorInfoType is the type URL of the ErrorInfo details
+	ErrorInfoType = "type.googleapis.com/google.rpc.ErrorInfo"
+	// RetryInfoType is the type URL of the RetryInfo details
+	RetryInfoType = "type.googleapis.com/google.rpc.RetryInfo"
+)
+
+var defaultConverter = errorex.BuildErrorConverterChain()
+
+// Operation is the status record of a long-running operation
+type Operation struct {
+	// Name is the ID of the operation
+	Name string `json:"name"`
+	// Metadata is the progress of the operation, such as the step it is at
+	Metadata any `json:"metadata,omitempty"`
+	// Done tells if the operation is over, failed when Error is set
+	Done bool `json:"done"`
+	// Error is the terminal error of a failed operation
+	Error *Status `json:"error,omitempty"`
+	// Response is the result of a successful operation
+	Response any `json:"response,omitempty"`
+}
+
+// Status is the terminal error of an operation
+type Status struct {
+	// Code is the gRPC code mapped to the code of the errorex with errorex.WithGRPCCode, DefaultCode when not set
+	Code int `json:"code"`
+	// Message is the serialized errorex, see errorex.Error
+	Message string `json:"message"`
+	// Details are the ErrorInfo and RetryInfo of the errorex, decoded as maps when the status is parsed
+	Details []any `json:"details,omitempty"`
+}
+
+// ErrorInfo is the detail of a Status naming the code of the errorex
+type ErrorInfo struct {
+	Type   string `json:"@type"`
+	Reason string `json:"reason"`
+	Domain string `json:"domain"`
+}
+
+// RetryInfo is the detail of a Status with the hint set with errorex.WithRetryAfter
+type RetryInfo struct {
+	Type string `json:"@type"`
+	// RetryDelay is the delay in seconds with an "s" suffix, e.g. "1.5s"
+	RetryDelay string `json:"retryDelay"`
+}
+
+// Pending returns the record of an operation still running
+func Pending(name string, metadata any) Operation {
+	return Operation{Name: name, Metadata: metadata}
+}
+
+// Succeeded returns the record of an operation that completed with the response
+func Succeeded(name string, response any, metadata any) Operation {
+	return Operation{Name: name, Metadata: metadata, Done: true, Response: response}
+}
+
+// Failed returns the record of an operation that failed with err, see NewStatus. A nil err leaves Error nil.
+func Failed(name string, err error, metadata any) Operation {
+	return Operation{Name: name, Metadata: metadata, Done: true, Error: NewStatus(err)}
+}
+
+// NewStatus returns the terminal error of an operation failing with err. Errors that are not errorex errors are
+// converted as errorex.ErrCodeUnknownError. The message crosses the trust boundary like errorex.Boundary: details
+// hidden by the severity policy are left out, projections for errorex.SinkExternal are applied and the causes, stack
+// trace and metadata are stripped. It returns nil for a nil err.
+func NewStatus(err error) *Status {
+	if err == nil {
+		return nil
+	}
+	var ex errorex.EX
+	if !errors.As(err, &ex) {
+		ex = defaultConverter.ConvertError(err)
+	}
+	st := &Status{
+		Code:    DefaultCode,
+		Message: string(errorex.AppendError(nil, errorex.Boundary(ex))),
+		Details: []any{ErrorInfo{Type: ErrorInfoType, Reason: ex.Code(), Domain: Domain}},
+	}
+	if info, ok := errorex.Resolve(ex.Code()); ok && info.GRPCCode != 0 {
+		st.Code = int(info.GRPCCode)
+	}
+	if delay, ok := errorex.RetryAfter(ex); ok {
+		st.Details = append(st.Details, RetryInfo{Type: RetryInfoType, RetryDelay: strconv.FormatFloat(delay.Seconds(), 'f', -1, 64) + "s"})
+	}
+	return st
+}
+
+// Err returns the errorex the operation failed with, nil when it did not fail, see Status.Err
+func (o Operation) Err() (errorex.EX, error) {
+	if o.Error == nil {
+		return nil, nil
+	}
+	return o.Error.Err()
+}
+
+// Err parses the errorex carried in the message of a status created by NewStatus, see errorex.ParseJSON. The
+// errorex is stamped with the hop of the service, see errorex.Received.
+func (s *Status) Err() (errorex.EX, error) {
+	ex, err := errorex.ParseJSON([]byte(s.Message))
+	if err != nil {
+		return nil, err
+	}
+	return errorex.Received(ex), nil
+}
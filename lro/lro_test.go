@@ -0,0 +1,107 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package lro
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type exportDetail struct {
+	Bucket string `json:"bucket"`
+	Rows   int    `json:"rows"`
+}
+
+type progress struct {
+	Step string `json:"step"`
+}
+
+func TestFailed(t *testing.T) {
+	errorex.RegisterErrorCode("lro.export_failed", "Export failed", exportDetail{}, errorex.WithGRPCCode(14))
+	codec := errorex.GetJSONCodec()
+
+	t.Run("should encode the terminal error", func(t *testing.T) {
+		operation := Failed("operations/42", errorex.New("lro.export_failed", exportDetail{Bucket: "exports", Rows: 10},
+			errorex.WithRetryAfter(1500*time.Millisecond)), progress{Step: "upload"})
+		assert.True(t, operation.Done)
+		assert.Equal(t, 14, operation.Error.Code)
+		assert.Equal(t, []any{
+			ErrorInfo{Type: ErrorInfoType, Reason: "lro.export_failed", Domain: Domain},
+			RetryInfo{Type: RetryInfoType, RetryDelay: "1.5s"},
+		}, operation.Error.Details)
+	})
+
+	t.Run("should rehydrate the typed errorex", func(t *testing.T) {
+		data, err := codec.Marshal(Failed("operations/42", errorex.New("lro.export_failed", exportDetail{Bucket: "exports", Rows: 10}), nil))
+		assert.NoError(t, err)
+		var polled Operation
+		assert.NoError(t, codec.Unmarshal(data, &polled))
+		ex, err := polled.Err()
+		assert.NoError(t, err)
+		assert.True(t, errorex.Is(ex, "lro.export_failed"))
+		assert.Equal(t, exportDetail{Bucket: "exports", Rows: 10}, ex.Detail())
+	})
+
+	t.Run("should strip the causes, stack trace and metadata", func(t *testing.T) {
+		errorex.SetStackConfig(errorex.StackConfig{Enabled: true})
+		defer errorex.SetStackConfig(errorex.StackConfig{})
+		operation := Failed("operations/44", errorex.New("lro.export_failed", exportDetail{Bucket: "exports"},
+			errorex.WithCause(errors.New("pq: password authentication failed for user admin")),
+			errorex.WithMetadata("db_host", "10.0.0.4")), nil)
+
+		assert.Equal(t, `{"code": "lro.export_failed", "detail": {"bucket":"exports","rows":0}}`, operation.Error.Message)
+	})
+
+	t.Run("should convert other errors", func(t *testing.T) {
+		operation := Failed("operations/43", errors.New("boom"), nil)
+		assert.Equal(t, DefaultCode, operation.Error.Code)
+		ex, err := operation.Err()
+		assert.NoError(t, err)
+		assert.Equal(t, errorex.ErrCodeUnknownError, ex.Code())
+	})
+
+	t.Run("should have no error when the operation did not fail", func(t *testing.T) {
+		for _, operation := range []Operation{Pending("operations/1", progress{Step: "scan"}), Succeeded("operations/2", "ok", nil)} {
+			ex, err := operation.Err()
+			assert.Nil(t, ex)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("should have no status for a nil error", func(t *testing.T) {
+		assert.Nil(t, NewStatus(nil))
+		ex, err := Failed("operations/45", nil, nil).Err()
+		assert.Nil(t, ex)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should reject statuses without an errorex", func(t *testing.T) {
+		_, err := (&Status{Code: 13, Message: "internal error"}).Err()
+		assert.Error(t, err)
+	})
+}
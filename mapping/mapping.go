@@ -0,0 +1,436 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package mapping builds error converters from tables of rules, so the conversion rules of a service can be
+// maintained as data, by people who do not write Go. Each rule matches errors by Go type or by a regular expression
+// on their message and maps them to a registered code, with the fields of the detail rendered from text/template
+// expressions. The table is read as YAML or JSON:
+//
+//	rules:
+//	  - type: "*fs.PathError"
+//	    code: storage.not_found
+//	    detail:
+//	      path: "{{.Error.Path}}"
+//	  - pattern: "^dial tcp (?P<addr>\\S+): connect: connection refused$"
+//	    code: network.refused
+//	    detail:
+//	      address: "{{.Groups.addr}}"
+//
+// or as CSV, with a header naming the type, pattern and code columns and a "detail.<field>" column per field:
+//
+//	type,pattern,code,detail.address
+//	,^dial tcp (?P<addr>\S+): connect: connection refused$,network.refused,{{.Groups.addr}}
+//
+// The converter is built once the codes are registered:
+//
+//	converter, err := mapping.Load("conversions.yaml", data)
+//	chain := errorex.BuildErrorConverterChain(converter)
+package mapping
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"gopkg.in/yaml.v3"
+)
+
+// detailPrefix is the prefix of the CSV columns of the detail fields
+const detailPrefix = "detail."
+
+// Rule maps the errors it matches to a code
+type Rule struct {
+	// Type is the Go type of an error of the chain, as printed by %T, e.g. "*fs.PathError". Empty to match any type.
+	Type string `json:"type" yaml:"type"`
+	// Pattern is a regular expression matching the message of the error, the one of Type when set. Empty to match
+	// any message. Its named groups are available to the detail expressions.
+	Pattern string `json:"pattern" yaml:"pattern"`
+	// Code is the registered code of the converted errorex
+	Code string `json:"code" yaml:"code"`
+	// Detail are the text/template expressions of the fields of the detail, by JSON name. The templates are
+	// executed with a Match.
+	Detail map[string]string `json:"detail" yaml:"detail"`
+}
+
+// Match is the data of the detail expressions
+type Match struct {
+	// Error is the matched error, the error of the chain of Rule.Type when set
+	Error error
+	// Type is the Go type of the matched error
+	Type string
+	// Message is the message of the matched error
+	Message string
+	// Groups are the named groups of Rule.Pattern
+	Groups map[string]string
+}
+
+// table is the YAML and JSON document of the rules
+type table struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Load builds the converter of a table of rules, the format being chosen from the extension of its name: .yaml,
+// .yml or .json, read with ParseYAML, or .csv, read with ParseCSV
+func Load(name string, data []byte) (errorex.ErrorConverter, error) {
+	var rules []Rule
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		rules, err = ParseYAML(data)
+	case ".csv":
+		rules, err = ParseCSV(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("%s: unsupported table format, use .yaml, .yml, .json or .csv", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	converter, err := NewConverter(rules)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return converter, nil
+}
+
+// ParseYAML decodes the rules under the "rules" key of a YAML or JSON document
+func ParseYAML(data []byte) ([]Rule, error) {
+	var t table
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	return t.Rules, nil
+}
+
+// ParseCSV decodes the rules of a CSV table, one per row after the header. The header names the "type",
+// "pattern" and "code" columns, in any order, and a "detail.<field>" column per detail field; empty detail cells
+// are left out.
+func ParseCSV(r io.Reader) ([]Rule, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	header := records[0]
+	for _, column := range header {
+		switch column {
+		case "type", "pattern", "code":
+		default:
+			if !strings.HasPrefix(column, detailPrefix) || column == detailPrefix {
+				return nil, fmt.Errorf("unknown column %q", column)
+			}
+		}
+	}
+	rules := make([]Rule, 0, len(records)-1)
+	for _, record := range records[1:] {
+		var rule Rule
+		for i, value := range record {
+			switch column := header[i]; column {
+			case "type":
+				rule.Type = value
+			case "pattern":
+				rule.Pattern = value
+			case "code":
+				rule.Code = value
+			default:
+				if value == "" {
+					continue
+				}
+				if rule.Detail == nil {
+					rule.Detail = make(map[string]string)
+				}
+				rule.Detail[strings.TrimPrefix(column, detailPrefix)] = value
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// compiledRule is a rule ready to match
+type compiledRule struct {
+	Rule
+	pattern    *regexp.Regexp
+	detailType reflect.Type
+	fields     []compiledField
+}
+
+// compiledField is a detail field and its expression
+type compiledField struct {
+	name       string
+	index      []int
+	expression *template.Template
+}
+
+// tableErrorConverter converts the errors matched by the rules of a table
+type tableErrorConverter struct {
+	errorex.BaseErrorConverter
+	rules []compiledRule
+}
+
+// NewConverter builds the converter of the rules, the first matching rule converting an error. Errors matched by
+// no rule are delegated to the next handler in the chain. Fields whose expression fails, or renders a value that
+// cannot be parsed as the type of the field, are left zero.
+// It returns an error if a code is not registered, a pattern or an expression does not compile, or the detail
+// names a field that is not a field of the detail type (see errorex.DetailFields) of type string, bool, integer,
+// float, time.Duration or time.Time (RFC 3339).
+func NewConverter(rules []Rule) (errorex.ErrorConverter, error) {
+	converter := &tableErrorConverter{rules: make([]compiledRule, 0, len(rules))}
+	for i, rule := range rules {
+		compiled, err := compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		converter.rules = append(converter.rules, compiled)
+	}
+	return converter, nil
+}
+
+// compile validates the rule and compiles its pattern and expressions
+func compile(rule Rule) (compiledRule, error) {
+	compiled := compiledRule{Rule: rule}
+	info, ok := errorex.Lookup(rule.Code)
+	if !ok {
+		return compiled, fmt.Errorf("code %q is not registered", rule.Code)
+	}
+	compiled.Code = info.Code
+	compiled.detailType = info.DetailType
+	if rule.Pattern != "" {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return compiled, err
+		}
+		compiled.pattern = pattern
+	}
+	if len(rule.Detail) == 0 {
+		return compiled, nil
+	}
+	if info.DetailType == nil || info.DetailType.Kind() != reflect.Struct {
+		return compiled, fmt.Errorf("code %q has no detail struct", rule.Code)
+	}
+	fields := make(map[string]errorex.DetailField)
+	for _, field := range errorex.DetailFields(info.DetailType) {
+		fields[field.Name] = field
+	}
+	for _, name := range sortedKeys(rule.Detail) {
+		field, ok := fields[name]
+		if !ok {
+			return compiled, fmt.Errorf("%s has no field %q", info.DetailType, name)
+		}
+		if !settable(field.Field.Type) {
+			return compiled, fmt.Errorf("field %q of %s has unsupported type %s", name, info.DetailType, field.Field.Type)
+		}
+		expression, err := template.New(name).Option("missingkey=zero").Parse(rule.Detail[name])
+		if err != nil {
+			return compiled, err
+		}
+		compiled.fields = append(compiled.fields, compiledField{name: name, index: field.Field.Index, expression: expression})
+	}
+	return compiled, nil
+}
+
+// ConvertError converts the errors matched by a rule, delegating the others to the next handler in the chain
+func (c *tableErrorConverter) ConvertError(err error) errorex.EX {
+	for i := range c.rules {
+		if match, ok := c.rules[i].match(err); ok {
+			return errorex.New[any](c.rules[i].Code, c.rules[i].detail(match))
+		}
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
+
+// match returns the match of the rule on err
+func (r *compiledRule) match(err error) (Match, bool) {
+	target := err
+	if r.Type != "" {
+		if target = findType(err, r.Type); target == nil {
+			return Match{}, false
+		}
+	}
+	match := Match{Error: target, Type: fmt.Sprintf("%T", target), Message: target.Error()}
+	if r.pattern == nil {
+		return match, true
+	}
+	groups := r.pattern.FindStringSubmatch(match.Message)
+	if groups == nil {
+		return Match{}, false
+	}
+	match.Groups = make(map[string]string)
+	for i, name := range r.pattern.SubexpNames() {
+		if name != "" {
+			match.Groups[name] = groups[i]
+		}
+	}
+	return match, true
+}
+
+// detail renders the detail of the match
+func (r *compiledRule) detail(match Match) any {
+	if r.detailType == nil {
+		return nil
+	}
+	detail := reflect.New(r.detailType).Elem()
+	for _, field := range r.fields {
+		var rendered strings.Builder
+		if field.expression.Execute(&rendered, match) != nil {
+			continue
+		}
+		if target, ok := settableField(detail, field.index); ok {
+			_ = set(target, rendered.String())
+		}
+	}
+	return detail.Interface()
+}
+
+// settableField returns the field of the struct value at the index path, allocating the embedded pointers on the
+// way. It returns false when the path goes through an unexported embedded pointer.
+func settableField(value reflect.Value, index []int) (reflect.Value, bool) {
+	for i, position := range index {
+		if i > 0 && value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				if !value.CanSet() {
+					return reflect.Value{}, false
+				}
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.Field(position)
+	}
+	return value, value.CanSet()
+}
+
+// findType returns the first error of the chain of err, wrapped or joined, of the Go type
+func findType(err error, typeName string) error {
+	for depth, queue := 0, []error{err}; len(queue) > 0 && depth < maxDepth; depth++ {
+		current := queue[0]
+		queue = queue[1:]
+		if current == nil {
+			continue
+		}
+		if fmt.Sprintf("%T", current) == typeName {
+			return current
+		}
+		switch wrapper := current.(type) {
+		case interface{ Unwrap() error }:
+			queue = append(queue, wrapper.Unwrap())
+		case interface{ Unwrap() []error }:
+			queue = append(queue, wrapper.Unwrap()...)
+		}
+	}
+	return nil
+}
+
+// maxDepth is the number of errors of a chain after which findType stops looking
+const maxDepth = 64
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// settable tells if set can parse values of the type
+func settable(t reflect.Type) bool {
+	if t == durationType || t == timeType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// set parses the rendered value into the field
+func set(field reflect.Value, value string) error {
+	switch field.Type() {
+	case durationType:
+		duration, err := time.ParseDuration(value)
+		if err == nil {
+			field.SetInt(int64(duration))
+		}
+		return err
+	case timeType:
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err == nil {
+			field.Set(reflect.ValueOf(parsed))
+		}
+		return err
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	default:
+		return errors.New("unsupported type " + field.Type().String())
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the map in order, so errors are reported deterministically
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,150 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package mapping
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type notFoundDetail struct {
+	Path string `json:"path"`
+	Op   string `json:"op"`
+}
+
+type refusedDetail struct {
+	Address string        `json:"address"`
+	Port    int           `json:"port"`
+	Secure  bool          `json:"secure"`
+	Backoff time.Duration `json:"backoff"`
+	Load    float64       `json:"load"`
+}
+
+type requestInfo struct {
+	Method string `json:"method"`
+}
+
+// Origin is exported so encoding/json promotes the fields of the embedded pointer
+type Origin struct {
+	Host string `json:"host"`
+}
+
+type embeddedDetail struct {
+	Name string `json:"name"`
+	requestInfo
+	*Origin
+}
+
+func init() {
+	errorex.RegisterErrorCode("mapping.not_found", "Not found", notFoundDetail{})
+	errorex.RegisterErrorCode("mapping.refused", "Connection refused", refusedDetail{})
+	errorex.RegisterErrorCode("mapping.plain", "Plain", errorex.ErrorEXDetail{})
+	errorex.RegisterErrorCode("mapping.text", "Text", "")
+	errorex.RegisterErrorCode("mapping.embedded", "Embedded", embeddedDetail{})
+}
+
+const yamlTable = `
+rules:
+  - type: "*fs.PathError"
+    code: mapping.not_found
+    detail:
+      path: "{{.Error.Path}}"
+      op: "{{.Error.Op}}"
+  - pattern: "^dial tcp (?P<host>[^:]+):(?P<port>\\d+): connect: connection refused$"
+    code: mapping.refused
+    detail:
+      address: "{{.Groups.host}}"
+      port: "{{.Groups.port}}"
+      secure: "{{eq .Groups.port \"443\"}}"
+      backoff: "1s"
+      load: "not a number"
+  - pattern: "timeout"
+    code: mapping.plain
+`
+
+func TestLoad(t *testing.T) {
+	converter, err := Load("conversions.yaml", []byte(yamlTable))
+	assert.NoError(t, err)
+	chain := errorex.BuildErrorConverterChain(converter)
+
+	t.Run("should convert by type with the fields of the error", func(t *testing.T) {
+		_, openErr := os.Open("/errorex/missing")
+		ex := chain.ConvertError(fmt.Errorf("loading: %w", openErr))
+		assert.Equal(t, "mapping.not_found", ex.Code())
+		assert.Equal(t, notFoundDetail{Path: "/errorex/missing", Op: "open"}, ex.Detail())
+	})
+
+	t.Run("should convert by pattern with the named groups", func(t *testing.T) {
+		ex := chain.ConvertError(errors.New("dial tcp db.internal:443: connect: connection refused"))
+		assert.Equal(t, "mapping.refused", ex.Code())
+		assert.Equal(t, refusedDetail{Address: "db.internal", Port: 443, Secure: true, Backoff: time.Second}, ex.Detail())
+	})
+
+	t.Run("should convert rules without detail and delegate the others", func(t *testing.T) {
+		assert.Equal(t, "mapping.plain", chain.ConvertError(errors.New("read timeout")).Code())
+		assert.Equal(t, errorex.ErrCodeUnknownError, chain.ConvertError(errors.New("boom")).Code())
+	})
+
+	t.Run("should read CSV tables", func(t *testing.T) {
+		table := "code,type,detail.path,detail.op\n" +
+			"mapping.not_found,*fs.PathError,{{.Error.Path}},\n"
+		converter, err := Load("conversions.csv", []byte(table))
+		assert.NoError(t, err)
+		ex := errorex.BuildErrorConverterChain(converter).ConvertError(&fs.PathError{Op: "stat", Path: "/tmp/x", Err: fs.ErrNotExist})
+		assert.Equal(t, notFoundDetail{Path: "/tmp/x"}, ex.Detail())
+	})
+
+	t.Run("should set the fields of embedded structs", func(t *testing.T) {
+		table := "rules: [{code: mapping.embedded, detail: {name: users, method: GET, host: db.internal}}]"
+		converter, err := Load("embedded.yaml", []byte(table))
+		assert.NoError(t, err)
+		ex := errorex.BuildErrorConverterChain(converter).ConvertError(errors.New("boom"))
+		assert.Equal(t, embeddedDetail{Name: "users", requestInfo: requestInfo{Method: "GET"}, Origin: &Origin{Host: "db.internal"}},
+			ex.Detail())
+	})
+
+	t.Run("should reject invalid tables", func(t *testing.T) {
+		for name, table := range map[string]string{
+			"unregistered.yaml": "rules: [{code: mapping.missing}]",
+			"pattern.yaml":      "rules: [{code: mapping.plain, pattern: '('}]",
+			"field.yaml":        "rules: [{code: mapping.not_found, detail: {size: '1'}}]",
+			"expression.yaml":   "rules: [{code: mapping.not_found, detail: {path: '{{.Error'}}]",
+			"nodetail.yaml":     "rules: [{code: mapping.text, detail: {text: x}}]",
+			"column.csv":        "code,detail\nmapping.plain,x\n",
+			"table.toml":        "",
+		} {
+			_, err := Load(name, []byte(table))
+			assert.Error(t, err, name)
+			assert.True(t, strings.HasPrefix(err.Error(), name+": "), err.Error())
+		}
+	})
+}
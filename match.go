@@ -0,0 +1,142 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import "sync/atomic"
+
+// IsCode checks if the errorex has the code, aliases matching their code, like Is(e, code). It implements
+// CodeMatcher, so errorex errors can be checked without an EX:
+//
+//	if ex, ok := converter.ConvertError(err).(errorex.CodeMatcher); ok && ex.IsCode("db.not_found") {
+//		...
+//	}
+func (e *ex) IsCode(code string) bool {
+	return Is(e, code)
+}
+
+// Match returns the code of the first errorex in the chain of err, aliases resolved to their code, for switch
+// statements whose cases are codes:
+//
+//	switch errorex.Match(err) {
+//	case "db.not_found":
+//		...
+//	case "db.conflict":
+//		...
+//	}
+//
+// Cases must be canonical codes, an alias case never matches. It returns an empty string for nil and for errors
+// without an errorex in their chain.
+func Match(err error) string {
+	target, ok := firstEX(err)
+	if !ok {
+		return ""
+	}
+	return canonicalCode(target.Code())
+}
+
+// MapCodes returns a function translating errors into values of a protocol-specific enum, such as SIP response
+// codes, MQTT reason codes or FIX reject reasons, declared as a mapping of codes:
+//
+//	sipStatus := errorex.MapCodes(map[string]int{"call.busy": 486, "call.not_found": 404}, 500)
+//	reply(sipStatus(err))
+//
+// The code of the first errorex in the chain of err is looked up as is, then with its ancestors (see WithParent),
+// with the aliases in the mapping and of the errors resolved when translating, so codes may be registered later.
+// The aliases of the mapping are resolved again only when codes were registered since. Errors without a mapped
+// code translate to fallback and nil errors to the zero T. The mapping is copied.
+func MapCodes[T any](mapping map[string]T, fallback T) func(error) T {
+	codes := newCodeMapping(mapping)
+	return func(err error) T {
+		if err == nil {
+			var zero T
+			return zero
+		}
+		target, ok := firstEX(err)
+		if !ok {
+			return fallback
+		}
+		if value, ok := codes.lookup(target.Code()); ok {
+			return value
+		}
+		return fallback
+	}
+}
+
+// codeMapping is a copy of a mapping of codes whose aliases are resolved once per registry generation
+type codeMapping[T any] struct {
+	mapping  map[string]T
+	resolved atomic.Pointer[resolvedMapping[T]]
+}
+
+// resolvedMapping is the mapping of a codeMapping by canonical code, resolved at a registry generation
+type resolvedMapping[T any] struct {
+	generation uint64
+	values     map[string]T
+}
+
+// newCodeMapping copies the mapping
+func newCodeMapping[T any](mapping map[string]T) *codeMapping[T] {
+	copied := make(map[string]T, len(mapping))
+	for code, value := range mapping {
+		copied[code] = value
+	}
+	return &codeMapping[T]{mapping: copied}
+}
+
+// lookup returns the value mapped to the code, as is, or to the code or its nearest ancestor with the codes of the
+// mapping and the ancestors aliases resolved
+func (m *codeMapping[T]) lookup(code string) (T, bool) {
+	if value, ok := m.mapping[code]; ok {
+		return value, true
+	}
+	resolved := m.resolve()
+	current, ok := lookupCode(code)
+	for depth := 0; ok && depth <= maxParentDepth; depth++ {
+		if value, found := resolved[current.code]; found {
+			return value, true
+		}
+		if current.parent == "" {
+			break
+		}
+		current, ok = lookupCode(current.parent)
+	}
+	var zero T
+	return zero, false
+}
+
+// resolve returns the mapping by canonical code, resolved again when codes were registered since the last time
+func (m *codeMapping[T]) resolve() map[string]T {
+	generation := registryGeneration.Load()
+	if resolved := m.resolved.Load(); resolved != nil && resolved.generation == generation {
+		return resolved.values
+	}
+	values := make(map[string]T, len(m.mapping))
+	for mapped, value := range m.mapping {
+		if codeRegistry, ok := lookupCode(mapped); ok {
+			values[codeRegistry.code] = value
+		}
+	}
+	m.resolved.Store(&resolvedMapping[T]{generation: generation, values: values})
+	return values
+}
@@ -0,0 +1,115 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCode(t *testing.T) {
+	RegisterErrorCode("test.is_code", "test description", ErrorEXDetail{})
+	RegisterAlias("test.is_code_alias", "test.is_code")
+
+	t.Run("should match the code and its aliases", func(t *testing.T) {
+		err := New("test.is_code", ErrorEXDetail{}).(CodeMatcher)
+		assert.True(t, err.IsCode("test.is_code"))
+		assert.True(t, err.IsCode("test.is_code_alias"))
+		assert.False(t, err.IsCode(ErrCodeUnknownError))
+	})
+
+	t.Run("should not require IsCode from other implementations", func(t *testing.T) {
+		var target EX = codedEX{code: "test.is_code"}
+		_, ok := target.(CodeMatcher)
+		assert.False(t, ok)
+		assert.False(t, Is(target, "test.is_code"))
+	})
+}
+
+func TestMatch(t *testing.T) {
+	RegisterErrorCode("test.match", "test description", ErrorEXDetail{})
+	RegisterAlias("test.match_alias", "test.match")
+
+	t.Run("should return the canonical code of the first errorex", func(t *testing.T) {
+		err := fmt.Errorf("loading: %w", New("test.match_alias", ErrorEXDetail{}))
+		assert.Equal(t, "test.match", Match(err))
+	})
+
+	t.Run("should return the code of errorex created elsewhere", func(t *testing.T) {
+		assert.Equal(t, "foreign.code", Match(foreignEX{}))
+	})
+
+	t.Run("should return an empty string for other errors", func(t *testing.T) {
+		assert.Empty(t, Match(nil))
+		assert.Empty(t, Match(errors.New("boom")))
+	})
+}
+
+func TestMapCodes(t *testing.T) {
+	RegisterErrorCode("test.map.busy", "test description", ErrorEXDetail{})
+	RegisterErrorCode("test.map.call", "test description", ErrorEXDetail{})
+	RegisterErrorCode("test.map.call.rejected", "test description", ErrorEXDetail{}, WithParent("test.map.call"))
+	RegisterErrorCode("test.map.other", "test description", ErrorEXDetail{})
+	RegisterAlias("test.map.occupied", "test.map.busy")
+	sipStatus := MapCodes(map[string]int{"test.map.occupied": 486, "test.map.call": 603}, 500)
+
+	t.Run("should translate the mapped codes and their aliases", func(t *testing.T) {
+		assert.Equal(t, 486, sipStatus(fmt.Errorf("dialing: %w", New("test.map.busy", ErrorEXDetail{}))))
+		assert.Equal(t, 486, sipStatus(New("test.map.occupied", ErrorEXDetail{})))
+	})
+
+	t.Run("should translate the descendants of the mapped codes", func(t *testing.T) {
+		assert.Equal(t, 603, sipStatus(New("test.map.call.rejected", ErrorEXDetail{})))
+	})
+
+	t.Run("should translate other errors to the fallback", func(t *testing.T) {
+		assert.Equal(t, 500, sipStatus(New("test.map.other", ErrorEXDetail{})))
+		assert.Equal(t, 500, sipStatus(errors.New("boom")))
+		assert.Equal(t, 0, sipStatus(nil))
+	})
+
+	t.Run("should translate exact codes not registered locally", func(t *testing.T) {
+		assert.Equal(t, 404, MapCodes(map[string]int{"foreign.code": 404}, 500)(foreignEX{}))
+	})
+
+	t.Run("should resolve the codes registered after a translation", func(t *testing.T) {
+		status := MapCodes(map[string]int{"test.map.late_alias": 480}, 500)
+		assert.Equal(t, 500, status(New("test.map.other", ErrorEXDetail{})))
+
+		RegisterErrorCode("test.map.late", "test description", ErrorEXDetail{})
+		RegisterAlias("test.map.late_alias", "test.map.late")
+
+		assert.Equal(t, 480, status(New("test.map.late", ErrorEXDetail{})))
+	})
+
+	t.Run("should copy the mapping", func(t *testing.T) {
+		mapping := map[string]string{"test.map.other": "rejected"}
+		reason := MapCodes(mapping, "unknown")
+		mapping["test.map.other"] = "changed"
+		assert.Equal(t, "rejected", reason(New("test.map.other", ErrorEXDetail{})))
+	})
+}
@@ -0,0 +1,321 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package media provides the media.* errorex codes of upload and media processing failures and a converter for the
+// errors of the image decoders of the standard library and golang.org/x/image, of the size and dimension checks
+// of Limits and of ffmpeg and ffprobe run with os/exec:
+//
+//	limits := media.Limits{MaxWidth: 4096, MaxHeight: 4096, MaxSize: 10 << 20}
+//	if _, _, err := limits.DecodeConfig(bytes.NewReader(header)); err != nil {
+//		return converter.ConvertError(err)
+//	}
+//	img, _, err := image.Decode(limits.Reader(upload))
+package media
+
+import (
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/execex"
+)
+
+const (
+	// ErrCodeDecodeFailed is the errorex code for media that cannot be decoded, such as corrupt or truncated files
+	ErrCodeDecodeFailed = "media.decode_failed"
+	// ErrCodeUnsupportedFormat is the errorex code for media in a format or a variant of a format that is not
+	// supported
+	ErrCodeUnsupportedFormat = "media.unsupported_format"
+	// ErrCodeTooLarge is the errorex code for media exceeding the size or the dimensions of Limits
+	ErrCodeTooLarge = "media.too_large"
+	// ErrCodeProcessingFailed is the errorex code for ffmpeg and ffprobe failures that are not about the input
+	ErrCodeProcessingFailed = "media.processing_failed"
+)
+
+// Limits of LimitDetail
+const (
+	LimitWidth  = "width"
+	LimitHeight = "height"
+	LimitSize   = "size"
+)
+
+// FormatDetail is the detail of ErrCodeDecodeFailed and ErrCodeUnsupportedFormat
+type FormatDetail struct {
+	// Format is the name of the format, e.g. "png", empty when unknown
+	Format string `json:"format,omitempty"`
+	// Reason is the message of the decoder, without the format prefix
+	Reason string `json:"reason,omitempty"`
+}
+
+// LimitDetail is the detail of ErrCodeTooLarge
+type LimitDetail struct {
+	// Limit is the exceeded limit, LimitWidth, LimitHeight or LimitSize
+	Limit string `json:"limit"`
+	// Value is the width or height in pixels, or the size in bytes, of the media
+	Value int64 `json:"value"`
+	// Max is the maximum allowed value
+	Max int64 `json:"max"`
+}
+
+// ProcessingDetail is the detail of ErrCodeProcessingFailed
+type ProcessingDetail struct {
+	// Tool is the name of the executable, "ffmpeg" or "ffprobe"
+	Tool string `json:"tool"`
+	// ExitCode is the exit status of the tool, -1 when it was killed by a signal
+	ExitCode int `json:"exit_code"`
+	// Reason is the last line of the standard error of the tool, empty when it was not captured
+	Reason string `json:"reason,omitempty"`
+}
+
+func init() {
+	// Register the errorex codes
+	errorex.RegisterErrorCode(ErrCodeDecodeFailed, "Media cannot be decoded", FormatDetail{}, errorex.WithHTTPStatus(http.StatusUnprocessableEntity))
+	errorex.RegisterErrorCode(ErrCodeUnsupportedFormat, "Media format is not supported", FormatDetail{}, errorex.WithHTTPStatus(http.StatusUnsupportedMediaType))
+	errorex.RegisterErrorCode(ErrCodeTooLarge, "Media is too large", LimitDetail{}, errorex.WithHTTPStatus(http.StatusRequestEntityTooLarge))
+	errorex.RegisterErrorCode(ErrCodeProcessingFailed, "Media processing failed", ProcessingDetail{}, errorex.WithHTTPStatus(http.StatusInternalServerError))
+}
+
+// LimitError is the error of Limits for media exceeding a limit
+type LimitError struct {
+	// Limit is the exceeded limit, LimitWidth, LimitHeight or LimitSize
+	Limit string
+	// Value is the value of the media
+	Value int64
+	// Max is the maximum allowed value
+	Max int64
+}
+
+// Error describes the exceeded limit
+func (e *LimitError) Error() string {
+	return "media: " + e.Limit + " exceeds the limit"
+}
+
+// DecodeError is the error of Limits.DecodeConfig for images that cannot be decoded
+type DecodeError struct {
+	// Format is the name of the format of the image
+	Format string
+	// Err is the error of the decoder
+	Err error
+}
+
+// Error returns the message of the decoder
+func (e *DecodeError) Error() string {
+	return e.Format + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error of the decoder
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Limits are the maximum size and dimensions of media, zero limits are not enforced
+type Limits struct {
+	// MaxWidth is the maximum width in pixels
+	MaxWidth int
+	// MaxHeight is the maximum height in pixels
+	MaxHeight int
+	// MaxSize is the maximum size in bytes
+	MaxSize int64
+}
+
+// CheckSize returns a *LimitError when the size exceeds MaxSize
+func (l Limits) CheckSize(size int64) error {
+	if l.MaxSize > 0 && size > l.MaxSize {
+		return &LimitError{Limit: LimitSize, Value: size, Max: l.MaxSize}
+	}
+	return nil
+}
+
+// CheckDimensions returns a *LimitError when the width or the height of the image exceeds MaxWidth or MaxHeight
+func (l Limits) CheckDimensions(config image.Config) error {
+	if l.MaxWidth > 0 && config.Width > l.MaxWidth {
+		return &LimitError{Limit: LimitWidth, Value: int64(config.Width), Max: int64(l.MaxWidth)}
+	}
+	if l.MaxHeight > 0 && config.Height > l.MaxHeight {
+		return &LimitError{Limit: LimitHeight, Value: int64(config.Height), Max: int64(l.MaxHeight)}
+	}
+	return nil
+}
+
+// DecodeConfig decodes the format and the dimensions of an image with image.DecodeConfig and checks them against
+// the limits. The errors of the decoder, such as io.ErrUnexpectedEOF for truncated images, are wrapped in a
+// *DecodeError with the format. The decoders of the formats must be registered, e.g. by importing image/png.
+func (l Limits) DecodeConfig(r io.Reader) (image.Config, string, error) {
+	config, format, err := image.DecodeConfig(r)
+	var limitErr *LimitError
+	if err != nil && format != "" && !errors.As(err, &limitErr) {
+		return config, format, &DecodeError{Format: format, Err: err}
+	}
+	if err != nil {
+		return config, format, err
+	}
+	return config, format, l.CheckDimensions(config)
+}
+
+// Reader returns a reader of r failing with a *LimitError once more than MaxSize bytes are read, r itself when
+// MaxSize is not set. The rest of r is not read, so the value of the error is MaxSize+1.
+func (l Limits) Reader(r io.Reader) io.Reader {
+	if l.MaxSize <= 0 {
+		return r
+	}
+	return &limitedReader{reader: r, limits: l}
+}
+
+// limitedReader fails with a *LimitError once more than MaxSize bytes are read
+type limitedReader struct {
+	reader io.Reader
+	limits Limits
+	read   int64
+	err    error
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	// Read one byte past the limit to tell readers of exactly MaxSize bytes apart
+	if remaining := r.limits.MaxSize + 1 - r.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.err = r.limits.CheckSize(r.read); r.err != nil {
+		return n - 1, r.err
+	}
+	return n, err
+}
+
+// decoderFormats are the prefixes of the errors of the image decoders that have no typed errors
+var decoderFormats = []string{"gif", "bmp", "tiff", "webp", "vp8", "vp8l", "riff"}
+
+// ffmpegTools are the executables whose failures are converted
+var ffmpegTools = map[string]bool{"ffmpeg": true, "ffprobe": true}
+
+// ffmpegStderr are the messages of ffmpeg about its input, and the code they are converted to
+var ffmpegStderr = []struct {
+	message string
+	code    string
+}{
+	{"Invalid data found when processing input", ErrCodeDecodeFailed},
+	{"moov atom not found", ErrCodeDecodeFailed},
+	{"Unknown input format", ErrCodeUnsupportedFormat},
+	{"Decoder not found", ErrCodeUnsupportedFormat},
+	{"could not find codec parameters", ErrCodeUnsupportedFormat},
+}
+
+// mediaErrorConverter converts the errors of the image decoders, of Limits and of ffmpeg
+type mediaErrorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewMediaErrorConverter creates a converter of media errors:
+//   - a *LimitError becomes ErrCodeTooLarge
+//   - image.ErrFormat and the png and jpeg UnsupportedError become ErrCodeUnsupportedFormat
+//   - a *DecodeError, the png and jpeg FormatError, and the errors of the gif, bmp, tiff and webp decoders, become
+//     ErrCodeDecodeFailed
+//   - an *exec.ExitError of ffmpeg or ffprobe, run with the command wrapped by execex.WithCommand, becomes
+//     ErrCodeDecodeFailed or ErrCodeUnsupportedFormat when its standard error blames the input, ErrCodeProcessingFailed
+//     otherwise
+func NewMediaErrorConverter() errorex.ErrorConverter {
+	return &mediaErrorConverter{}
+}
+
+// ConvertError converts the media errors, delegating the others to the next handler in the chain
+func (c *mediaErrorConverter) ConvertError(err error) errorex.EX {
+	var limitErr *LimitError
+	if errors.As(err, &limitErr) {
+		return errorex.New(ErrCodeTooLarge, LimitDetail{Limit: limitErr.Limit, Value: limitErr.Value, Max: limitErr.Max})
+	}
+	if errors.Is(err, image.ErrFormat) {
+		return errorex.New(ErrCodeUnsupportedFormat, FormatDetail{})
+	}
+	var pngUnsupported png.UnsupportedError
+	if errors.As(err, &pngUnsupported) {
+		return errorex.New(ErrCodeUnsupportedFormat, formatDetail("png", pngUnsupported.Error()))
+	}
+	var jpegUnsupported jpeg.UnsupportedError
+	if errors.As(err, &jpegUnsupported) {
+		return errorex.New(ErrCodeUnsupportedFormat, formatDetail("jpeg", jpegUnsupported.Error()))
+	}
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return errorex.New(ErrCodeDecodeFailed, formatDetail(decodeErr.Format, decodeErr.Err.Error()))
+	}
+	var pngFormat png.FormatError
+	if errors.As(err, &pngFormat) {
+		return errorex.New(ErrCodeDecodeFailed, formatDetail("png", pngFormat.Error()))
+	}
+	var jpegFormat jpeg.FormatError
+	if errors.As(err, &jpegFormat) {
+		return errorex.New(ErrCodeDecodeFailed, formatDetail("jpeg", jpegFormat.Error()))
+	}
+	if ex, ok := convertFFmpeg(err); ok {
+		return ex
+	}
+	for _, format := range decoderFormats {
+		if strings.HasPrefix(err.Error(), format+": ") {
+			return errorex.New(ErrCodeDecodeFailed, formatDetail(format, err.Error()))
+		}
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
+
+// convertFFmpeg converts the exit errors of ffmpeg and ffprobe
+func convertFFmpeg(err error) (errorex.EX, bool) {
+	var commandErr *execex.CommandError
+	var exitErr *exec.ExitError
+	if !errors.As(err, &commandErr) || !errors.As(err, &exitErr) {
+		return nil, false
+	}
+	executable, _, _ := strings.Cut(commandErr.Command, " ")
+	tool := strings.TrimSuffix(filepath.Base(executable), ".exe")
+	if !ffmpegTools[tool] {
+		return nil, false
+	}
+	stderr := string(exitErr.Stderr)
+	reason := lastLine(stderr)
+	for _, known := range ffmpegStderr {
+		if strings.Contains(stderr, known.message) {
+			return errorex.New(known.code, FormatDetail{Reason: reason}), true
+		}
+	}
+	return errorex.New(ErrCodeProcessingFailed, ProcessingDetail{Tool: tool, ExitCode: exitErr.ExitCode(), Reason: reason}), true
+}
+
+// formatDetail returns the detail of a decoder error, the format prefix removed from the message
+func formatDetail(format, message string) FormatDetail {
+	return FormatDetail{Format: format, Reason: strings.TrimPrefix(message, format+": ")}
+}
+
+// lastLine returns the last non-empty line of the output
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,127 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"os/exec"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/execex"
+	"github.com/stretchr/testify/assert"
+)
+
+// encodedPNG returns a PNG image of the size
+func encodedPNG(t *testing.T, width, height int) []byte {
+	var buffer bytes.Buffer
+	assert.NoError(t, png.Encode(&buffer, image.NewGray(image.Rect(0, 0, width, height))))
+	return buffer.Bytes()
+}
+
+func TestLimits(t *testing.T) {
+	limits := Limits{MaxWidth: 64, MaxHeight: 32, MaxSize: 1 << 10}
+
+	t.Run("should decode the images within the limits", func(t *testing.T) {
+		config, format, err := limits.DecodeConfig(bytes.NewReader(encodedPNG(t, 64, 32)))
+		assert.NoError(t, err)
+		assert.Equal(t, "png", format)
+		assert.Equal(t, 64, config.Width)
+	})
+
+	t.Run("should reject the images exceeding the dimensions", func(t *testing.T) {
+		_, _, err := limits.DecodeConfig(bytes.NewReader(encodedPNG(t, 16, 33)))
+		assert.Equal(t, &LimitError{Limit: LimitHeight, Value: 33, Max: 32}, err)
+	})
+
+	t.Run("should reject the images exceeding the size", func(t *testing.T) {
+		_, err := png.Decode(Limits{MaxSize: 8}.Reader(bytes.NewReader(encodedPNG(t, 1, 1))))
+		var limitErr *LimitError
+		assert.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, &LimitError{Limit: LimitSize, Value: 9, Max: 8}, limitErr)
+		assert.NoError(t, Limits{}.CheckSize(1<<40))
+	})
+
+	t.Run("should wrap the decoder errors with the format", func(t *testing.T) {
+		_, _, err := limits.DecodeConfig(bytes.NewReader(encodedPNG(t, 1, 1)[:20]))
+		var decodeErr *DecodeError
+		assert.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, "png", decodeErr.Format)
+	})
+}
+
+func TestMediaErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewMediaErrorConverter())
+
+	t.Run("should convert the limit errors", func(t *testing.T) {
+		ex := converter.ConvertError(&LimitError{Limit: LimitWidth, Value: 8000, Max: 4096})
+		assert.Equal(t, ErrCodeTooLarge, ex.Code())
+		assert.Equal(t, LimitDetail{Limit: LimitWidth, Value: 8000, Max: 4096}, ex.Detail())
+	})
+
+	t.Run("should convert the unsupported formats", func(t *testing.T) {
+		_, _, err := image.DecodeConfig(bytes.NewReader([]byte("GIF89a not registered")))
+		assert.Equal(t, ErrCodeUnsupportedFormat, converter.ConvertError(err).Code())
+		ex := converter.ConvertError(png.UnsupportedError("bit depth 3"))
+		assert.Equal(t, FormatDetail{Format: "png", Reason: "unsupported feature: bit depth 3"}, ex.Detail())
+	})
+
+	t.Run("should convert the decode failures", func(t *testing.T) {
+		corrupt := encodedPNG(t, 1, 1)
+		corrupt[29] ^= 0xff
+		_, err := png.Decode(bytes.NewReader(corrupt))
+		ex := converter.ConvertError(err)
+		assert.Equal(t, ErrCodeDecodeFailed, ex.Code())
+		assert.Equal(t, FormatDetail{Format: "png", Reason: "invalid format: invalid checksum"}, ex.Detail())
+		_, _, err = Limits{}.DecodeConfig(bytes.NewReader(encodedPNG(t, 1, 1)[:20]))
+		assert.Equal(t, FormatDetail{Format: "png", Reason: "unexpected EOF"}, converter.ConvertError(err).Detail())
+		ex = converter.ConvertError(errors.New("gif: no color table"))
+		assert.Equal(t, FormatDetail{Format: "gif", Reason: "no color table"}, ex.Detail())
+	})
+
+	t.Run("should convert the ffmpeg failures", func(t *testing.T) {
+		cmd := exec.Command("sh", "-c", "echo 'input.mp4: Invalid data found when processing input' >&2; exit 1")
+		_, err := cmd.Output()
+		cmd.Args[0] = "/usr/bin/ffmpeg"
+		ex := converter.ConvertError(execex.WithCommand(err, cmd))
+		assert.Equal(t, ErrCodeDecodeFailed, ex.Code())
+		assert.Equal(t, FormatDetail{Reason: "input.mp4: Invalid data found when processing input"}, ex.Detail())
+
+		cmd = exec.Command("sh", "-c", "echo 'Conversion failed!' >&2; exit 2")
+		_, err = cmd.Output()
+		cmd.Args[0] = "ffprobe"
+		ex = converter.ConvertError(execex.WithCommand(err, cmd))
+		assert.Equal(t, ErrCodeProcessingFailed, ex.Code())
+		assert.Equal(t, ProcessingDetail{Tool: "ffprobe", ExitCode: 2, Reason: "Conversion failed!"}, ex.Detail())
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		cmd := exec.Command("sh", "-c", "exit 1")
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(execex.WithCommand(cmd.Run(), cmd)).Code())
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(errors.New("boom")).Code())
+	})
+}
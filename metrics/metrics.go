@@ -0,0 +1,91 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package metrics labels error series by code, severity and SLO impact class, so availability dashboards can
+// exclude client faults without listing codes. It does not depend on a metrics library, a prometheus CounterVec
+// is plugged as:
+//
+//	errors := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "errors_total"}, metrics.LabelNames)
+//	counter := metrics.Counter(func(values ...string) { errors.WithLabelValues(values...).Inc() })
+//	counter.Observe(err)
+//
+// and the availability query filters on fault_class!="client_fault". A Monitor fires callbacks when the rate of a
+// code exceeds a threshold, for in-process alerting or load shedding without a monitoring system:
+//
+//	monitor := metrics.NewMonitor(metrics.Rule{Code: "db.timeout", Threshold: 100, Window: time.Minute, OnBreach: shed})
+//	monitor.Observe(err)
+package metrics
+
+import (
+	"errors"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// Label names, in the order of the values returned by Values
+const (
+	LabelCode       = "code"
+	LabelSeverity   = "severity"
+	LabelFaultClass = "fault_class"
+)
+
+// OtherCode is the code label of errors without an errorex in their chain
+const OtherCode = "other"
+
+// LabelNames are the names of the labels, in the order of the values returned by Values
+var LabelNames = []string{LabelCode, LabelSeverity, LabelFaultClass}
+
+// Values returns the label values of err, in the order of LabelNames: the code of the first errorex in its chain
+// (aliases resolved), its severity and its fault class.
+func Values(err error) []string {
+	code := OtherCode
+	var target errorex.EX
+	if errors.As(err, &target) {
+		code = target.Code()
+		if info, ok := errorex.Lookup(code); ok {
+			code = info.Code
+		}
+	}
+	return []string{code, errorex.SeverityOf(err).String(), string(errorex.FaultClass(err))}
+}
+
+// Labels returns the label values of err by label name
+func Labels(err error) map[string]string {
+	values := Values(err)
+	labels := make(map[string]string, len(values))
+	for i, name := range LabelNames {
+		labels[name] = values[i]
+	}
+	return labels
+}
+
+// Counter increments the series with the label values
+type Counter func(values ...string)
+
+// Observe counts err in its series, nil errors are not counted
+func (c Counter) Observe(err error) {
+	if err == nil {
+		return
+	}
+	c(Values(err)...)
+}
@@ -0,0 +1,78 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	errorex.RegisterErrorCode("metrics.invalid", "Invalid", errorex.ErrorEXDetail{},
+		errorex.WithHTTPStatus(400), errorex.WithSeverity(errorex.SeverityInfo))
+	errorex.RegisterErrorCode("metrics.unavailable", "Unavailable", errorex.ErrorEXDetail{}, errorex.WithHTTPStatus(503))
+	errorex.RegisterAlias("metrics.bad_request", "metrics.invalid")
+}
+
+func TestValues(t *testing.T) {
+	t.Run("should label by code, severity and fault class", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", errorex.New("metrics.invalid", errorex.ErrorEXDetail{}))
+		assert.Equal(t, []string{"metrics.invalid", "info", "client_fault"}, Values(err))
+		assert.Equal(t, map[string]string{
+			"code":        "metrics.invalid",
+			"severity":    "info",
+			"fault_class": "client_fault",
+		}, Labels(err))
+	})
+
+	t.Run("should resolve aliases", func(t *testing.T) {
+		err := errorex.New("metrics.bad_request", errorex.ErrorEXDetail{})
+		assert.Equal(t, "metrics.invalid", Values(err)[0])
+	})
+
+	t.Run("should label other errors", func(t *testing.T) {
+		assert.Equal(t, []string{OtherCode, "error", "server_fault"}, Values(errors.New("other")))
+	})
+}
+
+func TestCounter(t *testing.T) {
+	counts := map[string]int{}
+	counter := Counter(func(values ...string) {
+		counts[values[0]+"/"+values[2]]++
+	})
+
+	counter.Observe(errorex.New("metrics.unavailable", errorex.ErrorEXDetail{}))
+	counter.Observe(errorex.New("metrics.unavailable", errorex.ErrorEXDetail{}))
+	counter.Observe(errorex.New("metrics.invalid", errorex.ErrorEXDetail{}))
+	counter.Observe(nil)
+
+	assert.Equal(t, map[string]int{
+		"metrics.unavailable/dependency_fault": 2,
+		"metrics.invalid/client_fault":         1,
+	}, counts)
+}
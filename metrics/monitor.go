@@ -0,0 +1,144 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// Rule fires when more than Threshold errors of a code are observed within Window, e.g. more than 100 db.timeout
+// per minute, for in-process alerting or load shedding
+type Rule struct {
+	// Code is the code counted, its aliases included, empty counts every error
+	Code      string
+	Threshold int
+	Window    time.Duration
+	// OnBreach is called, outside the Monitor lock, once when the rule is breached. It is called again only after
+	// the rate drops back to the threshold.
+	OnBreach func(breach Breach)
+}
+
+// Breach describes a breached Rule
+type Breach struct {
+	Rule Rule
+	// Count is the number of errors counted within the window, Threshold + 1
+	Count int
+	// Since is the time of the oldest error counted
+	Since time.Time
+	// Err is the error that breached the rule
+	Err error
+}
+
+// ruleState is a Rule with the times of its latest Threshold + 1 errors
+type ruleState struct {
+	rule     Rule
+	code     string
+	times    []time.Time
+	next     int
+	breached bool
+}
+
+// Monitor counts errors against threshold rules, observing them like a Counter
+type Monitor struct {
+	mutex sync.Mutex
+	rules []*ruleState
+	now   func() time.Time
+}
+
+// NewMonitor creates a Monitor with the rules
+func NewMonitor(rules ...Rule) *Monitor {
+	monitor := &Monitor{now: time.Now}
+	for _, rule := range rules {
+		monitor.AddRule(rule)
+	}
+	return monitor
+}
+
+// AddRule adds a rule to the Monitor, a negative threshold counts as zero
+func (m *Monitor) AddRule(rule Rule) {
+	if rule.Threshold < 0 {
+		rule.Threshold = 0
+	}
+	code := rule.Code
+	if info, ok := errorex.Lookup(code); ok {
+		code = info.Code
+	}
+	m.mutex.Lock()
+	m.rules = append(m.rules, &ruleState{rule: rule, code: code, times: make([]time.Time, 0, rule.Threshold+1)})
+	m.mutex.Unlock()
+}
+
+// Observe counts err against the rules and fires the breached ones, nil errors are not counted
+func (m *Monitor) Observe(err error) {
+	if err == nil {
+		return
+	}
+	code := Values(err)[0]
+	now := m.now()
+	var breaches []Breach
+	m.mutex.Lock()
+	for _, state := range m.rules {
+		if state.code != "" && state.code != code {
+			continue
+		}
+		if breach, ok := state.observe(now); ok {
+			breach.Err = err
+			breaches = append(breaches, breach)
+		}
+	}
+	m.mutex.Unlock()
+	for _, breach := range breaches {
+		if breach.Rule.OnBreach != nil {
+			breach.Rule.OnBreach(breach)
+		}
+	}
+}
+
+// observe records an error at now, telling if it breaches the rule
+func (s *ruleState) observe(now time.Time) (Breach, bool) {
+	size := s.rule.Threshold + 1
+	if len(s.times) < size {
+		s.times = append(s.times, now)
+	} else {
+		s.times[s.next] = now
+		s.next = (s.next + 1) % size
+	}
+	if len(s.times) < size {
+		return Breach{}, false
+	}
+	// the ring is full, its oldest entry is the Threshold + 1 latest error
+	oldest := s.times[s.next]
+	if now.Sub(oldest) >= s.rule.Window {
+		s.breached = false
+		return Breach{}, false
+	}
+	if s.breached {
+		return Breach{}, false
+	}
+	s.breached = true
+	return Breach{Rule: s.rule, Count: size, Since: oldest}, true
+}
@@ -0,0 +1,111 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitor(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newMonitor := func(now *time.Time, rules ...Rule) *Monitor {
+		monitor := NewMonitor(rules...)
+		monitor.now = func() time.Time { return *now }
+		return monitor
+	}
+
+	t.Run("should fire once when the threshold is exceeded within the window", func(t *testing.T) {
+		now := start
+		var breaches []Breach
+		monitor := newMonitor(&now, Rule{
+			Code: "metrics.unavailable", Threshold: 2, Window: time.Minute,
+			OnBreach: func(breach Breach) { breaches = append(breaches, breach) },
+		})
+		for range 2 {
+			monitor.Observe(errorex.New("metrics.unavailable", errorex.ErrorEXDetail{}))
+			now = now.Add(10 * time.Second)
+		}
+		assert.Empty(t, breaches)
+		last := errorex.New("metrics.unavailable", errorex.ErrorEXDetail{})
+		monitor.Observe(last)
+		monitor.Observe(errorex.New("metrics.unavailable", errorex.ErrorEXDetail{}))
+		assert.Len(t, breaches, 1)
+		assert.Equal(t, 3, breaches[0].Count)
+		assert.Equal(t, start, breaches[0].Since)
+		assert.Equal(t, last, breaches[0].Err)
+	})
+
+	t.Run("should not fire when the errors are spread over the window", func(t *testing.T) {
+		now := start
+		fired := 0
+		monitor := newMonitor(&now, Rule{
+			Code: "metrics.unavailable", Threshold: 2, Window: time.Minute,
+			OnBreach: func(Breach) { fired++ },
+		})
+		for range 10 {
+			monitor.Observe(errorex.New("metrics.unavailable", errorex.ErrorEXDetail{}))
+			now = now.Add(30 * time.Second)
+		}
+		assert.Zero(t, fired)
+	})
+
+	t.Run("should fire again after the rate recovers", func(t *testing.T) {
+		now := start
+		fired := 0
+		monitor := newMonitor(&now, Rule{
+			Code: "metrics.unavailable", Threshold: 1, Window: time.Minute,
+			OnBreach: func(Breach) { fired++ },
+		})
+		burst := func() {
+			for range 3 {
+				monitor.Observe(errorex.New("metrics.unavailable", errorex.ErrorEXDetail{}))
+			}
+		}
+		burst()
+		now = now.Add(2 * time.Minute)
+		monitor.Observe(errorex.New("metrics.unavailable", errorex.ErrorEXDetail{}))
+		now = now.Add(2 * time.Minute)
+		burst()
+		assert.Equal(t, 2, fired)
+	})
+
+	t.Run("should count aliases and other codes separately", func(t *testing.T) {
+		now := start
+		var codes []string
+		monitor := newMonitor(&now,
+			Rule{Code: "metrics.bad_request", Threshold: 1, Window: time.Minute, OnBreach: func(Breach) { codes = append(codes, "invalid") }},
+			Rule{Threshold: 2, Window: time.Minute, OnBreach: func(Breach) { codes = append(codes, "any") }},
+		)
+		monitor.Observe(errorex.New("metrics.invalid", errorex.ErrorEXDetail{}))
+		monitor.Observe(errors.New("other"))
+		monitor.Observe(errorex.New("metrics.bad_request", errorex.ErrorEXDetail{}))
+		monitor.Observe(nil)
+		assert.Equal(t, []string{"invalid", "any"}, codes)
+	})
+}
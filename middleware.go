@@ -0,0 +1,98 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+// ConvertFunc converts an error into an errorex, like ErrorConverter.ConvertError
+type ConvertFunc func(err error) EX
+
+// Middleware runs around the conversion of a chain, before and after it, composable like HTTP middleware:
+//
+//	chain := errorex.WithMiddleware(errorex.BuildErrorConverterChain(converters...),
+//		errorex.OnUnknown(func(err error, ex errorex.EX) { log.Printf("unconverted error: %v", err) }),
+//		errorex.Stamp(func(ex errorex.EX) []errorex.Option {
+//			return []errorex.Option{errorex.WithMetadata("trace_id", traceID())}
+//		}),
+//	)
+type Middleware func(next ConvertFunc) ConvertFunc
+
+// middlewareConverter runs a chain through its middleware
+type middlewareConverter struct {
+	chain   ErrorConverter
+	convert ConvertFunc
+	// next converts the errors the chain does not convert
+	next ErrorConverter
+}
+
+// WithMiddleware returns a converter running the conversions of the chain through the middleware, the first
+// middleware being the outermost. The handler set with SetNext converts the errors the chain returns nil for, the
+// chain itself is not modified.
+func WithMiddleware(chain ErrorConverter, middleware ...Middleware) ErrorConverter {
+	convert := ConvertFunc(chain.ConvertError)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		convert = middleware[i](convert)
+	}
+	return &middlewareConverter{chain: chain, convert: convert}
+}
+
+// ConvertError converts the error through the middleware
+func (c *middlewareConverter) ConvertError(err error) EX {
+	if converted := c.convert(err); converted != nil || c.next == nil || err == nil {
+		return converted
+	}
+	return c.next.ConvertError(err)
+}
+
+// SetNext sets the handler converting the errors the chain does not convert
+func (c *middlewareConverter) SetNext(next ErrorConverter) {
+	c.next = next
+}
+
+// Stamp is a Middleware applying the options returned by fn to every errorex produced by the chain, e.g. to stamp
+// trace IDs. The errorex is copied before the options are applied, so errors passed through the chain unchanged
+// are never modified. Errorex errors of other implementations are returned as is.
+func Stamp(fn func(ex EX) []Option) Middleware {
+	return func(next ConvertFunc) ConvertFunc {
+		return func(err error) EX {
+			converted := next(err)
+			if _, ok := converted.(*ex); !ok {
+				return converted
+			}
+			return Annotate(converted, fn(converted)...)
+		}
+	}
+}
+
+// OnUnknown is a Middleware calling fn when the chain falls back to ErrCodeUnknownError, e.g. to log the errors
+// that still lack a converter
+func OnUnknown(fn func(err error, ex EX)) Middleware {
+	return func(next ConvertFunc) ConvertFunc {
+		return func(err error) EX {
+			converted := next(err)
+			if converted != nil && converted.Code() == ErrCodeUnknownError {
+				fn(err, converted)
+			}
+			return converted
+		}
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMiddleware(t *testing.T) {
+	t.Run("should run the middleware around the chain in order", func(t *testing.T) {
+		var calls []string
+		trace := func(name string) Middleware {
+			return func(next ConvertFunc) ConvertFunc {
+				return func(err error) EX {
+					calls = append(calls, "before "+name)
+					converted := next(err)
+					calls = append(calls, "after "+name)
+					return converted
+				}
+			}
+		}
+		chain := WithMiddleware(BuildErrorConverterChain(), trace("outer"), trace("inner"))
+
+		converted := chain.ConvertError(errors.New("boom"))
+
+		assert.Equal(t, ErrCodeUnknownError, converted.Code())
+		assert.Equal(t, []string{"before outer", "before inner", "after inner", "after outer"}, calls)
+	})
+
+	t.Run("should stamp copies of the converted errors", func(t *testing.T) {
+		chain := WithMiddleware(BuildErrorConverterChain(), Stamp(func(ex EX) []Option {
+			return []Option{WithMetadata("trace_id", "abc")}
+		}))
+		original := New(ErrCodeNotRegistered, ErrorEXDetail{Code: "x"})
+
+		stamped := chain.ConvertError(original)
+
+		metadata, _ := Metadata(stamped)
+		assert.Equal(t, map[string]string{"trace_id": "abc"}, metadata)
+		_, ok := Metadata(original)
+		assert.False(t, ok)
+	})
+
+	t.Run("should report the unknown fallbacks", func(t *testing.T) {
+		var unknown []error
+		chain := WithMiddleware(BuildErrorConverterChain(), OnUnknown(func(err error, ex EX) {
+			unknown = append(unknown, err)
+		}))
+		boom := errors.New("boom")
+
+		chain.ConvertError(boom)
+		chain.ConvertError(New(ErrCodeNotRegistered, ErrorEXDetail{}))
+
+		assert.Equal(t, []error{boom}, unknown)
+	})
+	t.Run("should delegate the errors the chain does not convert without modifying it", func(t *testing.T) {
+		inner := &codeErrorConverter{message: "inner"}
+		chain := WithMiddleware(inner)
+		chain.SetNext(&codeErrorConverter{message: "next"})
+
+		assert.Equal(t, ErrorEXDetail{Code: "inner"}, chain.ConvertError(errors.New("inner")).Detail())
+		assert.Equal(t, ErrorEXDetail{Code: "next"}, chain.ConvertError(errors.New("next")).Detail())
+		assert.Nil(t, chain.ConvertError(errors.New("other")))
+		assert.Nil(t, inner.ConvertError(errors.New("next")))
+	})
+}
+
+// codeErrorConverter converts the errors with its message into ErrCodeNotRegistered errors
+type codeErrorConverter struct {
+	BaseErrorConverter
+	message string
+}
+
+func (c *codeErrorConverter) ConvertError(err error) EX {
+	if err.Error() != c.message {
+		return c.BaseErrorConverter.ConvertError(err)
+	}
+	return New(ErrCodeNotRegistered, ErrorEXDetail{Code: c.message})
+}
@@ -0,0 +1,64 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import "sync/atomic"
+
+// Mode tells how errorex handles programmer errors, such as creating an errorex with an unregistered code or a
+// detail of the wrong type
+type Mode int32
+
+const (
+	// Strict panics with a RegistrationError on programmer errors, the default
+	Strict Mode = iota
+	// Lenient returns an ErrCodeInternal errorex describing the programmer error from the functions returning an
+	// errorex (New, Wrap, NewPooled, WithDetail, Definition.New) and ignores it in Release and Is, so the same code
+	// can run in production where panics are unacceptable. Misuses while registering codes still panic, as they
+	// happen during initialization.
+	Lenient
+)
+
+var mode atomic.Int32
+
+// ErrorEXInternal is the detail of ErrCodeInternal, describing the programmer error
+type ErrorEXInternal struct {
+	// Code is the code of the programmer error, e.g. ErrCodeNotRegistered
+	Code string `json:"code"`
+	// Description is the description of the code
+	Description string `json:"description"`
+	// Detail is the detail of the programmer error, e.g. an ErrorEXDetail naming the unregistered code
+	Detail any `json:"detail"`
+	// Caller is the call site outside errorex of the misused function
+	Caller Frame `json:"caller"`
+}
+
+// SetMode sets how errorex handles programmer errors, Strict by default
+func SetMode(m Mode) {
+	mode.Store(int32(m))
+}
+
+// GetMode returns how errorex handles programmer errors
+func GetMode() Mode {
+	return Mode(mode.Load())
+}
@@ -0,0 +1,71 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetMode(t *testing.T) {
+	t.Run("should panic in strict mode", func(t *testing.T) {
+		assert.Equal(t, Strict, GetMode())
+		assert.Panics(t, func() {
+			New("mode.unregistered", ErrorEXDetail{})
+		})
+	})
+
+	t.Run("should return internal errors in lenient mode", func(t *testing.T) {
+		SetMode(Lenient)
+		defer SetMode(Strict)
+
+		err := New("mode.unregistered", ErrorEXDetail{})
+
+		assert.True(t, Is(err, ErrCodeInternal))
+		detail := err.Detail().(ErrorEXInternal)
+		assert.Equal(t, ErrCodeNotRegistered, detail.Code)
+		assert.Equal(t, "Errorex code not registered", detail.Description)
+		assert.Equal(t, ErrorEXDetail{Code: "mode.unregistered"}, detail.Detail)
+		assert.Contains(t, detail.Caller.File, "mode_test.go")
+
+		mismatch := Wrap(nil, ErrCodeNotRegistered, UnknownErrorDetail{})
+
+		assert.Equal(t, ErrDetailTypeMismatch, mismatch.Detail().(ErrorEXInternal).Code)
+	})
+
+	t.Run("should ignore misuses without errors to return in lenient mode", func(t *testing.T) {
+		SetMode(Lenient)
+		defer SetMode(Strict)
+		SetStrictIs(true)
+		defer SetStrictIs(false)
+		pooled := NewPooled(ErrCodeNotRegistered, ErrorEXDetail{})
+		Release(pooled)
+
+		assert.NotPanics(t, func() {
+			Release(pooled)
+			Is(pooled, "mode.unregistered")
+		})
+	})
+}
@@ -0,0 +1,151 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package mq
+
+import (
+	"context"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// DefaultMaxAttempts is the number of deliveries of a message failing with retryable errors before it is
+	// dead-lettered, when ConsumerOptions.MaxAttempts is not set
+	DefaultMaxAttempts = 5
+)
+
+// Decision is what the consumer should do with a processed message
+type Decision int
+
+const (
+	// Ack acknowledges the message, it was processed
+	Ack Decision = iota
+	// Nack rejects the message without requeuing it, the broker may route it to its own dead-letter queue
+	Nack
+	// Requeue asks the broker to deliver the message again
+	Requeue
+	// DLQ moves the message to the dead-letter queue of the application, see StampDeadLetter
+	DLQ
+)
+
+// String returns the name of the decision, e.g. for metric labels
+func (d Decision) String() string {
+	switch d {
+	case Ack:
+		return "ack"
+	case Nack:
+		return "nack"
+	case Requeue:
+		return "requeue"
+	case DLQ:
+		return "dead_letter"
+	}
+	return "unknown"
+}
+
+// Handler processes a message
+type Handler[M any] func(ctx context.Context, msg M) error
+
+// Result is the outcome of a message processed by a Consumer
+type Result struct {
+	Decision Decision
+	// Error is the converted error of the handler, nil when it succeeded
+	Error errorex.EX
+	// Attempt is the delivery of the message, starting at 1
+	Attempt  int
+	Duration time.Duration
+}
+
+// ConsumerOptions configures a Consumer
+type ConsumerOptions[M any] struct {
+	// Converter converts the errors of the handler, errorex.BuildErrorConverterChain() when nil
+	Converter errorex.ErrorConverter
+	// MaxAttempts is the number of deliveries of a message failing with retryable errors before it is dead-lettered
+	MaxAttempts int
+	// Attempt returns the delivery of the message, starting at 1, e.g. from the delivery count of the broker.
+	// Messages are seen as first deliveries when nil.
+	Attempt func(msg M) int
+	// Codes overrides the decision for errors with these codes, the code of the error taking precedence over its
+	// ancestors (see errorex.WithParent)
+	Codes map[string]Decision
+	// Observe is called with the result of every message, e.g. to emit metrics
+	Observe func(ctx context.Context, result Result)
+}
+
+// Consumer wraps a message handler, converting its errors with a chain and deciding what to do with the message:
+// messages are acknowledged on success, requeued on retryable errors (see errorex.WithRetryable) until
+// MaxAttempts deliveries and dead-lettered otherwise, unless ConsumerOptions.Codes says otherwise.
+//
+//	consume := mq.Consumer(handle, mq.ConsumerOptions[*nats.Msg]{Attempt: deliveries})
+//	result := consume(ctx, msg)
+//	switch result.Decision {
+//	case mq.Ack:
+//		msg.Ack()
+//	...
+//	}
+func Consumer[M any](handler Handler[M], options ConsumerOptions[M]) func(ctx context.Context, msg M) Result {
+	converter := options.Converter
+	if converter == nil {
+		converter = errorex.BuildErrorConverterChain()
+	}
+	maxAttempts := options.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	decisions := make(map[string]*Decision, len(options.Codes))
+	for code, decision := range options.Codes {
+		decisions[code] = &decision
+	}
+	codeDecision := errorex.MapCodes(decisions, nil)
+	return func(ctx context.Context, msg M) Result {
+		result := Result{Attempt: 1}
+		if options.Attempt != nil {
+			result.Attempt = options.Attempt(msg)
+		}
+		start := time.Now()
+		err := handler(ctx, msg)
+		result.Duration = time.Since(start)
+		if err != nil {
+			result.Error = converter.ConvertError(err)
+			result.Decision = decide(result.Error, result.Attempt, maxAttempts, codeDecision)
+		}
+		if options.Observe != nil {
+			options.Observe(ctx, result)
+		}
+		return result
+	}
+}
+
+// decide returns the decision for a message that failed with the errorex, codeDecision returning the decision
+// mapped to its code or nearest ancestor
+func decide(ex errorex.EX, attempt int, maxAttempts int, codeDecision func(error) *Decision) Decision {
+	if decision := codeDecision(ex); decision != nil {
+		return *decision
+	}
+	if errorex.IsRetryable(ex) && attempt < maxAttempts {
+		return Requeue
+	}
+	return DLQ
+}
@@ -0,0 +1,113 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package mq
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type message struct {
+	deliveries int
+	err        error
+}
+
+func init() {
+	errorex.RegisterErrorCode("mq.unavailable", "Unavailable", errorex.ErrorEXDetail{}, errorex.WithRetryable())
+	errorex.RegisterErrorCode("mq.duplicate", "Duplicate", errorex.ErrorEXDetail{})
+	errorex.RegisterErrorCode("mq.duplicate.replayed", "Replayed", errorex.ErrorEXDetail{}, errorex.WithParent("mq.duplicate"))
+}
+
+func TestConsumer(t *testing.T) {
+	var results []Result
+	consume := Consumer(func(ctx context.Context, msg message) error {
+		return msg.err
+	}, ConsumerOptions[message]{
+		MaxAttempts: 3,
+		Attempt: func(msg message) int {
+			return msg.deliveries
+		},
+		Codes: map[string]Decision{
+			"mq.duplicate":          Ack,
+			"mq.duplicate.replayed": Nack,
+		},
+		Observe: func(ctx context.Context, result Result) {
+			results = append(results, result)
+		},
+	})
+	unavailable := errorex.New("mq.unavailable", errorex.ErrorEXDetail{})
+
+	t.Run("should ack processed messages", func(t *testing.T) {
+		result := consume(context.Background(), message{deliveries: 1})
+		assert.Equal(t, Ack, result.Decision)
+		assert.Nil(t, result.Error)
+	})
+
+	t.Run("should requeue retryable errors until the max attempts", func(t *testing.T) {
+		assert.Equal(t, Requeue, consume(context.Background(), message{deliveries: 2, err: unavailable}).Decision)
+		result := consume(context.Background(), message{deliveries: 3, err: unavailable})
+		assert.Equal(t, DLQ, result.Decision)
+		assert.Equal(t, 3, result.Attempt)
+	})
+
+	t.Run("should convert and dead-letter other errors", func(t *testing.T) {
+		result := consume(context.Background(), message{deliveries: 1, err: errors.New("boom")})
+		assert.Equal(t, DLQ, result.Decision)
+		assert.True(t, errorex.Is(result.Error, errorex.ErrCodeUnknownError))
+	})
+
+	t.Run("should follow the decisions of the codes", func(t *testing.T) {
+		result := consume(context.Background(), message{deliveries: 1, err: errorex.New("mq.duplicate", errorex.ErrorEXDetail{})})
+		assert.Equal(t, Ack, result.Decision)
+		assert.NotNil(t, result.Error)
+	})
+
+	t.Run("should observe every result", func(t *testing.T) {
+		assert.Len(t, results, 5)
+		assert.Equal(t, "dead_letter", results[2].Decision.String())
+	})
+
+	t.Run("should prefer the decision of the nearest code", func(t *testing.T) {
+		for range 10 {
+			result := consume(context.Background(), message{deliveries: 1, err: errorex.New("mq.duplicate.replayed", errorex.ErrorEXDetail{})})
+			assert.Equal(t, Nack, result.Decision)
+		}
+	})
+}
+
+func TestConsumerDefaults(t *testing.T) {
+	t.Run("should see messages as first deliveries", func(t *testing.T) {
+		consume := Consumer(func(ctx context.Context, err error) error {
+			return err
+		}, ConsumerOptions[error]{})
+
+		result := consume(context.Background(), errorex.New("mq.unavailable", errorex.ErrorEXDetail{}))
+		assert.Equal(t, Requeue, result.Decision)
+		assert.Equal(t, 1, result.Attempt)
+	})
+}
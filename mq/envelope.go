@@ -0,0 +1,217 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package mq carries errorex errors through message queues such as Kafka or NATS, so asynchronous pipelines pass
+// structured failures between services:
+//
+//	data, err := mq.EncodeEnvelope(ex, map[string]string{
+//		mq.HeaderOrigin:  "billing",
+//		mq.HeaderTraceID: traceID,
+//	})
+//
+// The envelope is versioned, consumers reject versions they do not know. Messages moved to a dead-letter queue
+// are stamped with StampDeadLetter, recording why they failed.
+package mq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// EnvelopeVersion is the version of the envelopes written by EncodeEnvelope
+	EnvelopeVersion = 1
+)
+
+// Well known headers
+const (
+	// HeaderOrigin is the name of the service that produced the error
+	HeaderOrigin = "errorex-origin"
+	// HeaderTraceID is the ID of the trace the error belongs to
+	HeaderTraceID = "errorex-trace-id"
+	// HeaderDeadLetterCode is the errorex code of the failure that moved a message to a dead-letter queue
+	HeaderDeadLetterCode = "errorex-dead-letter-code"
+	// HeaderDeadLetterError is the message of the failure that moved a message to a dead-letter queue
+	HeaderDeadLetterError = "errorex-dead-letter-error"
+	// HeaderDeadLetterOrigin is the service that moved a message to a dead-letter queue
+	HeaderDeadLetterOrigin = "errorex-dead-letter-origin"
+	// HeaderDeadLetterTime is when a message was moved to a dead-letter queue, in RFC 3339 format
+	HeaderDeadLetterTime = "errorex-dead-letter-time"
+	// HeaderDeadLetterAttempts is the number of times the message was processed before being dead-lettered
+	HeaderDeadLetterAttempts = "errorex-dead-letter-attempts"
+)
+
+// Envelope is a decoded message queue error envelope
+type Envelope struct {
+	Version int
+	// Error is the errorex carried by the envelope
+	Error errorex.EX
+	// Origin is the name of the service that produced the error
+	Origin string
+	// TraceID is the ID of the trace the error belongs to
+	TraceID string
+	// Headers are the other headers given to EncodeEnvelope
+	Headers map[string]string
+}
+
+// DeadLetter describes why a message was moved to a dead-letter queue, as stamped by StampDeadLetter
+type DeadLetter struct {
+	Code     string
+	Error    string
+	Origin   string
+	Time     time.Time
+	Attempts int
+}
+
+// envelope is the serialized form of Envelope
+type envelope struct {
+	Version int               `json:"version"`
+	Error   json.RawMessage   `json:"error"`
+	Origin  string            `json:"origin,omitempty"`
+	TraceID string            `json:"trace_id,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// EncodeEnvelope serializes the errorex into a versioned envelope, as it crosses a trust boundary (see
+// errorex.Boundary): the unexposed details, the causes and the metadata are left out and the sink filter is applied.
+// The errors of an errorex.EXGroup are serialized one by one. HeaderOrigin and HeaderTraceID are taken from the
+// headers into their own fields, the other headers are carried as is.
+func EncodeEnvelope(ex errorex.EX, headers map[string]string) ([]byte, error) {
+	if ex == nil {
+		return nil, errors.New("mq: nil errorex")
+	}
+	bounded := ex
+	if group, ok := ex.(errorex.EXGroup); ok {
+		members := make(errorex.EXGroup, len(group))
+		for i, member := range group {
+			members[i] = errorex.Boundary(member)
+		}
+		bounded = members
+	} else {
+		bounded = errorex.Boundary(ex)
+	}
+	e := envelope{
+		Version: EnvelopeVersion,
+		Error:   errorex.AppendError(nil, bounded),
+		Origin:  headers[HeaderOrigin],
+		TraceID: headers[HeaderTraceID],
+	}
+	for key, value := range headers {
+		if key == HeaderOrigin || key == HeaderTraceID {
+			continue
+		}
+		if e.Headers == nil {
+			e.Headers = make(map[string]string, len(headers))
+		}
+		e.Headers[key] = value
+	}
+	return errorex.GetJSONCodec().Marshal(e)
+}
+
+// DecodeEnvelope parses an envelope written by EncodeEnvelope, the errorex is parsed with errorex.ParseJSON, the
+// errors of a group into an errorex.EXGroup, and stamped with the hop of the service, see errorex.Received
+func DecodeEnvelope(data []byte) (Envelope, error) {
+	var e envelope
+	if err := errorex.GetJSONCodec().Unmarshal(data, &e); err != nil {
+		return Envelope{}, fmt.Errorf("invalid envelope: %w", err)
+	}
+	if e.Version != EnvelopeVersion {
+		return Envelope{}, fmt.Errorf("unsupported envelope version %d", e.Version)
+	}
+	ex, err := decodeError(e.Error)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{
+		Version: e.Version,
+		Error:   ex,
+		Origin:  e.Origin,
+		TraceID: e.TraceID,
+		Headers: e.Headers,
+	}, nil
+}
+
+// decodeError parses the error of an envelope, a JSON array being the errors of an errorex.EXGroup
+func decodeError(data json.RawMessage) (errorex.EX, error) {
+	var members []json.RawMessage
+	if errorex.GetJSONCodec().Unmarshal(data, &members) != nil {
+		ex, err := errorex.ParseJSON(data)
+		if err != nil {
+			return nil, err
+		}
+		return errorex.Received(ex), nil
+	}
+	group := make(errorex.EXGroup, len(members))
+	for i, member := range members {
+		ex, err := errorex.ParseJSON(member)
+		if err != nil {
+			return nil, err
+		}
+		group[i] = errorex.Received(ex)
+	}
+	return group, nil
+}
+
+// StampDeadLetter records in the headers of a message why it is moved to a dead-letter queue: the code and the
+// message of err, the service doing it, the time of the errorex clock (see errorex.SetClock) and the number of
+// attempts. It returns the headers, allocating
+// them when nil.
+func StampDeadLetter(headers map[string]string, err error, origin string, attempts int) map[string]string {
+	if headers == nil {
+		headers = make(map[string]string, 5)
+	}
+	var ex errorex.EX
+	if errors.As(err, &ex) {
+		headers[HeaderDeadLetterCode] = ex.Code()
+	} else {
+		delete(headers, HeaderDeadLetterCode)
+	}
+	if err != nil {
+		headers[HeaderDeadLetterError] = err.Error()
+	}
+	headers[HeaderDeadLetterOrigin] = origin
+	headers[HeaderDeadLetterTime] = errorex.Now().UTC().Format(time.RFC3339Nano)
+	headers[HeaderDeadLetterAttempts] = strconv.Itoa(attempts)
+	return headers
+}
+
+// DeadLetterOf returns the dead-letter stamp of the headers of a message, if it has one
+func DeadLetterOf(headers map[string]string) (DeadLetter, bool) {
+	timestamp, ok := headers[HeaderDeadLetterTime]
+	if !ok {
+		return DeadLetter{}, false
+	}
+	deadLetter := DeadLetter{
+		Code:   headers[HeaderDeadLetterCode],
+		Error:  headers[HeaderDeadLetterError],
+		Origin: headers[HeaderDeadLetterOrigin],
+	}
+	deadLetter.Time, _ = time.Parse(time.RFC3339Nano, timestamp)
+	deadLetter.Attempts, _ = strconv.Atoi(headers[HeaderDeadLetterAttempts])
+	return deadLetter, true
+}
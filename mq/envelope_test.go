@@ -0,0 +1,142 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package mq
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type paymentDetail struct {
+	Invoice string `json:"invoice"`
+}
+
+func init() {
+	errorex.RegisterErrorCode("mq.declined", "Declined", paymentDetail{})
+}
+
+func TestEnvelope(t *testing.T) {
+	t.Run("should round trip the errorex and the headers", func(t *testing.T) {
+		data, err := EncodeEnvelope(errorex.New("mq.declined", paymentDetail{Invoice: "42"}), map[string]string{
+			HeaderOrigin:  "billing",
+			HeaderTraceID: "trace",
+			"tenant":      "acme",
+		})
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"version": 1, "error": {"code": "mq.declined", "detail": {"invoice": "42"}}, "origin": "billing", "trace_id": "trace", "headers": {"tenant": "acme"}}`, string(data))
+
+		envelope, err := DecodeEnvelope(data)
+
+		assert.NoError(t, err)
+		assert.True(t, errorex.Is(envelope.Error, "mq.declined"))
+		assert.Equal(t, paymentDetail{Invoice: "42"}, envelope.Error.Detail())
+		assert.Equal(t, "billing", envelope.Origin)
+		assert.Equal(t, "trace", envelope.TraceID)
+		assert.Equal(t, map[string]string{"tenant": "acme"}, envelope.Headers)
+	})
+
+	t.Run("should leave out what does not cross a trust boundary", func(t *testing.T) {
+		errorex.SetSinkFilter(func(ex errorex.EX, sink errorex.Sink) errorex.EX {
+			return errorex.WithDetail(ex, paymentDetail{Invoice: "[filtered]"})
+		})
+		defer errorex.SetSinkFilter(nil)
+		ex := errorex.Wrap(errors.New("password=secret"), "mq.declined", paymentDetail{Invoice: "42"},
+			errorex.WithMetadata("card", "4111"))
+
+		data, err := EncodeEnvelope(ex, nil)
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"version": 1, "error": {"code": "mq.declined", "detail": {"invoice": "[filtered]"}}}`, string(data))
+	})
+
+	t.Run("should round trip groups", func(t *testing.T) {
+		group := errorex.EXGroup{
+			errorex.New("mq.declined", paymentDetail{Invoice: "1"}),
+			errorex.New("mq.declined", paymentDetail{Invoice: "2"}),
+		}
+
+		data, err := EncodeEnvelope(group, nil)
+		assert.NoError(t, err)
+		envelope, err := DecodeEnvelope(data)
+
+		assert.NoError(t, err)
+		decoded, ok := envelope.Error.(errorex.EXGroup)
+		assert.True(t, ok)
+		assert.Len(t, decoded, 2)
+		assert.Equal(t, paymentDetail{Invoice: "2"}, decoded[1].Detail())
+	})
+
+	t.Run("should reject invalid envelopes", func(t *testing.T) {
+		_, err := EncodeEnvelope(nil, nil)
+		assert.Error(t, err)
+
+		for _, data := range []string{`{`, `{"version": 2, "error": {"code": "mq.declined"}}`, `{"version": 1, "error": {"code": "mq.missing"}}`} {
+			_, err = DecodeEnvelope([]byte(data))
+			assert.Error(t, err, data)
+		}
+	})
+}
+
+func TestStampDeadLetter(t *testing.T) {
+	t.Run("should stamp and read the dead-letter headers", func(t *testing.T) {
+		before := time.Now().Add(-time.Second)
+		headers := StampDeadLetter(nil, errorex.New("mq.declined", paymentDetail{}), "worker", 3)
+
+		deadLetter, ok := DeadLetterOf(headers)
+
+		assert.True(t, ok)
+		assert.Equal(t, "mq.declined", deadLetter.Code)
+		assert.Contains(t, deadLetter.Error, "mq.declined")
+		assert.Equal(t, "worker", deadLetter.Origin)
+		assert.Equal(t, 3, deadLetter.Attempts)
+		assert.True(t, deadLetter.Time.After(before))
+	})
+
+	t.Run("should stamp the time of the errorex clock", func(t *testing.T) {
+		at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		errorex.SetClock(func() time.Time { return at })
+		defer errorex.SetClock(nil)
+
+		deadLetter, _ := DeadLetterOf(StampDeadLetter(nil, errors.New("boom"), "worker", 1))
+
+		assert.Equal(t, at, deadLetter.Time)
+	})
+
+	t.Run("should stamp other errors without code", func(t *testing.T) {
+		headers := StampDeadLetter(map[string]string{HeaderDeadLetterCode: "old"}, errors.New("boom"), "worker", 1)
+
+		deadLetter, _ := DeadLetterOf(headers)
+		assert.Empty(t, deadLetter.Code)
+		assert.Equal(t, "boom", deadLetter.Error)
+	})
+
+	t.Run("should report messages that were not dead-lettered", func(t *testing.T) {
+		_, ok := DeadLetterOf(map[string]string{"tenant": "acme"})
+		assert.False(t, ok)
+	})
+}
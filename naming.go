@@ -0,0 +1,134 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// NamingMode tells what RegisterErrorCode does with codes violating the naming policy
+type NamingMode int
+
+const (
+	// NamingStrict makes RegisterErrorCode panic with ErrCodeInvalidName
+	NamingStrict NamingMode = iota
+	// NamingWarn registers the code and reports the violations to NamingPolicy.Warn
+	NamingWarn
+)
+
+// NamingPolicy is the format the codes must follow, keeping large catalogs consistent.
+// The zero value of each rule disables it.
+type NamingPolicy struct {
+	// Pattern must match the whole code, e.g. ^[a-z]+(\.[a-z_]+)+$
+	Pattern *regexp.Regexp
+	// MaxLength is the maximum number of bytes of a code
+	MaxLength int
+	// Segments is the minimum number of non-empty dot separated segments, e.g. 2 for "billing.declined"
+	Segments int
+	// Lowercase requires codes without upper case letters
+	Lowercase bool
+	// Mode tells whether violations panic or only warn
+	Mode NamingMode
+	// Warn receives the violations in NamingWarn mode, they are logged with the log package when nil
+	Warn func(code string, problems []string)
+}
+
+// ErrorEXNamingViolation is the detail of ErrCodeInvalidName
+type ErrorEXNamingViolation struct {
+	Code     string   `json:"code"`
+	Problems []string `json:"problems"`
+}
+
+var namingPolicy atomic.Pointer[NamingPolicy]
+
+// SetNamingPolicy sets the policy enforced by RegisterErrorCode on the codes registered after it, nil disables it.
+// Aliases are not checked, so the old names of renamed codes keep working.
+func SetNamingPolicy(policy *NamingPolicy) {
+	if policy != nil {
+		copied := *policy
+		policy = &copied
+	}
+	namingPolicy.Store(policy)
+}
+
+// Problems returns the rules of the policy violated by the code, nil when it complies
+func (p NamingPolicy) Problems(code string) []string {
+	var problems []string
+	if p.Pattern != nil && !p.Pattern.MatchString(code) {
+		problems = append(problems, fmt.Sprintf("does not match %s", p.Pattern))
+	}
+	if p.MaxLength > 0 && len(code) > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("longer than %d characters", p.MaxLength))
+	}
+	if p.Segments > 0 {
+		segments := strings.Split(code, ".")
+		for _, segment := range segments {
+			if segment == "" {
+				problems = append(problems, "has an empty segment")
+				break
+			}
+		}
+		if len(segments) < p.Segments {
+			problems = append(problems, fmt.Sprintf("has less than %d dot separated segments", p.Segments))
+		}
+	}
+	if p.Lowercase && strings.ToLower(code) != code {
+		problems = append(problems, "is not lowercase")
+	}
+	return problems
+}
+
+// checkNaming enforces the naming policy on a code being registered
+func checkNaming(code string) {
+	if violation := namingViolation(code); violation != nil {
+		// Fatal errorex
+		fatal(violation)
+	}
+}
+
+// namingViolation returns the ErrCodeInvalidName errorex of a code violating a strict naming policy, nil when the
+// code complies. Violations of NamingWarn policies are reported and return nil.
+func namingViolation(code string) EX {
+	policy := namingPolicy.Load()
+	if policy == nil {
+		return nil
+	}
+	problems := policy.Problems(code)
+	if len(problems) == 0 {
+		return nil
+	}
+	if policy.Mode == NamingStrict {
+		return New(ErrCodeInvalidName, ErrorEXNamingViolation{Code: code, Problems: problems})
+	}
+	if policy.Warn != nil {
+		policy.Warn(code, problems)
+		return nil
+	}
+	log.Printf("errorex: code %q violates the naming policy: %s", code, strings.Join(problems, ", "))
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamingPolicy(t *testing.T) {
+	policy := NamingPolicy{
+		Pattern:   regexp.MustCompile(`^[a-zA-Z_.]+$`),
+		MaxLength: 24,
+		Segments:  2,
+		Lowercase: true,
+	}
+
+	t.Run("should list the violated rules", func(t *testing.T) {
+		assert.Nil(t, policy.Problems("billing.declined"))
+		assert.Equal(t, []string{
+			"does not match ^[a-zA-Z_.]+$",
+			"has less than 2 dot separated segments",
+			"is not lowercase",
+		}, policy.Problems("Billing1"))
+		assert.Equal(t, []string{
+			"longer than 24 characters",
+			"has an empty segment",
+		}, policy.Problems("billing..declined_by_the_bank"))
+	})
+
+	t.Run("should panic in strict mode", func(t *testing.T) {
+		SetNamingPolicy(&policy)
+		defer SetNamingPolicy(nil)
+
+		assert.PanicsWithError(t, New(ErrCodeInvalidName, ErrorEXNamingViolation{
+			Code:     "NamingStrict",
+			Problems: []string{"has less than 2 dot separated segments", "is not lowercase"},
+		}).Error(), func() {
+			RegisterErrorCode("NamingStrict", "Strict", ErrorEXDetail{})
+		})
+		_, ok := Lookup("NamingStrict")
+		assert.False(t, ok)
+
+		assert.NotPanics(t, func() {
+			RegisterErrorCode("naming.strict", "Strict", ErrorEXDetail{})
+			RegisterAlias("NamingAlias", "naming.strict")
+		})
+	})
+
+	t.Run("should only warn in warn mode", func(t *testing.T) {
+		var warnings []string
+		warn := policy
+		warn.Mode = NamingWarn
+		warn.Warn = func(code string, problems []string) {
+			warnings = append(warnings, code)
+			assert.Equal(t, []string{"is not lowercase"}, problems)
+		}
+		SetNamingPolicy(&warn)
+		defer SetNamingPolicy(nil)
+
+		RegisterErrorCode("naming.Warn", "Warn", ErrorEXDetail{})
+
+		assert.Equal(t, []string{"naming.Warn"}, warnings)
+		_, ok := Lookup("naming.Warn")
+		assert.True(t, ok)
+	})
+}
@@ -0,0 +1,158 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package objectstore
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/smithy-go"
+	"github.com/fkmatsuda/errorex"
+	"github.com/minio/minio-go/v7"
+	"google.golang.org/api/googleapi"
+)
+
+// s3Codes maps the error codes of the S3 API, also returned by MinIO, to the codes of this package
+var s3Codes = map[string]string{
+	"NoSuchKey":                      ErrCodeNotFound,
+	"NoSuchBucket":                   ErrCodeNotFound,
+	"NoSuchVersion":                  ErrCodeNotFound,
+	"NotFound":                       ErrCodeNotFound,
+	"PreconditionFailed":             ErrCodePreconditionFailed,
+	"TooManyBuckets":                 ErrCodeQuotaExceeded,
+	"XMinioStorageFull":              ErrCodeQuotaExceeded,
+	"XMinioAdminBucketQuotaExceeded": ErrCodeQuotaExceeded,
+	"BadDigest":                      ErrCodeChecksumMismatch,
+	"InvalidDigest":                  ErrCodeChecksumMismatch,
+	"XAmzContentSHA256Mismatch":      ErrCodeChecksumMismatch,
+}
+
+// statusCodes maps the HTTP statuses of bodiless responses, e.g. of HEAD requests, to the codes of this package
+var statusCodes = map[int]string{
+	http.StatusNotFound:           ErrCodeNotFound,
+	http.StatusPreconditionFailed: ErrCodePreconditionFailed,
+}
+
+// s3ErrorConverter converts the errors of the AWS SDK for S3
+type s3ErrorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewS3ErrorConverter creates a converter of the smithy.APIError returned by the AWS SDK for S3, mapping its
+// error code, or its HTTP status when the response had no body
+func NewS3ErrorConverter() errorex.ErrorConverter {
+	return &s3ErrorConverter{}
+}
+
+// ConvertError converts the S3 errors, delegating the others to the next handler in the chain
+func (c *s3ErrorConverter) ConvertError(err error) errorex.EX {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		if code, ok := s3Codes[apiErr.ErrorCode()]; ok {
+			return newEX(code, err, ObjectDetail{Reason: apiErr.ErrorCode()})
+		}
+	}
+	var responseErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &responseErr) {
+		if code, ok := statusCodes[responseErr.HTTPStatusCode()]; ok {
+			return newEX(code, err, ObjectDetail{Reason: http.StatusText(responseErr.HTTPStatusCode())})
+		}
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
+
+// minioErrorConverter converts the errors of the MinIO client
+type minioErrorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewMinIOErrorConverter creates a converter of the minio.ErrorResponse returned by the MinIO client, mapping its
+// error code, or its HTTP status when the response had no body, with its bucket and key
+func NewMinIOErrorConverter() errorex.ErrorConverter {
+	return &minioErrorConverter{}
+}
+
+// ConvertError converts the MinIO errors, delegating the others to the next handler in the chain
+func (c *minioErrorConverter) ConvertError(err error) errorex.EX {
+	var response minio.ErrorResponse
+	if !errors.As(err, &response) {
+		return c.BaseErrorConverter.ConvertError(err)
+	}
+	code, ok := s3Codes[response.Code]
+	if !ok {
+		code, ok = statusCodes[response.StatusCode]
+	}
+	if !ok {
+		return c.BaseErrorConverter.ConvertError(err)
+	}
+	detail := ObjectDetail{Bucket: response.BucketName, Key: response.Key, Reason: response.Code}
+	if detail.Reason == "" {
+		detail.Reason = http.StatusText(response.StatusCode)
+	}
+	return newEX(code, err, detail)
+}
+
+// gcsErrorConverter converts the errors of the Google Cloud Storage client
+type gcsErrorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewGCSErrorConverter creates a converter of the errors returned by the Google Cloud Storage client:
+// storage.ErrObjectNotExist and storage.ErrBucketNotExist, and the *googleapi.Error of failed preconditions,
+// exceeded quotas and checksum mismatches
+func NewGCSErrorConverter() errorex.ErrorConverter {
+	return &gcsErrorConverter{}
+}
+
+// ConvertError converts the Google Cloud Storage errors, delegating the others to the next handler in the chain
+func (c *gcsErrorConverter) ConvertError(err error) errorex.EX {
+	switch {
+	case errors.Is(err, storage.ErrObjectNotExist):
+		return newEX(ErrCodeNotFound, err, ObjectDetail{Reason: storage.ErrObjectNotExist.Error()})
+	case errors.Is(err, storage.ErrBucketNotExist):
+		return newEX(ErrCodeNotFound, err, ObjectDetail{Reason: storage.ErrBucketNotExist.Error()})
+	case strings.Contains(err.Error(), "storage: bad CRC on read"):
+		// The client does not export the error of the CRC32C validation of downloads
+		return newEX(ErrCodeChecksumMismatch, err, ObjectDetail{Reason: "bad CRC on read"})
+	}
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return c.BaseErrorConverter.ConvertError(err)
+	}
+	reason := apiErr.Message
+	for _, item := range apiErr.Errors {
+		if item.Reason == "quotaExceeded" {
+			return newEX(ErrCodeQuotaExceeded, err, ObjectDetail{Reason: reason})
+		}
+	}
+	if apiErr.Code == http.StatusBadRequest && strings.Contains(apiErr.Message, "doesn't match calculated") {
+		return newEX(ErrCodeChecksumMismatch, err, ObjectDetail{Reason: reason})
+	}
+	if code, ok := statusCodes[apiErr.Code]; ok {
+		return newEX(code, err, ObjectDetail{Reason: reason})
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
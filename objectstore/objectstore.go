@@ -0,0 +1,109 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package objectstore defines the canonical errorex codes of object storage and converters for the errors of the
+// AWS S3, Google Cloud Storage and MinIO clients, so storage abstractions return the same errors whatever the
+// backend:
+//
+//	converter := errorex.BuildErrorConverterChain(objectstore.NewS3ErrorConverter(), objectstore.NewGCSErrorConverter())
+//	if _, err := client.GetObject(ctx, input); err != nil {
+//		return converter.ConvertError(objectstore.WithObject(err, bucket, key))
+//	}
+//
+// It is a separate module, so depending on errorex does not pull the storage clients.
+package objectstore
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// ErrCodeNotFound is the errorex code for missing objects and buckets
+	ErrCodeNotFound = "objectstore.not_found"
+	// ErrCodePreconditionFailed is the errorex code for conditional requests whose precondition does not hold
+	ErrCodePreconditionFailed = "objectstore.precondition_failed"
+	// ErrCodeQuotaExceeded is the errorex code for writes rejected by a storage or bucket quota
+	ErrCodeQuotaExceeded = "objectstore.quota_exceeded"
+	// ErrCodeChecksumMismatch is the errorex code for contents not matching their declared checksum
+	ErrCodeChecksumMismatch = "objectstore.checksum_mismatch"
+)
+
+// ObjectDetail is the detail of the codes of this package
+type ObjectDetail struct {
+	// Bucket is the name of the bucket, empty when unknown
+	Bucket string `json:"bucket,omitempty"`
+	// Key is the key of the object, empty when unknown or for bucket errors
+	Key string `json:"key,omitempty"`
+	// Reason is the error code or message of the backend
+	Reason string `json:"reason,omitempty"`
+}
+
+func init() {
+	// Register the errorex codes
+	errorex.RegisterErrorCode(ErrCodeNotFound, "Object not found", ObjectDetail{}, errorex.WithHTTPStatus(http.StatusNotFound))
+	errorex.RegisterErrorCode(ErrCodePreconditionFailed, "Object precondition failed", ObjectDetail{}, errorex.WithHTTPStatus(http.StatusPreconditionFailed))
+	errorex.RegisterErrorCode(ErrCodeQuotaExceeded, "Storage quota exceeded", ObjectDetail{}, errorex.WithHTTPStatus(http.StatusInsufficientStorage))
+	errorex.RegisterErrorCode(ErrCodeChecksumMismatch, "Object checksum mismatch", ObjectDetail{}, errorex.WithHTTPStatus(http.StatusBadRequest))
+}
+
+// ObjectError is an error raised while accessing an object
+type ObjectError struct {
+	// Bucket is the name of the bucket
+	Bucket string
+	// Key is the key of the object
+	Key string
+	// Err is the underlying error
+	Err error
+}
+
+// Error returns the bucket and key followed by the message of the underlying error
+func (e *ObjectError) Error() string {
+	return e.Bucket + "/" + e.Key + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ObjectError) Unwrap() error {
+	return e.Err
+}
+
+// WithObject wraps err with the bucket and key it was raised for, reported in the detail of the converted errorex;
+// nil errors are returned as they are
+func WithObject(err error, bucket string, key string) error {
+	if err == nil {
+		return err
+	}
+	return &ObjectError{Bucket: bucket, Key: key, Err: err}
+}
+
+// newEX creates an errorex of this package, filling the bucket and key from an ObjectError in the chain of err
+func newEX(code string, err error, detail ObjectDetail) errorex.EX {
+	var objectErr *ObjectError
+	if errors.As(err, &objectErr) {
+		detail.Bucket = objectErr.Bucket
+		detail.Key = objectErr.Key
+	}
+	return errorex.New(code, detail)
+}
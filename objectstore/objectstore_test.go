@@ -0,0 +1,124 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package objectstore
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/fkmatsuda/errorex"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/googleapi"
+)
+
+func TestWithObject(t *testing.T) {
+	t.Run("should keep nil errors", func(t *testing.T) {
+		assert.NoError(t, WithObject(nil, "bucket", "key"))
+	})
+
+	t.Run("should wrap the error with the object", func(t *testing.T) {
+		err := errors.New("boom")
+		wrapped := WithObject(err, "bucket", "key")
+		assert.EqualError(t, wrapped, "bucket/key: boom")
+		assert.ErrorIs(t, wrapped, err)
+	})
+}
+
+func TestS3ErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewS3ErrorConverter())
+
+	t.Run("should convert the API error codes", func(t *testing.T) {
+		for reason, code := range map[string]string{
+			"NoSuchKey":          ErrCodeNotFound,
+			"PreconditionFailed": ErrCodePreconditionFailed,
+			"TooManyBuckets":     ErrCodeQuotaExceeded,
+			"BadDigest":          ErrCodeChecksumMismatch,
+		} {
+			err := WithObject(&smithy.GenericAPIError{Code: reason}, "bucket", "key")
+			ex := converter.ConvertError(err)
+			assert.Equal(t, code, ex.Code())
+			assert.Equal(t, ObjectDetail{Bucket: "bucket", Key: "key", Reason: reason}, ex.Detail())
+		}
+	})
+
+	t.Run("should convert the status of bodiless responses", func(t *testing.T) {
+		err := &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusNotFound}},
+			Err:      errors.New("not found"),
+		}
+		assert.Equal(t, ErrCodeNotFound, converter.ConvertError(err).Code())
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(&smithy.GenericAPIError{Code: "AccessDenied"}).Code())
+	})
+}
+
+func TestMinIOErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewMinIOErrorConverter())
+
+	t.Run("should convert the error responses", func(t *testing.T) {
+		ex := converter.ConvertError(minio.ErrorResponse{Code: "XMinioStorageFull", BucketName: "bucket", Key: "key", StatusCode: http.StatusInsufficientStorage})
+		assert.Equal(t, ErrCodeQuotaExceeded, ex.Code())
+		assert.Equal(t, ObjectDetail{Bucket: "bucket", Key: "key", Reason: "XMinioStorageFull"}, ex.Detail())
+	})
+
+	t.Run("should convert the status of bodiless responses", func(t *testing.T) {
+		ex := converter.ConvertError(minio.ErrorResponse{StatusCode: http.StatusPreconditionFailed})
+		assert.Equal(t, ErrCodePreconditionFailed, ex.Code())
+		assert.Equal(t, "Precondition Failed", ex.Detail().(ObjectDetail).Reason)
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(minio.ErrorResponse{Code: "AccessDenied", StatusCode: http.StatusForbidden}).Code())
+	})
+}
+
+func TestGCSErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewGCSErrorConverter())
+
+	t.Run("should convert missing objects and buckets", func(t *testing.T) {
+		ex := converter.ConvertError(WithObject(storage.ErrObjectNotExist, "bucket", "key"))
+		assert.Equal(t, ErrCodeNotFound, ex.Code())
+		assert.Equal(t, "key", ex.Detail().(ObjectDetail).Key)
+		assert.Equal(t, ErrCodeNotFound, converter.ConvertError(storage.ErrBucketNotExist).Code())
+	})
+
+	t.Run("should convert the API errors", func(t *testing.T) {
+		assert.Equal(t, ErrCodePreconditionFailed, converter.ConvertError(&googleapi.Error{Code: http.StatusPreconditionFailed}).Code())
+		quota := &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}}
+		assert.Equal(t, ErrCodeQuotaExceeded, converter.ConvertError(quota).Code())
+		checksum := &googleapi.Error{Code: http.StatusBadRequest, Message: `Provided MD5 hash "x" doesn't match calculated MD5 hash "y".`}
+		assert.Equal(t, ErrCodeChecksumMismatch, converter.ConvertError(checksum).Code())
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(&googleapi.Error{Code: http.StatusForbidden}).Code())
+	})
+}
@@ -0,0 +1,68 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"time"
+)
+
+// Option attaches metadata to an errorex instance when it is created by New
+type Option func(e *ex)
+
+// RetryAfterHinter is implemented by errors that carry a hint of how long to wait before retrying
+type RetryAfterHinter interface {
+	// RetryAfter returns the hint, zero when not set
+	RetryAfter() time.Duration
+}
+
+// WithRetryAfter hints how long the caller should wait before retrying, serialized as "retry_after_ms".
+// It is honored by the retry package, and mapped to the Retry-After header by httpex and to RetryInfo by grpcex.
+func WithRetryAfter(delay time.Duration) Option {
+	return func(e *ex) {
+		e.retryAfter = delay
+	}
+}
+
+// RetryAfter returns the retry hint of the first error in the chain of err implementing RetryAfterHinter
+func RetryAfter(err error) (time.Duration, bool) {
+	var hinter RetryAfterHinter
+	if !errors.As(err, &hinter) {
+		return 0, false
+	}
+	delay := hinter.RetryAfter()
+	return delay, delay > 0
+}
+
+// RetryAfter returns the hint set by WithRetryAfter, zero when not set
+func (e *ex) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// applyOptions applies the options to the errorex
+func applyOptions(e *ex, options []Option) {
+	for _, option := range options {
+		option(e)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetryAfter(t *testing.T) {
+	t.Run("should attach the retry hint", func(t *testing.T) {
+		e := New(ErrCodeUnknownError, UnknownErrorDetail{Detail: "busy"}, WithRetryAfter(1500*time.Millisecond))
+
+		delay, ok := RetryAfter(fmt.Errorf("calling: %w", e))
+		assert.True(t, ok)
+		assert.Equal(t, 1500*time.Millisecond, delay)
+		assert.Equal(t, `{"code": "errorex.000", "detail": {"detail":"busy"}, "retry_after_ms": 1500}`, e.Error())
+	})
+
+	t.Run("should survive ParseJSON", func(t *testing.T) {
+		e := New(ErrCodeUnknownError, UnknownErrorDetail{Detail: "busy"}, WithRetryAfter(2*time.Second))
+
+		parsed, err := ParseJSON([]byte(e.Error()))
+
+		assert.NoError(t, err)
+		delay, _ := RetryAfter(parsed)
+		assert.Equal(t, 2*time.Second, delay)
+	})
+
+	t.Run("should apply to pooled and defined errors", func(t *testing.T) {
+		pooled := NewPooled(ErrCodeUnknownError, UnknownErrorDetail{}, WithRetryAfter(time.Second))
+		delay, _ := RetryAfter(pooled)
+		assert.Equal(t, time.Second, delay)
+		Release(pooled)
+
+		definition := Define[UnknownErrorDetail]("option.defined", "Defined")
+		delay, _ = RetryAfter(definition.New(UnknownErrorDetail{}, WithRetryAfter(time.Minute)))
+		assert.Equal(t, time.Minute, delay)
+	})
+
+	t.Run("should report missing hints", func(t *testing.T) {
+		_, ok := RetryAfter(New(ErrCodeUnknownError, UnknownErrorDetail{}))
+		assert.False(t, ok)
+		_, ok = RetryAfter(errors.New("other"))
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,66 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+// Owner is the team owning a code, set with WithOwner
+type Owner struct {
+	// Team is the name of the owning team, e.g. "billing"
+	Team string
+	// Contact is how to reach the team, such as an on-call alias, a chat channel or an email address
+	Contact string
+}
+
+// WithOwner sets the team owning the code and how to reach it. Codes without an owner inherit the owner of their
+// parent, see WithParent.
+func WithOwner(team, contact string) RegistrationOption {
+	return func(registry *errorCodeRegistry) {
+		registry.owner = Owner{Team: team, Contact: contact}
+	}
+}
+
+// OwnerOf returns the owner of the code of the first errorex in the chain of err, inherited from the closest
+// ancestor with an owner when the code has none, and false when no owner is set or err has no errorex
+func OwnerOf(err error) (Owner, bool) {
+	target, ok := firstEX(err)
+	if !ok {
+		return Owner{}, false
+	}
+	return CodeOwner(target.Code())
+}
+
+// CodeOwner returns the owner of a code, inherited from the closest ancestor with an owner when the code has none,
+// and false when no owner is set or the code is not registered
+func CodeOwner(code string) (Owner, bool) {
+	current, ok := lookupCode(code)
+	for depth := 0; ok && depth <= maxParentDepth; depth++ {
+		if current.owner != (Owner{}) {
+			return current.owner, true
+		}
+		if current.parent == "" {
+			break
+		}
+		current, ok = lookupCode(current.parent)
+	}
+	return Owner{}, false
+}
@@ -0,0 +1,69 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnerOf(t *testing.T) {
+	RegisterErrorCode("test.owner.billing", "test description", ErrorEXDetail{}, WithOwner("billing", "#billing-oncall"))
+	RegisterErrorCode("test.owner.settlement_failed", "test description", ErrorEXDetail{}, WithParent("test.owner.billing"))
+	RegisterErrorCode("test.owner.chargeback", "test description", ErrorEXDetail{}, WithParent("test.owner.billing"), WithOwner("risk", "risk@example.com"))
+	RegisterErrorCode("test.owner.unowned", "test description", ErrorEXDetail{})
+	RegisterAlias("test.owner.settlement", "test.owner.settlement_failed")
+
+	t.Run("should return the owner of the code", func(t *testing.T) {
+		owner, ok := OwnerOf(fmt.Errorf("settling: %w", New("test.owner.chargeback", ErrorEXDetail{})))
+		assert.True(t, ok)
+		assert.Equal(t, Owner{Team: "risk", Contact: "risk@example.com"}, owner)
+	})
+
+	t.Run("should inherit the owner of the parent", func(t *testing.T) {
+		owner, ok := OwnerOf(New("test.owner.settlement", ErrorEXDetail{}))
+		assert.True(t, ok)
+		assert.Equal(t, "billing", owner.Team)
+		assert.Equal(t, "#billing-oncall", owner.Contact)
+	})
+
+	t.Run("should report unowned codes and other errors", func(t *testing.T) {
+		_, ok := OwnerOf(New("test.owner.unowned", ErrorEXDetail{}))
+		assert.False(t, ok)
+		_, ok = OwnerOf(errors.New("boom"))
+		assert.False(t, ok)
+		_, ok = CodeOwner("test.owner.missing")
+		assert.False(t, ok)
+	})
+
+	t.Run("should list the owner in the catalog", func(t *testing.T) {
+		info, _ := Lookup("test.owner.billing")
+		assert.Equal(t, Owner{Team: "billing", Contact: "#billing-oncall"}, info.Owner)
+		info, _ = Lookup("test.owner.settlement_failed")
+		assert.Zero(t, info.Owner)
+	})
+}
@@ -0,0 +1,154 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// payload is the serialized form of an errorex, as written by Error
+type payload struct {
+	Code     string            `json:"code"`
+	Detail   json.RawMessage   `json:"detail"`
+	ID       string            `json:"id"`
+	Time     string            `json:"time"`
+	Metadata map[string]string `json:"metadata"`
+	// RetryAfterMS is the hint set by WithRetryAfter, in milliseconds
+	RetryAfterMS int64 `json:"retry_after_ms"`
+	// Cause is the serialized errorex cause, the text of another error, or a CauseTail
+	Cause json.RawMessage `json:"cause"`
+	Stack []Frame         `json:"stack"`
+}
+
+// ParseJSON parses an errorex serialized by Error, e.g. received from another service.
+// The detail is decoded into the type registered for the code, with the configured JSONCodec, so the parsed
+// errorex works with Is and Detail like a local one. Aliases resolve to their code, and codes bound to a
+// constructor with RegisterConstructor are rebuilt through it. Unknown and missing detail fields are handled
+// following SetParseStrictness. Details encrypted by Encrypted are decrypted with the keys set with
+// SetDecryptionKeys, or kept as an EncryptedDetail without keys.
+// Codes not registered locally but resolved by the catalog provider (see Resolve) are parsed with their detail
+// decoded as generic JSON. It returns an ErrCodeNotRegistered errorex if the code is neither registered nor
+// resolved. Like the encoder, it follows up to GetMaxCauseDepth causes, the deeper ones are left out.
+func ParseJSON(data []byte) (EX, error) {
+	return parseJSON(data, 0)
+}
+
+// parseJSON parses an errorex serialized by Error, depth being the number of causes above it
+func parseJSON(data []byte, depth int) (EX, error) {
+	codec := GetJSONCodec()
+	var p payload
+	if err := codec.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid errorex payload: %w", err)
+	}
+	if p.Code == "" {
+		return nil, fmt.Errorf("invalid errorex payload: missing code")
+	}
+	codeRegistry, ok := lookupCode(p.Code)
+	if !ok {
+		if _, resolved := Resolve(p.Code); !resolved {
+			return nil, New(ErrCodeNotRegistered, ErrorEXDetail{Code: p.Code})
+		}
+		// codes known only to the catalog provider have no detail type, their detail is kept as generic JSON
+		codeRegistry = errorCodeRegistry{code: p.Code}
+	}
+	e := &ex{
+		code:       codeRegistry.code,
+		id:         p.ID,
+		metadata:   p.Metadata,
+		retryAfter: time.Duration(p.RetryAfterMS) * time.Millisecond,
+	}
+	detailData, encrypted, err := decryptDetail(codec, p.Code, p.Detail)
+	if err != nil {
+		return nil, err
+	}
+	p.Detail = detailData
+	if encrypted != nil {
+		e.detail = *encrypted
+	} else if codeRegistry.detailType == nil || isInterfaceDetail(codeRegistry.detailType) {
+		if len(p.Detail) > 0 {
+			if err := codec.Unmarshal(p.Detail, &e.detail); err != nil {
+				return nil, fmt.Errorf("invalid detail of %s: %w", p.Code, err)
+			}
+		}
+	} else {
+		if err := checkFields(codeRegistry, p.Detail); err != nil {
+			return nil, err
+		}
+		detail := reflect.New(codeRegistry.detailType)
+		if len(p.Detail) > 0 {
+			if err := codec.Unmarshal(p.Detail, detail.Interface()); err != nil {
+				return nil, fmt.Errorf("invalid detail of %s: %w", p.Code, err)
+			}
+		}
+		e.detail = detail.Elem().Interface()
+	}
+	if p.Time != "" {
+		timestamp, err := time.Parse(time.RFC3339Nano, p.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid errorex payload: %w", err)
+		}
+		e.timestamp = timestamp
+	}
+	if len(p.Stack) > 0 {
+		e.stack = resolvedStack(p.Stack)
+	}
+	if len(p.Cause) > 0 {
+		cause, err := parseCause(codec, p.Cause, depth)
+		if err != nil {
+			return nil, err
+		}
+		e.cause = cause
+	}
+	return construct(e), nil
+}
+
+// parseCause parses a serialized cause: an errorex, a CauseInfo or the text of an error. Truncated tails, cycles
+// and causes past GetMaxCauseDepth have no cause to restore and return nil.
+func parseCause(codec JSONCodec, data json.RawMessage, depth int) (error, error) {
+	if depth >= GetMaxCauseDepth() {
+		return nil, nil
+	}
+	var text string
+	if codec.Unmarshal(data, &text) == nil {
+		return errors.New(text), nil
+	}
+	var probe struct {
+		Code string `json:"code"`
+		CauseInfo
+	}
+	if err := codec.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("invalid errorex cause: %w", err)
+	}
+	if probe.Code == "" {
+		if probe.Type == "" && probe.Message == "" {
+			return nil, nil
+		}
+		return &probe.CauseInfo, nil
+	}
+	return parseJSON(data, depth+1)
+}
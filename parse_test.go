@@ -0,0 +1,93 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type parseDetail struct {
+	Field string `json:"field"`
+	Count int    `json:"count"`
+}
+
+func TestParseJSON(t *testing.T) {
+	RegisterErrorCode("parse.detail", "Parse detail", parseDetail{})
+	RegisterErrorCode[any]("parse.any", "Parse any", nil)
+	RegisterAlias("parse.old", "parse.detail")
+
+	t.Run("should parse the output of Error", func(t *testing.T) {
+		original := New("parse.detail", parseDetail{Field: "name", Count: 2})
+
+		parsed, err := ParseJSON([]byte(original.Error()))
+
+		assert.NoError(t, err)
+		assert.True(t, Is(parsed, "parse.detail"))
+		assert.Equal(t, parseDetail{Field: "name", Count: 2}, parsed.Detail())
+		assert.Equal(t, original.Error(), parsed.Error())
+	})
+
+	t.Run("should parse the instance metadata and the stack", func(t *testing.T) {
+		defer SetInstanceConfig(GetInstanceConfig())
+		defer SetStackConfig(GetStackConfig())
+		defer TestMode()()
+		SetInstanceConfig(InstanceConfig{IDs: true, Timestamps: true})
+		SetStackConfig(StackConfig{Enabled: true, MaxDepth: 2})
+		original := New("parse.detail", parseDetail{})
+
+		parsed, err := ParseJSON([]byte(original.Error()))
+
+		assert.NoError(t, err)
+		id, _ := InstanceID(parsed)
+		assert.Equal(t, "test-000001", id)
+		timestamp, _ := Timestamp(parsed)
+		assert.True(t, timestamp.Equal(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)))
+		frames, _ := StackTrace(parsed)
+		assert.Len(t, frames, 2)
+		assert.Equal(t, original.Error(), parsed.Error())
+	})
+
+	t.Run("should resolve aliases and untyped details", func(t *testing.T) {
+		parsed, err := ParseJSON([]byte(`{"code": "parse.old", "detail": {"field": "name"}}`))
+		assert.NoError(t, err)
+		assert.Equal(t, "parse.detail", parsed.Code())
+
+		parsed, err = ParseJSON([]byte(`{"code": "parse.any", "detail": {"field": "name"}}`))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"field": "name"}, parsed.Detail())
+	})
+
+	t.Run("should fail on invalid payloads", func(t *testing.T) {
+		_, err := ParseJSON([]byte(`{"code": "parse.missing", "detail": {}}`))
+		assert.True(t, Is(err, ErrCodeNotRegistered))
+
+		for _, payload := range []string{`{`, `{"detail": {}}`, `{"code": "parse.detail", "detail": "text"}`, `{"code": "parse.detail", "time": "yesterday"}`} {
+			_, err = ParseJSON([]byte(payload))
+			assert.Error(t, err, payload)
+		}
+	})
+}
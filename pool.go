@@ -0,0 +1,93 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var exPool = sync.Pool{
+	New: func() any {
+		e := &ex{pooled: &pooledState{}}
+		e.pooled.encoder = json.NewEncoder(&e.pooled.buffer)
+		return e
+	},
+}
+
+// pooledState holds the bookkeeping and the serialization buffer of a pooled errorex
+type pooledState struct {
+	encodeBuffer
+	inUse atomic.Bool
+}
+
+// NewPooled returns an errorex.EX taken from an internal pool.
+// It behaves like New, but the returned value is recycled once Release is called, together with
+// the buffer used by Error(), which avoids allocations in hot paths such as parsers or per-row processing.
+//
+// Ownership: the caller owns the returned value and must call Release exactly once, after the error
+// has been fully handled. A pooled errorex must not be shared between goroutines, and no reference to it
+// (or to the value returned by Detail) may be retained after Release.
+func NewPooled[T any](code string, detail T, options ...Option) EX {
+	code, internal := checkDetail(code, detail)
+	if internal != nil {
+		return internal
+	}
+	e := exPool.Get().(*ex)
+	e.pooled.inUse.Store(true)
+	e.code = code
+	e.detail = storedDetail(detail)
+	e.stack = captureStack(code, 0)
+	stampInstance(e)
+	applyOptions(e, options)
+	return e
+}
+
+// Release returns an errorex created by NewPooled to the pool.
+// Errors that were not created by NewPooled are ignored, so it is safe to call Release on any EX.
+// Releasing the same pooled errorex twice panics, unless in Lenient mode (see SetMode).
+func Release(err EX) {
+	e, ok := err.(*ex)
+	if !ok || e.pooled == nil {
+		return
+	}
+	if !e.pooled.inUse.CompareAndSwap(true, false) {
+		// Fatal errorex, ignored in Lenient mode
+		misuse(New(ErrCodeAlreadyReleased, ErrorEXDetail{Code: e.code}))
+		return
+	}
+	e.code = ""
+	e.detail = nil
+	e.stack = nil
+	e.id = ""
+	e.timestamp = time.Time{}
+	e.retryAfter = 0
+	e.metadata = nil
+	e.cause = nil
+	e.escalated = 0
+	e.pooled.buffer.Reset()
+	exPool.Put(e)
+}
@@ -0,0 +1,110 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPooled(t *testing.T) {
+	RegisterErrorCode("test.pooled", "test description", struct{ Message string }{})
+
+	t.Run("should behave like an errorex created by New", func(t *testing.T) {
+		detail := struct{ Message string }{Message: "test detail"}
+		pooled := NewPooled("test.pooled", detail)
+		defer Release(pooled)
+
+		expected := New("test.pooled", detail)
+		assert.Equal(t, expected.Code(), pooled.Code())
+		assert.Equal(t, expected.Detail(), pooled.Detail())
+		assert.Equal(t, expected.Error(), pooled.Error())
+		assert.True(t, Is(pooled, "test.pooled"))
+	})
+
+	t.Run("should panic if the detail type does not match", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewPooled("test.pooled", struct{ Label string }{})
+		})
+	})
+
+	t.Run("should panic when released twice", func(t *testing.T) {
+		pooled := NewPooled("test.pooled", struct{ Message string }{})
+		Release(pooled)
+
+		expectedMessage := New(ErrCodeAlreadyReleased, ErrorEXDetail{Code: ""}).Error()
+		assert.PanicsWithError(t, expectedMessage, func() {
+			Release(pooled)
+		})
+	})
+
+	t.Run("should ignore errors not created by NewPooled", func(t *testing.T) {
+		ex := New("test.pooled", struct{ Message string }{})
+		assert.NotPanics(t, func() {
+			Release(ex)
+			Release(ex)
+		})
+		assert.Equal(t, "test.pooled", ex.Code())
+	})
+
+	t.Run("should be safe to use from multiple goroutines", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					detail := struct{ Message string }{Message: "row"}
+					pooled := NewPooled("test.pooled", detail)
+					assert.Equal(t, `{"code": "test.pooled", "detail": {"Message":"row"}}`, pooled.Error())
+					Release(pooled)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func BenchmarkNewPooled(b *testing.B) {
+	RegisterErrorCode("bench.pooled", "bench description", struct{ Message string }{})
+	detail := struct{ Message string }{Message: "row"}
+
+	b.Run("New", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = New("bench.pooled", detail).Error()
+		}
+	})
+
+	b.Run("NewPooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			pooled := NewPooled("bench.pooled", detail)
+			_ = pooled.Error()
+			Release(pooled)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Sink is a destination of errorex errors whose view of the detail can be projected with RegisterProjection
+type Sink string
+
+const (
+	// SinkExternal is the responses sent to clients, written by httpex and grpcex
+	SinkExternal Sink = "external"
+	// SinkLog is the application logs
+	SinkLog Sink = "log"
+	// SinkMetrics is the metrics labels and exemplars
+	SinkMetrics Sink = "metrics"
+	// SinkReport is the error reporting services
+	SinkReport Sink = "report"
+	// SinkAudit is the audit records built by ToAuditEntry
+	SinkAudit Sink = "audit"
+)
+
+// projections holds the projections by canonical code and sink
+var (
+	projectionMutex sync.RWMutex
+	projections     = make(map[string]map[Sink]func(detail any) any)
+	sinkFilter      atomic.Pointer[SinkFilter]
+)
+
+// SinkFilter transforms the errorex serialized to a sink before its projection, e.g. to scrub secrets
+type SinkFilter func(err EX, sink Sink) EX
+
+// SetSinkFilter sets the filter applied by Projected, and so by Boundary, ToAuditEntry and the sinks of errorex
+// (httpex, grpcex, lro, jsonapi and webhook), a nil filter removes it:
+//
+//	errorex.SetSinkFilter(scrub.New().Filter)
+func SetSinkFilter(filter SinkFilter) {
+	if filter == nil {
+		sinkFilter.Store(nil)
+		return
+	}
+	sinkFilter.Store(&filter)
+}
+
+// RegisterProjection sets how a sink sees the detail of a code, e.g. the log sink gets the full SQL statement
+// while the external sink only gets the constraint name:
+//
+//	errorex.RegisterProjection("db.constraint", errorex.SinkExternal, func(detail ConstraintDetail) any {
+//		return map[string]string{"constraint": detail.Constraint}
+//	})
+//
+// A later call replaces the projection of the code for the sink.
+// It panics if the code is not registered.
+func RegisterProjection[T any](code string, sink Sink, project func(detail T) any) {
+	codeRegistry, ok := lookupCode(code)
+	if !ok {
+		// Fatal errorex
+		fatal(New(ErrCodeNotRegistered, ErrorEXDetail{Code: code}))
+	}
+	projectionMutex.Lock()
+	defer projectionMutex.Unlock()
+	if projections[codeRegistry.code] == nil {
+		projections[codeRegistry.code] = make(map[Sink]func(detail any) any)
+	}
+	projections[codeRegistry.code][sink] = func(detail any) any {
+		typed, _ := detail.(T)
+		return project(typed)
+	}
+}
+
+// Project returns the detail of the errorex as seen by the sink: the result of the projection registered for its
+// code, or the detail itself. Encrypted details are returned as they are.
+func Project(err EX, sink Sink) any {
+	if _, ok := err.Detail().(EncryptedDetail); ok {
+		return err.Detail()
+	}
+	if project, ok := projectionOf(err.Code(), sink); ok {
+		return project(err.Detail())
+	}
+	return err.Detail()
+}
+
+// Projected returns a copy of the errorex whose detail is the projection for the sink, to serialize it for the
+// sink. The filter set by SetSinkFilter is applied first, so the projection is made from the filtered detail.
+// The detail is kept when no projection is registered for its code, when it is encrypted, or when the errorex is
+// not created by errorex. The copy is not type checked, Detail returns the projection.
+func Projected(err EX, sink Sink) EX {
+	if filter := sinkFilter.Load(); filter != nil {
+		err = (*filter)(err, sink)
+	}
+	project, ok := projectionOf(err.Code(), sink)
+	if !ok {
+		return err
+	}
+	e, ok := err.(*ex)
+	if !ok {
+		return err
+	}
+	if _, ok := e.detail.(EncryptedDetail); ok {
+		return err
+	}
+	projected := e.clone()
+	projected.detail = project(e.detail)
+	return projected
+}
+
+// projectionOf returns the projection registered for the code, or for the code it is an alias of
+func projectionOf(code string, sink Sink) (func(detail any) any, bool) {
+	codeRegistry, ok := lookupCode(code)
+	if !ok {
+		return nil, false
+	}
+	projectionMutex.RLock()
+	defer projectionMutex.RUnlock()
+	project, ok := projections[codeRegistry.code][sink]
+	return project, ok
+}
@@ -0,0 +1,92 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type constraintDetail struct {
+	Constraint string `json:"constraint"`
+	Statement  string `json:"statement"`
+}
+
+func TestRegisterProjection(t *testing.T) {
+	RegisterErrorCode("projection.constraint", "Constraint violated", constraintDetail{})
+	RegisterAlias("projection.unique", "projection.constraint")
+	RegisterProjection("projection.unique", SinkExternal, func(detail constraintDetail) any {
+		return map[string]string{"constraint": detail.Constraint}
+	})
+	err := New("projection.constraint", constraintDetail{Constraint: "users_email_key", Statement: "INSERT INTO users"})
+
+	t.Run("should project the detail for the sink", func(t *testing.T) {
+		assert.Equal(t, map[string]string{"constraint": "users_email_key"}, Project(err, SinkExternal))
+		assert.Equal(t, err.Detail(), Project(err, SinkLog))
+	})
+
+	t.Run("should serialize the projection", func(t *testing.T) {
+		projected := Projected(err, SinkExternal)
+
+		assert.Equal(t, `{"code": "projection.constraint", "detail": {"constraint":"users_email_key"}}`, projected.Error())
+		assert.Contains(t, err.Error(), "INSERT INTO users")
+		assert.Same(t, err, Projected(err, SinkReport))
+	})
+
+	t.Run("should panic for unregistered codes", func(t *testing.T) {
+		assert.Panics(t, func() {
+			RegisterProjection("projection.unregistered", SinkLog, func(detail ErrorEXDetail) any { return nil })
+		})
+	})
+}
+
+func TestSetSinkFilter(t *testing.T) {
+	RegisterErrorCode("projection.filtered", "Constraint violated", constraintDetail{})
+	RegisterProjection("projection.filtered", SinkExternal, func(detail constraintDetail) any {
+		return map[string]string{"constraint": detail.Constraint}
+	})
+	err := New("projection.filtered", constraintDetail{Constraint: "users_email_key", Statement: "INSERT INTO users"})
+	var sinks []Sink
+	SetSinkFilter(func(err EX, sink Sink) EX {
+		sinks = append(sinks, sink)
+		return WithDetail(err, constraintDetail{Constraint: "[filtered]"})
+	})
+	defer SetSinkFilter(nil)
+
+	t.Run("should filter the errorex before its projection", func(t *testing.T) {
+		assert.Equal(t, map[string]string{"constraint": "[filtered]"}, Projected(err, SinkExternal).Detail())
+		assert.Equal(t, constraintDetail{Constraint: "[filtered]"}, Projected(err, SinkReport).Detail())
+		assert.Equal(t, []Sink{SinkExternal, SinkReport}, sinks)
+	})
+
+	t.Run("should filter the errorex crossing a boundary", func(t *testing.T) {
+		assert.Equal(t, `{"code": "projection.filtered", "detail": {"constraint":"[filtered]"}}`, Boundary(err).Error())
+	})
+
+	t.Run("should not filter once removed", func(t *testing.T) {
+		SetSinkFilter(nil)
+		assert.Same(t, err, Projected(err, SinkReport))
+	})
+}
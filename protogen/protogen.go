@@ -0,0 +1,411 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package protogen generates a .proto file with messages for the details of the registered errorex codes and an
+// enum of the codes, for organizations that mandate protobuf contracts between services.
+//
+// Enum values are derived from a hash of the code, so they are stable when codes are added or removed. Message
+// field numbers follow the declaration order of the Go struct fields, so fields must only be appended to keep
+// the wire format compatible.
+//
+// Like docgen, it is used from a small command importing the error domains and calling Main.
+package protogen
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// Options configures the generated file
+type Options struct {
+	// Package is the proto package, "errorex.v1" when empty
+	Package string
+	// GoPackage sets the go_package option when not empty
+	GoPackage string
+	// EnumName is the name of the code enum, "ErrorCode" when empty
+	EnumName string
+}
+
+// generator keeps the messages already named while walking the detail types
+type generator struct {
+	names    map[reflect.Type]string
+	used     map[string]reflect.Type
+	messages []reflect.Type
+	// wrappers are the messages of details that are not named structs, by message name
+	wrappers map[string]reflect.Type
+	imports  map[string]bool
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Generate writes the .proto file of the catalog
+func Generate(w io.Writer, catalog []errorex.CodeInfo, options Options) error {
+	if options.Package == "" {
+		options.Package = "errorex.v1"
+	}
+	if options.EnumName == "" {
+		options.EnumName = "ErrorCode"
+	}
+	g := &generator{
+		names:    make(map[reflect.Type]string),
+		used:     make(map[string]reflect.Type),
+		wrappers: make(map[string]reflect.Type),
+		imports:  make(map[string]bool),
+	}
+	type enumValue struct {
+		name   string
+		number uint32
+		code   string
+		detail string
+	}
+	values := make([]enumValue, 0, len(catalog))
+	numbers := make(map[uint32]string)
+	for _, info := range catalog {
+		number := enumNumber(info.Code)
+		if other, ok := numbers[number]; ok {
+			return fmt.Errorf("codes %s and %s have the same enum number %d", other, info.Code, number)
+		}
+		numbers[number] = info.Code
+		values = append(values, enumValue{
+			name:   constantName(options.EnumName) + "_" + constantName(info.Code),
+			number: number,
+			code:   info.Code,
+			detail: g.detailMessage(info),
+		})
+	}
+	// Render the messages first, they discover the imports
+	var body strings.Builder
+	for i := 0; i < len(g.messages); i++ {
+		body.WriteString("\n")
+		g.writeMessage(&body, g.messages[i])
+	}
+	wrapperNames := make([]string, 0, len(g.wrappers))
+	for name := range g.wrappers {
+		wrapperNames = append(wrapperNames, name)
+	}
+	sort.Strings(wrapperNames)
+	for _, name := range wrapperNames {
+		fmt.Fprintf(&body, "\nmessage %s {\n  %s value = 1;\n}\n", name, g.fieldType(g.wrappers[name]))
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "// Code generated by errorex protogen. DO NOT EDIT.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `syntax = "proto3";`)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "package %s;\n", options.Package)
+	imports := make([]string, 0, len(g.imports))
+	for name := range g.imports {
+		imports = append(imports, name)
+	}
+	sort.Strings(imports)
+	if len(imports) > 0 {
+		fmt.Fprintln(out)
+	}
+	for _, name := range imports {
+		fmt.Fprintf(out, "import %q;\n", name)
+	}
+	if options.GoPackage != "" {
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "option go_package = %q;\n", options.GoPackage)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "enum %s {\n", options.EnumName)
+	fmt.Fprintf(out, "  %s_UNSPECIFIED = 0;\n", constantName(options.EnumName))
+	for _, value := range values {
+		fmt.Fprintf(out, "  // %s, detail %s\n", value.code, value.detail)
+		fmt.Fprintf(out, "  %s = %d;\n", value.name, value.number)
+	}
+	fmt.Fprintln(out, "}")
+	out.WriteString(body.String())
+	return out.Flush()
+}
+
+// Main generates the .proto file of the registry of the running program according to the command line flags:
+//
+//	-out file          output file (stdout)
+//	-package name      proto package (errorex.v1)
+//	-go_package path   go_package option
+//	-prefix prefix     only generate codes starting with prefix
+func Main() {
+	output := flag.String("out", "", "output file, stdout when empty")
+	pkg := flag.String("package", "", "proto package")
+	goPackage := flag.String("go_package", "", "go_package option")
+	prefix := flag.String("prefix", "", "only generate codes starting with prefix")
+	flag.Parse()
+	if err := run(*output, *prefix, Options{Package: *pkg, GoPackage: *goPackage}); err != nil {
+		fmt.Fprintln(os.Stderr, "protogen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(output, prefix string, options Options) error {
+	var catalog []errorex.CodeInfo
+	for _, info := range errorex.Catalog() {
+		if strings.HasPrefix(info.Code, prefix) {
+			catalog = append(catalog, info)
+		}
+	}
+	if output == "" {
+		return Generate(os.Stdout, catalog, options)
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := Generate(file, catalog, options); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// detailMessage returns the message of the detail of a code
+func (g *generator) detailMessage(info errorex.CodeInfo) string {
+	t := info.DetailType
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != nil && t.Kind() == reflect.Struct && t.Name() != "" && t != timeType {
+		return g.messageName(t)
+	}
+	name := pascalName(info.Code) + "Detail"
+	if t == nil {
+		t = reflect.TypeOf((*any)(nil)).Elem()
+	}
+	if t.Kind() == reflect.Struct && t.Name() == "" {
+		// Anonymous structs become messages named after the code
+		g.names[t] = name
+		g.used[name] = t
+		g.messages = append(g.messages, t)
+		return name
+	}
+	g.wrappers[name] = t
+	return name
+}
+
+// messageName returns the message name of a named struct, qualifying it with its package on collisions
+func (g *generator) messageName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := pascalName(t.Name())
+	if other, ok := g.used[name]; ok && other != t {
+		pkg := t.PkgPath()
+		name = pascalName(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+	}
+	g.names[t] = name
+	g.used[name] = t
+	g.messages = append(g.messages, t)
+	return name
+}
+
+// writeMessage writes the message of a struct
+func (g *generator) writeMessage(w io.Writer, t reflect.Type) {
+	fmt.Fprintf(w, "message %s {\n", g.names[t])
+	for i, field := range errorex.DetailFields(t) {
+		name := snakeName(field.Name)
+		option := ""
+		if lowerCamel(name) != field.Name {
+			option = fmt.Sprintf(" [json_name = %q]", field.Name)
+		}
+		fmt.Fprintf(w, "  %s %s = %d%s;\n", g.fieldType(field.Field.Type), name, i+1, option)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// fieldType returns the proto type of a field, including the repeated or optional label
+func (g *generator) fieldType(t reflect.Type) string {
+	optional := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		optional = true
+	}
+	switch {
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8:
+		element := t.Elem()
+		for element.Kind() == reflect.Pointer {
+			element = element.Elem()
+		}
+		if isContainer(element) {
+			return "repeated " + g.valueType()
+		}
+		return "repeated " + g.scalarType(element)
+	case t.Kind() == reflect.Map:
+		if t.Key().Kind() != reflect.String || isContainer(t.Elem()) {
+			return g.structType()
+		}
+		return fmt.Sprintf("map<string, %s>", g.scalarType(t.Elem()))
+	}
+	scalar := g.scalarType(t)
+	if optional && !strings.Contains(scalar, ".") && t.Kind() != reflect.Struct && scalar != "bytes" {
+		return "optional " + scalar
+	}
+	return scalar
+}
+
+// scalarType returns the proto type of a non repeated value
+func (g *generator) scalarType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		g.imports["google/protobuf/timestamp.proto"] = true
+		return "google.protobuf.Timestamp"
+	case t == durationType:
+		// encoding/json serializes durations as nanoseconds
+		return "int64"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return "int32"
+	case reflect.Int, reflect.Int64:
+		return "int64"
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "uint32"
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return "uint64"
+	case reflect.Float32:
+		return "float"
+	case reflect.Float64:
+		return "double"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes"
+		}
+		return g.valueType()
+	case reflect.Map:
+		return g.structType()
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structType()
+		}
+		return g.messageName(t)
+	}
+	return g.valueType()
+}
+
+// valueType returns google.protobuf.Value, used for values that proto3 cannot express
+func (g *generator) valueType() string {
+	g.imports["google/protobuf/struct.proto"] = true
+	return "google.protobuf.Value"
+}
+
+// structType returns google.protobuf.Struct, used for maps that proto3 cannot express
+func (g *generator) structType() string {
+	g.imports["google/protobuf/struct.proto"] = true
+	return "google.protobuf.Struct"
+}
+
+// isContainer reports whether a type is a slice or a map, which proto3 cannot nest
+func isContainer(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8) || t.Kind() == reflect.Map || t.Kind() == reflect.Array
+}
+
+// enumNumber derives a stable enum number from the code, FNV-1a reduced to 29 bits and never zero
+func enumNumber(code string) uint32 {
+	hash := uint32(2166136261)
+	for i := 0; i < len(code); i++ {
+		hash ^= uint32(code[i])
+		hash *= 16777619
+	}
+	hash &= 1<<29 - 1
+	if hash == 0 {
+		hash = 1
+	}
+	return hash
+}
+
+// words splits an identifier or code into lower case words
+func words(name string) []string {
+	var result []string
+	var current []rune
+	runes := []rune(name)
+	flush := func() {
+		if len(current) > 0 {
+			result = append(result, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+	return result
+}
+
+// pascalName converts a name into PascalCase (billing.card_expired -> BillingCardExpired)
+func pascalName(name string) string {
+	var b strings.Builder
+	for _, word := range words(name) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// snakeName converts a name into snake_case (invoiceID -> invoice_id)
+func snakeName(name string) string {
+	return strings.Join(words(name), "_")
+}
+
+// constantName converts a name into UPPER_SNAKE_CASE (billing.card_expired -> BILLING_CARD_EXPIRED)
+func constantName(name string) string {
+	return strings.ToUpper(snakeName(name))
+}
+
+// lowerCamel converts a snake_case name into the lowerCamelCase used by protobuf as JSON name
+func lowerCamel(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,106 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package protogen
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type declinedDetail struct {
+	Reason    string            `json:"reason"`
+	Limit     *money            `json:"limit"`
+	Charges   []money           `json:"charges,omitempty"`
+	Labels    map[string]string `json:"labels"`
+	At        time.Time         `json:"at"`
+	Raw       []byte            `json:"raw"`
+	Retries   *int              `json:"retries"`
+	InvoiceID string            `json:"invoiceID"`
+	Matrix    [][]int           `json:"matrix"`
+	Timeout   time.Duration     `json:"timeout"`
+}
+
+func init() {
+	errorex.RegisterErrorCode("protogen.declined", "Payment declined", declinedDetail{})
+	errorex.RegisterErrorCode("protogen.inline", "Inline detail", struct{ Message string }{})
+	errorex.RegisterErrorCode("protogen.plain", "Plain detail", "")
+}
+
+func TestGenerate(t *testing.T) {
+	var catalog []errorex.CodeInfo
+	for _, code := range []string{"protogen.declined", "protogen.inline", "protogen.plain"} {
+		info, _ := errorex.Lookup(code)
+		catalog = append(catalog, info)
+	}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, Generate(&buffer, catalog, Options{GoPackage: "example.com/errorspb"}))
+	proto := buffer.String()
+
+	assert.Contains(t, proto, "package errorex.v1;")
+	assert.Contains(t, proto, "import \"google/protobuf/struct.proto\";\nimport \"google/protobuf/timestamp.proto\";")
+	assert.Contains(t, proto, `option go_package = "example.com/errorspb";`)
+	assert.Contains(t, proto, "  ERROR_CODE_UNSPECIFIED = 0;\n  // protogen.declined, detail DeclinedDetail\n")
+	assert.Contains(t, proto, `message DeclinedDetail {
+  string reason = 1;
+  Money limit = 2;
+  repeated Money charges = 3;
+  map<string, string> labels = 4;
+  google.protobuf.Timestamp at = 5;
+  bytes raw = 6;
+  optional int64 retries = 7;
+  string invoice_id = 8 [json_name = "invoiceID"];
+  repeated google.protobuf.Value matrix = 9;
+  int64 timeout = 10;
+}`)
+	assert.Contains(t, proto, "message Money {\n  int64 amount = 1;\n  string currency = 2;\n}")
+	assert.Contains(t, proto, "message ProtogenInlineDetail {\n  string message = 1 [json_name = \"Message\"];\n}")
+	assert.Contains(t, proto, "message ProtogenPlainDetail {\n  string value = 1;\n}")
+}
+
+func TestEnumNumber(t *testing.T) {
+	t.Run("should be stable and not zero", func(t *testing.T) {
+		assert.Equal(t, enumNumber("billing.declined"), enumNumber("billing.declined"))
+		assert.NotEqual(t, enumNumber("billing.declined"), enumNumber("billing.expired"))
+		assert.NotZero(t, enumNumber(""))
+		assert.Less(t, enumNumber("billing.declined"), uint32(1<<29))
+	})
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, "BillingCardExpired", pascalName("billing.card_expired"))
+	assert.Equal(t, "invoice_id", snakeName("invoiceID"))
+	assert.Equal(t, "http_status", snakeName("HTTPStatus"))
+	assert.Equal(t, "ERROR_CODE", constantName("ErrorCode"))
+	assert.Equal(t, "invoiceId", lowerCamel("invoice_id"))
+}
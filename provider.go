@@ -0,0 +1,132 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CatalogProvider describes the codes that are not registered locally, e.g. fetching them from a central error
+// catalog service, so gateways can render the errors of services whose codes they never registered
+type CatalogProvider interface {
+	// LookupCode returns the description of the code, false when the provider does not know it
+	LookupCode(code string) (CodeInfo, bool)
+}
+
+// CatalogProviderFunc adapts a function to CatalogProvider
+type CatalogProviderFunc func(code string) (CodeInfo, bool)
+
+// LookupCode calls the function
+func (fn CatalogProviderFunc) LookupCode(code string) (CodeInfo, bool) {
+	return fn(code)
+}
+
+// catalogProviderHolder lets a nil provider be stored
+type catalogProviderHolder struct {
+	provider CatalogProvider
+}
+
+var catalogProvider atomic.Pointer[catalogProviderHolder]
+
+// SetCatalogProvider sets the provider consulted by Resolve for the codes not registered locally, nil removes it.
+// The provider is called on every lookup of an unknown code, see CachedProvider for remote catalogs.
+func SetCatalogProvider(provider CatalogProvider) {
+	catalogProvider.Store(&catalogProviderHolder{provider: provider})
+}
+
+// Resolve returns the description of a code like Lookup, consulting the provider set with SetCatalogProvider
+// when the code is not registered locally. Codes known only to the provider are still not registered: New and
+// Is reject them, only the descriptions (messages, HTTP and gRPC mappings, ...) are read through.
+func Resolve(code string) (CodeInfo, bool) {
+	if info, ok := Lookup(code); ok {
+		return info, true
+	}
+	holder := catalogProvider.Load()
+	if holder == nil || holder.provider == nil {
+		return CodeInfo{}, false
+	}
+	return holder.provider.LookupCode(code)
+}
+
+// maxCachedLookups bounds the lookups kept by a cachedProvider, as the codes looked up may come from the wire
+const maxCachedLookups = 4096
+
+// cachedLookup is a lookup result kept by a cachedProvider
+type cachedLookup struct {
+	info    CodeInfo
+	found   bool
+	expires time.Time
+}
+
+// cachedProvider caches the lookups of a provider
+type cachedProvider struct {
+	provider CatalogProvider
+	ttl      time.Duration
+	mutex    sync.RWMutex
+	lookups  map[string]cachedLookup
+}
+
+// CachedProvider caches the lookups of the provider, including the codes it does not know, for the ttl. It keeps up
+// to 4096 lookups, dropping the expired ones, then arbitrary ones, when full.
+func CachedProvider(provider CatalogProvider, ttl time.Duration) CatalogProvider {
+	return &cachedProvider{provider: provider, ttl: ttl, lookups: make(map[string]cachedLookup)}
+}
+
+// LookupCode returns the cached lookup of the code, calling the provider when it is missing or expired
+func (c *cachedProvider) LookupCode(code string) (CodeInfo, bool) {
+	c.mutex.RLock()
+	lookup, ok := c.lookups[code]
+	c.mutex.RUnlock()
+	if ok && now().Before(lookup.expires) {
+		return lookup.info, lookup.found
+	}
+	info, found := c.provider.LookupCode(code)
+	at := now()
+	c.mutex.Lock()
+	if _, ok := c.lookups[code]; !ok && len(c.lookups) >= maxCachedLookups {
+		c.evict(at)
+	}
+	c.lookups[code] = cachedLookup{info: info, found: found, expires: at.Add(c.ttl)}
+	c.mutex.Unlock()
+	return info, found
+}
+
+// evict makes room for a lookup, dropping the expired lookups or, when none expired, an arbitrary one. The mutex is
+// held.
+func (c *cachedProvider) evict(at time.Time) {
+	for code, lookup := range c.lookups {
+		if !at.Before(lookup.expires) {
+			delete(c.lookups, code)
+		}
+	}
+	if len(c.lookups) < maxCachedLookups {
+		return
+	}
+	for code := range c.lookups {
+		delete(c.lookups, code)
+		return
+	}
+}
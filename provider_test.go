@@ -0,0 +1,122 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	calls := 0
+	provider := CatalogProviderFunc(func(code string) (CodeInfo, bool) {
+		calls++
+		if code != "remote.not_found" {
+			return CodeInfo{}, false
+		}
+		return CodeInfo{Code: code, Description: "Remote resource not found", HTTPStatus: 404}, true
+	})
+
+	t.Run("should consult the provider for unknown codes", func(t *testing.T) {
+		SetCatalogProvider(provider)
+		defer SetCatalogProvider(nil)
+
+		info, ok := Resolve("remote.not_found")
+
+		assert.True(t, ok)
+		assert.Equal(t, "Remote resource not found", info.Description)
+		_, ok = Resolve("remote.unknown")
+		assert.False(t, ok)
+		assert.Equal(t, "Remote resource not found", Message(&ex{code: "remote.not_found"}))
+	})
+
+	t.Run("should prefer the local registry", func(t *testing.T) {
+		SetCatalogProvider(provider)
+		defer SetCatalogProvider(nil)
+		calls = 0
+
+		info, ok := Resolve(ErrCodeNotRegistered)
+
+		assert.True(t, ok)
+		assert.Equal(t, ErrCodeNotRegistered, info.Code)
+		assert.Zero(t, calls)
+	})
+
+	t.Run("should parse the codes resolved by the provider", func(t *testing.T) {
+		SetCatalogProvider(provider)
+		defer SetCatalogProvider(nil)
+
+		parsed, err := ParseJSON([]byte(`{"code": "remote.not_found", "detail": {"resource": "invoice"}}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "remote.not_found", parsed.Code())
+		assert.Equal(t, map[string]any{"resource": "invoice"}, parsed.Detail())
+		assert.Equal(t, "Remote resource not found", Message(parsed))
+		_, err = ParseJSON([]byte(`{"code": "remote.unknown"}`))
+		assert.True(t, Is(err, ErrCodeNotRegistered))
+	})
+
+	t.Run("should not resolve without a provider", func(t *testing.T) {
+		_, ok := Resolve("remote.not_found")
+
+		assert.False(t, ok)
+	})
+}
+
+func TestCachedProvider(t *testing.T) {
+	calls := 0
+	provider := CachedProvider(CatalogProviderFunc(func(code string) (CodeInfo, bool) {
+		calls++
+		return CodeInfo{Code: code}, code == "remote.cached"
+	}), time.Minute)
+	current := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return current })
+	defer SetClock(nil)
+
+	t.Run("should cache the lookups until they expire", func(t *testing.T) {
+		provider.LookupCode("remote.cached")
+		_, ok := provider.LookupCode("remote.cached")
+		provider.LookupCode("remote.missing")
+		provider.LookupCode("remote.missing")
+
+		assert.True(t, ok)
+		assert.Equal(t, 2, calls)
+
+		current = current.Add(time.Minute)
+		provider.LookupCode("remote.cached")
+
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("should bound the cached lookups", func(t *testing.T) {
+		for i := range 2 * maxCachedLookups {
+			provider.LookupCode(fmt.Sprintf("remote.flood.%d", i))
+		}
+
+		assert.Len(t, provider.(*cachedProvider).lookups, maxCachedLookups)
+	})
+}
@@ -0,0 +1,17 @@
+module github.com/fkmatsuda/errorex/ratelimit
+
+go 1.25.0
+
+require (
+	github.com/fkmatsuda/errorex v0.0.0
+	github.com/stretchr/testify v1.10.0
+	golang.org/x/time v0.15.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/fkmatsuda/errorex => ../
@@ -0,0 +1,139 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package ratelimit provides the standard errorex codes of throttled requests, quota.exceeded and rate.limited,
+// helpers building them from golang.org/x/time/rate limiters, and the RateLimit-Limit, RateLimit-Remaining,
+// RateLimit-Reset and Retry-After headers written by httpex.WriteError for them:
+//
+//	limiter := rate.NewLimiter(10, 20)
+//	...
+//	if err := ratelimit.Allow(limiter); err != nil {
+//		httpex.WriteError(w, err)
+//		return
+//	}
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/httpex"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// ErrCodeQuotaExceeded is the errorex code for requests exceeding a quota over a period, such as a daily
+	// allowance of calls
+	ErrCodeQuotaExceeded = "quota.exceeded"
+	// ErrCodeRateLimited is the errorex code for requests rejected by a rate limiter
+	ErrCodeRateLimited = "rate.limited"
+)
+
+// Headers written by httpex.WriteError for the codes of the package
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+)
+
+// LimitDetail is the detail of ErrCodeQuotaExceeded and ErrCodeRateLimited
+type LimitDetail struct {
+	// Limit is the number of requests allowed, the quota or the burst of the limiter
+	Limit int64 `json:"limit"`
+	// Remaining is the number of requests still allowed
+	Remaining int64 `json:"remaining"`
+	// Reset is when requests are allowed again, zero when they never are
+	Reset time.Time `json:"reset"`
+}
+
+func init() {
+	// Register the errorex codes, 8 being the gRPC ResourceExhausted code
+	errorex.RegisterErrorCode(ErrCodeQuotaExceeded, "Quota exceeded", LimitDetail{},
+		errorex.WithHTTPStatus(http.StatusTooManyRequests), errorex.WithGRPCCode(8))
+	errorex.RegisterErrorCode(ErrCodeRateLimited, "Rate limited", LimitDetail{},
+		errorex.WithHTTPStatus(http.StatusTooManyRequests), errorex.WithGRPCCode(8), errorex.WithRetryable())
+
+	httpex.RegisterHeaders(ErrCodeQuotaExceeded, limitHeaders)
+	httpex.RegisterHeaders(ErrCodeRateLimited, limitHeaders)
+}
+
+// QuotaExceeded returns an ErrCodeQuotaExceeded errorex for a quota of limit requests with remaining requests left,
+// renewed at reset, hinting to retry at reset (see errorex.WithRetryAfter) when it is set
+func QuotaExceeded(limit, remaining int64, reset time.Time, options ...errorex.Option) errorex.EX {
+	if !reset.IsZero() {
+		options = append([]errorex.Option{errorex.WithRetryAfter(max(reset.Sub(time.Now()), 0))}, options...)
+	}
+	return errorex.New(ErrCodeQuotaExceeded, LimitDetail{Limit: limit, Remaining: max(remaining, 0), Reset: reset}, options...)
+}
+
+// Allow reserves a token of the limiter, returning nil when it is available now and an ErrCodeRateLimited errorex
+// otherwise, see AllowN
+func Allow(limiter *rate.Limiter) errorex.EX {
+	return AllowN(limiter, time.Now(), 1)
+}
+
+// AllowN reserves n tokens of the limiter at t, returning nil when they are available at t. Otherwise the
+// reservation is canceled and the ErrCodeRateLimited errorex of Limited is returned.
+func AllowN(limiter *rate.Limiter, t time.Time, n int) errorex.EX {
+	reservation := limiter.ReserveN(t, n)
+	if reservation.OK() && reservation.DelayFrom(t) == 0 {
+		return nil
+	}
+	ex := Limited(limiter, reservation, t)
+	reservation.CancelAt(t)
+	return ex
+}
+
+// Limited returns the ErrCodeRateLimited errorex of a reservation of the limiter made at t: the limit is the burst
+// of the limiter, the remaining requests its tokens at t and the reset the time the reservation can act, with the
+// matching retry hint (see errorex.WithRetryAfter). Reservations that can never act, because they ask for more
+// tokens than the burst, have no reset and no hint.
+func Limited(limiter *rate.Limiter, reservation *rate.Reservation, t time.Time, options ...errorex.Option) errorex.EX {
+	detail := LimitDetail{
+		Limit:     int64(limiter.Burst()),
+		Remaining: max(int64(math.Floor(limiter.TokensAt(t))), 0),
+	}
+	if reservation.OK() {
+		delay := reservation.DelayFrom(t)
+		detail.Reset = t.Add(delay)
+		options = append([]errorex.Option{errorex.WithRetryAfter(delay)}, options...)
+	}
+	return errorex.New(ErrCodeRateLimited, detail, options...)
+}
+
+// limitHeaders sets the RateLimit headers, the reset in seconds from now rounded up
+func limitHeaders(ex errorex.EX, header http.Header) {
+	detail, ok := ex.Detail().(LimitDetail)
+	if !ok {
+		return
+	}
+	header.Set(HeaderLimit, strconv.FormatInt(detail.Limit, 10))
+	header.Set(HeaderRemaining, strconv.FormatInt(detail.Remaining, 10))
+	if !detail.Reset.IsZero() {
+		reset := math.Ceil(max(time.Until(detail.Reset), 0).Seconds())
+		header.Set(HeaderReset, strconv.FormatInt(int64(reset), 10))
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/httpex"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestAllowN(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should allow the requests within the limit", func(t *testing.T) {
+		limiter := rate.NewLimiter(1, 2)
+		assert.Nil(t, AllowN(limiter, start, 1))
+		assert.Nil(t, AllowN(limiter, start, 1))
+	})
+
+	t.Run("should reject the requests over the limit and keep the tokens", func(t *testing.T) {
+		limiter := rate.NewLimiter(2, 3)
+		assert.Nil(t, AllowN(limiter, start, 3))
+		ex := AllowN(limiter, start, 1)
+		assert.Equal(t, ErrCodeRateLimited, ex.Code())
+		assert.Equal(t, LimitDetail{Limit: 3, Remaining: 0, Reset: start.Add(500 * time.Millisecond)}, ex.Detail())
+		delay, _ := errorex.RetryAfter(ex)
+		assert.Equal(t, 500*time.Millisecond, delay)
+		assert.True(t, errorex.IsRetryable(ex))
+		assert.Nil(t, AllowN(limiter, start.Add(500*time.Millisecond), 1))
+	})
+
+	t.Run("should have no reset for requests over the burst", func(t *testing.T) {
+		ex := AllowN(rate.NewLimiter(1, 2), start, 5)
+		assert.Equal(t, LimitDetail{Limit: 2, Remaining: 2}, ex.Detail())
+		_, ok := errorex.RetryAfter(ex)
+		assert.False(t, ok)
+	})
+}
+
+func TestQuotaExceeded(t *testing.T) {
+	t.Run("should hint to retry at the reset", func(t *testing.T) {
+		reset := time.Now().Add(time.Hour)
+		ex := QuotaExceeded(1000, -1, reset)
+		assert.Equal(t, LimitDetail{Limit: 1000, Remaining: 0, Reset: reset}, ex.Detail())
+		delay, ok := errorex.RetryAfter(ex)
+		assert.True(t, ok)
+		assert.InDelta(t, time.Hour, delay, float64(time.Second))
+		assert.False(t, errorex.IsRetryable(ex))
+	})
+
+	t.Run("should write the rate limit headers", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		httpex.WriteError(recorder, QuotaExceeded(1000, 0, time.Now().Add(90*time.Second)))
+		assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		assert.Equal(t, "1000", recorder.Header().Get(HeaderLimit))
+		assert.Equal(t, "0", recorder.Header().Get(HeaderRemaining))
+		assert.Equal(t, "90", recorder.Header().Get(HeaderReset))
+		assert.Equal(t, "90", recorder.Header().Get("Retry-After"))
+	})
+
+	t.Run("should leave out the reset when not set", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		httpex.WriteError(recorder, AllowN(rate.NewLimiter(1, 1), time.Now(), 2))
+		assert.Equal(t, "1", recorder.Header().Get(HeaderLimit))
+		assert.Empty(t, recorder.Header().Get(HeaderReset))
+	})
+}
@@ -0,0 +1,244 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// registryShards is the number of shards of the registry, it must be a power of two
+	registryShards = 64
+)
+
+var (
+	// registry holds the codes while it is still accepting registrations, sharded by code prefix
+	registry [registryShards]registryShard
+	// freezeMutex serializes Freeze calls
+	freezeMutex sync.Mutex
+	// frozenCodes holds the immutable snapshot taken by Freeze, lookups use it without locking
+	frozenCodes atomic.Pointer[map[string]errorCodeRegistry]
+	// registryGeneration is incremented by every registration, to invalidate what is resolved from the registry
+	registryGeneration atomic.Uint64
+	// aliases lists the aliases of each canonical code
+	aliasMutex sync.RWMutex
+	aliases    = make(map[string][]string)
+)
+
+// registryShard is a portion of the registry guarded by its own lock.
+// Codes sharing the same prefix (the part before the first dot) live in the same shard, so registration bursts
+// from different domains don't contend and lookups of related codes hit the same map.
+type registryShard struct {
+	mutex sync.RWMutex
+	codes map[string]errorCodeRegistry
+}
+
+// shardOf returns the shard of a code, hashing its prefix with FNV-1a
+func shardOf(code string) *registryShard {
+	hash := uint32(2166136261)
+	for i := 0; i < len(code) && code[i] != '.'; i++ {
+		hash ^= uint32(code[i])
+		hash *= 16777619
+	}
+	return &registry[hash&(registryShards-1)]
+}
+
+// Freeze stops the registry from accepting new codes.
+// After Freeze, the registry is swapped to an immutable snapshot so New and Is never touch a mutex.
+// It is intended to be called once the application has finished its initialization, calling it again has no effect.
+// Any RegisterErrorCode after Freeze panics with ErrCodeRegistryFrozen.
+// The deferred aliases and the parents are resolved first (see ResolveReferences), it panics with
+// ErrCodeUnresolvedReference if some of them are not registered.
+func Freeze() {
+	freezeMutex.Lock()
+	defer freezeMutex.Unlock()
+	if frozenCodes.Load() != nil {
+		return
+	}
+	if problems := resolveReferences(); len(problems) > 0 {
+		// Fatal errorex
+		fatal(New(ErrCodeUnresolvedReference, ErrorEXUnresolvedReferences{References: problems}))
+	}
+	// Hold every shard while the snapshot is taken, so no registration is lost
+	size := 0
+	for i := range registry {
+		registry[i].mutex.Lock()
+		size += len(registry[i].codes)
+	}
+	snapshot := make(map[string]errorCodeRegistry, size)
+	for i := range registry {
+		for code, codeRegistry := range registry[i].codes {
+			snapshot[code] = codeRegistry
+		}
+	}
+	frozenCodes.Store(&snapshot)
+	for i := range registry {
+		registry[i].mutex.Unlock()
+	}
+}
+
+// IsFrozen reports whether Freeze was called
+func IsFrozen() bool {
+	return frozenCodes.Load() != nil
+}
+
+// lookupCode returns the registry of a code
+func lookupCode(code string) (errorCodeRegistry, bool) {
+	if snapshot := frozenCodes.Load(); snapshot != nil {
+		codeRegistry, ok := (*snapshot)[code]
+		return codeRegistry, ok
+	}
+	shard := shardOf(code)
+	shard.mutex.RLock()
+	codeRegistry, ok := shard.codes[code]
+	shard.mutex.RUnlock()
+	return codeRegistry, ok
+}
+
+// registerCode adds a registry, panicking on repeats or when the registry is frozen
+func registerCode(codeRegistry errorCodeRegistry) {
+	registerEntry(codeRegistry.code, codeRegistry)
+}
+
+// registerEntry adds a registry under the given key, which is the code or one of its aliases
+func registerEntry(key string, codeRegistry errorCodeRegistry) {
+	shard := shardOf(key)
+	shard.mutex.Lock()
+	if frozenCodes.Load() != nil {
+		shard.mutex.Unlock()
+		// Fatal errorex
+		fatal(New(ErrCodeRegistryFrozen, ErrorEXDetail{Code: key}))
+	}
+	// Prevent repeats
+	if _, ok := shard.codes[key]; ok {
+		shard.mutex.Unlock()
+		// Fatal errorex
+		fatal(New(ErrCodeAlreadyRegistered, ErrorEXDetail{Code: key}))
+	}
+	if shard.codes == nil {
+		shard.codes = make(map[string]errorCodeRegistry)
+	}
+	shard.codes[key] = codeRegistry
+	registryGeneration.Add(1)
+	shard.mutex.Unlock()
+}
+
+// registerBatch adds the registries holding every shard, so either all of them are added or none is.
+// It returns the errorex of each code already registered, or ErrCodeRegistryFrozen.
+func registerBatch(registries []errorCodeRegistry) error {
+	if len(registries) == 0 {
+		return nil
+	}
+	frozen, repeated := addBatch(registries)
+	if frozen {
+		return New(ErrCodeRegistryFrozen, ErrorEXDetail{Code: registries[0].code})
+	}
+	var problems []error
+	for _, code := range repeated {
+		problems = append(problems, New(ErrCodeAlreadyRegistered, ErrorEXDetail{Code: code}))
+	}
+	return errors.Join(problems...)
+}
+
+// addBatch adds the registries unless the registry is frozen or one of their codes is already registered,
+// returning the repeated codes. No errorex is created while the shards are held, as New looks codes up.
+func addBatch(registries []errorCodeRegistry) (frozen bool, repeated []string) {
+	for i := range registry {
+		registry[i].mutex.Lock()
+	}
+	defer func() {
+		for i := range registry {
+			registry[i].mutex.Unlock()
+		}
+	}()
+	if frozenCodes.Load() != nil {
+		return true, nil
+	}
+	for _, codeRegistry := range registries {
+		if _, ok := shardOf(codeRegistry.code).codes[codeRegistry.code]; ok {
+			repeated = append(repeated, codeRegistry.code)
+		}
+	}
+	if len(repeated) > 0 {
+		return false, repeated
+	}
+	for _, codeRegistry := range registries {
+		shard := shardOf(codeRegistry.code)
+		if shard.codes == nil {
+			shard.codes = make(map[string]errorCodeRegistry)
+		}
+		shard.codes[codeRegistry.code] = codeRegistry
+	}
+	registryGeneration.Add(1)
+	return false, nil
+}
+
+// RegisterAlias registers alias as another name of a registered code, keeping wire compatibility when codes are
+// renamed: errors created with the alias get the canonical code, and Is matches the alias and the canonical code
+// interchangeably. It panics if the code is not registered, if the alias is already registered or if the registry
+// is frozen.
+func RegisterAlias(alias string, code string) {
+	target, ok := lookupCode(code)
+	if !ok {
+		// Fatal errorex
+		fatal(New(ErrCodeNotRegistered, ErrorEXDetail{Code: code}))
+	}
+	target.alias = alias
+	registerEntry(alias, target)
+	aliasMutex.Lock()
+	aliases[target.code] = append(aliases[target.code], alias)
+	sort.Strings(aliases[target.code])
+	aliasMutex.Unlock()
+}
+
+// aliasesOf returns a copy of the aliases of a canonical code
+func aliasesOf(code string) []string {
+	aliasMutex.RLock()
+	defer aliasMutex.RUnlock()
+	if len(aliases[code]) == 0 {
+		return nil
+	}
+	return append([]string(nil), aliases[code]...)
+}
+
+// rangeCodes calls fn for every registered code, in no particular order
+func rangeCodes(fn func(codeRegistry errorCodeRegistry)) {
+	if snapshot := frozenCodes.Load(); snapshot != nil {
+		for _, codeRegistry := range *snapshot {
+			fn(codeRegistry)
+		}
+		return
+	}
+	for i := range registry {
+		shard := &registry[i]
+		shard.mutex.RLock()
+		for _, codeRegistry := range shard.codes {
+			fn(codeRegistry)
+		}
+		shard.mutex.RUnlock()
+	}
+}
@@ -0,0 +1,186 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unfreezeRegistry reverts Freeze so that tests can keep registering codes
+func unfreezeRegistry() {
+	freezeMutex.Lock()
+	defer freezeMutex.Unlock()
+	frozenCodes.Store(nil)
+}
+
+func TestFreeze(t *testing.T) {
+	RegisterErrorCode("test.freeze", "test description", struct{ Message string }{})
+	Freeze()
+	defer unfreezeRegistry()
+
+	t.Run("should report the registry as frozen", func(t *testing.T) {
+		assert.True(t, IsFrozen())
+	})
+
+	t.Run("should panic when registering after freeze", func(t *testing.T) {
+		expectedMessage := New(ErrCodeRegistryFrozen, ErrorEXDetail{Code: "test.frozen"}).Error()
+		assert.PanicsWithError(t, expectedMessage, func() {
+			RegisterErrorCode("test.frozen", "test description", struct{ Message string }{})
+		})
+	})
+
+	t.Run("should keep creating and checking errors", func(t *testing.T) {
+		ex := New("test.freeze", struct{ Message string }{Message: "test detail"})
+		assert.True(t, Is(ex, "test.freeze"))
+		assert.Panics(t, func() {
+			New("test.frozen", struct{ Message string }{})
+		})
+	})
+
+	t.Run("should be idempotent", func(t *testing.T) {
+		assert.NotPanics(t, Freeze)
+		assert.True(t, IsFrozen())
+	})
+
+	t.Run("should be safe to read while freezing", func(t *testing.T) {
+		unfreezeRegistry()
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					assert.True(t, Is(New("test.freeze", struct{ Message string }{}), "test.freeze"))
+				}
+			}()
+		}
+		Freeze()
+		wg.Wait()
+	})
+}
+
+func TestRegistryShards(t *testing.T) {
+	t.Run("should keep codes sharing a prefix in the same shard", func(t *testing.T) {
+		assert.Same(t, shardOf("billing.declined"), shardOf("billing.expired"))
+		assert.Same(t, shardOf("billing"), shardOf("billing.expired"))
+	})
+
+	t.Run("should register codes concurrently", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(prefix int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					code := fmt.Sprintf("test.shard%d.code%d", prefix, j)
+					RegisterErrorCode(code, "test description", struct{ Message string }{})
+					assert.True(t, Is(New(code, struct{ Message string }{}), code))
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+}
+
+// registerCounter keeps codes unique across the runs of BenchmarkRegistryRegister
+var registerCounter atomic.Int64
+
+func BenchmarkRegistryRegister(b *testing.B) {
+	prefixes := make([]string, 16)
+	for i := range prefixes {
+		prefixes[i] = fmt.Sprintf("bench%d", i)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n := registerCounter.Add(1)
+			RegisterErrorCode(fmt.Sprintf("%s.code%d", prefixes[n%16], n), "bench description", struct{}{})
+		}
+	})
+}
+
+func BenchmarkRegistryLookup(b *testing.B) {
+	RegisterErrorCode("bench.lookup", "bench description", struct{ Message string }{})
+	detail := struct{ Message string }{Message: "bench detail"}
+
+	b.Run("mutex", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				Is(New("bench.lookup", detail), "bench.lookup")
+			}
+		})
+	})
+
+	b.Run("frozen", func(b *testing.B) {
+		Freeze()
+		defer unfreezeRegistry()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				Is(New("bench.lookup", detail), "bench.lookup")
+			}
+		})
+	})
+}
+
+func TestRegisterAlias(t *testing.T) {
+	RegisterErrorCode("test.alias.new", "test description", struct{ Message string }{})
+	RegisterAlias("test.alias.old", "test.alias.new")
+
+	t.Run("should create errors with the canonical code", func(t *testing.T) {
+		ex := New("test.alias.old", struct{ Message string }{})
+		assert.Equal(t, "test.alias.new", ex.Code())
+	})
+
+	t.Run("should match the alias and the canonical code interchangeably", func(t *testing.T) {
+		current := New("test.alias.new", struct{ Message string }{})
+		assert.True(t, Is(current, "test.alias.old"))
+		assert.True(t, Is(current, "test.alias.new"))
+		assert.True(t, Is(&ex{code: "test.alias.old"}, "test.alias.new"))
+	})
+
+	t.Run("should list the aliases of the code", func(t *testing.T) {
+		info, ok := Lookup("test.alias.old")
+		assert.True(t, ok)
+		assert.Equal(t, "test.alias.new", info.Code)
+		assert.Equal(t, []string{"test.alias.old"}, info.Aliases)
+
+		for _, info := range Catalog() {
+			assert.NotEqual(t, "test.alias.old", info.Code)
+		}
+	})
+
+	t.Run("should panic for unregistered codes and repeated aliases", func(t *testing.T) {
+		assert.Panics(t, func() {
+			RegisterAlias("test.alias.other", "unregistered.code")
+		})
+		assert.Panics(t, func() {
+			RegisterAlias("test.alias.old", "test.alias.new")
+		})
+	})
+}
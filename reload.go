@@ -0,0 +1,106 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import "sync/atomic"
+
+// ReloadableChain is a converter chain whose handlers can be swapped at runtime, e.g. when a configuration reload
+// adds a mapping, without restarting the service. Conversions in flight finish on the chain they started with.
+type ReloadableChain struct {
+	chain atomic.Pointer[chainHolder]
+	// next is the handler set with SetNext, reached through the tails of the chains
+	next atomic.Pointer[chainHolder]
+}
+
+// chainHolder holds the current chain, or the next handler, of a ReloadableChain
+type chainHolder struct {
+	converter ErrorConverter
+}
+
+// reloadTail is the last handler of the chains built by Reload, before the unknown error converter: it delegates to
+// the next handler of the ReloadableChain when one is set, then to its own next handler if that returns nil
+type reloadTail struct {
+	BaseErrorConverter
+	chain *ReloadableChain
+}
+
+func (t *reloadTail) ConvertError(err error) EX {
+	if next := t.chain.next.Load(); next != nil {
+		if converted := next.converter.ConvertError(err); converted != nil {
+			return converted
+		}
+	}
+	return t.BaseErrorConverter.ConvertError(err)
+}
+
+// NewReloadableChain creates a ReloadableChain of the handlers, built with BuildErrorConverterChain
+func NewReloadableChain(converters ...ErrorConverter) *ReloadableChain {
+	chain := &ReloadableChain{}
+	chain.Reload(converters...)
+	return chain
+}
+
+// Reload atomically replaces the handlers of the chain, built with BuildErrorConverterChain.
+// The handlers must be new instances: building a chain sets their next handler, which would race with the
+// conversions in flight on the previous chain.
+// The chain ends with a Tail, so the handler set with SetNext is kept.
+func (c *ReloadableChain) Reload(converters ...ErrorConverter) {
+	c.Swap(BuildErrorConverterChain(append(append([]ErrorConverter(nil), converters...), c.Tail())...))
+}
+
+// Tail returns a new handler delegating to the handler set with SetNext, to be the last handler of the chains given
+// to Swap, e.g.:
+//
+//	chain.Swap(errorex.WithMiddleware(errorex.BuildErrorConverterChain(handler, chain.Tail()), middleware))
+//
+// The errors the handler set with SetNext does not convert, or all of them when none is set, are delegated to its own
+// next handler.
+func (c *ReloadableChain) Tail() ErrorConverter {
+	return &reloadTail{chain: c}
+}
+
+// Swap atomically replaces the chain by another, e.g. one wrapped with WithMiddleware, returning the previous one.
+// The handler set with SetNext is only reached if the chain ends with a Tail.
+func (c *ReloadableChain) Swap(chain ErrorConverter) ErrorConverter {
+	previous := c.chain.Swap(&chainHolder{converter: chain})
+	if previous == nil {
+		return nil
+	}
+	return previous.converter
+}
+
+// ConvertError converts the error through the current chain
+func (c *ReloadableChain) ConvertError(err error) EX {
+	return c.chain.Load().converter.ConvertError(err)
+}
+
+// SetNext sets the handler converting the errors no handler of the chain converts, reached through the Tail of the
+// chains, so it is kept by Reload. The chains are not modified, it is safe with conversions in flight.
+func (c *ReloadableChain) SetNext(next ErrorConverter) {
+	if next == nil {
+		c.next.Store(nil)
+		return
+	}
+	c.next.Store(&chainHolder{converter: next})
+}
@@ -0,0 +1,114 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// reloadConverter converts the errors with its message into test.reload errors
+type reloadConverter struct {
+	BaseErrorConverter
+	message string
+}
+
+func (c *reloadConverter) ConvertError(err error) EX {
+	if err.Error() != c.message {
+		return c.BaseErrorConverter.ConvertError(err)
+	}
+	return New("test.reload", ErrorEXDetail{Code: c.message})
+}
+
+func TestReloadableChain(t *testing.T) {
+	RegisterErrorCode("test.reload", "test description", ErrorEXDetail{})
+
+	t.Run("should convert through the current handlers", func(t *testing.T) {
+		chain := NewReloadableChain()
+		assert.Equal(t, ErrCodeUnknownError, chain.ConvertError(errors.New("test error")).Code())
+
+		chain.Reload(&reloadConverter{message: "test error"})
+
+		assert.Equal(t, "test.reload", chain.ConvertError(errors.New("test error")).Code())
+		assert.Equal(t, ErrCodeUnknownError, chain.ConvertError(errors.New("other")).Code())
+	})
+
+	t.Run("should swap the chain", func(t *testing.T) {
+		chain := NewReloadableChain(&reloadConverter{message: "test error"})
+		var unknown []error
+		previous := chain.Swap(WithMiddleware(BuildErrorConverterChain(), OnUnknown(func(err error, ex EX) {
+			unknown = append(unknown, err)
+		})))
+
+		assert.Equal(t, "test.reload", previous.ConvertError(errors.New("test error")).Code())
+		assert.Equal(t, ErrCodeUnknownError, chain.ConvertError(errors.New("test error")).Code())
+		assert.Len(t, unknown, 1)
+	})
+
+	t.Run("should keep the next handler across reloads", func(t *testing.T) {
+		reloadable := NewReloadableChain(&reloadConverter{message: "first"})
+		chain := BuildErrorConverterChain(reloadable, &reloadConverter{message: "next"})
+
+		assert.Equal(t, "test.reload", chain.ConvertError(errors.New("next")).Code())
+		reloadable.Reload(&reloadConverter{message: "second"})
+
+		assert.Equal(t, "test.reload", chain.ConvertError(errors.New("second")).Code())
+		assert.Equal(t, "test.reload", chain.ConvertError(errors.New("next")).Code())
+		assert.Equal(t, ErrCodeUnknownError, chain.ConvertError(errors.New("first")).Code())
+		assert.Equal(t, ErrCodeUnknownError, chain.ConvertError(errors.New("other")).Code())
+	})
+
+	t.Run("should reach the next handler through the tail of swapped chains", func(t *testing.T) {
+		reloadable := NewReloadableChain()
+		reloadable.SetNext(&reloadConverter{message: "next"})
+
+		reloadable.Swap(WithMiddleware(BuildErrorConverterChain(&reloadConverter{message: "swapped"}, reloadable.Tail())))
+
+		assert.Equal(t, "test.reload", reloadable.ConvertError(errors.New("swapped")).Code())
+		assert.Equal(t, "test.reload", reloadable.ConvertError(errors.New("next")).Code())
+		assert.Equal(t, ErrCodeUnknownError, reloadable.ConvertError(errors.New("other")).Code())
+	})
+
+	t.Run("should reload while converting", func(t *testing.T) {
+		chain := NewReloadableChain()
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 100 {
+					code := chain.ConvertError(errors.New("test error")).Code()
+					assert.Contains(t, []string{ErrCodeUnknownError, "test.reload"}, code)
+				}
+			}()
+		}
+		for range 100 {
+			chain.Reload(&reloadConverter{message: "test error"})
+		}
+		wg.Wait()
+	})
+}
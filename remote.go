@@ -0,0 +1,61 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+const (
+	// MetadataRemoteCode is the metadata key holding the code of the remote errorex an errorex was caused by, set
+	// by CausedByRemote
+	MetadataRemoteCode = "remote_code"
+	// MetadataRemoteID is the metadata key holding the instance ID of the remote errorex an errorex was caused by,
+	// set by CausedByRemote when the remote errorex has one
+	MetadataRemoteID = "remote_id"
+)
+
+// CausedByRemote returns a new errorex, like New, caused by an errorex received from an upstream service, e.g. with
+// httpex.FromResponse. Instead of nesting the remote payload as its cause, the code and the instance ID of the
+// remote errorex are linked in the metadata, under MetadataRemoteCode and MetadataRemoteID, so they correlate with
+// the logs of the upstream service without exposing its details. A nil remote is not linked.
+func CausedByRemote[T any](code string, detail T, remote EX, options ...Option) EX {
+	code, internal := checkDetail(code, detail)
+	if internal != nil {
+		return internal
+	}
+	e := newEX(code, detail, 1)
+	applyOptions(e, options)
+	if remote != nil {
+		WithMetadata(MetadataRemoteCode, remote.Code())(e)
+		if id, ok := InstanceID(remote); ok {
+			WithMetadata(MetadataRemoteID, id)(e)
+		}
+	}
+	return e
+}
+
+// RemoteCause returns the code and the instance ID of the remote errorex linked by CausedByRemote to the first
+// error in the chain of err carrying metadata. The ID is empty when the remote errorex had none.
+func RemoteCause(err error) (code string, id string, ok bool) {
+	metadata, _ := Metadata(err)
+	code, ok = metadata[MetadataRemoteCode]
+	return code, metadata[MetadataRemoteID], ok
+}
@@ -0,0 +1,68 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCausedByRemote(t *testing.T) {
+	RegisterErrorCode("test.remote.upstream", "test description", ErrorEXDetail{})
+	RegisterErrorCode("test.remote.local", "test description", ErrorEXDetail{})
+
+	t.Run("should link the code and the ID of the remote errorex", func(t *testing.T) {
+		SetInstanceConfig(InstanceConfig{IDs: true})
+		defer SetInstanceConfig(InstanceConfig{})
+		remote, err := ParseJSON([]byte(New("test.remote.upstream", ErrorEXDetail{Code: "secret"}).Error()))
+		assert.NoError(t, err)
+		remoteID, _ := InstanceID(remote)
+
+		local := CausedByRemote("test.remote.local", ErrorEXDetail{Code: "order"}, remote, WithTenant("acme"))
+
+		code, id, ok := RemoteCause(fmt.Errorf("placing order: %w", local))
+		assert.True(t, ok)
+		assert.Equal(t, "test.remote.upstream", code)
+		assert.Equal(t, remoteID, id)
+		assert.Equal(t, "acme", Tenant(local))
+		assert.Nil(t, errors.Unwrap(local))
+		assert.NotContains(t, local.Error(), "secret")
+	})
+
+	t.Run("should link remote errorex errors without ID", func(t *testing.T) {
+		local := CausedByRemote("test.remote.local", ErrorEXDetail{}, New("test.remote.upstream", ErrorEXDetail{}))
+		code, id, ok := RemoteCause(local)
+		assert.True(t, ok)
+		assert.Equal(t, "test.remote.upstream", code)
+		assert.Empty(t, id)
+	})
+
+	t.Run("should not link a nil remote", func(t *testing.T) {
+		_, _, ok := RemoteCause(CausedByRemote("test.remote.local", ErrorEXDetail{}, nil))
+		assert.False(t, ok)
+	})
+}
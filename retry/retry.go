@@ -0,0 +1,229 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package retry retries operations failing with errorex errors, selecting the retry policy by the code of the
+// returned error:
+//
+//	err := retry.Do(ctx, func(ctx context.Context) error {
+//		return client.Charge(ctx, card)
+//	}, retry.Policy{
+//		MaxAttempts: 5,
+//		Codes: map[string]retry.Policy{
+//			payments.ErrCodeRateLimited: {MaxAttempts: 10, InitialBackoff: time.Second},
+//		},
+//	})
+//
+// Errors whose code was registered with errorex.WithRetryable, or has a policy in Policy.Codes, are retried.
+// A hint set with errorex.WithRetryAfter replaces the backoff of the policy, capped at its MaxBackoff.
+// When the operation fails after more than one attempt the returned error is an ErrCodeFailed errorex holding
+// the history of the attempts.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// ErrCodeFailed is the errorex code returned when a retried operation failed
+	ErrCodeFailed = "errorex.retry.failed"
+
+	// DefaultMaxAttempts is the number of attempts when Policy.MaxAttempts is not set
+	DefaultMaxAttempts = 3
+	// DefaultInitialBackoff is the delay before the first retry when Policy.InitialBackoff is not set
+	DefaultInitialBackoff = 100 * time.Millisecond
+	// DefaultMaxBackoff is the maximum delay between attempts when Policy.MaxBackoff is not set
+	DefaultMaxBackoff = 10 * time.Second
+	// DefaultMultiplier is the growth factor of the delays when Policy.Multiplier is not set
+	DefaultMultiplier = 2
+)
+
+// Reasons of the failure of a retried operation
+const (
+	ReasonMaxAttempts  = "max_attempts"
+	ReasonNotRetryable = "not_retryable"
+	ReasonCanceled     = "canceled"
+)
+
+// Policy configures how an operation is retried
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts
+	MaxBackoff time.Duration
+	// Multiplier is the growth factor of the delay after each retry
+	Multiplier float64
+	// Jitter is the fraction, between 0 and 1, of each delay that is randomized to spread retries
+	Jitter float64
+	// Codes overrides the policy for errors with these codes, which are retried even if not registered as
+	// retryable, a MaxAttempts of 1 disables their retries. The policy of the code of the error is selected, else
+	// the one of its nearest ancestor (see errorex.WithParent). Unset fields are not inherited from the parent policy.
+	Codes map[string]Policy
+}
+
+// Attempt records a failed attempt
+type Attempt struct {
+	// Code is the errorex code of the error, empty for other errors
+	Code string `json:"code,omitempty"`
+	// Error is the message of the error
+	Error string `json:"error"`
+	// Backoff is the delay waited after the attempt, zero for the last one
+	Backoff time.Duration `json:"backoff"`
+}
+
+// FailedDetail is the detail of ErrCodeFailed
+type FailedDetail struct {
+	// Reason is one of ReasonMaxAttempts, ReasonNotRetryable or ReasonCanceled
+	Reason   string    `json:"reason"`
+	Attempts []Attempt `json:"attempts"`
+}
+
+func init() {
+	// Register the errorex codes
+	errorex.RegisterErrorCode(ErrCodeFailed, "Retried operation failed", FailedDetail{})
+}
+
+// Do calls fn until it succeeds, fails with an error that is not retried, exhausts the attempts of the policy
+// selected by the code of its error or the context is done.
+// The error of fn is returned as is when it failed on the first attempt, otherwise the returned error is an
+// ErrCodeFailed errorex with the history of the attempts.
+func Do(ctx context.Context, fn func(ctx context.Context) error, policy Policy) error {
+	var attempts []Attempt
+	codePolicy := policy.codePolicies()
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		record := Attempt{Error: err.Error()}
+		var ex errorex.EX
+		if errors.As(err, &ex) {
+			record.Code = ex.Code()
+		}
+		selected, retried := policy.selectFor(ex, codePolicy)
+		reason := ""
+		switch {
+		case !retried:
+			reason = ReasonNotRetryable
+		case attempt >= selected.maxAttempts():
+			reason = ReasonMaxAttempts
+		}
+		if reason != "" {
+			if attempt == 1 {
+				return err
+			}
+			return failed(reason, append(attempts, record))
+		}
+		record.Backoff = selected.backoff(attempt)
+		if hint, ok := errorex.RetryAfter(err); ok {
+			record.Backoff = min(hint, selected.maxBackoff())
+		}
+		attempts = append(attempts, record)
+		timer := time.NewTimer(record.Backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return failed(ReasonCanceled, attempts)
+		case <-timer.C:
+		}
+	}
+}
+
+// History returns the attempts of a retried operation, if err is an ErrCodeFailed errorex
+func History(err error) ([]Attempt, bool) {
+	var ex errorex.EX
+	if !errors.As(err, &ex) || ex.Code() != ErrCodeFailed {
+		return nil, false
+	}
+	detail, ok := ex.Detail().(FailedDetail)
+	return detail.Attempts, ok
+}
+
+// failed creates the ErrCodeFailed errorex
+func failed(reason string, attempts []Attempt) error {
+	return errorex.New(ErrCodeFailed, FailedDetail{Reason: reason, Attempts: attempts})
+}
+
+// codePolicies returns the function selecting the policy of the code of an error, see Codes, nil when there is none
+func (p Policy) codePolicies() func(error) *Policy {
+	if len(p.Codes) == 0 {
+		return nil
+	}
+	policies := make(map[string]*Policy, len(p.Codes))
+	for code := range p.Codes {
+		codePolicy := p.Codes[code]
+		policies[code] = &codePolicy
+	}
+	return errorex.MapCodes(policies, nil)
+}
+
+// selectFor returns the policy for the errorex, selected by codePolicy (see codePolicies), nil for other errors,
+// and whether it is retried at all
+func (p Policy) selectFor(ex errorex.EX, codePolicy func(error) *Policy) (Policy, bool) {
+	if ex == nil {
+		return p, false
+	}
+	if codePolicy != nil {
+		if selected := codePolicy(ex); selected != nil {
+			return *selected, true
+		}
+	}
+	return p, errorex.IsRetryable(ex)
+}
+
+func (p Policy) maxAttempts() int {
+	if p.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return p.MaxAttempts
+}
+
+func (p Policy) maxBackoff() time.Duration {
+	if p.MaxBackoff <= 0 {
+		return DefaultMaxBackoff
+	}
+	return p.MaxBackoff
+}
+
+// backoff returns the delay after the attempt, starting at 1
+func (p Policy) backoff(attempt int) time.Duration {
+	initial, maxBackoff, multiplier := p.InitialBackoff, p.maxBackoff(), p.Multiplier
+	if initial <= 0 {
+		initial = DefaultInitialBackoff
+	}
+	if multiplier < 1 {
+		multiplier = DefaultMultiplier
+	}
+	delay := math.Min(float64(initial)*math.Pow(multiplier, float64(attempt-1)), float64(maxBackoff))
+	if jitter := math.Min(math.Max(p.Jitter, 0), 1); jitter > 0 {
+		delay -= delay * jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,197 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	errorex.RegisterErrorCode("retry.unavailable", "Unavailable", errorex.ErrorEXDetail{}, errorex.WithRetryable())
+	errorex.RegisterErrorCode("retry.limited", "Rate limited", errorex.ErrorEXDetail{})
+	errorex.RegisterErrorCode("retry.invalid", "Invalid", errorex.ErrorEXDetail{})
+	errorex.RegisterErrorCode("retry.limited.burst", "Burst limited", errorex.ErrorEXDetail{}, errorex.WithParent("retry.limited"))
+	errorex.RegisterErrorCode("retry.limited.burst.user", "User burst limited", errorex.ErrorEXDetail{}, errorex.WithParent("retry.limited.burst"))
+}
+
+// failing returns an operation failing with the errors in order, then succeeding
+func failing(calls *int, errs ...error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		*calls++
+		if *calls <= len(errs) {
+			return errs[*calls-1]
+		}
+		return nil
+	}
+}
+
+func TestDo(t *testing.T) {
+	fast := Policy{InitialBackoff: time.Millisecond}
+	unavailable := errorex.New("retry.unavailable", errorex.ErrorEXDetail{})
+
+	t.Run("should retry retryable errors until success", func(t *testing.T) {
+		calls := 0
+		err := Do(context.Background(), failing(&calls, unavailable, fmt.Errorf("dial: %w", unavailable)), fast)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("should return the error of a single attempt as is", func(t *testing.T) {
+		calls := 0
+		invalid := errorex.New("retry.invalid", errorex.ErrorEXDetail{})
+		assert.Same(t, invalid, Do(context.Background(), failing(&calls, invalid), fast))
+
+		calls = 0
+		other := errors.New("other")
+		assert.Same(t, other, Do(context.Background(), failing(&calls, other), fast))
+	})
+
+	t.Run("should aggregate the attempts when exhausted", func(t *testing.T) {
+		calls := 0
+		err := Do(context.Background(), failing(&calls, unavailable, unavailable, unavailable, unavailable), fast)
+
+		assert.Equal(t, 3, calls)
+		assert.True(t, errorex.Is(err, ErrCodeFailed))
+		detail := err.(errorex.EX).Detail().(FailedDetail)
+		assert.Equal(t, ReasonMaxAttempts, detail.Reason)
+		assert.Len(t, detail.Attempts, 3)
+		assert.Equal(t, "retry.unavailable", detail.Attempts[0].Code)
+		assert.Equal(t, time.Millisecond, detail.Attempts[0].Backoff)
+		assert.Equal(t, 2*time.Millisecond, detail.Attempts[1].Backoff)
+		assert.Zero(t, detail.Attempts[2].Backoff)
+		history, ok := History(err)
+		assert.True(t, ok)
+		assert.Equal(t, detail.Attempts, history)
+	})
+
+	t.Run("should stop on errors that are not retried", func(t *testing.T) {
+		calls := 0
+		err := Do(context.Background(), failing(&calls, unavailable, errors.New("other")), fast)
+
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, ReasonNotRetryable, err.(errorex.EX).Detail().(FailedDetail).Reason)
+	})
+
+	t.Run("should select the policy by code", func(t *testing.T) {
+		policy := Policy{
+			InitialBackoff: time.Millisecond,
+			Codes: map[string]Policy{
+				"retry.limited":     {MaxAttempts: 5, InitialBackoff: time.Millisecond},
+				"retry.unavailable": {MaxAttempts: 1},
+			},
+		}
+		limited := errorex.New("retry.limited", errorex.ErrorEXDetail{})
+
+		calls := 0
+		assert.NoError(t, Do(context.Background(), failing(&calls, limited, limited, limited, limited), policy))
+		assert.Equal(t, 5, calls)
+
+		calls = 0
+		assert.Same(t, unavailable, Do(context.Background(), failing(&calls, unavailable), policy))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should select the policy of the nearest code", func(t *testing.T) {
+		policy := Policy{
+			Codes: map[string]Policy{
+				"retry.limited":       {MaxAttempts: 2, InitialBackoff: time.Millisecond},
+				"retry.limited.burst": {MaxAttempts: 4, InitialBackoff: time.Millisecond},
+			},
+		}
+		for range 10 {
+			for code, expected := range map[string]int{"retry.limited.burst": 4, "retry.limited.burst.user": 4, "retry.limited": 2} {
+				calls := 0
+				burst := errorex.New(code, errorex.ErrorEXDetail{})
+				Do(context.Background(), failing(&calls, burst, burst, burst, burst, burst), policy)
+				assert.Equal(t, expected, calls, code)
+			}
+		}
+	})
+
+	t.Run("should honor the retry hints", func(t *testing.T) {
+		calls := 0
+		hinted := errorex.New("retry.unavailable", errorex.ErrorEXDetail{}, errorex.WithRetryAfter(5*time.Millisecond))
+
+		err := Do(context.Background(), failing(&calls, hinted, hinted), Policy{MaxAttempts: 2, InitialBackoff: time.Hour})
+
+		history, _ := History(err)
+		assert.Equal(t, 5*time.Millisecond, history[0].Backoff)
+	})
+
+	t.Run("should cap the retry hints at the maximum backoff", func(t *testing.T) {
+		calls := 0
+		hinted := errorex.New("retry.unavailable", errorex.ErrorEXDetail{}, errorex.WithRetryAfter(time.Hour))
+
+		err := Do(context.Background(), failing(&calls, hinted, hinted), Policy{MaxAttempts: 2, MaxBackoff: time.Millisecond})
+
+		history, _ := History(err)
+		assert.Equal(t, time.Millisecond, history[0].Backoff)
+	})
+
+	t.Run("should stop when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		fn := func(ctx context.Context) error {
+			calls++
+			cancel()
+			return unavailable
+		}
+
+		err := Do(ctx, fn, Policy{InitialBackoff: time.Hour})
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, ReasonCanceled, err.(errorex.EX).Detail().(FailedDetail).Reason)
+	})
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	t.Run("should grow up to the maximum", func(t *testing.T) {
+		policy := Policy{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second}
+		assert.Equal(t, time.Second, policy.backoff(1))
+		assert.Equal(t, 2*time.Second, policy.backoff(2))
+		assert.Equal(t, 3*time.Second, policy.backoff(3))
+	})
+
+	t.Run("should randomize with jitter", func(t *testing.T) {
+		policy := Policy{InitialBackoff: time.Second, Jitter: 0.5}
+		for i := 0; i < 100; i++ {
+			delay := policy.backoff(1)
+			assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+			assert.LessOrEqual(t, delay, time.Second)
+		}
+	})
+
+	t.Run("should use the defaults", func(t *testing.T) {
+		assert.Equal(t, DefaultInitialBackoff, Policy{}.backoff(1))
+		assert.Equal(t, DefaultMaxAttempts, Policy{}.maxAttempts())
+	})
+}
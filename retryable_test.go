@@ -0,0 +1,56 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	RegisterErrorCode("retryable.timeout", "Timeout", ErrorEXDetail{}, WithRetryable())
+	RegisterErrorCode("retryable.invalid", "Invalid", ErrorEXDetail{})
+
+	t.Run("should report the retryable codes", func(t *testing.T) {
+		assert.True(t, IsRetryable(New("retryable.timeout", ErrorEXDetail{})))
+		assert.False(t, IsRetryable(New("retryable.invalid", ErrorEXDetail{})))
+	})
+
+	t.Run("should find the errorex in the chain", func(t *testing.T) {
+		assert.True(t, IsRetryable(fmt.Errorf("calling: %w", New("retryable.timeout", ErrorEXDetail{}))))
+	})
+
+	t.Run("should not retry other errors", func(t *testing.T) {
+		assert.False(t, IsRetryable(errors.New("other")))
+		assert.False(t, IsRetryable(nil))
+	})
+
+	t.Run("should list the flag in the catalog", func(t *testing.T) {
+		info, _ := Lookup("retryable.timeout")
+		assert.True(t, info.Retryable)
+	})
+}
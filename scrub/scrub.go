@@ -0,0 +1,328 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package scrub removes secrets from the details, metadata and causes of errorex errors before they are serialized
+// to external sinks (logs, reporters, responses). Rules run against every string of a detail, whatever its struct
+// tags, as a defense in depth against accidental leaks. Setting the scrubber as the sink filter of errorex scrubs
+// the errors written by httpex, grpcex, lro, jsonapi and webhook:
+//
+//	scrubber := scrub.New()
+//	errorex.SetSinkFilter(scrubber.Filter)
+//
+// It can also be applied explicitly, e.g. reporter.Report(scrubber.Scrub(ex)).
+//
+// The default rules detect JWTs, credit card numbers and well known API key formats, more rules can be registered.
+package scrub
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// Rule detects a kind of secret
+type Rule struct {
+	// Name identifies the rule in the replacement, e.g. [REDACTED:jwt]
+	Name string
+	// Pattern matches the candidate secrets
+	Pattern *regexp.Regexp
+	// Validate filters the matches of the pattern, all matches are secrets when nil
+	Validate func(match string) bool
+}
+
+// Default rules
+var (
+	// JWT detects JSON Web Tokens
+	JWT = Rule{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`eyJ[A-Za-z0-9_-]{4,}\.eyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]*`),
+	}
+	// CreditCard detects payment card numbers, validated with the Luhn checksum
+	CreditCard = Rule{
+		Name:     "credit_card",
+		Pattern:  regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Validate: luhn,
+	}
+	// APIKey detects the API keys of AWS, Stripe, GitHub, Slack and Google
+	APIKey = Rule{
+		Name: "api_key",
+		Pattern: regexp.MustCompile(`\b(?:(?:AKIA|ASIA)[0-9A-Z]{16}|(?:sk|pk|rk)_(?:live|test)_[0-9A-Za-z]{16,}|` +
+			`gh[pousr]_[0-9A-Za-z]{36,}|xox[abprs]-[0-9A-Za-z-]{10,}|AIza[0-9A-Za-z_-]{35})\b`),
+	}
+
+	// DefaultRules are the rules used by New when none is given
+	DefaultRules = []Rule{JWT, CreditCard, APIKey}
+)
+
+// Scrubber replaces the secrets found by its rules in the details of errorex errors
+type Scrubber struct {
+	mutex sync.RWMutex
+	rules []Rule
+}
+
+// New creates a Scrubber with the rules, DefaultRules when none is given
+func New(rules ...Rule) *Scrubber {
+	if len(rules) == 0 {
+		rules = DefaultRules
+	}
+	return &Scrubber{rules: append([]Rule(nil), rules...)}
+}
+
+// Register adds a rule to the scrubber
+func (s *Scrubber) Register(rule Rule) {
+	s.mutex.Lock()
+	s.rules = append(s.rules, rule)
+	s.mutex.Unlock()
+}
+
+// Scrub returns a copy of the errorex whose detail (see errorex.WithDetail), metadata values and causes have the
+// secrets replaced. Errorex causes are scrubbed the same way, other causes are replaced by their errorex.CauseInfo
+// with the message and fields scrubbed. Causes past errorex.GetMaxCauseDepth are kept, serialization summarizes
+// them without their messages. The errorex is returned as is when it has no secrets.
+func (s *Scrubber) Scrub(ex errorex.EX) errorex.EX {
+	scrubbed, _ := s.scrub(ex, errorex.GetMaxCauseDepth())
+	return scrubbed
+}
+
+// Filter scrubs the errorex for every sink, it is meant to be set with errorex.SetSinkFilter
+func (s *Scrubber) Filter(ex errorex.EX, _ errorex.Sink) errorex.EX {
+	return s.Scrub(ex)
+}
+
+// scrub scrubs the errorex and up to depth of its causes, and tells whether anything changed
+func (s *Scrubber) scrub(ex errorex.EX, depth int) (errorex.EX, bool) {
+	if ex == nil {
+		return ex, false
+	}
+	scrubbed, changed := ex, false
+	if _, encrypted := ex.Detail().(errorex.EncryptedDetail); ex.Detail() != nil && !encrypted {
+		if detail, detailChanged := s.value(reflect.ValueOf(ex.Detail()), make(map[visit]bool)); detailChanged {
+			scrubbed, changed = errorex.WithDetail(ex, detail.Interface()), true
+		}
+	}
+	var options []errorex.Option
+	if carrier, ok := ex.(errorex.MetadataCarrier); ok {
+		for key, value := range carrier.Metadata() {
+			if clean := s.ScrubString(value); clean != value {
+				options = append(options, errorex.WithMetadata(key, clean))
+			}
+		}
+	}
+	if cause, causeChanged := s.cause(errors.Unwrap(ex), depth); causeChanged {
+		options = append(options, errorex.WithCause(cause))
+	}
+	if len(options) == 0 {
+		return scrubbed, changed
+	}
+	return errorex.Annotate(scrubbed, options...), true
+}
+
+// cause returns a copy of the cause with its secrets replaced, and whether anything changed
+func (s *Scrubber) cause(cause error, depth int) (error, bool) {
+	if cause == nil || depth <= 0 {
+		return cause, false
+	}
+	if ex, ok := cause.(errorex.EX); ok {
+		return s.scrub(ex, depth-1)
+	}
+	info := errorex.DescribeCause(cause)
+	message := s.ScrubString(info.Message)
+	changed := message != info.Message
+	info.Message = message
+	fields := make(map[string]any, len(info.Fields))
+	for name, value := range info.Fields {
+		if text, ok := value.(string); ok {
+			clean := s.ScrubString(text)
+			changed = changed || clean != text
+			value = clean
+		}
+		fields[name] = value
+	}
+	if !changed {
+		return cause, false
+	}
+	if len(fields) > 0 {
+		info.Fields = fields
+	}
+	return &info, true
+}
+
+// ScrubString replaces the secrets of a string by [REDACTED:<rule name>]
+func (s *Scrubber) ScrubString(text string) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, rule := range s.rules {
+		text = rule.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.Validate != nil && !rule.Validate(match) {
+				return match
+			}
+			return "[REDACTED:" + rule.Name + "]"
+		})
+	}
+	return text
+}
+
+// visit identifies a pointer, map or slice being scrubbed
+type visit struct {
+	pointer uintptr
+	typ     reflect.Type
+}
+
+// value returns a copy of v with its strings scrubbed, and whether anything changed.
+// The original value is never modified, v itself is returned when nothing changed. Visiting holds the pointers,
+// maps and slices being scrubbed, the references back to them are kept as they are so cycles are not followed.
+func (s *Scrubber) value(v reflect.Value, visiting map[visit]bool) (reflect.Value, bool) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if v.IsNil() || (v.Kind() == reflect.Slice && v.Len() == 0) {
+			break
+		}
+		key := visit{pointer: v.Pointer(), typ: v.Type()}
+		if visiting[key] {
+			return v, false
+		}
+		visiting[key] = true
+		defer delete(visiting, key)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		scrubbed := s.ScrubString(v.String())
+		if scrubbed == v.String() {
+			return v, false
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.SetString(scrubbed)
+		return copied, true
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v, false
+		}
+		elem, changed := s.value(v.Elem(), visiting)
+		if !changed {
+			return v, false
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(elem)
+		return copied, true
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		elem, changed := s.value(v.Elem(), visiting)
+		if !changed {
+			return v, false
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(elem)
+		return copied, true
+	case reflect.Struct:
+		var copied reflect.Value
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			field, changed := s.value(v.Field(i), visiting)
+			if !changed {
+				continue
+			}
+			if !copied.IsValid() {
+				copied = reflect.New(v.Type()).Elem()
+				copied.Set(v)
+			}
+			copied.Field(i).Set(field)
+		}
+		if !copied.IsValid() {
+			return v, false
+		}
+		return copied, true
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v, false
+		}
+		var copied reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			elem, changed := s.value(v.Index(i), visiting)
+			if !changed {
+				continue
+			}
+			if !copied.IsValid() {
+				if v.Kind() == reflect.Slice {
+					copied = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+					reflect.Copy(copied, v)
+				} else {
+					copied = reflect.New(v.Type()).Elem()
+					copied.Set(v)
+				}
+			}
+			copied.Index(i).Set(elem)
+		}
+		if !copied.IsValid() {
+			return v, false
+		}
+		return copied, true
+	case reflect.Map:
+		if v.IsNil() {
+			return v, false
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		changed := false
+		iterator := v.MapRange()
+		for iterator.Next() {
+			key, keyChanged := iterator.Key(), false
+			if key.Kind() == reflect.String {
+				key, keyChanged = s.value(key, visiting)
+			}
+			elem, elemChanged := s.value(iterator.Value(), visiting)
+			changed = changed || keyChanged || elemChanged
+			copied.SetMapIndex(key, elem)
+		}
+		if !changed {
+			return v, false
+		}
+		return copied, true
+	}
+	return v, false
+}
+
+// luhn validates the checksum of a card number, ignoring spaces and dashes
+func luhn(number string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(number)
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,107 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+)
+
+// sentinel is a sentinel error with the code registered for it
+type sentinel struct {
+	err    error
+	code   string
+	detail any
+}
+
+// sentinelErrorConverter converts sentinel errors registered with RegisterSentinels
+type sentinelErrorConverter struct {
+	BaseErrorConverter
+	sentinels []sentinel
+	byError   map[error]int
+}
+
+// RegisterSentinels registers a code for each package-level sentinel error, e.g. io.EOF or sql.ErrNoRows, and
+// returns the converter turning errors matching them, wrapped or not, into errorex errors of their code, caused by
+// the converted error and with the Detail of the Registration as detail. It speeds up the migration of packages
+// declaring their errors as variables.
+// The codes are registered with RegisterAll, atomically, the returned error is the one of RegisterAll and the
+// converter is nil when it fails.
+// An error wrapping several sentinels converts to the outermost one.
+func RegisterSentinels(sentinels map[error]Registration) (ErrorConverter, error) {
+	converter := &sentinelErrorConverter{byError: make(map[error]int, len(sentinels))}
+	for err, registration := range sentinels {
+		converter.sentinels = append(converter.sentinels, sentinel{err: err, code: registration.Code, detail: registration.Detail})
+	}
+	sort.Slice(converter.sentinels, func(i, j int) bool {
+		return converter.sentinels[i].code < converter.sentinels[j].code
+	})
+	registrations := make([]Registration, 0, len(converter.sentinels))
+	for i, sentinel := range converter.sentinels {
+		converter.byError[sentinel.err] = i
+		registrations = append(registrations, sentinels[sentinel.err])
+	}
+	if err := RegisterAll(registrations); err != nil {
+		return nil, err
+	}
+	return converter, nil
+}
+
+func (c *sentinelErrorConverter) ConvertError(err error) EX {
+	if sentinel, ok := c.match(err); ok {
+		return New(sentinel.code, sentinel.detail, WithCause(err))
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
+
+// match returns the sentinel of the outermost error of the chain that is a sentinel, falling back to errors.Is,
+// in code order, for errors matching sentinels through an Is method
+func (c *sentinelErrorConverter) match(err error) (sentinel, bool) {
+	pending := []error{err}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		if current == nil {
+			continue
+		}
+		if reflect.TypeOf(current).Comparable() {
+			if i, ok := c.byError[current]; ok {
+				return c.sentinels[i], true
+			}
+		}
+		switch unwrapped := current.(type) {
+		case interface{ Unwrap() error }:
+			pending = append(pending, unwrapped.Unwrap())
+		case interface{ Unwrap() []error }:
+			pending = append(pending, unwrapped.Unwrap()...)
+		}
+	}
+	for _, sentinel := range c.sentinels {
+		if errors.Is(err, sentinel.err) {
+			return sentinel, true
+		}
+	}
+	return sentinel{}, false
+}
@@ -0,0 +1,95 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errLegacyMissing  = errors.New("legacy: missing")
+	errLegacyConflict = errors.New("legacy: conflict")
+)
+
+// legacyError matches errLegacyConflict through an Is method
+type legacyError struct{}
+
+func (legacyError) Error() string { return "legacy: conflicting version" }
+
+func (legacyError) Is(target error) bool { return target == errLegacyConflict }
+
+func TestRegisterSentinels(t *testing.T) {
+	converter, err := RegisterSentinels(map[error]Registration{
+		errLegacyMissing:  {Code: "sentinel.missing", Description: "Missing", Detail: ErrorEXDetail{Code: "missing"}},
+		errLegacyConflict: {Code: "sentinel.conflict", Description: "Conflict", Options: []RegistrationOption{WithHTTPStatus(409)}},
+	})
+	assert.NoError(t, err)
+	chain := BuildErrorConverterChain(converter)
+
+	t.Run("should register the codes", func(t *testing.T) {
+		info, ok := Lookup("sentinel.conflict")
+		assert.True(t, ok)
+		assert.Equal(t, 409, info.HTTPStatus)
+	})
+
+	t.Run("should convert wrapped sentinels", func(t *testing.T) {
+		wrapped := fmt.Errorf("loading user: %w", errLegacyMissing)
+		converted := chain.ConvertError(wrapped)
+		assert.Equal(t, "sentinel.missing", converted.Code())
+		assert.Equal(t, ErrorEXDetail{Code: "missing"}, converted.Detail())
+		assert.ErrorIs(t, converted, errLegacyMissing)
+	})
+
+	t.Run("should convert to the outermost sentinel", func(t *testing.T) {
+		wrapped := fmt.Errorf("%w: %w", errLegacyConflict, fmt.Errorf("retry: %w", errLegacyMissing))
+		assert.Equal(t, "sentinel.conflict", chain.ConvertError(wrapped).Code())
+		joined := errors.Join(fmt.Errorf("first: %w", errLegacyMissing), errLegacyConflict)
+		assert.Equal(t, "sentinel.conflict", converter.ConvertError(joined).Code())
+	})
+
+	t.Run("should convert errors matching through an Is method", func(t *testing.T) {
+		assert.Equal(t, "sentinel.conflict", chain.ConvertError(legacyError{}).Code())
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		assert.Equal(t, ErrCodeUnknownError, chain.ConvertError(errors.New("other")).Code())
+	})
+
+	t.Run("should register nothing when a code fails", func(t *testing.T) {
+		converter, err := RegisterSentinels(map[error]Registration{
+			errors.New("new"):       {Code: "sentinel.new", Description: "New"},
+			errors.New("duplicate"): {Code: "sentinel.missing", Description: "Duplicate"},
+		})
+		assert.Nil(t, converter)
+		problems := err.(interface{ Unwrap() []error }).Unwrap()
+		assert.Len(t, problems, 1)
+		assert.Equal(t, ErrCodeAlreadyRegistered, problems[0].(EX).Code())
+		_, ok := Lookup("sentinel.new")
+		assert.False(t, ok)
+	})
+}
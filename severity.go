@@ -0,0 +1,116 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Severity classifies how serious the errors of a code are
+type Severity int
+
+const (
+	// SeverityDebug is for errors only relevant when debugging
+	SeverityDebug Severity = iota + 1
+	// SeverityInfo is for expected errors, such as validation failures
+	SeverityInfo
+	// SeverityWarning is for errors worth looking at, but not requiring action
+	SeverityWarning
+	// SeverityError is for errors requiring action, the default severity
+	SeverityError
+	// SeverityCritical is for errors requiring immediate action
+	SeverityCritical
+)
+
+var severityNames = map[Severity]string{
+	SeverityDebug:    "debug",
+	SeverityInfo:     "info",
+	SeverityWarning:  "warning",
+	SeverityError:    "error",
+	SeverityCritical: "critical",
+}
+
+// String returns the name of the severity
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// MarshalText encodes the severity as its name
+func (s Severity) MarshalText() ([]byte, error) {
+	if _, ok := severityNames[s]; !ok {
+		return nil, fmt.Errorf("invalid severity %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name
+func (s *Severity) UnmarshalText(text []byte) error {
+	for severity, name := range severityNames {
+		if name == string(text) {
+			*s = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid severity %q", text)
+}
+
+// WithSeverity sets the severity of the code
+func WithSeverity(severity Severity) RegistrationOption {
+	return func(registry *errorCodeRegistry) {
+		registry.severity = severity
+	}
+}
+
+// SeverityOf returns the severity of the first errorex in the chain of err: the severity it was escalated to by
+// Escalate, the override of its tenant set with SetTenantOverride, or the severity of its code. Other errors are
+// SeverityError.
+func SeverityOf(err error) Severity {
+	var target EX
+	if !errors.As(err, &target) {
+		return SeverityError
+	}
+	if escalated, ok := escalatedSeverity(target); ok {
+		return escalated
+	}
+	if override, ok := tenantOverrideOf(target); ok && override.Severity != 0 {
+		return override.Severity
+	}
+	codeRegistry, ok := lookupCode(target.Code())
+	if !ok {
+		return SeverityError
+	}
+	return codeRegistry.severityOrDefault()
+}
+
+// severityOrDefault returns the registered severity, SeverityError when not set
+func (r errorCodeRegistry) severityOrDefault() Severity {
+	if r.severity == 0 {
+		return SeverityError
+	}
+	return r.severity
+}
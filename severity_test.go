@@ -0,0 +1,57 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeverity(t *testing.T) {
+	RegisterErrorCode("severity.validation", "Validation failed", ErrorEXDetail{}, WithSeverity(SeverityInfo))
+	RegisterErrorCode("severity.default", "Default", ErrorEXDetail{})
+
+	t.Run("should return the severity of the code", func(t *testing.T) {
+		assert.Equal(t, SeverityInfo, SeverityOf(New("severity.validation", ErrorEXDetail{})))
+		assert.Equal(t, SeverityError, SeverityOf(New("severity.default", ErrorEXDetail{})))
+		assert.Equal(t, SeverityError, SeverityOf(errors.New("other")))
+		info, _ := Lookup("severity.default")
+		assert.Equal(t, SeverityError, info.Severity)
+	})
+
+	t.Run("should encode the severity names", func(t *testing.T) {
+		data, err := json.Marshal(map[string]Severity{"severity": SeverityCritical})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"severity":"critical"}`, string(data))
+
+		var decoded map[string]Severity
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, SeverityCritical, decoded["severity"])
+		assert.Error(t, json.Unmarshal([]byte(`{"severity":"fatal"}`), &decoded))
+		assert.Equal(t, "Severity(42)", Severity(42).String())
+	})
+}
@@ -0,0 +1,148 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// SizeLimits bounds the serialized details, so a detail accidentally holding a huge payload cannot blow up log
+// pipelines or responses. Zero fields are unlimited, the default.
+type SizeLimits struct {
+	// MaxDetailBytes is the maximum size of a serialized detail, larger details are replaced by a TruncatedDetail
+	MaxDetailBytes int
+	// MaxStringBytes is the maximum size of the strings of a detail, longer strings are cut at a rune boundary and
+	// get a "...[truncated N bytes]" suffix
+	MaxStringBytes int
+	// MaxArrayLength is the maximum number of elements of the arrays of a detail, longer arrays are cut and get a
+	// final "...[truncated N items]" element
+	MaxArrayLength int
+}
+
+// TruncatedDetail replaces serialized details larger than SizeLimits.MaxDetailBytes
+type TruncatedDetail struct {
+	// Truncated is always true
+	Truncated bool `json:"truncated"`
+	// Bytes is the size of the serialized detail, after the string and array limits
+	Bytes int `json:"bytes"`
+}
+
+var sizeLimits atomic.Pointer[SizeLimits]
+
+// SetSizeLimits sets the limits applied when errorex errors are serialized by Error and AppendError, the zero
+// SizeLimits removes them. The detail returned by Detail is never modified.
+func SetSizeLimits(limits SizeLimits) {
+	if limits == (SizeLimits{}) {
+		sizeLimits.Store(nil)
+		return
+	}
+	sizeLimits.Store(&limits)
+}
+
+// GetSizeLimits returns the limits set with SetSizeLimits
+func GetSizeLimits() SizeLimits {
+	if limits := sizeLimits.Load(); limits != nil {
+		return *limits
+	}
+	return SizeLimits{}
+}
+
+// writeDetail appends the JSON encoding of the detail to the buffer, applying the size limits
+func (b *encodeBuffer) writeDetail(detail any) error {
+	mark := b.buffer.Len()
+	if err := b.encode(detail); err != nil {
+		return err
+	}
+	limits := sizeLimits.Load()
+	if limits == nil {
+		return nil
+	}
+	if _, ok := detail.(EncryptedDetail); ok {
+		// the limits were applied before encrypting
+		return nil
+	}
+	if limits.MaxStringBytes > 0 || limits.MaxArrayLength > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(b.buffer.Bytes()[mark:]))
+		decoder.UseNumber()
+		var generic any
+		if decoder.Decode(&generic) == nil {
+			if truncated, changed := truncateValue(generic, *limits); changed {
+				b.buffer.Truncate(mark)
+				if err := b.encode(truncated); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if size := b.buffer.Len() - mark; limits.MaxDetailBytes > 0 && size > limits.MaxDetailBytes {
+		b.buffer.Truncate(mark)
+		return b.encode(TruncatedDetail{Truncated: true, Bytes: size})
+	}
+	return nil
+}
+
+// truncateValue applies the string and array limits to a decoded JSON value, telling if it changed
+func truncateValue(value any, limits SizeLimits) (any, bool) {
+	switch typed := value.(type) {
+	case string:
+		if limits.MaxStringBytes <= 0 || len(typed) <= limits.MaxStringBytes {
+			return typed, false
+		}
+		cut := limits.MaxStringBytes
+		for cut > 0 && !utf8.RuneStart(typed[cut]) {
+			cut--
+		}
+		return typed[:cut] + "...[truncated " + strconv.Itoa(len(typed)-cut) + " bytes]", true
+	case []any:
+		changed := false
+		dropped := 0
+		if limits.MaxArrayLength > 0 && len(typed) > limits.MaxArrayLength {
+			dropped = len(typed) - limits.MaxArrayLength
+			typed = typed[:limits.MaxArrayLength]
+			changed = true
+		}
+		for i, element := range typed {
+			var elementChanged bool
+			typed[i], elementChanged = truncateValue(element, limits)
+			changed = changed || elementChanged
+		}
+		if dropped > 0 {
+			typed = append(typed, "...[truncated "+strconv.Itoa(dropped)+" items]")
+		}
+		return typed, changed
+	case map[string]any:
+		changed := false
+		for key, element := range typed {
+			var elementChanged bool
+			typed[key], elementChanged = truncateValue(element, limits)
+			changed = changed || elementChanged
+		}
+		return typed, changed
+	}
+	return value, false
+}
@@ -0,0 +1,83 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type payloadDetail struct {
+	Body  string   `json:"body"`
+	Items []string `json:"items"`
+	Count int64    `json:"count"`
+}
+
+func TestSizeLimits(t *testing.T) {
+	RegisterErrorCode("test.size", "test description", payloadDetail{})
+	defer SetSizeLimits(SizeLimits{})
+	detailOf := func(err EX) map[string]any {
+		var decoded map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(err.Error()), &decoded))
+		return decoded["detail"].(map[string]any)
+	}
+
+	t.Run("should not limit by default", func(t *testing.T) {
+		SetSizeLimits(SizeLimits{})
+		err := New("test.size", payloadDetail{Body: strings.Repeat("a", 1<<16)})
+		assert.Len(t, detailOf(err)["body"], 1<<16)
+	})
+
+	t.Run("should truncate the strings at a rune boundary", func(t *testing.T) {
+		SetSizeLimits(SizeLimits{MaxStringBytes: 4})
+		err := New("test.size", payloadDetail{Body: "abcédef", Items: []string{"short"}})
+		assert.Equal(t, map[string]any{
+			"body":  "abc...[truncated 5 bytes]",
+			"items": []any{"shor...[truncated 1 bytes]"},
+			"count": float64(0),
+		}, detailOf(err))
+	})
+
+	t.Run("should truncate the arrays", func(t *testing.T) {
+		SetSizeLimits(SizeLimits{MaxArrayLength: 2, MaxStringBytes: 8})
+		err := New("test.size", payloadDetail{Items: []string{"a", "b", "c", "d"}})
+		assert.Equal(t, []any{"a", "b", "...[truncated 2 items]"}, detailOf(err)["items"])
+	})
+
+	t.Run("should replace oversized details", func(t *testing.T) {
+		SetSizeLimits(SizeLimits{MaxDetailBytes: 64})
+		err := New("test.size", payloadDetail{Body: strings.Repeat("a", 100)})
+		assert.Equal(t, map[string]any{"truncated": true, "bytes": float64(134)}, detailOf(err))
+		assert.Len(t, err.Detail().(payloadDetail).Body, 100)
+	})
+
+	t.Run("should keep the numbers exact", func(t *testing.T) {
+		SetSizeLimits(SizeLimits{MaxStringBytes: 4})
+		err := New("test.size", payloadDetail{Body: "abcdef", Count: 1<<62 + 1})
+		assert.Contains(t, err.Error(), `"count":4611686018427387905`)
+	})
+}
@@ -0,0 +1,149 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultStackDepth is the maximum number of frames captured when StackConfig.MaxDepth is not set
+	DefaultStackDepth = 32
+)
+
+var (
+	stackConfig atomic.Pointer[StackConfig]
+	// trimFrameFiles reduces the files of the frames to their base names, set by TestMode
+	trimFrameFiles atomic.Bool
+)
+
+// StackConfig configures the capture of stack traces when an errorex is created
+type StackConfig struct {
+	// Enabled turns stack capture on, it is disabled by default
+	Enabled bool
+	// MaxDepth is the maximum number of frames captured, DefaultStackDepth is used when it is zero
+	MaxDepth int
+	// Skip is the number of frames skipped above the caller of New, useful when New is called from helpers
+	Skip int
+}
+
+// Frame is a resolved stack frame
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// StackTracer is implemented by errors that carry a stack trace
+type StackTracer interface {
+	// StackTrace returns the frames captured when the error was created
+	StackTrace() []Frame
+}
+
+// SetStackConfig sets how stack traces are captured.
+// Only the raw program counters are captured when the errorex is created, frames are resolved lazily
+// the first time they are needed (on serialization or StackTrace), keeping errorex creation cheap in hot loops.
+func SetStackConfig(config StackConfig) {
+	stackConfig.Store(&config)
+}
+
+// GetStackConfig returns the current stack capture configuration
+func GetStackConfig() StackConfig {
+	if config := stackConfig.Load(); config != nil {
+		return *config
+	}
+	return StackConfig{}
+}
+
+// StackTrace returns the stack trace of an error, if it carries one
+func StackTrace(err error) ([]Frame, bool) {
+	tracer, ok := err.(StackTracer)
+	if !ok {
+		return nil, false
+	}
+	frames := tracer.StackTrace()
+	return frames, len(frames) > 0
+}
+
+// stack holds the raw program counters and resolves them on demand
+type stack struct {
+	pcs    []uintptr
+	trim   bool
+	once   sync.Once
+	frames []Frame
+}
+
+// captureStack captures the stack above its caller for an errorex with the code, skipping skip more frames.
+// It returns nil when stack capture is disabled for the code, see SetStackConfig and SetSeverityPolicy.
+func captureStack(code string, skip int) *stack {
+	config := GetStackConfig()
+	if !capturesStack(code, config.Enabled) {
+		return nil
+	}
+	depth := config.MaxDepth
+	if depth <= 0 {
+		depth = DefaultStackDepth
+	}
+	pcs := make([]uintptr, depth)
+	// Skip runtime.Callers, captureStack and its caller
+	n := runtime.Callers(3+skip+config.Skip, pcs)
+	if n == 0 {
+		return nil
+	}
+	return &stack{pcs: pcs[:n], trim: trimFrameFiles.Load()}
+}
+
+// Frames resolves the program counters into frames, only once
+func (s *stack) Frames() []Frame {
+	s.once.Do(func() {
+		frames := runtime.CallersFrames(s.pcs)
+		s.frames = make([]Frame, 0, len(s.pcs))
+		for {
+			frame, more := frames.Next()
+			file := frame.File
+			if s.trim {
+				file = filepath.Base(file)
+			}
+			s.frames = append(s.frames, Frame{
+				Function: frame.Function,
+				File:     file,
+				Line:     frame.Line,
+			})
+			if !more {
+				break
+			}
+		}
+	})
+	return s.frames
+}
+
+// resolvedStack returns a stack holding frames resolved elsewhere, such as frames parsed by ParseJSON
+func resolvedStack(frames []Frame) *stack {
+	s := &stack{frames: frames}
+	s.once.Do(func() {})
+	return s
+}
@@ -0,0 +1,112 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newStackHelper() EX {
+	return New("test.stack", struct{ Message string }{Message: "test detail"})
+}
+
+func TestStackTrace(t *testing.T) {
+	RegisterErrorCode("test.stack", "test description", struct{ Message string }{})
+	defer SetStackConfig(StackConfig{})
+
+	t.Run("should not capture a stack when disabled", func(t *testing.T) {
+		SetStackConfig(StackConfig{})
+		ex := newStackHelper()
+
+		_, ok := StackTrace(ex)
+		assert.False(t, ok)
+		assert.JSONEq(t, `{"code": "test.stack", "detail": {"Message":"test detail"}}`, ex.Error())
+	})
+
+	t.Run("should capture the caller of New", func(t *testing.T) {
+		SetStackConfig(StackConfig{Enabled: true})
+		ex := newStackHelper()
+
+		frames, ok := StackTrace(ex)
+		assert.True(t, ok)
+		assert.True(t, strings.HasSuffix(frames[0].Function, "newStackHelper"))
+		assert.True(t, strings.HasSuffix(frames[0].File, "stack_test.go"))
+	})
+
+	t.Run("should skip frames", func(t *testing.T) {
+		SetStackConfig(StackConfig{Enabled: true, Skip: 1})
+		ex := newStackHelper()
+
+		frames, _ := StackTrace(ex)
+		assert.False(t, strings.HasSuffix(frames[0].Function, "newStackHelper"))
+	})
+
+	t.Run("should limit the depth", func(t *testing.T) {
+		SetStackConfig(StackConfig{Enabled: true, MaxDepth: 2})
+		ex := newStackHelper()
+
+		frames, _ := StackTrace(ex)
+		assert.Len(t, frames, 2)
+	})
+
+	t.Run("should serialize the stack", func(t *testing.T) {
+		SetStackConfig(StackConfig{Enabled: true, MaxDepth: 1})
+		for _, ex := range []EX{newStackHelper(), NewPooled("test.stack", struct{ Message string }{Message: "test detail"})} {
+			var payload struct {
+				Code  string  `json:"code"`
+				Stack []Frame `json:"stack"`
+			}
+			assert.NoError(t, json.Unmarshal([]byte(ex.Error()), &payload))
+			assert.Equal(t, "test.stack", payload.Code)
+			assert.Len(t, payload.Stack, 1)
+			Release(ex)
+		}
+	})
+
+	t.Run("should return false for errors without stack", func(t *testing.T) {
+		_, ok := StackTrace(fmt.Errorf("test error"))
+		assert.False(t, ok)
+	})
+}
+
+func BenchmarkStackCapture(b *testing.B) {
+	RegisterErrorCode("bench.stack", "bench description", struct{ Message string }{})
+	defer SetStackConfig(StackConfig{})
+	detail := struct{ Message string }{Message: "bench detail"}
+
+	for _, depth := range []int{8, 32} {
+		b.Run(fmt.Sprintf("depth-%d", depth), func(b *testing.B) {
+			SetStackConfig(StackConfig{Enabled: true, MaxDepth: depth})
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = New("bench.stack", detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// Strictness tells how ParseJSON handles details whose fields differ from the registered detail type, such as
+// during rolling upgrades where producers and consumers run different versions of a detail
+type Strictness int
+
+const (
+	// ParseLenient ignores unknown fields and leaves missing fields zero, the default
+	ParseLenient Strictness = iota
+	// ParseRejectUnknown fails on fields the detail type does not have, unless they are deprecated
+	ParseRejectUnknown
+	// ParseStrict also fails on missing fields, unless they are deprecated or tagged omitempty
+	ParseStrict
+)
+
+var parseStrictness atomic.Int32
+
+// SetParseStrictness sets how ParseJSON handles unknown and missing detail fields.
+// Only the top level fields of struct details are checked.
+func SetParseStrictness(strictness Strictness) {
+	parseStrictness.Store(int32(strictness))
+}
+
+// GetParseStrictness returns how ParseJSON handles unknown and missing detail fields
+func GetParseStrictness() Strictness {
+	return Strictness(parseStrictness.Load())
+}
+
+// WithDeprecatedFields marks detail fields, by JSON name, as deprecated: they are neither required by ParseStrict
+// nor rejected by ParseRejectUnknown, so fields can be removed from the detail type while older producers still
+// send them, and added while older producers don't send them yet.
+func WithDeprecatedFields(names ...string) RegistrationOption {
+	return func(registry *errorCodeRegistry) {
+		registry.deprecatedFields = append(registry.deprecatedFields, names...)
+		sort.Strings(registry.deprecatedFields)
+	}
+}
+
+// checkFields checks the fields of a serialized detail against the detail type, following the parse strictness
+func checkFields(codeRegistry errorCodeRegistry, data json.RawMessage) error {
+	strictness := GetParseStrictness()
+	if strictness == ParseLenient || codeRegistry.detailType == nil {
+		return nil
+	}
+	fields := DetailFields(codeRegistry.detailType)
+	if fields == nil {
+		return nil
+	}
+	var received map[string]json.RawMessage
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &received); err != nil {
+			return nil
+		}
+	}
+	deprecated := make(map[string]bool, len(codeRegistry.deprecatedFields))
+	for _, name := range codeRegistry.deprecatedFields {
+		deprecated[name] = true
+	}
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.Name] = true
+		if _, ok := received[field.Name]; !ok && strictness == ParseStrict && !field.OmitEmpty && !deprecated[field.Name] {
+			return fmt.Errorf("invalid detail of %s: missing field %q", codeRegistry.code, field.Name)
+		}
+	}
+	names := make([]string, 0, len(received))
+	for name := range received {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !known[name] && !deprecated[name] {
+			return fmt.Errorf("invalid detail of %s: unknown field %q", codeRegistry.code, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type strictDetail struct {
+	Account string `json:"account"`
+	Region  string `json:"region"`
+	Note    string `json:"note,omitempty"`
+}
+
+func TestParseStrictness(t *testing.T) {
+	RegisterErrorCode("strict.declined", "Declined", strictDetail{}, WithDeprecatedFields("region", "legacy_id"))
+	defer SetParseStrictness(ParseLenient)
+
+	parse := func(detail string) error {
+		_, err := ParseJSON([]byte(`{"code": "strict.declined", "detail": ` + detail + `}`))
+		return err
+	}
+
+	t.Run("should record the deprecated fields", func(t *testing.T) {
+		info, _ := Lookup("strict.declined")
+		assert.Equal(t, []string{"legacy_id", "region"}, info.DeprecatedFields)
+	})
+
+	t.Run("should tolerate unknown and missing fields by default", func(t *testing.T) {
+		assert.Equal(t, ParseLenient, GetParseStrictness())
+		assert.NoError(t, parse(`{"unknown": 1}`))
+	})
+
+	t.Run("should reject unknown fields", func(t *testing.T) {
+		SetParseStrictness(ParseRejectUnknown)
+
+		assert.EqualError(t, parse(`{"account": "a", "unknown": 1}`), `invalid detail of strict.declined: unknown field "unknown"`)
+		assert.NoError(t, parse(`{"account": "a", "legacy_id": 1}`))
+		assert.NoError(t, parse(`{}`))
+	})
+
+	t.Run("should reject missing fields", func(t *testing.T) {
+		SetParseStrictness(ParseStrict)
+
+		assert.EqualError(t, parse(`{"legacy_id": 1}`), `invalid detail of strict.declined: missing field "account"`)
+		assert.NoError(t, parse(`{"account": "a"}`))
+	})
+}
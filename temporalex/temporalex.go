@@ -0,0 +1,89 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package temporalex converts errorex errors to and from Temporal application errors, preserving the code, the
+// detail and the retryability so the retry policies of Temporal follow the errorex classification.
+//
+// Activities return their errors through ToApplicationError:
+//
+//	func (a *Activities) Charge(ctx context.Context, request ChargeRequest) error {
+//		return temporalex.ToApplicationError(a.service.Charge(ctx, request))
+//	}
+//
+// and workflows recover them with FromError:
+//
+//	err := workflow.ExecuteActivity(ctx, activities.Charge, request).Get(ctx, nil)
+//	if ex, ok := temporalex.FromError(err); ok && payments.ErrDeclined.Is(ex) {
+//		...
+//	}
+//
+// It is a separate module, so depending on errorex does not pull the Temporal SDK.
+package temporalex
+
+import (
+	"errors"
+
+	"github.com/fkmatsuda/errorex"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ToApplicationError converts the first errorex in the chain of err into a Temporal ApplicationError with the code
+// as error type, the serialized errorex as detail and retryable only when the code is (see errorex.WithRetryable).
+// The retry hint set with errorex.WithRetryAfter becomes the next retry delay.
+// Nil is returned for nil errors and other errors are returned as is.
+func ToApplicationError(err error) error {
+	var ex errorex.EX
+	if !errors.As(err, &ex) {
+		return err
+	}
+	options := temporal.ApplicationErrorOptions{
+		NonRetryable: !errorex.IsRetryable(ex),
+		Details:      []any{string(errorex.AppendError(nil, ex))},
+	}
+	if delay, ok := errorex.RetryAfter(ex); ok {
+		options.NextRetryDelay = delay
+	}
+	message := ex.Code()
+	if info, ok := errorex.Lookup(ex.Code()); ok && info.Description != "" {
+		message = info.Description
+	}
+	return temporal.NewApplicationErrorWithOptions(message, ex.Code(), options)
+}
+
+// FromError reconstructs the errorex carried by a Temporal ApplicationError created by ToApplicationError,
+// anywhere in the chain of err, such as the ActivityError returned to a workflow
+func FromError(err error) (errorex.EX, bool) {
+	var applicationErr *temporal.ApplicationError
+	if !errors.As(err, &applicationErr) || !applicationErr.HasDetails() {
+		return nil, false
+	}
+	var payload string
+	if detailsErr := applicationErr.Details(&payload); detailsErr != nil {
+		return nil, false
+	}
+	ex, parseErr := errorex.ParseJSON([]byte(payload))
+	if parseErr != nil {
+		return nil, false
+	}
+	return ex, true
+}
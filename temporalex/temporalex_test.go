@@ -0,0 +1,101 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package temporalex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+type chargeDetail struct {
+	Invoice string `json:"invoice"`
+}
+
+func init() {
+	errorex.RegisterErrorCode("temporalex.declined", "Payment declined", chargeDetail{})
+	errorex.RegisterErrorCode("temporalex.unavailable", "Gateway unavailable", chargeDetail{}, errorex.WithRetryable())
+}
+
+func TestToApplicationError(t *testing.T) {
+	t.Run("should convert the errorex", func(t *testing.T) {
+		var applicationErr *temporal.ApplicationError
+		err := ToApplicationError(fmt.Errorf("charging: %w", errorex.New("temporalex.declined", chargeDetail{Invoice: "42"})))
+
+		assert.True(t, errors.As(err, &applicationErr))
+		assert.Equal(t, "temporalex.declined", applicationErr.Type())
+		assert.Equal(t, "Payment declined", applicationErr.Message())
+		assert.True(t, applicationErr.NonRetryable())
+	})
+
+	t.Run("should keep the retryability and the retry hint", func(t *testing.T) {
+		var applicationErr *temporal.ApplicationError
+		err := ToApplicationError(errorex.New("temporalex.unavailable", chargeDetail{}, errorex.WithRetryAfter(time.Minute)))
+
+		assert.True(t, errors.As(err, &applicationErr))
+		assert.False(t, applicationErr.NonRetryable())
+		assert.Equal(t, time.Minute, applicationErr.NextRetryDelay())
+	})
+
+	t.Run("should return other errors as is", func(t *testing.T) {
+		other := errors.New("other")
+		assert.Same(t, other, ToApplicationError(other))
+		assert.Nil(t, ToApplicationError(nil))
+	})
+}
+
+func TestFromError(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+
+	t.Run("should reconstruct the errorex returned by an activity", func(t *testing.T) {
+		env := suite.NewTestActivityEnvironment()
+		charge := func(ctx context.Context) error {
+			return ToApplicationError(errorex.New("temporalex.declined", chargeDetail{Invoice: "42"}))
+		}
+		env.RegisterActivity(charge)
+
+		_, err := env.ExecuteActivity(charge)
+
+		ex, ok := FromError(err)
+		assert.True(t, ok)
+		assert.True(t, errorex.Is(ex, "temporalex.declined"))
+		assert.Equal(t, chargeDetail{Invoice: "42"}, ex.Detail())
+	})
+
+	t.Run("should ignore other errors", func(t *testing.T) {
+		_, ok := FromError(errors.New("other"))
+		assert.False(t, ok)
+		_, ok = FromError(temporal.NewApplicationError("failed", "other"))
+		assert.False(t, ok)
+		_, ok = FromError(temporal.NewApplicationError("failed", "other", "not an errorex"))
+		assert.False(t, ok)
+	})
+}
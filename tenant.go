@@ -0,0 +1,179 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	// MetadataTenant is the metadata key set by WithTenant
+	MetadataTenant = "tenant"
+	// MetadataUser is the metadata key set by WithUser
+	MetadataUser = "user"
+	// RedactedValue replaces the metadata values removed by Redact
+	RedactedValue = "[redacted]"
+)
+
+var (
+	tenantMutex     sync.RWMutex
+	tenantOverrides = make(map[string]map[string]TenantOverride)
+)
+
+// MetadataCarrier is implemented by errors that carry metadata
+type MetadataCarrier interface {
+	// Metadata returns the metadata of the error, it must not be modified
+	Metadata() map[string]string
+}
+
+// TenantOverride replaces the message and the severity of a code for the errors of a tenant
+type TenantOverride struct {
+	// Message replaces the description of the code, see Message
+	Message string
+	// Severity replaces the severity of the code, see SeverityOf
+	Severity Severity
+}
+
+// WithMetadata attaches a key/value pair to the errorex, serialized under "metadata"
+func WithMetadata(key string, value string) Option {
+	return func(e *ex) {
+		if e.metadata == nil {
+			e.metadata = make(map[string]string, 2)
+		}
+		e.metadata[key] = value
+	}
+}
+
+// WithTenant scopes the errorex to a tenant, stored in its metadata under MetadataTenant
+func WithTenant(id string) Option {
+	return WithMetadata(MetadataTenant, id)
+}
+
+// WithUser scopes the errorex to a user, stored in its metadata under MetadataUser
+func WithUser(id string) Option {
+	return WithMetadata(MetadataUser, id)
+}
+
+// Metadata returns the metadata of the first error in the chain of err implementing MetadataCarrier
+func Metadata(err error) (map[string]string, bool) {
+	var carrier MetadataCarrier
+	if !errors.As(err, &carrier) {
+		return nil, false
+	}
+	metadata := carrier.Metadata()
+	return metadata, len(metadata) > 0
+}
+
+// Tenant returns the tenant the error is scoped to, empty if none
+func Tenant(err error) string {
+	metadata, _ := Metadata(err)
+	return metadata[MetadataTenant]
+}
+
+// User returns the user the error is scoped to, empty if none
+func User(err error) string {
+	metadata, _ := Metadata(err)
+	return metadata[MetadataUser]
+}
+
+// Redact returns a copy of the errorex with the values of the metadata keys replaced by RedactedValue, to be used
+// before the error crosses a privacy boundary. MetadataTenant and MetadataUser are redacted when no key is given.
+// Errors that were not created by this package are returned as is.
+func Redact(err EX, keys ...string) EX {
+	e, ok := err.(*ex)
+	if !ok || len(e.metadata) == 0 {
+		return err
+	}
+	if len(keys) == 0 {
+		keys = []string{MetadataTenant, MetadataUser}
+	}
+	redacted := e.clone()
+	for _, key := range keys {
+		if _, ok := redacted.metadata[key]; ok {
+			redacted.metadata[key] = RedactedValue
+		}
+	}
+	return redacted
+}
+
+// Metadata returns the metadata of the errorex, it must not be modified
+func (e *ex) Metadata() map[string]string {
+	return e.metadata
+}
+
+// SetTenantOverride overrides the message and severity of a code for the errors scoped to the tenant with
+// WithTenant. It panics if the code is not registered.
+func SetTenantOverride(tenant string, code string, override TenantOverride) {
+	codeRegistry, ok := lookupCode(code)
+	if !ok {
+		// Fatal errorex
+		fatal(New(ErrCodeNotRegistered, ErrorEXDetail{Code: code}))
+	}
+	tenantMutex.Lock()
+	defer tenantMutex.Unlock()
+	if tenantOverrides[tenant] == nil {
+		tenantOverrides[tenant] = make(map[string]TenantOverride)
+	}
+	tenantOverrides[tenant][codeRegistry.code] = override
+}
+
+// ClearTenantOverrides removes the overrides of the tenant
+func ClearTenantOverrides(tenant string) {
+	tenantMutex.Lock()
+	delete(tenantOverrides, tenant)
+	tenantMutex.Unlock()
+}
+
+// Message returns the message of the first errorex in the chain of err: the override of its tenant set with
+// SetTenantOverride, or the description of its code (see Resolve). Other errors return their Error().
+func Message(err error) string {
+	var target EX
+	if !errors.As(err, &target) {
+		return err.Error()
+	}
+	if override, ok := tenantOverrideOf(target); ok && override.Message != "" {
+		return override.Message
+	}
+	if info, ok := Resolve(target.Code()); ok {
+		return info.Description
+	}
+	return target.Code()
+}
+
+// tenantOverrideOf returns the override for the tenant and the code of the errorex
+func tenantOverrideOf(target EX) (TenantOverride, bool) {
+	tenant := Tenant(target)
+	if tenant == "" {
+		return TenantOverride{}, false
+	}
+	codeRegistry, ok := lookupCode(target.Code())
+	if !ok {
+		return TenantOverride{}, false
+	}
+	tenantMutex.RLock()
+	override, ok := tenantOverrides[tenant][codeRegistry.code]
+	tenantMutex.RUnlock()
+	return override, ok
+}
@@ -0,0 +1,96 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTenant(t *testing.T) {
+	RegisterErrorCode("tenant.quota", "Quota exceeded", ErrorEXDetail{}, WithSeverity(SeverityWarning))
+
+	t.Run("should scope the errorex to the tenant and the user", func(t *testing.T) {
+		e := New("tenant.quota", ErrorEXDetail{}, WithTenant("acme"), WithUser("john"), WithMetadata("region", "eu"))
+
+		assert.Equal(t, "acme", Tenant(fmt.Errorf("wrapped: %w", e)))
+		assert.Equal(t, "john", User(e))
+		metadata, ok := Metadata(e)
+		assert.True(t, ok)
+		assert.Equal(t, "eu", metadata["region"])
+		assert.Equal(t, `{"code": "tenant.quota", "detail": {"code":""}, "metadata": {"region":"eu","tenant":"acme","user":"john"}}`, e.Error())
+
+		parsed, err := ParseJSON([]byte(e.Error()))
+		assert.NoError(t, err)
+		assert.Equal(t, "acme", Tenant(parsed))
+	})
+
+	t.Run("should redact the tenant and the user", func(t *testing.T) {
+		e := New("tenant.quota", ErrorEXDetail{}, WithTenant("acme"), WithUser("john"), WithMetadata("region", "eu"))
+
+		redacted := Redact(e)
+
+		assert.Equal(t, RedactedValue, Tenant(redacted))
+		assert.Equal(t, RedactedValue, User(redacted))
+		assert.Equal(t, "acme", Tenant(e))
+		partial, _ := Metadata(Redact(e, MetadataUser))
+		assert.Equal(t, map[string]string{"region": "eu", "tenant": "acme", "user": RedactedValue}, partial)
+	})
+
+	t.Run("should report errors without metadata", func(t *testing.T) {
+		_, ok := Metadata(New("tenant.quota", ErrorEXDetail{}))
+		assert.False(t, ok)
+		assert.Empty(t, Tenant(errors.New("other")))
+	})
+}
+
+func TestTenantOverride(t *testing.T) {
+	RegisterErrorCode("tenant.limit", "Limit reached", ErrorEXDetail{}, WithSeverity(SeverityWarning))
+	SetTenantOverride("acme", "tenant.limit", TenantOverride{Message: "Upgrade your plan", Severity: SeverityInfo})
+	defer ClearTenantOverrides("acme")
+
+	t.Run("should override the message and the severity for the tenant", func(t *testing.T) {
+		e := New("tenant.limit", ErrorEXDetail{}, WithTenant("acme"))
+
+		assert.Equal(t, "Upgrade your plan", Message(e))
+		assert.Equal(t, SeverityInfo, SeverityOf(e))
+	})
+
+	t.Run("should use the code for other tenants", func(t *testing.T) {
+		e := New("tenant.limit", ErrorEXDetail{}, WithTenant("globex"))
+
+		assert.Equal(t, "Limit reached", Message(e))
+		assert.Equal(t, SeverityWarning, SeverityOf(e))
+		assert.Equal(t, "other", Message(errors.New("other")))
+	})
+
+	t.Run("should panic for unregistered codes", func(t *testing.T) {
+		assert.Panics(t, func() {
+			SetTenantOverride("acme", "tenant.missing", TenantOverride{})
+		})
+	})
+}
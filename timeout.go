@@ -0,0 +1,72 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import "context"
+
+// conversion is the outcome of a chain run by ConvertWithContext
+type conversion struct {
+	ex       EX
+	panicked any
+}
+
+// ConvertWithContext converts err through the chain within the deadline of the context, protecting the latency
+// budget of requests from slow converters, such as ones doing I/O to enrich the error. When the context is done
+// before the chain returns, the conversion falls through to the unknown converter (see NewUnknownErrorConverter)
+// and the chain is left to finish in the background, its result discarded. Panics of the chain are raised again
+// in the caller while it waits for the result.
+func ConvertWithContext(ctx context.Context, chain ErrorConverter, err error) EX {
+	if ctx.Done() == nil {
+		return chain.ConvertError(err)
+	}
+	if ctx.Err() != nil {
+		return convertUnknown(err)
+	}
+	result := make(chan conversion, 1)
+	go func() {
+		defer func() {
+			if panicked := recover(); panicked != nil {
+				result <- conversion{panicked: panicked}
+			}
+		}()
+		result <- conversion{ex: chain.ConvertError(err)}
+	}()
+	select {
+	case converted := <-result:
+		if converted.panicked != nil {
+			panic(converted.panicked)
+		}
+		return converted.ex
+	case <-ctx.Done():
+		return convertUnknown(err)
+	}
+}
+
+// convertUnknown converts err like the last handler of a chain, nil errors are not converted
+func convertUnknown(err error) EX {
+	if err == nil {
+		return nil
+	}
+	return NewUnknownErrorConverter().ConvertError(err)
+}
@@ -0,0 +1,95 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowConverter blocks until released, like a converter doing I/O
+type slowConverter struct {
+	BaseErrorConverter
+	release chan struct{}
+}
+
+func (c *slowConverter) ConvertError(err error) EX {
+	<-c.release
+	return New(ErrCodeNotRegistered, ErrorEXDetail{Code: "slow"})
+}
+
+// panickingConverter panics on every conversion
+type panickingConverter struct {
+	BaseErrorConverter
+}
+
+func (c *panickingConverter) ConvertError(err error) EX {
+	panic("converter failed")
+}
+
+func TestConvertWithContext(t *testing.T) {
+	t.Run("should return the conversion of the chain", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		converted := ConvertWithContext(ctx, BuildErrorConverterChain(), errors.New("boom"))
+
+		assert.Equal(t, ErrCodeUnknownError, converted.Code())
+		assert.Equal(t, UnknownErrorDetail{Detail: "boom"}, converted.Detail())
+	})
+
+	t.Run("should fall through to unknown when the deadline passes", func(t *testing.T) {
+		slow := &slowConverter{release: make(chan struct{})}
+		defer close(slow.release)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		converted := ConvertWithContext(ctx, BuildErrorConverterChain(slow), errors.New("boom"))
+
+		assert.Equal(t, ErrCodeUnknownError, converted.Code())
+	})
+
+	t.Run("should not run the chain when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		converted := ConvertWithContext(ctx, BuildErrorConverterChain(&panickingConverter{}), errors.New("boom"))
+
+		assert.Equal(t, ErrCodeUnknownError, converted.Code())
+		assert.Nil(t, ConvertWithContext(ctx, BuildErrorConverterChain(), nil))
+	})
+
+	t.Run("should raise the panics of the chain", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		assert.PanicsWithValue(t, "converter failed", func() {
+			ConvertWithContext(ctx, BuildErrorConverterChain(&panickingConverter{}), errors.New("boom"))
+		})
+	})
+}
@@ -0,0 +1,124 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package tlsex provides the errorex codes of TLS certificate verification failures and a converter for the errors
+// of crypto/x509, so expired certificates, unknown authorities and hostname mismatches are told apart from generic
+// network failures:
+//
+//	converter := errorex.BuildErrorConverterChain(tlsex.NewCertificateErrorConverter())
+//	if _, err := client.Get(url); err != nil {
+//		return converter.ConvertError(err)
+//	}
+package tlsex
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+const (
+	// ErrCodeCertificateExpired is the errorex code for certificates that are expired or not yet valid
+	ErrCodeCertificateExpired = "tls.certificate_expired"
+	// ErrCodeUnknownAuthority is the errorex code for certificates signed by an authority that is not trusted
+	ErrCodeUnknownAuthority = "tls.unknown_authority"
+	// ErrCodeHostnameMismatch is the errorex code for certificates not valid for the host they were presented by
+	ErrCodeHostnameMismatch = "tls.hostname_mismatch"
+	// ErrCodeCertificateInvalid is the errorex code for the other certificate verification failures
+	ErrCodeCertificateInvalid = "tls.certificate_invalid"
+)
+
+// CertificateDetail is the detail of the codes of this package
+type CertificateDetail struct {
+	// Subject is the distinguished name of the subject of the certificate
+	Subject string `json:"subject,omitempty"`
+	// Issuer is the distinguished name of the issuer of the certificate
+	Issuer string `json:"issuer,omitempty"`
+	// NotBefore is the start of the validity of the certificate
+	NotBefore time.Time `json:"not_before"`
+	// NotAfter is the expiry of the certificate
+	NotAfter time.Time `json:"not_after"`
+	// Host is the host name that was verified, for ErrCodeHostnameMismatch
+	Host string `json:"host,omitempty"`
+	// Reason is the message of the verification error
+	Reason string `json:"reason"`
+}
+
+func init() {
+	// Register the errorex codes
+	errorex.RegisterErrorCode(ErrCodeCertificateExpired, "Certificate expired", CertificateDetail{}, errorex.WithHTTPStatus(http.StatusBadGateway))
+	errorex.RegisterErrorCode(ErrCodeUnknownAuthority, "Certificate signed by unknown authority", CertificateDetail{}, errorex.WithHTTPStatus(http.StatusBadGateway))
+	errorex.RegisterErrorCode(ErrCodeHostnameMismatch, "Certificate hostname mismatch", CertificateDetail{}, errorex.WithHTTPStatus(http.StatusBadGateway))
+	errorex.RegisterErrorCode(ErrCodeCertificateInvalid, "Certificate invalid", CertificateDetail{}, errorex.WithHTTPStatus(http.StatusBadGateway))
+}
+
+// certificateErrorConverter converts the certificate verification errors of crypto/x509
+type certificateErrorConverter struct {
+	errorex.BaseErrorConverter
+}
+
+// NewCertificateErrorConverter creates a converter of the certificate verification errors of crypto/x509, as
+// returned by crypto/tls handshakes: an x509.CertificateInvalidError becomes ErrCodeCertificateExpired when the
+// certificate is expired and ErrCodeCertificateInvalid otherwise, an x509.UnknownAuthorityError
+// ErrCodeUnknownAuthority and an x509.HostnameError ErrCodeHostnameMismatch
+func NewCertificateErrorConverter() errorex.ErrorConverter {
+	return &certificateErrorConverter{}
+}
+
+// ConvertError converts the crypto/x509 errors, delegating the others to the next handler in the chain
+func (c *certificateErrorConverter) ConvertError(err error) errorex.EX {
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &invalidErr) {
+		code := ErrCodeCertificateInvalid
+		if invalidErr.Reason == x509.Expired {
+			code = ErrCodeCertificateExpired
+		}
+		return errorex.New(code, certificateDetail(invalidErr.Cert, invalidErr.Error()))
+	}
+	var authorityErr x509.UnknownAuthorityError
+	if errors.As(err, &authorityErr) {
+		return errorex.New(ErrCodeUnknownAuthority, certificateDetail(authorityErr.Cert, authorityErr.Error()))
+	}
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &hostnameErr) {
+		detail := certificateDetail(hostnameErr.Certificate, hostnameErr.Error())
+		detail.Host = hostnameErr.Host
+		return errorex.New(ErrCodeHostnameMismatch, detail)
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
+
+// certificateDetail describes the certificate, which may be nil
+func certificateDetail(cert *x509.Certificate, reason string) CertificateDetail {
+	detail := CertificateDetail{Reason: reason}
+	if cert != nil {
+		detail.Subject = cert.Subject.String()
+		detail.Issuer = cert.Issuer.String()
+		detail.NotBefore = cert.NotBefore
+		detail.NotAfter = cert.NotAfter
+	}
+	return detail
+}
@@ -0,0 +1,107 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package tlsex
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func certificate(t *testing.T, notAfter time.Time) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "example.com"},
+		DNSNames:              []string{"example.com"},
+		NotBefore:             notAfter.Add(-24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert
+}
+
+func verify(cert *x509.Certificate, host string) error {
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	_, err := cert.Verify(x509.VerifyOptions{Roots: roots, DNSName: host})
+	return err
+}
+
+func TestCertificateErrorConverter(t *testing.T) {
+	converter := errorex.BuildErrorConverterChain(NewCertificateErrorConverter())
+
+	t.Run("should convert expired certificates", func(t *testing.T) {
+		cert := certificate(t, time.Now().Add(-time.Hour))
+		ex := converter.ConvertError(verify(cert, "example.com"))
+		assert.Equal(t, ErrCodeCertificateExpired, ex.Code())
+		detail := ex.Detail().(CertificateDetail)
+		assert.Equal(t, "CN=example.com", detail.Subject)
+		assert.Equal(t, "CN=example.com", detail.Issuer)
+		assert.True(t, cert.NotAfter.Equal(detail.NotAfter))
+	})
+
+	t.Run("should convert hostname mismatches", func(t *testing.T) {
+		ex := converter.ConvertError(verify(certificate(t, time.Now().Add(time.Hour)), "other.com"))
+		assert.Equal(t, ErrCodeHostnameMismatch, ex.Code())
+		assert.Equal(t, "other.com", ex.Detail().(CertificateDetail).Host)
+	})
+
+	t.Run("should convert unknown authorities of TLS handshakes", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.NotFoundHandler())
+		defer server.Close()
+		_, err := http.Get(server.URL)
+		ex := converter.ConvertError(err)
+		assert.Equal(t, ErrCodeUnknownAuthority, ex.Code())
+		assert.NotEmpty(t, ex.Detail().(CertificateDetail).Subject)
+	})
+
+	t.Run("should convert other invalid certificates", func(t *testing.T) {
+		ex := converter.ConvertError(x509.CertificateInvalidError{Reason: x509.NotAuthorizedToSign})
+		assert.Equal(t, ErrCodeCertificateInvalid, ex.Code())
+		assert.Empty(t, ex.Detail().(CertificateDetail).Subject)
+	})
+
+	t.Run("should delegate other errors", func(t *testing.T) {
+		assert.Equal(t, errorex.ErrCodeUnknownError, converter.ConvertError(errors.New("boom")).Code())
+	})
+}
@@ -0,0 +1,93 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// MetadataConversionPath is the metadata key listing the handlers of the chain consulted by the conversion, in
+	// order, separated by ConversionPathSeparator, set when SetTraceConversions is enabled
+	MetadataConversionPath = "conversion_path"
+	// MetadataConversionMatch is the metadata key naming the handler of the chain that produced the errorex, set
+	// when SetTraceConversions is enabled
+	MetadataConversionMatch = "conversion_match"
+	// ConversionPathSeparator separates the handlers in MetadataConversionPath
+	ConversionPathSeparator = " > "
+)
+
+var traceConversions atomic.Bool
+
+// SetTraceConversions makes the chains built by BuildErrorConverterChain record the handlers consulted by each
+// conversion in the metadata of the resulting errorex, under MetadataConversionPath and MetadataConversionMatch,
+// answering why an error came out as ErrCodeUnknownError. It is disabled by default, as tracing copies the
+// errorex at every handler of the chain.
+func SetTraceConversions(enabled bool) {
+	traceConversions.Store(enabled)
+}
+
+// ConversionTrace returns the handlers consulted by the conversion of err, in order, and the one that produced it,
+// when it was converted by a chain while SetTraceConversions was enabled
+func ConversionTrace(err error) (path []string, match string, ok bool) {
+	metadata, _ := Metadata(err)
+	joined, ok := metadata[MetadataConversionPath]
+	if !ok {
+		return nil, "", false
+	}
+	return strings.Split(joined, ConversionPathSeparator), metadata[MetadataConversionMatch], true
+}
+
+// tracedConverter records the handler in the conversion path of the errorex produced through it
+type tracedConverter struct {
+	ErrorConverter
+	name string
+}
+
+// traced wraps a handler of a chain so it shows in the conversion traces
+func traced(converter ErrorConverter) ErrorConverter {
+	return &tracedConverter{ErrorConverter: converter, name: strings.TrimPrefix(fmt.Sprintf("%T", converter), "*")}
+}
+
+// ConvertError converts the error through the handler, recording it in the conversion path when tracing is enabled.
+// Errors that carry a path were produced further down the chain, the others were produced by this handler.
+func (c *tracedConverter) ConvertError(err error) EX {
+	converted := c.ErrorConverter.ConvertError(err)
+	if !traceConversions.Load() {
+		return converted
+	}
+	e, ok := converted.(*ex)
+	if !ok {
+		return converted
+	}
+	traced := e.clone()
+	if path, delegated := e.metadata[MetadataConversionPath]; delegated && error(e) != err {
+		traced.metadata[MetadataConversionPath] = c.name + ConversionPathSeparator + path
+		return traced
+	}
+	applyOptions(traced, []Option{WithMetadata(MetadataConversionPath, c.name), WithMetadata(MetadataConversionMatch, c.name)})
+	return traced
+}
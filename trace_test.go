@@ -0,0 +1,81 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tracedTestConverter struct {
+	BaseErrorConverter
+}
+
+func (c *tracedTestConverter) ConvertError(err error) EX {
+	if err.Error() == "traced" {
+		return New(ErrCodeNotRegistered, ErrorEXDetail{Code: "traced"})
+	}
+	return c.BaseErrorConverter.ConvertError(err)
+}
+
+func TestSetTraceConversions(t *testing.T) {
+	chain := BuildErrorConverterChain(&tracedTestConverter{})
+
+	t.Run("should not trace by default", func(t *testing.T) {
+		_, _, ok := ConversionTrace(chain.ConvertError(errors.New("boom")))
+
+		assert.False(t, ok)
+	})
+
+	t.Run("should record the consulted handlers", func(t *testing.T) {
+		SetTraceConversions(true)
+		defer SetTraceConversions(false)
+
+		path, match, ok := ConversionTrace(chain.ConvertError(errors.New("boom")))
+
+		assert.True(t, ok)
+		assert.Equal(t, []string{"errorex.exErrorConverter", "errorex.tracedTestConverter", "errorex.unknownErrorConverter"}, path)
+		assert.Equal(t, "errorex.unknownErrorConverter", match)
+
+		path, match, _ = ConversionTrace(chain.ConvertError(errors.New("traced")))
+
+		assert.Equal(t, []string{"errorex.exErrorConverter", "errorex.tracedTestConverter"}, path)
+		assert.Equal(t, "errorex.tracedTestConverter", match)
+	})
+
+	t.Run("should not modify the errors passed through", func(t *testing.T) {
+		SetTraceConversions(true)
+		defer SetTraceConversions(false)
+		original := New(ErrCodeNotRegistered, ErrorEXDetail{})
+
+		path, match, _ := ConversionTrace(chain.ConvertError(original))
+
+		assert.Equal(t, []string{"errorex.exErrorConverter"}, path)
+		assert.Equal(t, "errorex.exErrorConverter", match)
+		_, ok := Metadata(original)
+		assert.False(t, ok)
+	})
+}
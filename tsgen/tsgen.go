@@ -0,0 +1,245 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package tsgen generates TypeScript types for the details of the registered errorex codes, so frontend teams
+// get compile-time types for every error payload the backend can return.
+//
+// It emits one interface per named detail struct (including nested ones) and a discriminated union keyed by
+// code:
+//
+//	export type ErrorEX =
+//	  | { code: "billing.declined"; detail: DeclinedDetail }
+//	  | { code: "billing.expired"; detail: ExpiredDetail };
+//
+// Like docgen, it is used from a small command importing the error domains and calling Main.
+package tsgen
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// Options configures the generated code
+type Options struct {
+	// UnionName is the name of the discriminated union, "ErrorEX" when empty
+	UnionName string
+}
+
+// generator keeps the interfaces already named while walking the detail types
+type generator struct {
+	names      map[reflect.Type]string
+	used       map[string]reflect.Type
+	interfaces []reflect.Type
+}
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*interface{ MarshalText() ([]byte, error) })(nil)).Elem()
+)
+
+// Generate writes the TypeScript declarations of the catalog
+func Generate(w io.Writer, catalog []errorex.CodeInfo, options Options) error {
+	if options.UnionName == "" {
+		options.UnionName = "ErrorEX"
+	}
+	g := &generator{names: make(map[reflect.Type]string), used: make(map[string]reflect.Type)}
+	members := make([]string, 0, len(catalog))
+	for _, info := range catalog {
+		members = append(members, fmt.Sprintf("{ code: %s; detail: %s }", strconv.Quote(info.Code), g.typeOf(info.DetailType)))
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "// Code generated by errorex tsgen. DO NOT EDIT.")
+	// Interfaces may be discovered while rendering other interfaces
+	for i := 0; i < len(g.interfaces); i++ {
+		fmt.Fprintln(out)
+		g.writeInterface(out, g.interfaces[i])
+	}
+	fmt.Fprintln(out)
+	if len(members) == 0 {
+		fmt.Fprintf(out, "export type %s = never;\n", options.UnionName)
+	} else {
+		fmt.Fprintf(out, "export type %s =\n", options.UnionName)
+		for i, member := range members {
+			end := ""
+			if i == len(members)-1 {
+				end = ";"
+			}
+			fmt.Fprintf(out, "  | %s%s\n", member, end)
+		}
+	}
+	fmt.Fprintf(out, "\nexport type %sCode = %s[\"code\"];\n", options.UnionName, options.UnionName)
+	return out.Flush()
+}
+
+// Main generates the declarations of the registry of the running program according to the command line flags:
+//
+//	-out file        output file (stdout)
+//	-union name      name of the discriminated union (ErrorEX)
+//	-prefix prefix   only generate codes starting with prefix
+func Main() {
+	output := flag.String("out", "", "output file, stdout when empty")
+	union := flag.String("union", "", "name of the discriminated union")
+	prefix := flag.String("prefix", "", "only generate codes starting with prefix")
+	flag.Parse()
+	if err := run(*output, *prefix, Options{UnionName: *union}); err != nil {
+		fmt.Fprintln(os.Stderr, "tsgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(output, prefix string, options Options) error {
+	var catalog []errorex.CodeInfo
+	for _, info := range errorex.Catalog() {
+		if strings.HasPrefix(info.Code, prefix) {
+			catalog = append(catalog, info)
+		}
+	}
+	if output == "" {
+		return Generate(os.Stdout, catalog, options)
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := Generate(file, catalog, options); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// typeOf returns the TypeScript type of a Go type, registering named structs as interfaces
+func (g *generator) typeOf(t reflect.Type) string {
+	if t == nil {
+		return "unknown"
+	}
+	if t.Kind() == reflect.Pointer {
+		return g.typeOf(t.Elem()) + " | null"
+	}
+	if t.Implements(jsonMarshaler) || reflect.PointerTo(t).Implements(jsonMarshaler) {
+		// time.Time and similar marshal themselves as strings
+		if t.Implements(textMarshaler) || reflect.PointerTo(t).Implements(textMarshaler) {
+			return "string"
+		}
+		return "unknown"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		element := g.typeOf(t.Elem())
+		if strings.Contains(element, " ") {
+			element = "(" + element + ")"
+		}
+		return element + "[]"
+	case reflect.Map:
+		return "Record<string, " + g.typeOf(t.Elem()) + ">"
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.objectLiteral(t)
+		}
+		return g.interfaceName(t)
+	}
+	return "unknown"
+}
+
+// interfaceName returns the name of the interface of a named struct, qualifying it with its package on collisions
+func (g *generator) interfaceName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if other, ok := g.used[name]; ok && other != t {
+		pkg := t.PkgPath()
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	g.names[t] = name
+	g.used[name] = t
+	g.interfaces = append(g.interfaces, t)
+	return name
+}
+
+// objectLiteral renders an anonymous struct inline
+func (g *generator) objectLiteral(t reflect.Type) string {
+	fields := errorex.DetailFields(t)
+	if len(fields) == 0 {
+		return "Record<string, never>"
+	}
+	members := make([]string, 0, len(fields))
+	for _, field := range fields {
+		members = append(members, g.member(field))
+	}
+	return "{ " + strings.Join(members, "; ") + " }"
+}
+
+// writeInterface writes the interface of a named struct
+func (g *generator) writeInterface(w io.Writer, t reflect.Type) {
+	fmt.Fprintf(w, "export interface %s {\n", g.names[t])
+	for _, field := range errorex.DetailFields(t) {
+		fmt.Fprintf(w, "  %s;\n", g.member(field))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// member renders a field as an interface member
+func (g *generator) member(field errorex.DetailField) string {
+	name := field.Name
+	if !isIdentifier(name) {
+		name = strconv.Quote(name)
+	}
+	if field.OmitEmpty {
+		name += "?"
+	}
+	return name + ": " + g.typeOf(field.Field.Type)
+}
+
+// isIdentifier reports whether name can be used unquoted as a TypeScript property
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		if r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return false
+	}
+	return name != ""
+}
@@ -0,0 +1,99 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package tsgen
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type declinedDetail struct {
+	Reason  string            `json:"reason"`
+	Limit   *money            `json:"limit"`
+	Charges []money           `json:"charges,omitempty"`
+	Labels  map[string]string `json:"labels"`
+	At      time.Time         `json:"at"`
+	Raw     []byte            `json:"raw"`
+	Kebab   bool              `json:"is-kebab"`
+}
+
+func init() {
+	errorex.RegisterErrorCode("tsgen.declined", "Payment declined", declinedDetail{})
+	errorex.RegisterErrorCode("tsgen.inline", "Inline detail", struct{ Message string }{})
+	errorex.RegisterErrorCode("tsgen.plain", "Plain detail", 0)
+}
+
+const expected = `// Code generated by errorex tsgen. DO NOT EDIT.
+
+export interface declinedDetail {
+  reason: string;
+  limit: money | null;
+  charges?: money[];
+  labels: Record<string, string>;
+  at: string;
+  raw: string;
+  "is-kebab": boolean;
+}
+
+export interface money {
+  amount: number;
+  currency: string;
+}
+
+export type ApiError =
+  | { code: "tsgen.declined"; detail: declinedDetail }
+  | { code: "tsgen.inline"; detail: { Message: string } }
+  | { code: "tsgen.plain"; detail: number };
+
+export type ApiErrorCode = ApiError["code"];
+`
+
+func TestGenerate(t *testing.T) {
+	t.Run("should generate interfaces and the union", func(t *testing.T) {
+		var catalog []errorex.CodeInfo
+		for _, code := range []string{"tsgen.declined", "tsgen.inline", "tsgen.plain"} {
+			info, _ := errorex.Lookup(code)
+			catalog = append(catalog, info)
+		}
+
+		var buffer bytes.Buffer
+		assert.NoError(t, Generate(&buffer, catalog, Options{UnionName: "ApiError"}))
+		assert.Equal(t, expected, buffer.String())
+	})
+
+	t.Run("should generate an empty union", func(t *testing.T) {
+		var buffer bytes.Buffer
+		assert.NoError(t, Generate(&buffer, nil, Options{}))
+		assert.Contains(t, buffer.String(), "export type ErrorEX = never;")
+	})
+}
@@ -0,0 +1,55 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import "time"
+
+// MetadataExpiresAt is the metadata key of the expiration set by WithTTL, in RFC 3339 format
+const MetadataExpiresAt = "expires_at"
+
+// WithTTL sets how long the errorex can be trusted, e.g. when a "not found" result is cached for 30s.
+// The expiration is stored in the metadata under MetadataExpiresAt, so it survives serialization.
+func WithTTL(ttl time.Duration) Option {
+	return func(e *ex) {
+		WithMetadata(MetadataExpiresAt, now().Add(ttl).UTC().Format(time.RFC3339Nano))(e)
+	}
+}
+
+// ExpiresAt returns the expiration set with WithTTL on the first errorex in the chain of err
+func ExpiresAt(err error) (time.Time, bool) {
+	metadata, _ := Metadata(err)
+	value, ok := metadata[MetadataExpiresAt]
+	if !ok {
+		return time.Time{}, false
+	}
+	expiresAt, parseErr := time.Parse(time.RFC3339Nano, value)
+	return expiresAt, parseErr == nil
+}
+
+// IsStale tells if the TTL set with WithTTL on err has passed, by the clock set with SetClock.
+// Errors without a TTL never become stale.
+func IsStale(err error) bool {
+	expiresAt, ok := ExpiresAt(err)
+	return ok && !now().Before(expiresAt)
+}
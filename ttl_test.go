@@ -0,0 +1,69 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTTL(t *testing.T) {
+	RegisterErrorCode("ttl.not_found", "Not found", ErrorEXDetail{})
+	current := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return current })
+	defer SetClock(nil)
+
+	t.Run("should become stale after the TTL", func(t *testing.T) {
+		err := New("ttl.not_found", ErrorEXDetail{}, WithTTL(30*time.Second))
+
+		expiresAt, ok := ExpiresAt(err)
+		assert.True(t, ok)
+		assert.Equal(t, current.Add(30*time.Second), expiresAt)
+		assert.False(t, IsStale(err))
+
+		current = current.Add(30 * time.Second)
+		assert.True(t, IsStale(err))
+	})
+
+	t.Run("should survive serialization", func(t *testing.T) {
+		err := New("ttl.not_found", ErrorEXDetail{}, WithTTL(time.Minute))
+
+		parsed, parseErr := ParseJSON([]byte(err.Error()))
+
+		assert.NoError(t, parseErr)
+		assert.False(t, IsStale(parsed))
+		current = current.Add(time.Minute)
+		assert.True(t, IsStale(parsed))
+	})
+
+	t.Run("should never be stale without a TTL", func(t *testing.T) {
+		_, ok := ExpiresAt(New("ttl.not_found", ErrorEXDetail{}))
+		assert.False(t, ok)
+		assert.False(t, IsStale(New("ttl.not_found", ErrorEXDetail{})))
+		assert.False(t, IsStale(errors.New("other")))
+	})
+}
@@ -0,0 +1,105 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldError is the failure of a validation rule on a field of the input
+type FieldError struct {
+	// Field is the path of the field, the dot separated JSON names of the fields, e.g. "address.zip"
+	Field string `json:"field"`
+	// Key is the message key of the rule, e.g. "validation.min_length", localized by LocalizeFields
+	Key string `json:"key"`
+	// Params are the parameters of the rule, e.g. {"min": 8}, replacing the "{min}" placeholders of the message
+	Params map[string]any `json:"params,omitempty"`
+	// Message is the message of the failure, localized by LocalizeFields
+	Message string `json:"message,omitempty"`
+}
+
+// ValidationDetail is the detail of ErrCodeValidation, the fields failing validation
+type ValidationDetail struct {
+	Fields []FieldError `json:"fields"`
+}
+
+// FieldPaths returns the paths of the fields failing validation, in order
+func (d ValidationDetail) FieldPaths() []string {
+	paths := make([]string, 0, len(d.Fields))
+	for _, field := range d.Fields {
+		paths = append(paths, field.Field)
+	}
+	return paths
+}
+
+// Validation returns an ErrCodeValidation errorex with the fields failing validation and the options applied
+func Validation(fields []FieldError, options ...Option) EX {
+	e := newEX(ErrCodeValidation, ValidationDetail{Fields: fields}, 1)
+	applyOptions(e, options)
+	return e
+}
+
+// LocalizeFields returns a copy of the errorex with the message of each FieldError of its ValidationDetail rendered
+// in the first of the languages with a message for its key (see RegisterMessages), its placeholders replaced by
+// the params of the field:
+//
+//	errorex.RegisterMessages("pt-BR", map[string]string{
+//		"validation.min_length": "deve ter ao menos {min} caracteres",
+//	})
+//
+// Fields whose key has no message in any language keep their message. Errors whose detail is not a ValidationDetail
+// are returned as is.
+func LocalizeFields(err EX, tags ...string) EX {
+	detail, ok := err.Detail().(ValidationDetail)
+	if !ok || len(tags) == 0 || len(detail.Fields) == 0 {
+		return err
+	}
+	fields := make([]FieldError, len(detail.Fields))
+	for i, field := range detail.Fields {
+		if message, ok := localized(canonicalCode(field.Key), tags); ok {
+			field.Message = renderParams(message, field.Params)
+		}
+		fields[i] = field
+	}
+	return WithDetail(err, ValidationDetail{Fields: fields})
+}
+
+// renderParams replaces the "{name}" placeholders of the message by the params, unknown placeholders are kept
+func renderParams(message string, params map[string]any) string {
+	if len(params) == 0 || !strings.Contains(message, "{") {
+		return message
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	replacements := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(params[name]))
+	}
+	return strings.NewReplacer(replacements...).Replace(message)
+}
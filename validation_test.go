@@ -0,0 +1,69 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalizeFields(t *testing.T) {
+	RegisterMessages("pt", map[string]string{
+		"test.validation.required":   "campo obrigatório",
+		"test.validation.min_length": "deve ter ao menos {min} caracteres, {unknown}",
+	})
+	RegisterMessages("pt-BR", map[string]string{"test.validation.required": "preenchimento obrigatório"})
+	err := Validation([]FieldError{
+		{Field: "name", Key: "test.validation.required", Message: "is required"},
+		{Field: "password", Key: "test.validation.min_length", Params: map[string]any{"min": 8}, Message: "too short"},
+		{Field: "address.zip", Key: "test.validation.zip", Message: "invalid zip"},
+	}, WithRetryAfter(0))
+
+	t.Run("should render the messages in the first language with one", func(t *testing.T) {
+		localized := LocalizeFields(err, "de", "pt-BR")
+		assert.True(t, Is(localized, ErrCodeValidation))
+		fields := localized.Detail().(ValidationDetail).Fields
+		assert.Equal(t, "preenchimento obrigatório", fields[0].Message)
+		assert.Equal(t, "deve ter ao menos 8 caracteres, {unknown}", fields[1].Message)
+		assert.Equal(t, "invalid zip", fields[2].Message)
+		assert.Equal(t, "is required", err.Detail().(ValidationDetail).Fields[0].Message)
+	})
+
+	t.Run("should keep the messages without a language", func(t *testing.T) {
+		assert.Equal(t, err, LocalizeFields(err))
+		assert.Equal(t, err, LocalizeFields(err, "de"))
+	})
+
+	t.Run("should return other errors as is", func(t *testing.T) {
+		other := New(ErrCodeNotRegistered, ErrorEXDetail{Code: "x"})
+		assert.Equal(t, other, LocalizeFields(other, "pt"))
+	})
+
+	t.Run("should expose the field paths", func(t *testing.T) {
+		assert.Equal(t, []string{"name", "password", "address.zip"}, err.Detail().(ValidationDetail).FieldPaths())
+		info, _ := Lookup(ErrCodeValidation)
+		assert.Equal(t, 422, info.HTTPStatus)
+	})
+}
@@ -0,0 +1,103 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Warnings carries non-fatal errorex notices alongside a successful result, e.g. the rows skipped by a batch
+// import. It serializes as a JSON array of errorex errors, to be embedded in response bodies under "warnings":
+//
+//	type ImportResponse struct {
+//		Imported int              `json:"imported"`
+//		Warnings errorex.Warnings `json:"warnings,omitempty"`
+//	}
+//
+// Each warning is an EX, so it can be reported through the same hooks as errors (metrics, reporters, ...).
+type Warnings []EX
+
+// Add appends the warnings, nil ones are ignored
+func (w *Warnings) Add(warnings ...EX) {
+	for _, warning := range warnings {
+		if warning != nil {
+			*w = append(*w, warning)
+		}
+	}
+}
+
+// Has tells if one of the warnings has the code, see Is
+func (w Warnings) Has(code string) bool {
+	for _, warning := range w {
+		if Is(warning, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// Err joins the warnings into an error with errors.Join, nil when there are none, for the code paths that
+// escalate warnings into a failure
+func (w Warnings) Err() error {
+	if len(w) == 0 {
+		return nil
+	}
+	errs := make([]error, len(w))
+	for i, warning := range w {
+		errs[i] = warning
+	}
+	return errors.Join(errs...)
+}
+
+// MarshalJSON encodes the warnings as an array of serialized errorex errors
+func (w Warnings) MarshalJSON() ([]byte, error) {
+	data := []byte{'['}
+	for i, warning := range w {
+		if i > 0 {
+			data = append(data, ',')
+		}
+		data = AppendError(data, warning)
+	}
+	return append(data, ']'), nil
+}
+
+// UnmarshalJSON decodes an array of serialized errorex errors with ParseJSON
+func (w *Warnings) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("invalid warnings: %w", err)
+	}
+	warnings := make(Warnings, 0, len(raw))
+	for _, item := range raw {
+		warning, err := ParseJSON(item)
+		if err != nil {
+			return err
+		}
+		warnings = append(warnings, warning)
+	}
+	*w = warnings
+	return nil
+}
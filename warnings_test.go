@@ -0,0 +1,78 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package errorex
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type importResponse struct {
+	Imported int      `json:"imported"`
+	Warnings Warnings `json:"warnings,omitempty"`
+}
+
+func TestWarnings(t *testing.T) {
+	t.Run("should collect the warnings", func(t *testing.T) {
+		var warnings Warnings
+
+		warnings.Add(New(ErrCodeNotRegistered, ErrorEXDetail{Code: "row.3"}), nil)
+
+		assert.Len(t, warnings, 1)
+		assert.True(t, warnings.Has(ErrCodeNotRegistered))
+		assert.False(t, warnings.Has(ErrCodeUnknownError))
+	})
+
+	t.Run("should serialize under warnings", func(t *testing.T) {
+		response := importResponse{Imported: 2}
+		response.Warnings.Add(New(ErrCodeNotRegistered, ErrorEXDetail{Code: "row.3"}))
+
+		data, err := json.Marshal(response)
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"imported":2,"warnings":[{"code":"errorex.001","detail":{"code":"row.3"}}]}`, string(data))
+
+		var parsed importResponse
+		assert.NoError(t, json.Unmarshal(data, &parsed))
+		assert.True(t, parsed.Warnings.Has(ErrCodeNotRegistered))
+		assert.Equal(t, ErrorEXDetail{Code: "row.3"}, parsed.Warnings[0].Detail())
+	})
+
+	t.Run("should leave out empty warnings", func(t *testing.T) {
+		data, _ := json.Marshal(importResponse{Imported: 1})
+
+		assert.JSONEq(t, `{"imported":1}`, string(data))
+	})
+
+	t.Run("should join the warnings into an error", func(t *testing.T) {
+		warning := New(ErrCodeNotRegistered, ErrorEXDetail{})
+		warnings := Warnings{warning}
+
+		assert.NoError(t, Warnings{}.Err())
+		assert.True(t, errors.Is(warnings.Err(), warning))
+	})
+}
@@ -0,0 +1,64 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package webhook
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// Router routes errorex errors to the reporter of the team owning their code, see errorex.WithOwner:
+//
+//	router := &webhook.Router{
+//		Owners:  map[string]*webhook.Reporter{"billing": billingReporter},
+//		Default: platformReporter,
+//	}
+//	router.Report(ctx, err)
+type Router struct {
+	// Owners are the reporters of each team, by the team name set with errorex.WithOwner
+	Owners map[string]*Reporter
+	// Default reports the errors whose code has no owner or whose owner has no reporter, they are dropped when nil
+	Default *Reporter
+}
+
+// Report posts err with the reporter of the owner of its code, or the default reporter. Nil errors, errors without
+// an errorex in their chain and errors without a reporter are not posted and no error is returned.
+func (r *Router) Report(ctx context.Context, err error) error {
+	var target errorex.EX
+	if !errors.As(err, &target) {
+		return nil
+	}
+	reporter := r.Default
+	if owner, ok := errorex.CodeOwner(target.Code()); ok {
+		if owned, found := r.Owners[owner.Team]; found {
+			reporter = owned
+		}
+	}
+	if reporter == nil {
+		return nil
+	}
+	return reporter.Report(ctx, err)
+}
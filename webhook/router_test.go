@@ -0,0 +1,60 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter(t *testing.T) {
+	errorex.RegisterErrorCode("webhook.billing", "Billing", errorex.ErrorEXDetail{}, errorex.WithOwner("billing", "@billing-oncall"))
+	errorex.RegisterErrorCode("webhook.billing.settlement_failed", "Settlement failed", errorex.ErrorEXDetail{}, errorex.WithParent("webhook.billing"))
+	errorex.RegisterErrorCode("webhook.search", "Search", errorex.ErrorEXDetail{}, errorex.WithOwner("search", ""))
+
+	t.Run("should route by owner", func(t *testing.T) {
+		billing, billingURL := serve(t)
+		platform, platformURL := serve(t)
+		router := &Router{
+			Owners:  map[string]*Reporter{"billing": NewReporter(billingURL, Template(`{"text":{{json .Contact}}}`))},
+			Default: NewReporter(platformURL, SlackTemplate),
+		}
+		ctx := context.Background()
+		assert.NoError(t, router.Report(ctx, errorex.New("webhook.billing.settlement_failed", errorex.ErrorEXDetail{})))
+		assert.NoError(t, router.Report(ctx, errorex.New("webhook.search", errorex.ErrorEXDetail{})))
+		assert.NoError(t, router.Report(ctx, errorex.New("webhook.outage", outageDetail{})))
+		assert.NoError(t, router.Report(ctx, errors.New("boom")))
+		assert.Equal(t, []map[string]any{{"text": "@billing-oncall"}}, billing.payloads)
+		assert.Len(t, platform.payloads, 2)
+	})
+
+	t.Run("should drop errors without a reporter", func(t *testing.T) {
+		router := &Router{Owners: map[string]*Reporter{}}
+		assert.NoError(t, router.Report(context.Background(), errorex.New("webhook.billing", errorex.ErrorEXDetail{})))
+	})
+}
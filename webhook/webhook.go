@@ -0,0 +1,260 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package webhook reports errorex errors by posting them to a webhook, such as the incoming webhooks of Slack and
+// Microsoft Teams or the PagerDuty Events API, the payload being rendered by a template:
+//
+//	reporter := webhook.NewReporter(slackURL, webhook.SlackTemplate)
+//	reporter.MinSeverity = errorex.SeverityError
+//	reporter.Limit, reporter.Window = 10, time.Minute
+//	...
+//	reporter.Report(ctx, err)
+//
+// Errors whose severity behavior does not report them (see errorex.SetSeverityPolicy) are never posted.
+// A Router sends each error to the reporter of the team owning its code.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+)
+
+// Predefined templates
+var (
+	// SlackTemplate is the payload of the Slack incoming webhooks
+	SlackTemplate = Template(`{"text":{{json .Summary}}}`)
+	// TeamsTemplate is the payload of the Microsoft Teams incoming webhooks
+	TeamsTemplate = Template(`{"@type":"MessageCard","@context":"https://schema.org/extensions",` +
+		`"summary":{{json .Summary}},"title":{{json .Code}},"text":{{json .Message}}}`)
+)
+
+// Event is the data of the payload templates
+type Event struct {
+	// Code is the code of the errorex
+	Code string
+	// Description is the description of the code
+	Description string
+	// Message is the message of the errorex, see errorex.Message
+	Message string
+	// Severity is the name of the severity of the errorex
+	Severity string
+	// Detail is the detail of the errorex as projected for errorex.SinkReport
+	Detail any
+	// InstanceID is the instance ID of the errorex, empty when not stamped
+	InstanceID string
+	// Timestamp is the time the errorex was created, zero when not stamped
+	Timestamp time.Time
+	// Service is the name of the service, see errorex.SetServiceName
+	Service string
+	// Owner is the team owning the code, see errorex.WithOwner, empty when not set
+	Owner string
+	// Contact is how to reach the owning team, empty when not set
+	Contact string
+	// Suppressed is the number of errors dropped by the rate limit since the previous report
+	Suppressed int
+	// Summary is a one line description: the severity, the code and the message, and the suppressed count
+	Summary string
+	// Error is the JSON serialization of the errorex as projected for errorex.SinkReport
+	Error string
+}
+
+// Reporter posts errorex errors to a webhook
+type Reporter struct {
+	// URL is the URL of the webhook
+	URL string
+	// Template renders the payload from an Event, see Template
+	Template *template.Template
+	// ContentType is the content type of the payload, "application/json" when empty
+	ContentType string
+	// Client posts the payloads, http.DefaultClient when nil
+	Client *http.Client
+	// Codes are the codes to report, all codes when empty. Aliases match the code they are an alias of.
+	Codes []string
+	// MinSeverity is the lowest severity to report, all severities when zero
+	MinSeverity errorex.Severity
+	// Limit is the number of reports allowed in each Window, unlimited when zero
+	Limit int
+	// Window is the period of the rate limit
+	Window time.Duration
+
+	mutex       sync.Mutex
+	windowStart time.Time
+	sent        int
+	suppressed  int
+}
+
+// NewReporter creates a Reporter posting to the URL the payloads rendered by the template
+func NewReporter(url string, payload *template.Template) *Reporter {
+	return &Reporter{URL: url, Template: payload}
+}
+
+// Template parses a payload template. Besides the fields of Event, it can use the json function, which encodes a
+// value with the errorex JSON codec, e.g. {"text":{{json .Summary}}}, and pagerDutySeverity, which maps a severity
+// name to the severities of PagerDuty.
+// It panics if the template cannot be parsed.
+func Template(text string) *template.Template {
+	return template.Must(template.New("webhook").Funcs(template.FuncMap{"json": encodeJSON, "pagerDutySeverity": pagerDutySeverity}).Parse(text))
+}
+
+// PagerDutyTemplate returns the payload of the PagerDuty Events API v2 for the routing key of an integration,
+// triggering an alert deduplicated by code
+func PagerDutyTemplate(routingKey string) *template.Template {
+	key, _ := encodeJSON(routingKey)
+	return Template(`{"routing_key":` + key + `,"event_action":"trigger","dedup_key":{{json .Code}},` +
+		`"payload":{"summary":{{json .Summary}},"source":{{json (or .Service "errorex")}},` +
+		`"severity":{{json (pagerDutySeverity .Severity)}},"custom_details":{{json .Detail}}}}`)
+}
+
+// Report posts err to the webhook when it passes the filters and the rate limit. Nil, errors without an errorex
+// in their chain and filtered errors are not posted and no error is returned. It returns an error if the payload
+// cannot be rendered or posted, or the webhook responds with a status other than 2xx.
+func (r *Reporter) Report(ctx context.Context, err error) error {
+	var target errorex.EX
+	if !errors.As(err, &target) || !r.accepts(target) {
+		return nil
+	}
+	suppressed, ok := r.allow()
+	if !ok {
+		return nil
+	}
+	var payload bytes.Buffer
+	if renderErr := r.Template.Execute(&payload, newEvent(target, suppressed)); renderErr != nil {
+		return fmt.Errorf("webhook: render payload: %w", renderErr)
+	}
+	request, requestErr := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, &payload)
+	if requestErr != nil {
+		return fmt.Errorf("webhook: %w", requestErr)
+	}
+	contentType := r.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	request.Header.Set("Content-Type", contentType)
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, postErr := client.Do(request)
+	if postErr != nil {
+		return fmt.Errorf("webhook: %w", postErr)
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook: unexpected status %s", response.Status)
+	}
+	return nil
+}
+
+// accepts tells if the errorex passes the filters of the reporter
+func (r *Reporter) accepts(target errorex.EX) bool {
+	if !errorex.BehaviorOf(target).Report {
+		return false
+	}
+	if r.MinSeverity != 0 && errorex.SeverityOf(target) < r.MinSeverity {
+		return false
+	}
+	if len(r.Codes) == 0 {
+		return true
+	}
+	for _, code := range r.Codes {
+		if errorex.Is(target, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// allow applies the rate limit, returning the number of reports suppressed since the previous one and if the
+// report is allowed
+func (r *Reporter) allow() (int, bool) {
+	if r.Limit <= 0 {
+		return 0, true
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := time.Now()
+	if now.Sub(r.windowStart) >= r.Window {
+		r.windowStart = now
+		r.sent = 0
+	}
+	if r.sent >= r.Limit {
+		r.suppressed++
+		return 0, false
+	}
+	r.sent++
+	suppressed := r.suppressed
+	r.suppressed = 0
+	return suppressed, true
+}
+
+// newEvent creates the template data of the errorex
+func newEvent(target errorex.EX, suppressed int) Event {
+	projected := errorex.Projected(target, errorex.SinkReport)
+	event := Event{
+		Code:       target.Code(),
+		Message:    errorex.Message(target),
+		Severity:   errorex.SeverityOf(target).String(),
+		Detail:     projected.Detail(),
+		Service:    errorex.GetServiceName(),
+		Suppressed: suppressed,
+		Error:      string(errorex.AppendError(nil, projected)),
+	}
+	if info, ok := errorex.Resolve(target.Code()); ok {
+		event.Description = info.Description
+	}
+	if owner, ok := errorex.CodeOwner(target.Code()); ok {
+		event.Owner, event.Contact = owner.Team, owner.Contact
+	}
+	event.InstanceID, _ = errorex.InstanceID(target)
+	event.Timestamp, _ = errorex.Timestamp(target)
+	event.Summary = fmt.Sprintf("[%s] %s: %s", event.Severity, event.Code, event.Message)
+	if suppressed > 0 {
+		event.Summary += fmt.Sprintf(" (%d more suppressed)", suppressed)
+	}
+	return event
+}
+
+// encodeJSON encodes a value as JSON for the templates
+func encodeJSON(value any) (string, error) {
+	data, err := errorex.GetJSONCodec().Marshal(value)
+	return string(data), err
+}
+
+// pagerDutySeverity maps a severity name to the severities of PagerDuty: critical, error, warning or info
+func pagerDutySeverity(severity string) string {
+	switch severity {
+	case "critical", "error", "warning":
+		return severity
+	}
+	return "info"
+}
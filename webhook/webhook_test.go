@@ -0,0 +1,147 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/stretchr/testify/assert"
+)
+
+type outageDetail struct {
+	Region string `json:"region"`
+}
+
+func init() {
+	errorex.RegisterErrorCode("webhook.outage", "Outage", outageDetail{}, errorex.WithSeverity(errorex.SeverityCritical))
+	errorex.RegisterErrorCode("webhook.invalid", "Invalid", errorex.ErrorEXDetail{}, errorex.WithSeverity(errorex.SeverityInfo))
+}
+
+// receiver records the payloads posted to it
+type receiver struct {
+	mutex    sync.Mutex
+	payloads []map[string]any
+	status   int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	data, _ := io.ReadAll(req.Body)
+	var payload map[string]any
+	_ = json.Unmarshal(data, &payload)
+	r.mutex.Lock()
+	r.payloads = append(r.payloads, payload)
+	r.mutex.Unlock()
+	if r.status != 0 {
+		w.WriteHeader(r.status)
+	}
+}
+
+func serve(t *testing.T) (*receiver, string) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return r, server.URL
+}
+
+func TestReporter(t *testing.T) {
+	outage := errorex.New("webhook.outage", outageDetail{Region: "eu-west-1"})
+
+	t.Run("should post the rendered payload", func(t *testing.T) {
+		r, url := serve(t)
+		reporter := NewReporter(url, SlackTemplate)
+		assert.NoError(t, reporter.Report(context.Background(), fmt.Errorf("checkout: %w", outage)))
+		assert.Equal(t, []map[string]any{{"text": "[critical] webhook.outage: Outage"}}, r.payloads)
+	})
+
+	t.Run("should filter by code and severity", func(t *testing.T) {
+		r, url := serve(t)
+		reporter := NewReporter(url, SlackTemplate)
+		reporter.MinSeverity = errorex.SeverityError
+		reporter.Codes = []string{"webhook.outage"}
+		assert.NoError(t, reporter.Report(context.Background(), errorex.New("webhook.invalid", errorex.ErrorEXDetail{})))
+		assert.NoError(t, reporter.Report(context.Background(), errors.New("boom")))
+		assert.NoError(t, reporter.Report(context.Background(), outage))
+		assert.Len(t, r.payloads, 1)
+	})
+
+	t.Run("should not post errors the severity policy does not report", func(t *testing.T) {
+		errorex.SetSeverityPolicy(errorex.SeverityPolicy{errorex.SeverityCritical: {}})
+		t.Cleanup(func() { errorex.SetSeverityPolicy(nil) })
+		r, url := serve(t)
+		assert.NoError(t, NewReporter(url, SlackTemplate).Report(context.Background(), outage))
+		assert.Empty(t, r.payloads)
+	})
+
+	t.Run("should rate limit and count the suppressed errors", func(t *testing.T) {
+		r, url := serve(t)
+		reporter := NewReporter(url, SlackTemplate)
+		reporter.Limit, reporter.Window = 1, 50*time.Millisecond
+		for range 3 {
+			assert.NoError(t, reporter.Report(context.Background(), outage))
+		}
+		time.Sleep(60 * time.Millisecond)
+		assert.NoError(t, reporter.Report(context.Background(), outage))
+		assert.Len(t, r.payloads, 2)
+		assert.Equal(t, "[critical] webhook.outage: Outage (2 more suppressed)", r.payloads[1]["text"])
+	})
+
+	t.Run("should render the PagerDuty events", func(t *testing.T) {
+		r, url := serve(t)
+		assert.NoError(t, NewReporter(url, PagerDutyTemplate("key")).Report(context.Background(), outage))
+		assert.Equal(t, map[string]any{
+			"routing_key":  "key",
+			"event_action": "trigger",
+			"dedup_key":    "webhook.outage",
+			"payload": map[string]any{
+				"summary":        "[critical] webhook.outage: Outage",
+				"source":         "errorex",
+				"severity":       "critical",
+				"custom_details": map[string]any{"region": "eu-west-1"},
+			},
+		}, r.payloads[0])
+	})
+
+	t.Run("should render the Teams cards", func(t *testing.T) {
+		r, url := serve(t)
+		assert.NoError(t, NewReporter(url, TeamsTemplate).Report(context.Background(), outage))
+		assert.Equal(t, "webhook.outage", r.payloads[0]["title"])
+		assert.Equal(t, "Outage", r.payloads[0]["text"])
+	})
+
+	t.Run("should return the failed posts", func(t *testing.T) {
+		r, url := serve(t)
+		r.status = http.StatusForbidden
+		assert.EqualError(t, NewReporter(url, SlackTemplate).Report(context.Background(), outage), "webhook: unexpected status 403 Forbidden")
+	})
+}
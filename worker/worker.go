@@ -0,0 +1,178 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+// Package worker runs fan-out jobs on a bounded pool of goroutines, reporting the failed tasks as an
+// errorex.EXGroup whose errors carry the label, the duration and the number of attempts of their task:
+//
+//	pool := worker.NewPool(ctx, 8, converter)
+//	for _, invoice := range invoices {
+//		pool.Submit(worker.Task{Label: invoice.ID, MaxAttempts: 3, Run: func(ctx context.Context) error {
+//			return billing.Send(ctx, invoice)
+//		}})
+//	}
+//	if failures := pool.Wait(); len(failures) > 0 {
+//		return failures
+//	}
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/retry"
+)
+
+// Metadata keys set on the errors of failed tasks
+const (
+	// MetadataTask is the label of the task
+	MetadataTask = "task"
+	// MetadataDuration is the time spent running the task over all its attempts, as formatted by time.Duration
+	MetadataDuration = "duration"
+	// MetadataAttempts is the number of times the task was run
+	MetadataAttempts = "attempts"
+)
+
+// Task is a unit of work submitted to a Pool
+type Task struct {
+	// Label identifies the task in its failure report
+	Label string
+	// Run does the work, the context is the one of the pool
+	Run func(ctx context.Context) error
+	// MaxAttempts is the number of times Run is attempted while it fails with retryable errors (see
+	// errorex.IsRetryable), when Retry.MaxAttempts is not set. It is 1 when not set either.
+	MaxAttempts int
+	// Retry is the policy of the attempts, see retry.Do: the failures are retried with its exponential backoff, or
+	// after their errorex.WithRetryAfter hints
+	Retry retry.Policy
+}
+
+// failure is the error of a failed task, with its submission order
+type failure struct {
+	index int
+	ex    errorex.EX
+}
+
+// Pool runs tasks on a bounded number of goroutines
+type Pool struct {
+	ctx       context.Context
+	converter errorex.ErrorConverter
+	slots     chan struct{}
+	group     sync.WaitGroup
+	mutex     sync.Mutex
+	submitted int
+	failures  []failure
+}
+
+// NewPool creates a pool running at most size tasks at a time, at least one, converting the errors of the tasks
+// through the converter, errorex.BuildErrorConverterChain() when nil
+func NewPool(ctx context.Context, size int, converter errorex.ErrorConverter) *Pool {
+	if converter == nil {
+		converter = errorex.BuildErrorConverterChain()
+	}
+	return &Pool{ctx: ctx, converter: converter, slots: make(chan struct{}, max(size, 1))}
+}
+
+// Submit runs the task on a worker of the pool, blocking while every worker is busy
+func (p *Pool) Submit(task Task) {
+	p.mutex.Lock()
+	index := p.submitted
+	p.submitted++
+	p.mutex.Unlock()
+	p.slots <- struct{}{}
+	p.group.Add(1)
+	go func() {
+		defer func() {
+			<-p.slots
+			p.group.Done()
+		}()
+		if ex := p.run(task); ex != nil {
+			p.mutex.Lock()
+			p.failures = append(p.failures, failure{index: index, ex: ex})
+			p.mutex.Unlock()
+		}
+	}()
+}
+
+// Wait waits for the submitted tasks and returns the errors of the failed ones, in submission order, nil when
+// every task succeeded
+func (p *Pool) Wait() errorex.EXGroup {
+	p.group.Wait()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.failures) == 0 {
+		return nil
+	}
+	sort.Slice(p.failures, func(i, j int) bool {
+		return p.failures[i].index < p.failures[j].index
+	})
+	group := make(errorex.EXGroup, len(p.failures))
+	for i, failed := range p.failures {
+		group[i] = failed.ex
+	}
+	return group
+}
+
+// run attempts the task following its retry policy, returning the annotated errorex of its last attempt when it
+// fails
+func (p *Pool) run(task Task) errorex.EX {
+	policy := task.Retry
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = max(task.MaxAttempts, 1)
+	}
+	started := time.Now()
+	attempts := 0
+	var last errorex.EX
+	err := retry.Do(p.ctx, func(ctx context.Context) error {
+		attempts++
+		if last = p.attempt(task); last != nil {
+			return last
+		}
+		return nil
+	}, policy)
+	if err == nil {
+		return nil
+	}
+	return errorex.Annotate(last,
+		errorex.WithMetadata(MetadataTask, task.Label),
+		errorex.WithMetadata(MetadataDuration, time.Since(started).String()),
+		errorex.WithMetadata(MetadataAttempts, strconv.Itoa(attempts)),
+	)
+}
+
+// attempt runs the task once, converting its error, panics are reported as errors
+func (p *Pool) attempt(task Task) (ex errorex.EX) {
+	defer func() {
+		if panicked := recover(); panicked != nil {
+			ex = p.converter.ConvertError(fmt.Errorf("task panicked: %v", panicked))
+		}
+	}()
+	if err := task.Run(p.ctx); err != nil {
+		return p.converter.ConvertError(err)
+	}
+	return nil
+}
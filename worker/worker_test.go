@@ -0,0 +1,137 @@
+/*
+ *   Copyright (c) 2024 fkmatsuda <fabio@fkmatsuda.dev>
+ *   All rights reserved.
+
+ *   Permission is hereby granted, free of charge, to any person obtaining a copy
+ *   of this software and associated documentation files (the "Software"), to deal
+ *   in the Software without restriction, including without limitation the rights
+ *   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *   copies of the Software, and to permit persons to whom the Software is
+ *   furnished to do so, subject to the following conditions:
+
+ *   The above copyright notice and this permission notice shall be included in all
+ *   copies or substantial portions of the Software.
+
+ *   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ *   SOFTWARE.
+ */
+
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fkmatsuda/errorex"
+	"github.com/fkmatsuda/errorex/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	errorex.RegisterErrorCode("worker.unavailable", "Unavailable", errorex.ErrorEXDetail{}, errorex.WithRetryable())
+}
+
+func TestPool(t *testing.T) {
+	t.Run("should report the failed tasks in submission order", func(t *testing.T) {
+		pool := NewPool(context.Background(), 2, nil)
+
+		for _, label := range []string{"a", "b", "c"} {
+			label := label
+			pool.Submit(Task{Label: label, Run: func(ctx context.Context) error {
+				if label == "b" {
+					return nil
+				}
+				return errors.New("failed " + label)
+			}})
+		}
+		failures := pool.Wait()
+
+		assert.Len(t, failures, 2)
+		assert.Equal(t, errorex.UnknownErrorDetail{Detail: "failed a"}, failures[0].Detail())
+		metadata, _ := errorex.Metadata(failures[1])
+		assert.Equal(t, "c", metadata[MetadataTask])
+		assert.Equal(t, "1", metadata[MetadataAttempts])
+		_, err := time.ParseDuration(metadata[MetadataDuration])
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return nil when every task succeeds", func(t *testing.T) {
+		pool := NewPool(context.Background(), 0, nil)
+		pool.Submit(Task{Label: "ok", Run: func(ctx context.Context) error { return nil }})
+
+		assert.Nil(t, pool.Wait())
+	})
+
+	t.Run("should bound the running tasks", func(t *testing.T) {
+		pool := NewPool(context.Background(), 2, nil)
+		var running, peak atomic.Int32
+
+		for i := 0; i < 6; i++ {
+			pool.Submit(Task{Run: func(ctx context.Context) error {
+				current := running.Add(1)
+				for {
+					highest := peak.Load()
+					if current <= highest || peak.CompareAndSwap(highest, current) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				running.Add(-1)
+				return nil
+			}})
+		}
+		pool.Wait()
+
+		assert.LessOrEqual(t, peak.Load(), int32(2))
+	})
+
+	t.Run("should retry the retryable failures", func(t *testing.T) {
+		pool := NewPool(context.Background(), 1, nil)
+		var calls atomic.Int32
+
+		pool.Submit(Task{Label: "flaky", MaxAttempts: 3, Run: func(ctx context.Context) error {
+			calls.Add(1)
+			return errorex.New("worker.unavailable", errorex.ErrorEXDetail{})
+		}})
+		failures := pool.Wait()
+
+		assert.Equal(t, int32(3), calls.Load())
+		metadata, _ := errorex.Metadata(failures[0])
+		assert.Equal(t, "3", metadata[MetadataAttempts])
+	})
+
+	t.Run("should back off between the attempts", func(t *testing.T) {
+		pool := NewPool(context.Background(), 1, nil)
+		var times []time.Time
+
+		pool.Submit(Task{Label: "flaky", Retry: retry.Policy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond}, Run: func(ctx context.Context) error {
+			times = append(times, time.Now())
+			return errorex.New("worker.unavailable", errorex.ErrorEXDetail{})
+		}})
+		failures := pool.Wait()
+
+		assert.Len(t, times, 3)
+		assert.GreaterOrEqual(t, times[1].Sub(times[0]), 10*time.Millisecond)
+		assert.GreaterOrEqual(t, times[2].Sub(times[1]), 20*time.Millisecond)
+		assert.Equal(t, "worker.unavailable", failures[0].Code())
+	})
+
+	t.Run("should report panics as failures", func(t *testing.T) {
+		pool := NewPool(context.Background(), 1, nil)
+
+		pool.Submit(Task{Label: "panics", Run: func(ctx context.Context) error {
+			panic("boom")
+		}})
+		failures := pool.Wait()
+
+		assert.Equal(t, errorex.UnknownErrorDetail{Detail: "task panicked: boom"}, failures[0].Detail())
+	})
+}